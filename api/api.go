@@ -20,6 +20,7 @@ import (
 	"go.sia.tech/hostd/host/settings"
 	"go.sia.tech/hostd/host/settings/pin"
 	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/disk"
 	"go.sia.tech/hostd/webhooks"
 	"go.sia.tech/jape"
 	"go.uber.org/zap"
@@ -53,6 +54,27 @@ type (
 		LastAnnouncement() (settings.Announcement, error)
 
 		UpdateDDNS(force bool) error
+
+		// RenterACL returns the renter access control list.
+		RenterACL() (settings.RenterACL, error)
+		// SetRenterACLMode sets the mode of the renter access control list.
+		SetRenterACLMode(mode string) error
+		// UpdateRenterACLEntry adds a renter to the access control list or
+		// updates the renter's existing entry.
+		UpdateRenterACLEntry(pk types.PublicKey, note string, expiration time.Time) error
+		// RemoveRenterACLEntry removes a renter from the access control
+		// list.
+		RemoveRenterACLEntry(pk types.PublicKey) error
+
+		// PricingProfiles returns every renter's pricing profile.
+		PricingProfiles() ([]settings.PricingProfile, error)
+		// PricingProfile returns the renter's pricing profile. The bool is
+		// false if the renter does not have a profile.
+		PricingProfile(types.PublicKey) (settings.PricingProfile, bool)
+		// UpdatePricingProfile adds or replaces a renter's pricing profile.
+		UpdatePricingProfile(settings.PricingProfile) error
+		// RemovePricingProfile removes a renter's pricing profile.
+		RemovePricingProfile(types.PublicKey) error
 	}
 
 	// An Index persists updates from the blockchain to a store
@@ -82,13 +104,62 @@ type (
 		AddVolume(ctx context.Context, localPath string, maxSectors uint64, result chan<- error) (storage.Volume, error)
 		RemoveVolume(ctx context.Context, id int64, force bool, result chan<- error) error
 		ResizeVolume(ctx context.Context, id int64, maxSectors uint64, result chan<- error) error
+		EvacuateVolume(ctx context.Context, id, destID int64, result chan<- error) error
+		// SetVolumePlacement sets the placement weight and priority of a
+		// volume.
+		SetVolumePlacement(id int64, weight uint64, priority int64) error
+		// SetVolumeAutoGrow sets the auto-grow policy of a volume.
+		SetVolumeAutoGrow(id int64, policy storage.AutoGrowPolicy) error
+		// SetVolumeTier sets the storage tier of a volume.
+		SetVolumeTier(id int64, tier string) error
+		// TieringStatus returns the status of sector tiering and the tiering
+		// stats of each volume.
+		TieringStatus() (storage.TieringStatus, error)
+		// SyncMetrics returns the sync policy and metrics about syncing
+		// sector data to disk.
+		SyncMetrics() storage.SyncMetrics
+		// PlacementStrategy returns the strategy used to place new sectors.
+		PlacementStrategy() (string, error)
+		// SetPlacementStrategy sets the strategy used to place new sectors.
+		SetPlacementStrategy(strategy string) error
+		// RebalanceVolumes moves sectors between volumes to match the
+		// placement strategy.
+		RebalanceVolumes(ctx context.Context, result chan<- error) error
 		SetReadOnly(id int64, readOnly bool) error
 		RemoveSector(root types.Hash256) error
 		ResizeCache(size uint32)
+		// ConfigureDiskCache configures the second-level sector cache.
+		ConfigureDiskCache(path string, sectors uint64) error
 		ReadSector(types.Hash256) (*[rhp2.SectorSize]byte, error)
 
 		// SectorReferences returns the references to a sector
 		SectorReferences(root types.Hash256) (storage.SectorReference, error)
+
+		// StartScrub starts or resumes verifying every sector in a volume.
+		StartScrub(id int64) error
+		// PauseScrub pauses a running volume scrub.
+		PauseScrub(id int64) error
+		// VolumeScrub returns the progress of a volume's most recent scrub.
+		VolumeScrub(id int64) (storage.VolumeScrub, error)
+		// ScrubErrors returns the sectors that failed verification during a
+		// volume's most recent scrub.
+		ScrubErrors(id int64, limit, offset int) ([]storage.ScrubError, error)
+
+		// LostSectors returns a paginated list of sectors that have been
+		// lost and not restored, and the total number of lost sectors.
+		LostSectors(limit, offset int) ([]storage.LostSector, int, error)
+		// RestoreSector writes a lost sector back to a volume.
+		RestoreSector(root types.Hash256, sector *[rhp2.SectorSize]byte) error
+
+		// BackupVolume copies the sectors added to a volume since its last
+		// backup to a backup directory.
+		BackupVolume(ctx context.Context, id int64, dir string, result chan<- error) error
+		// RestoreVolumeBackup restores a volume's lost sectors from a backup
+		// directory.
+		RestoreVolumeBackup(ctx context.Context, id int64, dir string, result chan<- error) error
+		// VolumeBackup returns the progress of a volume's most recent backup
+		// or restore.
+		VolumeBackup(id int64) (storage.VolumeBackup, error)
 	}
 
 	// A ContractManager manages the host's contracts
@@ -96,10 +167,43 @@ type (
 		Contracts(filter contracts.ContractFilter) ([]contracts.Contract, int, error)
 		Contract(id types.FileContractID) (contracts.Contract, error)
 
+		V2Contracts(filter contracts.V2ContractFilter) ([]contracts.V2Contract, int, error)
+		V2Contract(id types.FileContractID) (contracts.V2Contract, error)
+
 		// CheckIntegrity checks the integrity of a contract's sector roots on
 		// disk. The result of each sector checked is sent on the returned
 		// channel. Read errors are logged.
 		CheckIntegrity(ctx context.Context, contractID types.FileContractID) (<-chan contracts.IntegrityResult, uint64, error)
+		// CheckV2Integrity checks the integrity of a v2 contract's sector
+		// roots on disk. The result of each sector checked is sent on the
+		// returned channel. Read errors are logged.
+		CheckV2Integrity(ctx context.Context, contractID types.FileContractID) (<-chan contracts.IntegrityResult, uint64, error)
+		// IntegrityChecks returns a paginated list of completed scheduled
+		// integrity checks.
+		IntegrityChecks(limit, offset int) ([]contracts.IntegrityCheck, error)
+		// ContractIntegrityChecks returns a paginated list of completed
+		// scheduled integrity checks for a contract.
+		ContractIntegrityChecks(contractID types.FileContractID, limit, offset int) ([]contracts.IntegrityCheck, error)
+
+		// LostSectorRisks returns the unresolved contracts that reference
+		// lost sectors.
+		LostSectorRisks() ([]contracts.LostSectorRisk, error)
+
+		// Renter returns a renter's usage and quota.
+		Renter(types.PublicKey) (contracts.Renter, error)
+		// SetRenterQuota overrides the default quota for a renter.
+		SetRenterQuota(types.PublicKey, contracts.RenterQuota) error
+		// RemoveRenterQuota removes a renter's quota override.
+		RemoveRenterQuota(types.PublicKey) error
+
+		// Renters returns a paginated list of metrics for every renter and
+		// the total number of renters.
+		Renters(contracts.RenterFilter) ([]contracts.RenterMetrics, int, error)
+		// RenterMetrics returns the current metrics for a renter.
+		RenterMetrics(types.PublicKey) (contracts.RenterMetrics, error)
+		// RenterPeriodMetrics returns a renter's metrics for n periods
+		// starting at start.
+		RenterPeriodMetrics(pk types.PublicKey, start time.Time, periods int, interval metrics.Interval) ([]contracts.RenterMetrics, error)
 	}
 
 	// An AccountManager manages ephemeral accounts
@@ -125,6 +229,11 @@ type (
 		BroadcastV2TransactionSet(index types.ChainIndex, txns []types.V2Transaction)
 	}
 
+	// A DiskMonitor reports the health of the filesystems used by the host.
+	DiskMonitor interface {
+		Filesystems() []disk.FilesystemStatus
+	}
+
 	// The SQLite3Store provides an interface for backing up a SQLite3 database
 	SQLite3Store interface {
 		Backup(ctx context.Context, destPath string) error
@@ -161,6 +270,7 @@ type (
 		webhooks Webhooks
 
 		sqlite3Store SQLite3Store
+		diskMonitor  DiskMonitor
 
 		syncer    Syncer
 		chain     ChainManager
@@ -257,29 +367,73 @@ func NewServer(name string, hostKey types.PublicKey, cm ChainManager, s Syncer,
 		"PUT /settings/ddns/update": a.handlePUTDDNSUpdate,
 		"GET /settings/pinned":      a.requiresExplorer(a.handleGETPinnedSettings),
 		"PUT /settings/pinned":      a.requiresExplorer(a.handlePUTPinnedSettings),
+		// renter access control endpoints
+		"GET /settings/renters/acl":         a.handleGETRenterACL,
+		"PUT /settings/renters/acl":         a.handlePUTRenterACL,
+		"PUT /settings/renters/acl/:key":    a.handlePUTRenterACLEntry,
+		"DELETE /settings/renters/acl/:key": a.handleDELETERenterACLEntry,
+		// renter endpoints
+		"GET /renters":                      a.handleGETRenters,
+		"GET /renters/:key":                 a.handleGETRenter,
+		"GET /renters/:key/metrics":         a.handleGETRenterMetrics,
+		"GET /renters/:key/metrics/:period": a.handleGETRenterPeriodMetrics,
+		"PUT /renters/quotas/:key":          a.handlePUTRenterQuota,
+		"DELETE /renters/quotas/:key":       a.handleDELETERenterQuota,
+		// pricing profile endpoints
+		"GET /pricing/profiles":         a.handleGETPricingProfiles,
+		"GET /pricing/profiles/:key":    a.handleGETPricingProfile,
+		"PUT /pricing/profiles/:key":    a.handlePUTPricingProfile,
+		"DELETE /pricing/profiles/:key": a.handleDELETEPricingProfile,
 		// metrics endpoints
 		"GET /metrics":         a.handleGETMetrics,
 		"GET /metrics/:period": a.handleGETPeriodMetrics,
 		// contract endpoints
-		"POST /contracts":                 a.handlePostContracts,
-		"GET /contracts/:id":              a.handleGETContract,
-		"GET /contracts/:id/integrity":    a.handleGETContractCheck,
-		"PUT /contracts/:id/integrity":    a.handlePUTContractCheck,
-		"DELETE /contracts/:id/integrity": a.handleDeleteContractCheck,
+		"POST /contracts":                      a.handlePostContracts,
+		"GET /contracts/:id":                   a.handleGETContract,
+		"GET /contracts/:id/integrity":         a.handleGETContractCheck,
+		"PUT /contracts/:id/integrity":         a.handlePUTContractCheck,
+		"DELETE /contracts/:id/integrity":      a.handleDeleteContractCheck,
+		"GET /contracts/:id/integrity/history": a.handleGETContractCheckHistory,
+		// v2 contract endpoints
+		"POST /v2/contracts":                      a.handlePOSTV2Contracts,
+		"GET /v2/contracts/:id":                   a.handleGETV2Contract,
+		"GET /v2/contracts/:id/integrity":         a.handleGETContractCheck,
+		"PUT /v2/contracts/:id/integrity":         a.handlePUTV2ContractCheck,
+		"DELETE /v2/contracts/:id/integrity":      a.handleDeleteContractCheck,
+		"GET /v2/contracts/:id/integrity/history": a.handleGETContractCheckHistory,
+		// integrity check endpoints
+		"GET /integrity/history": a.handleGETCheckHistory,
 		// account endpoints
 		"GET /accounts":                  a.handleGETAccounts,
 		"GET /accounts/:account/funding": a.handleGETAccountFunding,
 		// sector endpoints
+		"GET /sectors/:root":        a.handleGETSector,
+		"PUT /sectors/:root":        a.handlePUTSector,
 		"DELETE /sectors/:root":     a.handleDeleteSector,
 		"GET /sectors/:root/verify": a.handleGETVerifySector,
 		// volume endpoints
-		"GET /volumes":               a.handleGETVolumes,
-		"POST /volumes":              a.handlePOSTVolume,
-		"GET /volumes/:id":           a.handleGETVolume,
-		"PUT /volumes/:id":           a.handlePUTVolume,
-		"DELETE /volumes/:id":        a.handleDeleteVolume,
-		"DELETE /volumes/:id/cancel": a.handleDELETEVolumeCancelOp,
-		"PUT /volumes/:id/resize":    a.handlePUTVolumeResize,
+		"GET /volumes":                     a.handleGETVolumes,
+		"POST /volumes":                    a.handlePOSTVolume,
+		"GET /volumes/:id":                 a.handleGETVolume,
+		"PUT /volumes/:id":                 a.handlePUTVolume,
+		"DELETE /volumes/:id":              a.handleDeleteVolume,
+		"DELETE /volumes/:id/cancel":       a.handleDELETEVolumeCancelOp,
+		"PUT /volumes/:id/resize":          a.handlePUTVolumeResize,
+		"PUT /volumes/:id/evacuate":        a.handlePUTVolumeEvacuate,
+		"GET /volumes/:id/scrub":           a.handleGETVolumeScrub,
+		"PUT /volumes/:id/scrub":           a.handlePUTVolumeScrub,
+		"PUT /volumes/:id/scrub/pause":     a.handlePUTVolumeScrubPause,
+		"GET /volumes/:id/backup":          a.handleGETVolumeBackup,
+		"POST /volumes/:id/backup":         a.handlePOSTVolumeBackup,
+		"POST /volumes/:id/backup/restore": a.handlePOSTVolumeBackupRestore,
+		// storage endpoints
+		"GET /storage/placement":    a.handleGETStoragePlacement,
+		"PUT /storage/placement":    a.handlePUTStoragePlacement,
+		"PUT /storage/rebalance":    a.handlePUTStorageRebalance,
+		"DELETE /storage/rebalance": a.handleDELETEStorageRebalance,
+		"GET /storage/lost":         a.handleGETStorageLost,
+		"GET /storage/tiering":      a.handleGETStorageTiering,
+		"GET /storage/sync":         a.handleGETStorageSync,
 		// tpool endpoints
 		"GET /tpool/fee": a.handleGETTPoolFee,
 		// wallet endpoints
@@ -290,6 +444,7 @@ func NewServer(name string, hostKey types.PublicKey, cm ChainManager, s Syncer,
 		// system endpoints
 		"GET /system/dir":             a.handleGETSystemDir,
 		"PUT /system/dir":             a.handlePUTSystemDir,
+		"GET /system/disks":           a.handleGETSystemDisks,
 		"POST /system/sqlite3/backup": a.handlePOSTSystemSQLite3Backup,
 		// webhook endpoints
 		"GET /webhooks":           a.handleGETWebhooks,
@@ -1,19 +1,26 @@
 package api
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"go.sia.tech/core/consensus"
+	rhp2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
 	"go.sia.tech/hostd/host/contracts"
 	"go.sia.tech/hostd/host/metrics"
 	"go.sia.tech/hostd/host/settings"
 	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/disk"
 	"go.sia.tech/hostd/webhooks"
 	"go.sia.tech/jape"
 )
@@ -112,6 +119,99 @@ func (c *Client) TestDDNS() error {
 	return c.c.PUT("/settings/ddns/update", nil)
 }
 
+// RenterACL returns the renter access control list.
+func (c *Client) RenterACL() (acl settings.RenterACL, err error) {
+	err = c.c.GET("/settings/renters/acl", &acl)
+	return
+}
+
+// SetRenterACLMode sets the mode of the renter access control list.
+func (c *Client) SetRenterACLMode(mode string) error {
+	return c.c.PUT("/settings/renters/acl", RenterACLModeRequest{Mode: mode})
+}
+
+// UpdateRenterACLEntry adds a renter to the access control list or updates
+// the renter's existing entry. A zero expiration means the entry does not
+// expire.
+func (c *Client) UpdateRenterACLEntry(pk types.PublicKey, note string, expiration time.Time) error {
+	return c.c.PUT(fmt.Sprintf("/settings/renters/acl/%v", pk), RenterACLEntryRequest{Note: note, Expiration: expiration})
+}
+
+// RemoveRenterACLEntry removes a renter from the access control list.
+func (c *Client) RemoveRenterACLEntry(pk types.PublicKey) error {
+	return c.c.DELETE(fmt.Sprintf("/settings/renters/acl/%v", pk))
+}
+
+// Renter returns a renter's usage and quota.
+func (c *Client) Renter(pk types.PublicKey) (renter contracts.Renter, err error) {
+	err = c.c.GET(fmt.Sprintf("/renters/%v", pk), &renter)
+	return
+}
+
+// SetRenterQuota overrides the default quota for a renter.
+func (c *Client) SetRenterQuota(pk types.PublicKey, quota contracts.RenterQuota) error {
+	return c.c.PUT(fmt.Sprintf("/renters/quotas/%v", pk), quota)
+}
+
+// RemoveRenterQuota removes a renter's quota override.
+func (c *Client) RemoveRenterQuota(pk types.PublicKey) error {
+	return c.c.DELETE(fmt.Sprintf("/renters/quotas/%v", pk))
+}
+
+// Renters returns a paginated list of metrics for every renter and the total
+// number of renters. Renters are sorted by the field, one of the
+// contracts.RenterSort constants.
+func (c *Client) Renters(limit, offset int, sortField string, desc bool) ([]contracts.RenterMetrics, int, error) {
+	v := url.Values{
+		"limit":  []string{strconv.Itoa(limit)},
+		"offset": []string{strconv.Itoa(offset)},
+		"sort":   []string{sortField},
+		"desc":   []string{strconv.FormatBool(desc)},
+	}
+	var resp RentersResponse
+	err := c.c.GET("/renters?"+v.Encode(), &resp)
+	return resp.Renters, resp.Count, err
+}
+
+// RenterMetrics returns the current metrics for a renter.
+func (c *Client) RenterMetrics(pk types.PublicKey) (metrics contracts.RenterMetrics, err error) {
+	err = c.c.GET(fmt.Sprintf("/renters/%v/metrics", pk), &metrics)
+	return
+}
+
+// RenterPeriodMetrics returns a renter's metrics for n periods starting at
+// start.
+func (c *Client) RenterPeriodMetrics(pk types.PublicKey, start time.Time, n int, interval metrics.Interval) (periods []contracts.RenterMetrics, err error) {
+	v := url.Values{
+		"start":   []string{start.Format(time.RFC3339)},
+		"periods": []string{strconv.Itoa(n)},
+	}
+	err = c.c.GET(fmt.Sprintf("/renters/%v/metrics/%v?%v", pk, interval, v.Encode()), &periods)
+	return
+}
+
+// PricingProfiles returns every renter's pricing profile.
+func (c *Client) PricingProfiles() (profiles []settings.PricingProfile, err error) {
+	err = c.c.GET("/pricing/profiles", &profiles)
+	return
+}
+
+// PricingProfile returns a renter's pricing profile.
+func (c *Client) PricingProfile(pk types.PublicKey) (profile settings.PricingProfile, err error) {
+	err = c.c.GET(fmt.Sprintf("/pricing/profiles/%v", pk), &profile)
+	return
+}
+
+// UpdatePricingProfile adds or replaces a renter's pricing profile.
+func (c *Client) UpdatePricingProfile(pk types.PublicKey, req PricingProfileRequest) error {
+	return c.c.PUT(fmt.Sprintf("/pricing/profiles/%v", pk), req)
+}
+
+// RemovePricingProfile removes a renter's pricing profile.
+func (c *Client) RemovePricingProfile(pk types.PublicKey) error {
+	return c.c.DELETE(fmt.Sprintf("/pricing/profiles/%v", pk))
+}
+
 // Metrics returns the metrics of the host at the specified time.
 func (c *Client) Metrics(at time.Time) (metrics metrics.Metrics, err error) {
 	v := url.Values{
@@ -162,12 +262,114 @@ func (c *Client) DeleteIntegrityCheck(id types.FileContractID) error {
 	return c.c.DELETE(fmt.Sprintf("/contracts/%v/integrity", id))
 }
 
+// IntegrityCheckHistory returns the results of completed scheduled integrity
+// checks.
+func (c *Client) IntegrityCheckHistory(limit, offset int) (checks []contracts.IntegrityCheck, err error) {
+	err = c.c.GET(fmt.Sprintf("/integrity/history?limit=%d&offset=%d", limit, offset), &checks)
+	return
+}
+
+// ContractIntegrityCheckHistory returns the results of completed scheduled
+// integrity checks for the specified contract.
+func (c *Client) ContractIntegrityCheckHistory(id types.FileContractID, limit, offset int) (checks []contracts.IntegrityCheck, err error) {
+	err = c.c.GET(fmt.Sprintf("/contracts/%v/integrity/history?limit=%d&offset=%d", id, limit, offset), &checks)
+	return
+}
+
+// V2Contracts returns the v2 contracts of the host matching the filter.
+func (c *Client) V2Contracts(filter contracts.V2ContractFilter) ([]contracts.V2Contract, int, error) {
+	var resp V2ContractsResponse
+	err := c.c.POST("/v2/contracts", filter, &resp)
+	return resp.Contracts, resp.Count, err
+}
+
+// V2Contract returns the v2 contract with the specified ID.
+func (c *Client) V2Contract(id types.FileContractID) (contract contracts.V2Contract, err error) {
+	err = c.c.GET("/v2/contracts/"+id.String(), &contract)
+	return
+}
+
+// StartV2IntegrityCheck starts an integrity check for the v2 contract with the
+// specified ID.
+func (c *Client) StartV2IntegrityCheck(id types.FileContractID) error {
+	return c.c.PUT(fmt.Sprintf("/v2/contracts/%v/integrity", id), nil)
+}
+
+// V2IntegrityCheckProgress returns the progress of the integrity check for the
+// specified v2 contract.
+func (c *Client) V2IntegrityCheckProgress(id types.FileContractID) (IntegrityCheckResult, error) {
+	var result IntegrityCheckResult
+	err := c.c.GET(fmt.Sprintf("/v2/contracts/%v/integrity", id), &result)
+	return result, err
+}
+
+// DeleteV2IntegrityCheck deletes the integrity check for the specified v2
+// contract.
+func (c *Client) DeleteV2IntegrityCheck(id types.FileContractID) error {
+	return c.c.DELETE(fmt.Sprintf("/v2/contracts/%v/integrity", id))
+}
+
 // DeleteSector deletes the sector with the specified root. This can cause
 // contract failures if the sector is still in use.
 func (c *Client) DeleteSector(root types.Hash256) error {
 	return c.c.DELETE(fmt.Sprintf("/sectors/%s", root))
 }
 
+// LostSectors returns a paginated list of lost sectors and the contracts at
+// risk of failing their storage proofs.
+func (c *Client) LostSectors(limit, offset int) (resp LostSectorsResponse, err error) {
+	v := url.Values{
+		"limit":  []string{strconv.Itoa(limit)},
+		"offset": []string{strconv.Itoa(offset)},
+	}
+	err = c.c.GET("/storage/lost?"+v.Encode(), &resp)
+	return
+}
+
+// sectorRequest sends a request with a raw sector body to the
+// /sectors/:root endpoint. The caller must close the response body.
+func (c *Client) sectorRequest(method string, root types.Hash256, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/sectors/%s", c.c.BaseURL, root), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth("", c.c.Password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.New(strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// ReadSector returns the raw data of a sector stored on the host.
+func (c *Client) ReadSector(root types.Hash256) (*[rhp2.SectorSize]byte, error) {
+	resp, err := c.sectorRequest(http.MethodGet, root, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sector: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var sector [rhp2.SectorSize]byte
+	if _, err := io.ReadFull(resp.Body, sector[:]); err != nil {
+		return nil, fmt.Errorf("failed to read sector: %w", err)
+	}
+	return &sector, nil
+}
+
+// RestoreSector writes a lost or corrupt sector back to the host's volumes.
+func (c *Client) RestoreSector(root types.Hash256, sector *[rhp2.SectorSize]byte) error {
+	resp, err := c.sectorRequest(http.MethodPut, root, bytes.NewReader(sector[:]))
+	if err != nil {
+		return fmt.Errorf("failed to restore sector: %w", err)
+	}
+	return resp.Body.Close()
+}
+
 // Volumes returns the volumes of the host.
 func (c *Client) Volumes() (volumes []VolumeMeta, err error) {
 	err = c.c.GET("/volumes", &volumes)
@@ -208,6 +410,96 @@ func (c *Client) ResizeVolume(id int, sectors uint64) error {
 	return c.c.PUT(fmt.Sprintf("/volumes/%v/resize", id), req)
 }
 
+// EvacuateVolume migrates all sectors stored in the volume with the specified
+// ID to the destination volume.
+func (c *Client) EvacuateVolume(id, destID int) error {
+	req := EvacuateVolumeRequest{
+		DestinationID: int64(destID),
+	}
+	return c.c.PUT(fmt.Sprintf("/volumes/%v/evacuate", id), req)
+}
+
+// PlacementStrategy returns the strategy used to place new sectors.
+func (c *Client) PlacementStrategy() (strategy string, err error) {
+	var resp StoragePlacement
+	err = c.c.GET("/storage/placement", &resp)
+	return resp.Strategy, err
+}
+
+// SetPlacementStrategy sets the strategy used to place new sectors.
+func (c *Client) SetPlacementStrategy(strategy string) error {
+	return c.c.PUT("/storage/placement", StoragePlacement{Strategy: strategy})
+}
+
+// TieringStatus returns the status of sector tiering and the tiering stats of
+// each volume with a tier.
+func (c *Client) TieringStatus() (status storage.TieringStatus, err error) {
+	err = c.c.GET("/storage/tiering", &status)
+	return
+}
+
+// SyncMetrics returns the sync policy and metrics about syncing sector data
+// to disk.
+func (c *Client) SyncMetrics() (metrics storage.SyncMetrics, err error) {
+	err = c.c.GET("/storage/sync", &metrics)
+	return
+}
+
+// RebalanceVolumes starts moving sectors between volumes to match the
+// placement strategy.
+func (c *Client) RebalanceVolumes() error {
+	return c.c.PUT("/storage/rebalance", nil)
+}
+
+// CancelRebalance cancels a running rebalance.
+func (c *Client) CancelRebalance() error {
+	return c.c.DELETE("/storage/rebalance")
+}
+
+// StartVolumeScrub starts verifying every sector stored in the volume with
+// the specified ID. If the volume's previous scrub was paused, it is resumed.
+func (c *Client) StartVolumeScrub(id int) error {
+	return c.c.PUT(fmt.Sprintf("/volumes/%v/scrub", id), nil)
+}
+
+// PauseVolumeScrub pauses the scrub of the volume with the specified ID.
+func (c *Client) PauseVolumeScrub(id int) error {
+	return c.c.PUT(fmt.Sprintf("/volumes/%v/scrub/pause", id), nil)
+}
+
+// VolumeScrub returns the progress of the most recent scrub of the volume
+// with the specified ID.
+func (c *Client) VolumeScrub(id int, limit, offset int) (resp VolumeScrubResponse, err error) {
+	err = c.c.GET(fmt.Sprintf("/volumes/%v/scrub?limit=%d&offset=%d", id, limit, offset), &resp)
+	return
+}
+
+// BackupVolume starts an incremental backup of the volume with the specified
+// ID to a directory on the host. The backup can be cancelled with
+// CancelVolumeOperation.
+func (c *Client) BackupVolume(id int, path string) error {
+	return c.c.POST(fmt.Sprintf("/volumes/%v/backup", id), VolumeBackupRequest{Path: path}, nil)
+}
+
+// RestoreVolumeBackup starts restoring the lost sectors of the volume with
+// the specified ID from a backup directory on the host.
+func (c *Client) RestoreVolumeBackup(id int, path string) error {
+	return c.c.POST(fmt.Sprintf("/volumes/%v/backup/restore", id), VolumeBackupRequest{Path: path}, nil)
+}
+
+// CancelVolumeOperation cancels the running operation on the volume with the
+// specified ID.
+func (c *Client) CancelVolumeOperation(id int) error {
+	return c.c.DELETE(fmt.Sprintf("/volumes/%v/cancel", id))
+}
+
+// VolumeBackup returns the progress of the most recent backup or restore of
+// the volume with the specified ID.
+func (c *Client) VolumeBackup(id int) (resp storage.VolumeBackup, err error) {
+	err = c.c.GET(fmt.Sprintf("/volumes/%v/backup", id), &resp)
+	return
+}
+
 // Wallet returns the state of the host's wallet.
 func (c *Client) Wallet() (resp WalletResponse, err error) {
 	err = c.c.GET("/wallet", &resp)
@@ -253,6 +545,12 @@ func (c *Client) BackupSQLite3(destPath string) error {
 	return c.c.POST("/system/sqlite3/backup", BackupRequest{destPath}, nil)
 }
 
+// SystemDisks returns the status of the filesystems monitored by the host.
+func (c *Client) SystemDisks() (resp []disk.FilesystemStatus, err error) {
+	err = c.c.GET("/system/disks", &resp)
+	return
+}
+
 // MkDir creates a new directory on the host.
 func (c *Client) MkDir(path string) error {
 	req := CreateDirRequest{
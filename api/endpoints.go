@@ -207,6 +207,13 @@ func (a *api) handlePATCHSettings(jc jape.Context) {
 		return
 	}
 
+	// configure the disk cache before updating the settings so an invalid
+	// cache path is not persisted
+	if err := a.volumes.ConfigureDiskCache(settings.SectorCachePath, settings.SectorCacheDiskSize); err != nil {
+		jc.Error(fmt.Errorf("failed to configure disk cache: %w", err), http.StatusBadRequest)
+		return
+	}
+
 	err = a.settings.UpdateSettings(settings)
 	if !a.checkServerError(jc, "failed to update settings", err) {
 		return
@@ -236,74 +243,234 @@ func (a *api) handlePUTDDNSUpdate(jc jape.Context) {
 	a.checkServerError(jc, "failed to update dynamic DNS", err)
 }
 
-func (a *api) handleGETMetrics(jc jape.Context) {
-	var timestamp time.Time
-	if err := jc.DecodeForm("timestamp", &timestamp); err != nil {
+func (a *api) handleGETRenter(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
 		return
-	} else if timestamp.IsZero() {
-		timestamp = time.Now()
 	}
 
-	metrics, err := a.metrics.Metrics(timestamp)
-	if !a.checkServerError(jc, "failed to get metrics", err) {
+	renter, err := a.contracts.Renter(pk)
+	if !a.checkServerError(jc, "failed to get renter", err) {
 		return
 	}
+	jc.Encode(renter)
+}
 
-	a.writeResponse(jc, Metrics(metrics))
+func (a *api) handleGETRenters(jc jape.Context) {
+	limit, offset := parseLimitParams(jc, 100, 500)
+	filter := contracts.RenterFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+	if err := jc.DecodeForm("sort", &filter.SortField); err != nil {
+		return
+	} else if err := jc.DecodeForm("desc", &filter.SortDesc); err != nil {
+		return
+	}
+
+	renters, count, err := a.contracts.Renters(filter)
+	if errors.Is(err, contracts.ErrInvalidRenterSort) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if !a.checkServerError(jc, "failed to get renters", err) {
+		return
+	}
+	jc.Encode(RentersResponse{
+		Renters: renters,
+		Count:   count,
+	})
 }
 
-func (a *api) handleGETPeriodMetrics(jc jape.Context) {
-	var interval metrics.Interval
-	if err := jc.DecodeParam("period", &interval); err != nil {
+func (a *api) handleGETRenterMetrics(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
 		return
 	}
-	var start time.Time
-	var periods int
-	if err := jc.DecodeForm("start", &start); err != nil {
+
+	metrics, err := a.contracts.RenterMetrics(pk)
+	if !a.checkServerError(jc, "failed to get renter metrics", err) {
 		return
-	} else if err := jc.DecodeForm("periods", &periods); err != nil {
+	}
+	jc.Encode(metrics)
+}
+
+func (a *api) handleGETRenterPeriodMetrics(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
 		return
-	} else if start.IsZero() {
-		jc.Error(errors.New("start time cannot be zero"), http.StatusBadRequest)
+	}
+	start, periods, interval, ok := parsePeriodParams(jc)
+	if !ok {
 		return
-	} else if start.After(time.Now()) {
-		jc.Error(errors.New("start time cannot be in the future"), http.StatusBadRequest)
+	}
+
+	period, err := a.contracts.RenterPeriodMetrics(pk, start, periods, interval)
+	if !a.checkServerError(jc, "failed to get renter metrics", err) {
 		return
 	}
+	jc.Encode(period)
+}
 
-	start, err := metrics.Normalize(start, interval)
-	if err != nil {
+func (a *api) handlePUTRenterQuota(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+	var quota contracts.RenterQuota
+	if err := jc.Decode(&quota); err != nil {
+		return
+	}
+
+	err := a.contracts.SetRenterQuota(pk, quota)
+	a.checkServerError(jc, "failed to set renter quota", err)
+}
+
+func (a *api) handleDELETERenterQuota(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+
+	err := a.contracts.RemoveRenterQuota(pk)
+	if errors.Is(err, contracts.ErrRenterQuotaNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	a.checkServerError(jc, "failed to remove renter quota", err)
+}
+
+func (a *api) handleGETRenterACL(jc jape.Context) {
+	acl, err := a.settings.RenterACL()
+	if !a.checkServerError(jc, "failed to get renter ACL", err) {
+		return
+	}
+	jc.Encode(acl)
+}
+
+func (a *api) handlePUTRenterACL(jc jape.Context) {
+	var req RenterACLModeRequest
+	if err := jc.Decode(&req); err != nil {
+		return
+	}
+
+	err := a.settings.SetRenterACLMode(req.Mode)
+	if errors.Is(err, settings.ErrInvalidRenterACLMode) {
 		jc.Error(err, http.StatusBadRequest)
 		return
 	}
+	a.checkServerError(jc, "failed to set renter ACL mode", err)
+}
 
-	if periods == 0 {
-		// if periods is 0 calculate the number of periods between start and now
-		switch interval {
-		case metrics.Interval5Minutes:
-			periods = int(time.Now().Truncate(5*time.Minute).Sub(start)/(5*time.Minute)) + 1
-		case metrics.Interval15Minutes:
-			periods = int(time.Now().Truncate(15*time.Minute).Sub(start)/(15*time.Minute)) + 1
-		case metrics.IntervalHourly:
-			periods = int(time.Now().Truncate(time.Hour).Sub(start)/time.Hour) + 1
-		case metrics.IntervalDaily:
-			y, m, d := time.Now().Date()
-			end := time.Date(y, m, d, 0, 0, 0, 0, start.Location())
-			periods = int(end.Sub(start)/(24*time.Hour)) + 1
-		case metrics.IntervalWeekly:
-			end := time.Now()
-			y, m, d := end.Date()
-			end = time.Date(y, m, d+int(end.Weekday()), 0, 0, 0, 0, start.Location())
-			periods = int(end.Sub(start)/(7*24*time.Hour)) + 1
-		case metrics.IntervalMonthly:
-			y, m, _ := start.Date()
-			y1, m1, _ := time.Now().Date()
-			periods = int((y1-y)*12+int(m1-m)) + 1
-		case metrics.IntervalYearly:
-			y, _, _ := start.Date()
-			y1, _, _ := time.Now().Date()
-			periods = int(y1-y) + 1
-		}
+func (a *api) handlePUTRenterACLEntry(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+	var req RenterACLEntryRequest
+	if err := jc.Decode(&req); err != nil {
+		return
+	}
+
+	err := a.settings.UpdateRenterACLEntry(pk, req.Note, req.Expiration)
+	a.checkServerError(jc, "failed to update renter ACL entry", err)
+}
+
+func (a *api) handleDELETERenterACLEntry(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+
+	err := a.settings.RemoveRenterACLEntry(pk)
+	if errors.Is(err, settings.ErrRenterACLEntryNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	a.checkServerError(jc, "failed to remove renter ACL entry", err)
+}
+
+func (a *api) handleGETPricingProfiles(jc jape.Context) {
+	profiles, err := a.settings.PricingProfiles()
+	if !a.checkServerError(jc, "failed to get pricing profiles", err) {
+		return
+	}
+	jc.Encode(profiles)
+}
+
+func (a *api) handleGETPricingProfile(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+
+	profile, ok := a.settings.PricingProfile(pk)
+	if !ok {
+		jc.Error(settings.ErrPricingProfileNotFound, http.StatusNotFound)
+		return
+	}
+	jc.Encode(profile)
+}
+
+func (a *api) handlePUTPricingProfile(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+	var req PricingProfileRequest
+	if err := jc.Decode(&req); err != nil {
+		return
+	}
+
+	err := a.settings.UpdatePricingProfile(settings.PricingProfile{
+		PublicKey:     pk,
+		Note:          req.Note,
+		ContractPrice: req.ContractPrice,
+		StoragePrice:  req.StoragePrice,
+		IngressPrice:  req.IngressPrice,
+		EgressPrice:   req.EgressPrice,
+		Collateral:    req.Collateral,
+	})
+	if errors.Is(err, settings.ErrInvalidPricingProfile) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	a.checkServerError(jc, "failed to update pricing profile", err)
+}
+
+func (a *api) handleDELETEPricingProfile(jc jape.Context) {
+	var pk types.PublicKey
+	if err := jc.DecodeParam("key", &pk); err != nil {
+		return
+	}
+
+	err := a.settings.RemovePricingProfile(pk)
+	if errors.Is(err, settings.ErrPricingProfileNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	a.checkServerError(jc, "failed to remove pricing profile", err)
+}
+
+func (a *api) handleGETMetrics(jc jape.Context) {
+	var timestamp time.Time
+	if err := jc.DecodeForm("timestamp", &timestamp); err != nil {
+		return
+	} else if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	metrics, err := a.metrics.Metrics(timestamp)
+	if !a.checkServerError(jc, "failed to get metrics", err) {
+		return
+	}
+
+	a.writeResponse(jc, Metrics(metrics))
+}
+
+func (a *api) handleGETPeriodMetrics(jc jape.Context) {
+	start, periods, interval, ok := parsePeriodParams(jc)
+	if !ok {
+		return
 	}
 
 	period, err := a.metrics.PeriodMetrics(start, periods, interval)
@@ -348,6 +515,41 @@ func (a *api) handleGETContract(jc jape.Context) {
 	jc.Encode(contract)
 }
 
+func (a *api) handlePOSTV2Contracts(jc jape.Context) {
+	var filter contracts.V2ContractFilter
+	if err := jc.Decode(&filter); err != nil {
+		return
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 500
+	}
+
+	contracts, count, err := a.contracts.V2Contracts(filter)
+	if !a.checkServerError(jc, "failed to get contracts", err) {
+		return
+	}
+	jc.Encode(V2ContractsResponse{
+		Contracts: contracts,
+		Count:     count,
+	})
+}
+
+func (a *api) handleGETV2Contract(jc jape.Context) {
+	var id types.FileContractID
+	if err := jc.DecodeParam("id", &id); err != nil {
+		return
+	}
+	contract, err := a.contracts.V2Contract(id)
+	if errors.Is(err, contracts.ErrNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if !a.checkServerError(jc, "failed to get contract", err) {
+		return
+	}
+	jc.Encode(contract)
+}
+
 func (a *api) handleGETVolume(jc jape.Context) {
 	var id int64
 	if err := jc.DecodeParam("id", &id); err != nil {
@@ -364,7 +566,7 @@ func (a *api) handleGETVolume(jc jape.Context) {
 	} else if !a.checkServerError(jc, "failed to get volume", err) {
 		return
 	}
-	jc.Encode(toJSONVolume(volume))
+	a.writeResponse(jc, toJSONVolume(volume))
 }
 
 func (a *api) handlePUTVolume(jc jape.Context) {
@@ -385,8 +587,45 @@ func (a *api) handlePUTVolume(jc jape.Context) {
 	if errors.Is(err, storage.ErrVolumeNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
+	} else if !a.checkServerError(jc, "failed to update volume", err) {
+		return
+	}
+
+	if req.AutoGrow != nil {
+		err := a.volumes.SetVolumeAutoGrow(id, *req.AutoGrow)
+		if !a.checkServerError(jc, "failed to update volume auto-grow policy", err) {
+			return
+		}
+	}
+
+	if req.Tier != nil {
+		err := a.volumes.SetVolumeTier(id, *req.Tier)
+		if errors.Is(err, storage.ErrInvalidVolumeTier) {
+			jc.Error(err, http.StatusBadRequest)
+			return
+		} else if !a.checkServerError(jc, "failed to update volume tier", err) {
+			return
+		}
 	}
-	a.checkServerError(jc, "failed to update volume", err)
+
+	if req.Weight == nil && req.Priority == nil {
+		return
+	}
+
+	// keep the current placement values that were not specified
+	volume, err := a.volumes.Volume(id)
+	if !a.checkServerError(jc, "failed to get volume", err) {
+		return
+	}
+	weight, priority := volume.Weight, volume.Priority
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	err = a.volumes.SetVolumePlacement(id, weight, priority)
+	a.checkServerError(jc, "failed to update volume placement", err)
 }
 
 func (a *api) handleDeleteSector(jc jape.Context) {
@@ -594,6 +833,14 @@ func (a *api) handlePUTSystemDir(jc jape.Context) {
 	a.checkServerError(jc, "failed to create dir", os.MkdirAll(req.Path, 0775))
 }
 
+func (a *api) handleGETSystemDisks(jc jape.Context) {
+	if a.diskMonitor == nil {
+		jc.Error(errors.New("disk monitor not enabled"), http.StatusNotFound)
+		return
+	}
+	a.writeResponse(jc, SystemDisksResponse(a.diskMonitor.Filesystems()))
+}
+
 func (a *api) handlePOSTSystemSQLite3Backup(jc jape.Context) {
 	if a.sqlite3Store == nil {
 		jc.Error(errors.New("sqlite3 store not available"), http.StatusNotFound)
@@ -715,6 +962,63 @@ func parseLimitParams(jc jape.Context, defaultLimit, maxLimit int) (limit, offse
 	return
 }
 
+// parsePeriodParams decodes the start time and number of periods of a
+// [GET] /metrics/:period style request. If the number of periods is not set,
+// it is calculated from the start time to now.
+func parsePeriodParams(jc jape.Context) (start time.Time, periods int, interval metrics.Interval, ok bool) {
+	if err := jc.DecodeParam("period", &interval); err != nil {
+		return
+	}
+	if err := jc.DecodeForm("start", &start); err != nil {
+		return
+	} else if err := jc.DecodeForm("periods", &periods); err != nil {
+		return
+	} else if start.IsZero() {
+		jc.Error(errors.New("start time cannot be zero"), http.StatusBadRequest)
+		return
+	} else if start.After(time.Now()) {
+		jc.Error(errors.New("start time cannot be in the future"), http.StatusBadRequest)
+		return
+	}
+
+	start, err := metrics.Normalize(start, interval)
+	if err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+
+	if periods == 0 {
+		// if periods is 0 calculate the number of periods between start and now
+		switch interval {
+		case metrics.Interval5Minutes:
+			periods = int(time.Now().Truncate(5*time.Minute).Sub(start)/(5*time.Minute)) + 1
+		case metrics.Interval15Minutes:
+			periods = int(time.Now().Truncate(15*time.Minute).Sub(start)/(15*time.Minute)) + 1
+		case metrics.IntervalHourly:
+			periods = int(time.Now().Truncate(time.Hour).Sub(start)/time.Hour) + 1
+		case metrics.IntervalDaily:
+			y, m, d := time.Now().Date()
+			end := time.Date(y, m, d, 0, 0, 0, 0, start.Location())
+			periods = int(end.Sub(start)/(24*time.Hour)) + 1
+		case metrics.IntervalWeekly:
+			end := time.Now()
+			y, m, d := end.Date()
+			end = time.Date(y, m, d+int(end.Weekday()), 0, 0, 0, 0, start.Location())
+			periods = int(end.Sub(start)/(7*24*time.Hour)) + 1
+		case metrics.IntervalMonthly:
+			y, m, _ := start.Date()
+			y1, m1, _ := time.Now().Date()
+			periods = int((y1-y)*12+int(m1-m)) + 1
+		case metrics.IntervalYearly:
+			y, _, _ := start.Date()
+			y1, _, _ := time.Now().Date()
+			periods = int(y1-y) + 1
+		}
+	}
+
+	return start, periods, interval, true
+}
+
 func toJSONVolume(vol storage.VolumeMeta) VolumeMeta {
 	jvm := VolumeMeta{
 		VolumeMeta: vol,
@@ -55,6 +55,16 @@ func (ic *integrityCheckJobs) Results(contractID types.FileContractID) (Integrit
 // CheckContract starts an integrity check for the specified contract. If a
 // check is already running, an error is returned.
 func (ic *integrityCheckJobs) CheckContract(contractID types.FileContractID) (uint64, error) {
+	return ic.startCheck(contractID, ic.contracts.CheckIntegrity)
+}
+
+// CheckV2Contract starts an integrity check for the specified v2 contract. If
+// a check is already running, an error is returned.
+func (ic *integrityCheckJobs) CheckV2Contract(contractID types.FileContractID) (uint64, error) {
+	return ic.startCheck(contractID, ic.contracts.CheckV2Integrity)
+}
+
+func (ic *integrityCheckJobs) startCheck(contractID types.FileContractID, checkFn func(context.Context, types.FileContractID) (<-chan contracts.IntegrityResult, uint64, error)) (uint64, error) {
 	ic.mu.Lock()
 	defer ic.mu.Unlock()
 
@@ -63,7 +73,7 @@ func (ic *integrityCheckJobs) CheckContract(contractID types.FileContractID) (ui
 		return 0, fmt.Errorf("integrity check already running for contract %v", contractID)
 	}
 
-	results, roots, err := ic.contracts.CheckIntegrity(context.Background(), contractID)
+	results, roots, err := checkFn(context.Background(), contractID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to check contract integrity: %w", err)
 	}
@@ -128,3 +138,37 @@ func (a *api) handlePUTContractCheck(c jape.Context) {
 	_, err := a.checks.CheckContract(contractID)
 	a.checkServerError(c, "failed to check contract integrity", err)
 }
+
+func (a *api) handlePUTV2ContractCheck(c jape.Context) {
+	var contractID types.FileContractID
+	if err := c.DecodeParam("id", &contractID); err != nil {
+		return
+	}
+
+	_, err := a.checks.CheckV2Contract(contractID)
+	a.checkServerError(c, "failed to check contract integrity", err)
+}
+
+func (a *api) handleGETCheckHistory(c jape.Context) {
+	limit, offset := parseLimitParams(c, 100, 500)
+
+	checks, err := a.contracts.IntegrityChecks(limit, offset)
+	if !a.checkServerError(c, "failed to get integrity check history", err) {
+		return
+	}
+	c.Encode(checks)
+}
+
+func (a *api) handleGETContractCheckHistory(c jape.Context) {
+	var contractID types.FileContractID
+	if err := c.DecodeParam("id", &contractID); err != nil {
+		return
+	}
+	limit, offset := parseLimitParams(c, 100, 500)
+
+	checks, err := a.contracts.ContractIntegrityChecks(contractID, limit, offset)
+	if !a.checkServerError(c, "failed to get integrity check history", err) {
+		return
+	}
+	c.Encode(checks)
+}
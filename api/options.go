@@ -28,6 +28,13 @@ func WithSQLite3Store(s SQLite3Store) ServerOption {
 	}
 }
 
+// WithDiskMonitor sets the filesystem monitor for the API server.
+func WithDiskMonitor(m DiskMonitor) ServerOption {
+	return func(a *api) {
+		a.diskMonitor = m
+	}
+}
+
 // WithWebhooks sets the webhooks manager for the API server.
 func WithWebhooks(w Webhooks) ServerOption {
 	return func(a *api) {
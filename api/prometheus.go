@@ -1,10 +1,12 @@
 package api
 
 import (
+	"strconv"
 	"time"
 
 	rhp2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/internal/prometheus"
 )
 
@@ -282,6 +284,14 @@ func (m Metrics) PrometheusMetric() []prometheus.Metric {
 			Name:  "hostd_metrics_storage_sector_cache_misses",
 			Value: float64(m.Storage.SectorCacheMisses),
 		},
+		{
+			Name:  "hostd_metrics_storage_sector_disk_cache_hits",
+			Value: float64(m.Storage.SectorDiskCacheHits),
+		},
+		{
+			Name:  "hostd_metrics_storage_sector_disk_cache_misses",
+			Value: float64(m.Storage.SectorDiskCacheMisses),
+		},
 		{
 			Name:  "hostd_metrics_data_rhp_ingress",
 			Value: float64(m.Data.RHP.Ingress),
@@ -345,75 +355,172 @@ func (t TPoolResp) PrometheusMetric() (metrics []prometheus.Metric) {
 	}
 }
 
+// latencyHistogramMetrics returns Prometheus samples for a volume latency
+// histogram. Bucket samples are cumulative and the bounds are in seconds.
+func latencyHistogramMetrics(name string, labels map[string]any, h storage.LatencyHistogram) (metrics []prometheus.Metric) {
+	var cumulative uint64
+	for i, count := range h.Buckets {
+		cumulative += count
+		le := "+Inf"
+		if i < len(storage.LatencyBuckets) {
+			le = strconv.FormatFloat(storage.LatencyBuckets[i].Seconds(), 'f', -1, 64)
+		}
+		bucketLabels := map[string]any{"le": le}
+		for k, v := range labels {
+			bucketLabels[k] = v
+		}
+		metrics = append(metrics, prometheus.Metric{
+			Name:   name + "_bucket",
+			Labels: bucketLabels,
+			Value:  float64(cumulative),
+		})
+	}
+	metrics = append(metrics, prometheus.Metric{
+		Name:   name + "_sum",
+		Labels: labels,
+		Value:  h.Sum.Seconds(),
+	}, prometheus.Metric{
+		Name:   name + "_count",
+		Labels: labels,
+		Value:  float64(h.Count),
+	})
+	return
+}
+
 // PrometheusMetric returns Prometheus samples for the hosts volumes.
 func (v VolumeResp) PrometheusMetric() (metrics []prometheus.Metric) {
 	for _, volume := range v {
+		metrics = append(metrics, volume.PrometheusMetric()...)
+	}
+	return
+}
+
+// PrometheusMetric returns Prometheus samples for a volume.
+func (volume VolumeMeta) PrometheusMetric() (metrics []prometheus.Metric) {
+	labels := map[string]any{
+		"id":         volume.ID,
+		"local_path": volume.LocalPath,
+	}
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_used_sectors",
+		Labels: labels,
+		Value:  float64(volume.UsedSectors),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_total_sectors",
+		Labels: labels,
+		Value:  float64(volume.TotalSectors),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_read_only",
+		Labels: labels,
+		Value: func() float64 {
+			if volume.ReadOnly {
+				return 1
+			}
+			return 0
+		}(),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_available",
+		Labels: labels,
+		Value: func() float64 {
+			if volume.Available {
+				return 1
+			}
+			return 0
+		}(),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_failed_reads",
+		Labels: labels,
+		Value:  float64(volume.FailedReads),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_failed_writes",
+		Labels: labels,
+		Value:  float64(volume.FailedWrites),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_successful_reads",
+		Labels: labels,
+		Value:  float64(volume.SuccessfulReads),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_successful_writes",
+		Labels: labels,
+		Value:  float64(volume.SuccessfulWrites),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_status",
+		Labels: labels,
+		Value: func() float64 {
+			if volume.Status == "creating" {
+				return 1
+			} else if volume.Status == "ready" {
+				return 2
+			}
+			return 0
+		}(),
+	})
+	metrics = append(metrics, prometheus.Metric{
+		Name:   "hostd_volume_health",
+		Labels: labels,
+		Value:  volume.Health,
+	})
+	metrics = append(metrics, latencyHistogramMetrics("hostd_volume_read_latency_seconds", labels, volume.ReadLatency)...)
+	metrics = append(metrics, latencyHistogramMetrics("hostd_volume_write_latency_seconds", labels, volume.WriteLatency)...)
+	metrics = append(metrics, latencyHistogramMetrics("hostd_volume_sync_latency_seconds", labels, volume.SyncLatency)...)
+	return
+}
+
+// PrometheusMetric returns Prometheus samples for the host's monitored
+// filesystems.
+func (d SystemDisksResponse) PrometheusMetric() (metrics []prometheus.Metric) {
+	for _, fs := range d {
+		if fs.Error != "" {
+			continue
+		}
 		labels := map[string]any{
-			"id":         volume.ID,
-			"local_path": volume.LocalPath,
+			"name": fs.Name,
+			"path": fs.Path,
 		}
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_used_sectors",
-			Labels: labels,
-			Value:  float64(volume.UsedSectors),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_total_sectors",
-			Labels: labels,
-			Value:  float64(volume.TotalSectors),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_read_only",
-			Labels: labels,
-			Value: func() float64 {
-				if volume.ReadOnly {
-					return 1
-				}
-				return 0
-			}(),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_available",
-			Labels: labels,
-			Value: func() float64 {
-				if volume.Available {
-					return 1
-				}
-				return 0
-			}(),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_failed_reads",
-			Labels: labels,
-			Value:  float64(volume.FailedReads),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_failed_writes",
-			Labels: labels,
-			Value:  float64(volume.FailedWrites),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_successful_reads",
-			Labels: labels,
-			Value:  float64(volume.SuccessfulReads),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_successful_writes",
-			Labels: labels,
-			Value:  float64(volume.SuccessfulWrites),
-		})
-		metrics = append(metrics, prometheus.Metric{
-			Name:   "hostd_volume_status",
-			Labels: labels,
-			Value: func() float64 {
-				if volume.Status == "creating" {
-					return 1
-				} else if volume.Status == "ready" {
-					return 2
-				}
-				return 0
-			}(),
-		})
+		var readOnly float64
+		if fs.ReadOnly {
+			readOnly = 1
+		}
+		metrics = append(metrics, []prometheus.Metric{
+			{
+				Name:      "hostd_filesystem_free_bytes",
+				Labels:    labels,
+				Value:     float64(fs.FreeBytes),
+				Timestamp: fs.Timestamp,
+			},
+			{
+				Name:      "hostd_filesystem_total_bytes",
+				Labels:    labels,
+				Value:     float64(fs.TotalBytes),
+				Timestamp: fs.Timestamp,
+			},
+			{
+				Name:      "hostd_filesystem_free_inodes",
+				Labels:    labels,
+				Value:     float64(fs.FreeInodes),
+				Timestamp: fs.Timestamp,
+			},
+			{
+				Name:      "hostd_filesystem_total_inodes",
+				Labels:    labels,
+				Value:     float64(fs.TotalInodes),
+				Timestamp: fs.Timestamp,
+			},
+			{
+				Name:      "hostd_filesystem_read_only",
+				Labels:    labels,
+				Value:     readOnly,
+				Timestamp: fs.Timestamp,
+			},
+		}...)
 	}
 	return
 }
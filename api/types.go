@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/hostd/host/metrics"
 	"go.sia.tech/hostd/host/settings"
 	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/disk"
 )
 
 // JSON keys for host setting fields
@@ -100,6 +101,16 @@ type (
 	// UpdateVolumeRequest is the request body for the [PUT] /volume/:id endpoint.
 	UpdateVolumeRequest struct {
 		ReadOnly bool `json:"readOnly"`
+		// Weight and Priority update the volume's sector placement. If
+		// omitted, the current values are kept.
+		Weight   *uint64 `json:"weight,omitempty"`
+		Priority *int64  `json:"priority,omitempty"`
+		// AutoGrow updates the volume's auto-grow policy. If omitted, the
+		// current policy is kept.
+		AutoGrow *storage.AutoGrowPolicy `json:"autoGrow,omitempty"`
+		// Tier updates the volume's storage tier. If omitted, the current
+		// tier is kept.
+		Tier *string `json:"tier,omitempty"`
 	}
 
 	// ResizeVolumeRequest is the request body for the [PUT] /volume/:id/resize endpoint.
@@ -107,12 +118,58 @@ type (
 		MaxSectors uint64 `json:"maxSectors"`
 	}
 
+	// StoragePlacement is the request and response body for the [GET] and
+	// [PUT] /storage/placement endpoints.
+	StoragePlacement struct {
+		Strategy string `json:"strategy"`
+	}
+
+	// LostSectorsResponse is the response body for the [GET] /storage/lost
+	// endpoint.
+	LostSectorsResponse struct {
+		// Count is the total number of lost sectors.
+		Count   int                  `json:"count"`
+		Sectors []storage.LostSector `json:"sectors"`
+		// Contracts are the unresolved contracts that reference lost
+		// sectors.
+		Contracts []contracts.LostSectorRisk `json:"contracts"`
+	}
+
+	// EvacuateVolumeRequest is the request body for the [PUT] /volume/:id/evacuate endpoint.
+	EvacuateVolumeRequest struct {
+		DestinationID int64 `json:"destinationID"`
+	}
+
+	// VolumeScrubResponse is the response body for the [GET] /volumes/:id/scrub endpoint.
+	VolumeScrubResponse struct {
+		storage.VolumeScrub
+		Errors []storage.ScrubError `json:"errors"`
+	}
+
+	// VolumeBackupRequest is the request body for the [POST] /volumes/:id/backup
+	// and [POST] /volumes/:id/backup/restore endpoints.
+	VolumeBackupRequest struct {
+		Path string `json:"path"`
+	}
+
 	// ContractsResponse is the response body for the [POST] /contracts endpoint.
 	ContractsResponse struct {
 		Count     int                  `json:"count"`
 		Contracts []contracts.Contract `json:"contracts"`
 	}
 
+	// V2ContractsResponse is the response body for the [POST] /v2/contracts endpoint.
+	V2ContractsResponse struct {
+		Count     int                    `json:"count"`
+		Contracts []contracts.V2Contract `json:"contracts"`
+	}
+
+	// RentersResponse is the response body for the [GET] /renters endpoint.
+	RentersResponse struct {
+		Count   int                       `json:"count"`
+		Renters []contracts.RenterMetrics `json:"renters"`
+	}
+
 	// WalletResponse is the response body for the [GET] /wallet endpoint.
 	WalletResponse struct {
 		wallet.Balance
@@ -151,6 +208,36 @@ type (
 		Directories []string `json:"directories"`
 	}
 
+	// SystemDisksResponse is the response body for the [GET] /system/disks
+	// endpoint.
+	SystemDisksResponse []disk.FilesystemStatus
+
+	// RenterACLModeRequest is the request body for the [PUT]
+	// /settings/renters/acl endpoint.
+	RenterACLModeRequest struct {
+		Mode string `json:"mode"`
+	}
+
+	// RenterACLEntryRequest is the request body for the [PUT]
+	// /settings/renters/acl/:key endpoint. A zero expiration means the entry
+	// does not expire.
+	RenterACLEntryRequest struct {
+		Note       string    `json:"note"`
+		Expiration time.Time `json:"expiration"`
+	}
+
+	// PricingProfileRequest is the request body for the [PUT]
+	// /pricing/profiles/:key endpoint. Prices that are nil use the host's
+	// settings.
+	PricingProfileRequest struct {
+		Note          string          `json:"note"`
+		ContractPrice *types.Currency `json:"contractPrice,omitempty"`
+		StoragePrice  *types.Currency `json:"storagePrice,omitempty"`
+		IngressPrice  *types.Currency `json:"ingressPrice,omitempty"`
+		EgressPrice   *types.Currency `json:"egressPrice,omitempty"`
+		Collateral    *types.Currency `json:"collateral,omitempty"`
+	}
+
 	// A CreateDirRequest is the request body for the [POST] /system/dir endpoint.
 	CreateDirRequest struct {
 		Path string `json:"path"`
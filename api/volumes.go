@@ -4,21 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"sync"
 
 	rhp2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
 	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/jape"
+	"go.uber.org/zap"
 )
 
 type (
 	volumeJobs struct {
 		volumes VolumeManager
 
-		mu   sync.Mutex // protects jobs
-		jobs map[int64]context.CancelFunc
+		mu        sync.Mutex // protects jobs and rebalance
+		jobs      map[int64]context.CancelFunc
+		rebalance context.CancelFunc
 	}
 )
 
@@ -112,6 +116,142 @@ func (vj *volumeJobs) ResizeVolume(id int64, newSize uint64) error {
 	return nil
 }
 
+func (vj *volumeJobs) EvacuateVolume(id, destID int64) error {
+	vj.mu.Lock()
+	defer vj.mu.Unlock()
+	if _, exists := vj.jobs[id]; exists {
+		return errors.New("volume is busy")
+	} else if _, exists := vj.jobs[destID]; exists {
+		return errors.New("destination volume is busy")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	complete := make(chan error, 1)
+	err := vj.volumes.EvacuateVolume(ctx, id, destID, complete)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	vj.jobs[id] = cancel
+	go func() {
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+		case <-complete:
+		}
+
+		vj.mu.Lock()
+		defer vj.mu.Unlock()
+		delete(vj.jobs, id)
+	}()
+	return nil
+}
+
+func (vj *volumeJobs) BackupVolume(id int64, path string) error {
+	vj.mu.Lock()
+	defer vj.mu.Unlock()
+	if _, exists := vj.jobs[id]; exists {
+		return errors.New("volume is busy")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	complete := make(chan error, 1)
+	err := vj.volumes.BackupVolume(ctx, id, path, complete)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	vj.jobs[id] = cancel
+	go func() {
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+		case <-complete:
+		}
+
+		vj.mu.Lock()
+		defer vj.mu.Unlock()
+		delete(vj.jobs, id)
+	}()
+	return nil
+}
+
+func (vj *volumeJobs) RestoreVolumeBackup(id int64, path string) error {
+	vj.mu.Lock()
+	defer vj.mu.Unlock()
+	if _, exists := vj.jobs[id]; exists {
+		return errors.New("volume is busy")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	complete := make(chan error, 1)
+	err := vj.volumes.RestoreVolumeBackup(ctx, id, path, complete)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	vj.jobs[id] = cancel
+	go func() {
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+		case <-complete:
+		}
+
+		vj.mu.Lock()
+		defer vj.mu.Unlock()
+		delete(vj.jobs, id)
+	}()
+	return nil
+}
+
+func (vj *volumeJobs) Rebalance() error {
+	vj.mu.Lock()
+	defer vj.mu.Unlock()
+	if vj.rebalance != nil {
+		return errors.New("rebalance already in progress")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	complete := make(chan error, 1)
+	if err := vj.volumes.RebalanceVolumes(ctx, complete); err != nil {
+		cancel()
+		return err
+	}
+
+	vj.rebalance = cancel
+	go func() {
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+		case <-complete:
+		}
+
+		vj.mu.Lock()
+		defer vj.mu.Unlock()
+		vj.rebalance = nil
+	}()
+	return nil
+}
+
+func (vj *volumeJobs) CancelRebalance() error {
+	vj.mu.Lock()
+	defer vj.mu.Unlock()
+	if vj.rebalance == nil {
+		return errors.New("no rebalance in progress")
+	}
+	vj.rebalance()
+	vj.rebalance = nil
+	return nil
+}
+
 func (vj *volumeJobs) Cancel(id int64) error {
 	vj.mu.Lock()
 	defer vj.mu.Unlock()
@@ -187,6 +327,30 @@ func (a *api) handlePUTVolumeResize(c jape.Context) {
 	a.checkServerError(c, "failed to resize volume", err)
 }
 
+func (a *api) handlePUTVolumeEvacuate(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+
+	var req EvacuateVolumeRequest
+	if err := c.Decode(&req); err != nil {
+		return
+	} else if req.DestinationID < 0 {
+		c.Error(errors.New("invalid destination volume id"), http.StatusBadRequest)
+		return
+	} else if req.DestinationID == id {
+		c.Error(errors.New("destination volume must be different from the source volume"), http.StatusBadRequest)
+		return
+	}
+
+	err := a.volumeJobs.EvacuateVolume(id, req.DestinationID)
+	a.checkServerError(c, "failed to evacuate volume", err)
+}
+
 func (a *api) handleDELETEVolumeCancelOp(c jape.Context) {
 	var id int64
 	if err := c.DecodeParam("id", &id); err != nil {
@@ -200,6 +364,252 @@ func (a *api) handleDELETEVolumeCancelOp(c jape.Context) {
 	a.checkServerError(c, "failed to cancel operation", err)
 }
 
+func (a *api) handleGETVolumeScrub(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+	limit, offset := parseLimitParams(c, 100, 500)
+
+	scrub, err := a.volumes.VolumeScrub(id)
+	if errors.Is(err, storage.ErrScrubNotFound) {
+		c.Error(err, http.StatusNotFound)
+		return
+	} else if !a.checkServerError(c, "failed to get volume scrub", err) {
+		return
+	}
+
+	scrubErrs, err := a.volumes.ScrubErrors(id, limit, offset)
+	if !a.checkServerError(c, "failed to get scrub errors", err) {
+		return
+	}
+	c.Encode(VolumeScrubResponse{
+		VolumeScrub: scrub,
+		Errors:      scrubErrs,
+	})
+}
+
+func (a *api) handlePUTVolumeScrub(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+
+	err := a.volumes.StartScrub(id)
+	if errors.Is(err, storage.ErrScrubRunning) {
+		c.Error(err, http.StatusConflict)
+		return
+	}
+	a.checkServerError(c, "failed to start volume scrub", err)
+}
+
+func (a *api) handlePUTVolumeScrubPause(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+
+	err := a.volumes.PauseScrub(id)
+	if errors.Is(err, storage.ErrScrubNotRunning) {
+		c.Error(err, http.StatusBadRequest)
+		return
+	}
+	a.checkServerError(c, "failed to pause volume scrub", err)
+}
+
+func (a *api) handleGETVolumeBackup(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+
+	backup, err := a.volumes.VolumeBackup(id)
+	if errors.Is(err, storage.ErrBackupNotFound) {
+		c.Error(err, http.StatusNotFound)
+		return
+	} else if !a.checkServerError(c, "failed to get volume backup", err) {
+		return
+	}
+	c.Encode(backup)
+}
+
+func (a *api) handlePOSTVolumeBackup(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+
+	var req VolumeBackupRequest
+	if err := c.Decode(&req); err != nil {
+		return
+	} else if req.Path == "" {
+		c.Error(errors.New("path is required"), http.StatusBadRequest)
+		return
+	}
+
+	err := a.volumeJobs.BackupVolume(id, req.Path)
+	if errors.Is(err, storage.ErrBackupRunning) {
+		c.Error(err, http.StatusConflict)
+		return
+	}
+	a.checkServerError(c, "failed to start volume backup", err)
+}
+
+func (a *api) handlePOSTVolumeBackupRestore(c jape.Context) {
+	var id int64
+	if err := c.DecodeParam("id", &id); err != nil {
+		return
+	} else if id < 0 {
+		c.Error(errors.New("invalid volume id"), http.StatusBadRequest)
+		return
+	}
+
+	var req VolumeBackupRequest
+	if err := c.Decode(&req); err != nil {
+		return
+	} else if req.Path == "" {
+		c.Error(errors.New("path is required"), http.StatusBadRequest)
+		return
+	}
+
+	err := a.volumeJobs.RestoreVolumeBackup(id, req.Path)
+	if errors.Is(err, storage.ErrBackupRunning) {
+		c.Error(err, http.StatusConflict)
+		return
+	}
+	a.checkServerError(c, "failed to start volume restore", err)
+}
+
+func (a *api) handleGETStoragePlacement(c jape.Context) {
+	strategy, err := a.volumes.PlacementStrategy()
+	if !a.checkServerError(c, "failed to get placement strategy", err) {
+		return
+	}
+	c.Encode(StoragePlacement{Strategy: strategy})
+}
+
+func (a *api) handlePUTStoragePlacement(c jape.Context) {
+	var req StoragePlacement
+	if err := c.Decode(&req); err != nil {
+		return
+	}
+
+	err := a.volumes.SetPlacementStrategy(req.Strategy)
+	if errors.Is(err, storage.ErrInvalidPlacementStrategy) {
+		c.Error(err, http.StatusBadRequest)
+		return
+	}
+	a.checkServerError(c, "failed to set placement strategy", err)
+}
+
+func (a *api) handleGETStorageTiering(c jape.Context) {
+	status, err := a.volumes.TieringStatus()
+	if !a.checkServerError(c, "failed to get tiering status", err) {
+		return
+	}
+	c.Encode(status)
+}
+
+func (a *api) handleGETStorageSync(c jape.Context) {
+	c.Encode(a.volumes.SyncMetrics())
+}
+
+func (a *api) handlePUTStorageRebalance(c jape.Context) {
+	err := a.volumeJobs.Rebalance()
+	a.checkServerError(c, "failed to rebalance volumes", err)
+}
+
+func (a *api) handleDELETEStorageRebalance(c jape.Context) {
+	err := a.volumeJobs.CancelRebalance()
+	a.checkServerError(c, "failed to cancel rebalance", err)
+}
+
+func (a *api) handleGETStorageLost(jc jape.Context) {
+	limit, offset := parseLimitParams(jc, 100, 500)
+
+	sectors, count, err := a.volumes.LostSectors(limit, offset)
+	if !a.checkServerError(jc, "failed to get lost sectors", err) {
+		return
+	}
+	risks, err := a.contracts.LostSectorRisks()
+	if !a.checkServerError(jc, "failed to get contracts at risk", err) {
+		return
+	}
+	jc.Encode(LostSectorsResponse{
+		Count:     count,
+		Sectors:   sectors,
+		Contracts: risks,
+	})
+}
+
+func (a *api) handleGETSector(jc jape.Context) {
+	var root types.Hash256
+	if err := jc.DecodeParam("root", &root); err != nil {
+		return
+	}
+
+	sector, err := a.volumes.ReadSector(root)
+	if errors.Is(err, storage.ErrSectorNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if !a.checkServerError(jc, "failed to read sector", err) {
+		return
+	}
+
+	jc.ResponseWriter.Header().Set("Content-Type", "application/octet-stream")
+	jc.ResponseWriter.Header().Set("Content-Length", strconv.Itoa(len(sector)))
+	if _, err := jc.ResponseWriter.Write(sector[:]); err != nil {
+		a.log.Debug("failed to write sector", zap.Stringer("root", root), zap.Error(err))
+	}
+}
+
+func (a *api) handlePUTSector(jc jape.Context) {
+	var root types.Hash256
+	if err := jc.DecodeParam("root", &root); err != nil {
+		return
+	}
+
+	// only restore sectors that would not be immediately pruned
+	refs, err := a.volumes.SectorReferences(root)
+	if !a.checkServerError(jc, "failed to get sector references", err) {
+		return
+	} else if len(refs.Contracts) == 0 && refs.TempStorage == 0 {
+		jc.Error(errors.New("sector is not referenced"), http.StatusNotFound)
+		return
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(jc.Request.Body, rhp2.SectorSize+1))
+	if err != nil {
+		jc.Error(fmt.Errorf("failed to read sector: %w", err), http.StatusBadRequest)
+		return
+	} else if len(buf) != rhp2.SectorSize {
+		jc.Error(fmt.Errorf("expected %d bytes, got %d", rhp2.SectorSize, len(buf)), http.StatusBadRequest)
+		return
+	}
+
+	err = a.volumes.RestoreSector(root, (*[rhp2.SectorSize]byte)(buf))
+	if errors.Is(err, storage.ErrSectorRootMismatch) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	a.checkServerError(jc, "failed to restore sector", err)
+}
+
 func (a *api) handleGETVerifySector(jc jape.Context) {
 	var root types.Hash256
 	if err := jc.DecodeParam("root", &root); err != nil {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -9,12 +10,16 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
 	"go.sia.tech/hostd/build"
 	"go.sia.tech/hostd/config"
+	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/persist/sqlite"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -77,6 +82,30 @@ var (
 				EnableANSI: runtime.GOOS != "windows",
 			},
 		},
+		DiskMonitor: config.DiskMonitor{
+			Interval:             time.Minute,
+			MinFreeBytes:         5 << 30, // 5 GiB
+			MinFreePercent:       5,
+			MinFreeInodesPercent: 5,
+		},
+		Tiering: config.Tiering{
+			Interval:   6 * time.Hour,
+			HotReads:   4,
+			HotWindow:  24 * time.Hour,
+			ColdAge:    7 * 24 * time.Hour,
+			MaxSectors: 1024,     // 4 GiB per run
+			MaxRate:    32 << 20, // 32 MiB/s
+		},
+		Sync: config.Sync{
+			Mode:     "immediate",
+			Window:   5 * time.Millisecond,
+			MaxBatch: 64,
+			Interval: 5 * time.Second,
+		},
+		IntegrityChecks: config.IntegrityChecks{
+			Interval:    0, // disabled by default, reads every stored sector
+			Concurrency: 2,
+		},
 	}
 
 	disableStdin bool
@@ -193,6 +222,8 @@ Commands:
 	config		Print the default hostd config
 	recalculate	Recalculate the contract account funding in the SQLite3 database
 	sqlite3		Perform various operations on the SQLite3 database
+	recover		Recover host data after database loss
+	volume		Back up and restore volume data
 `
 
 	versionUsage = `Usage:
@@ -238,6 +269,51 @@ Check the integrity of the SQLite3 database at the specified path. This is not s
 hostd sqlite3 backup <srcPath> <destPath>
 
 Create a backup of the SQLite3 database at the specified path. This is safe to run while the host is running.
+`
+
+	recoverUsage = `Usage:
+hostd recover [subcommand]
+
+Recover host data after database loss.
+
+Commands:
+	sectors	Rebuild the sector index of a volume from its data
+`
+
+	recoverSectorsUsage = `Usage:
+hostd recover sectors [flags] <volumePath>
+
+Rebuild the sector index of a volume by hashing every non-empty slot in the volume file. Slots are matched against the sector roots referenced by contracts in the database, such as a restored backup, and any roots supplied with the -roots flag. Matched sectors are added to the database at their slot. Slots that could not be matched are printed to stdout. The volume is added to the database if it is not already known.
+
+Sectors that are not referenced by a contract or temporary storage will be pruned when the host starts unless -expiration is set.
+
+This command is not safe to run while the host is running.
+`
+
+	volumeUsage = `Usage:
+hostd volume [subcommand]
+
+Back up and restore volume data.
+
+Commands:
+	backup	Incrementally back up the sectors stored in a volume
+	restore	Restore a volume's lost sectors from a backup
+`
+
+	volumeBackupUsage = `Usage:
+hostd volume backup <volumeID> <destPath>
+
+Copy the sectors stored in a volume to the destination directory. Sectors are stored as content-addressed files alongside a manifest of the volume, so only sectors added since the last backup are copied. The backup can also be started from the API with [POST] /volumes/:id/backup.
+
+This command is not safe to run while the host is running.
+`
+
+	volumeRestoreUsage = `Usage:
+hostd volume restore <volumeID> <srcPath>
+
+Restore the sectors in a volume's backup that are still referenced by contracts or temporary storage, but are no longer stored by the host. Restored sectors are written to any volume with free space, so the original volume does not need to exist.
+
+This command is not safe to run while the host is running.
 `
 )
 
@@ -286,6 +362,143 @@ func runRecalcCommand(srcPath string, log *zap.Logger) error {
 	return nil
 }
 
+// readSectorRoots reads a list of sector roots from a file, one per line.
+func readSectorRoots(fp string) (roots []types.Hash256, err error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open roots file: %w", err)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			continue
+		}
+		var root types.Hash256
+		if err := root.UnmarshalText([]byte(text)); err != nil {
+			return nil, fmt.Errorf("failed to parse root on line %d: %w", line, err)
+		}
+		roots = append(roots, root)
+	}
+	return roots, s.Err()
+}
+
+// offlineVolumeManagerOptions returns the options for a volume manager used
+// by an offline command. All background jobs are disabled so that the
+// command only performs the requested operation.
+func offlineVolumeManagerOptions(store *sqlite.Store, log *zap.Logger) []storage.VolumeManagerOption {
+	return []storage.VolumeManagerOption{
+		storage.WithLogger(log.Named("volumes")),
+		storage.WithHostKey(store.HostKey().PublicKey()),
+		storage.WithPruneInterval(0),
+		storage.WithReconnectInterval(0),
+		storage.WithAutoGrowInterval(0),
+		storage.WithHealthCheckInterval(0),
+		storage.WithResumeScrubs(false),
+	}
+}
+
+func runRecoverSectorsCommand(ctx context.Context, dir, volumePath, rootsPath string, expiration uint64, log *zap.Logger) error {
+	var roots []types.Hash256
+	if rootsPath != "" {
+		var err error
+		roots, err = readSectorRoots(rootsPath)
+		if err != nil {
+			return err
+		}
+		log.Info("loaded sector roots", zap.Int("count", len(roots)))
+	}
+
+	volumePath, err := filepath.Abs(volumePath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute volume path: %w", err)
+	}
+
+	store, err := openSQLite3Database(dir, log.Named("sqlite3"))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	// background jobs are disabled while recovering so that recovered
+	// sectors are not pruned and volumes are not modified before the
+	// recovery completes
+	vm, err := storage.NewVolumeManager(store, offlineVolumeManagerOptions(store, log)...)
+	if err != nil {
+		return fmt.Errorf("failed to create volume manager: %w", err)
+	}
+	defer vm.Close()
+
+	result, err := vm.RecoverSectors(ctx, volumePath, roots, expiration)
+	if err != nil {
+		return err
+	}
+
+	for _, sector := range result.Unmatched {
+		fmt.Printf("%d\t%s\n", sector.Index, sector.Root)
+	}
+	log.Info("recovery complete",
+		zap.Int64("volumeID", result.VolumeID),
+		zap.Uint64("slots", result.TotalSlots),
+		zap.Uint64("empty", result.EmptySlots),
+		zap.Uint64("recovered", result.Recovered),
+		zap.Uint64("alreadyIndexed", result.AlreadyIndexed),
+		zap.Uint64("unreferenced", result.Unreferenced),
+		zap.Int("unmatched", len(result.Unmatched)))
+	if result.Unreferenced > 0 && expiration == 0 {
+		log.Warn("some recovered sectors are not referenced by a contract and will be pruned", zap.Uint64("unreferenced", result.Unreferenced))
+	}
+	return nil
+}
+
+func runVolumeBackupCommand(ctx context.Context, dir string, volumeID int64, backupPath string, restore bool, log *zap.Logger) error {
+	backupPath, err := filepath.Abs(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute backup path: %w", err)
+	}
+
+	store, err := openSQLite3Database(dir, log.Named("sqlite3"))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	vm, err := storage.NewVolumeManager(store, offlineVolumeManagerOptions(store, log)...)
+	if err != nil {
+		return fmt.Errorf("failed to create volume manager: %w", err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	if restore {
+		err = vm.RestoreVolumeBackup(ctx, volumeID, backupPath, result)
+	} else {
+		err = vm.BackupVolume(ctx, volumeID, backupPath, result)
+	}
+	if err != nil {
+		return err
+	} else if err := <-result; err != nil {
+		return err
+	}
+
+	progress, err := vm.VolumeBackup(volumeID)
+	if err != nil {
+		return err
+	}
+	log.Info(progress.Operation+" complete",
+		zap.Int64("volumeID", volumeID),
+		zap.Uint64("sectors", progress.TotalSectors),
+		zap.Uint64("checked", progress.CheckedSectors),
+		zap.Uint64("copied", progress.CopiedSectors),
+		zap.Uint64("failed", progress.FailedSectors))
+	if progress.FailedSectors > 0 {
+		return fmt.Errorf("%d sectors failed", progress.FailedSectors)
+	}
+	return nil
+}
+
 func main() {
 	log := initStdoutLog(cfg.Log.StdOut.EnableANSI, cfg.Log.Level)
 	defer log.Sync()
@@ -325,6 +538,16 @@ func main() {
 	sqlite3Cmd := flagg.New("sqlite3", sqlite3Usage)
 	sqlite3BackupCmd := flagg.New("backup", sqlite3BackupUsage)
 	sqlite3IntegrityCmd := flagg.New("integrity", sqlite3IntegrityUsage)
+	recoverCmd := flagg.New("recover", recoverUsage)
+	recoverSectorsCmd := flagg.New("sectors", recoverSectorsUsage)
+	volumeCmd := flagg.New("volume", volumeUsage)
+	volumeBackupCmd := flagg.New("backup", volumeBackupUsage)
+	volumeRestoreCmd := flagg.New("restore", volumeRestoreUsage)
+
+	var recoverRootsPath string
+	var recoverExpiration uint64
+	recoverSectorsCmd.StringVar(&recoverRootsPath, "roots", "", "path to a file containing additional sector roots to match, one per line")
+	recoverSectorsCmd.Uint64Var(&recoverExpiration, "expiration", 0, "add recovered sectors that are not referenced by a contract to temporary storage until this block height")
 
 	cmd := flagg.Parse(flagg.Tree{
 		Cmd: rootCmd,
@@ -340,6 +563,19 @@ func main() {
 					{Cmd: sqlite3BackupCmd},
 				},
 			},
+			{
+				Cmd: recoverCmd,
+				Sub: []flagg.Tree{
+					{Cmd: recoverSectorsCmd},
+				},
+			},
+			{
+				Cmd: volumeCmd,
+				Sub: []flagg.Tree{
+					{Cmd: volumeBackupCmd},
+					{Cmd: volumeRestoreCmd},
+				},
+			},
 		},
 	})
 
@@ -410,6 +646,40 @@ func main() {
 		defer log.Sync()
 
 		checkFatalError("command failed", runBackupCommand(cmd.Arg(0), cmd.Arg(1)))
+	case recoverCmd:
+		cmd.Usage()
+	case recoverSectorsCmd:
+		if len(cmd.Args()) != 1 {
+			cmd.Usage()
+			return
+		}
+
+		log := initStdoutLog(cfg.Log.StdOut.EnableANSI, "info")
+		defer log.Sync()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		checkFatalError("sector recovery failed", runRecoverSectorsCommand(ctx, cfg.Directory, cmd.Arg(0), recoverRootsPath, recoverExpiration, log))
+	case volumeCmd:
+		cmd.Usage()
+	case volumeBackupCmd, volumeRestoreCmd:
+		if len(cmd.Args()) != 2 {
+			cmd.Usage()
+			return
+		}
+
+		volumeID, err := strconv.ParseInt(cmd.Arg(0), 10, 64)
+		checkFatalError("invalid volume ID", err)
+
+		log := initStdoutLog(cfg.Log.StdOut.EnableANSI, "info")
+		defer log.Sync()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		restore := cmd == volumeRestoreCmd
+		checkFatalError("volume backup failed", runVolumeBackupCommand(ctx, cfg.Directory, volumeID, cmd.Arg(1), restore, log))
 	case rootCmd:
 		if len(cmd.Args()) != 0 {
 			cmd.Usage()
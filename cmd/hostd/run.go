@@ -31,6 +31,7 @@ import (
 	"go.sia.tech/hostd/host/settings/pin"
 	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/index"
+	"go.sia.tech/hostd/internal/disk"
 	"go.sia.tech/hostd/persist/sqlite"
 	"go.sia.tech/hostd/rhp"
 	rhp2 "go.sia.tech/hostd/rhp/v2"
@@ -343,12 +344,60 @@ func runRootCmd(ctx context.Context, cfg config.Config, walletKey types.PrivateK
 		return fmt.Errorf("failed to normalize RHP3 address: %w", err)
 	}
 
-	vm, err := storage.NewVolumeManager(store, storage.WithLogger(log.Named("volumes")), storage.WithAlerter(am))
+	tiering := storage.TieringPolicy{
+		Interval:   cfg.Tiering.Interval,
+		HotReads:   cfg.Tiering.HotReads,
+		HotWindow:  cfg.Tiering.HotWindow,
+		ColdAge:    cfg.Tiering.ColdAge,
+		MaxSectors: cfg.Tiering.MaxSectors,
+		MaxRate:    cfg.Tiering.MaxRate,
+	}
+	syncPolicy := storage.SyncPolicy{
+		Mode:     cfg.Sync.Mode,
+		Window:   cfg.Sync.Window,
+		MaxBatch: cfg.Sync.MaxBatch,
+		Interval: cfg.Sync.Interval,
+	}
+	vm, err := storage.NewVolumeManager(store, storage.WithLogger(log.Named("volumes")), storage.WithAlerter(am), storage.WithEventReporter(wr), storage.WithHostKey(hostKey.PublicKey()), storage.WithTieringPolicy(tiering), storage.WithSyncPolicy(syncPolicy))
 	if err != nil {
 		return fmt.Errorf("failed to create storage manager: %w", err)
 	}
 	defer vm.Close()
 
+	var diskMonitor *disk.Monitor
+	if cfg.DiskMonitor.Interval > 0 {
+		monitoredPaths := func() ([]disk.MonitoredPath, error) {
+			paths := []disk.MonitoredPath{
+				{Name: "database", Path: cfg.Directory, Critical: true},
+			}
+			volumes, err := vm.Volumes()
+			if err != nil {
+				return paths, fmt.Errorf("failed to get volumes: %w", err)
+			}
+			for _, vol := range volumes {
+				paths = append(paths, disk.MonitoredPath{
+					Name: fmt.Sprintf("volume %d", vol.ID),
+					Path: vol.LocalPath,
+				})
+			}
+			return paths, nil
+		}
+
+		diskMonitor, err = disk.NewMonitor(monitoredPaths,
+			disk.WithMonitorLog(log.Named("diskMonitor")),
+			disk.WithMonitorAlerts(am),
+			disk.WithMonitorInterval(cfg.DiskMonitor.Interval),
+			disk.WithThresholds(disk.Thresholds{
+				MinFreeBytes:         cfg.DiskMonitor.MinFreeBytes,
+				MinFreePercent:       cfg.DiskMonitor.MinFreePercent,
+				MinFreeInodesPercent: cfg.DiskMonitor.MinFreeInodesPercent,
+			}))
+		if err != nil {
+			return fmt.Errorf("failed to create disk monitor: %w", err)
+		}
+		defer diskMonitor.Close()
+	}
+
 	var rhp4PortStr string
 	for _, addr := range cfg.RHP4.ListenAddresses {
 		_, portStr, err := net.SplitHostPort(addr.Address)
@@ -381,7 +430,21 @@ func runRootCmd(ctx context.Context, cfg config.Config, walletKey types.PrivateK
 	}
 	defer sm.Close()
 
-	contractManager, err := contracts.NewManager(store, vm, cm, s, wm, contracts.WithLog(log.Named("contracts")), contracts.WithAlerter(am))
+	if hs := sm.Settings(); hs.SectorCacheDiskSize > 0 {
+		// a misconfigured disk cache should not prevent the host from starting
+		if err := vm.ConfigureDiskCache(hs.SectorCachePath, hs.SectorCacheDiskSize); err != nil {
+			log.Error("failed to configure disk cache", zap.Error(err))
+		}
+	}
+
+	renterQuota := contracts.RenterQuota{
+		MaxSectors:        cfg.RenterQuota.MaxSectors,
+		MaxCollateral:     cfg.RenterQuota.MaxCollateral,
+		MaxContracts:      cfg.RenterQuota.MaxContracts,
+		MaxAccountBalance: cfg.RenterQuota.MaxAccountBalance,
+	}
+	contractManager, err := contracts.NewManager(store, vm, cm, s, wm, contracts.WithLog(log.Named("contracts")), contracts.WithAlerter(am), contracts.WithEventReporter(wr), contracts.WithRenterAccess(sm), contracts.WithDefaultRenterQuota(renterQuota),
+		contracts.WithIntegrityCheckInterval(cfg.IntegrityChecks.Interval), contracts.WithIntegrityCheckConcurrency(cfg.IntegrityChecks.Concurrency))
 	if err != nil {
 		return fmt.Errorf("failed to create contracts manager: %w", err)
 	}
@@ -417,7 +480,7 @@ func runRootCmd(ctx context.Context, cfg config.Config, walletKey types.PrivateK
 	go rhp3.Serve()
 	defer rhp3.Close()
 
-	rhp4 := rhp4.NewServer(hostKey, cm, s, contractManager, wm, sm, vm, rhp4.WithPriceTableValidity(30*time.Minute))
+	rhp4 := rhp4.NewServer(hostKey, contractManager.RHP4Chain(cm), s, contractManager, contractManager.RHP4Wallet(wm), sm, vm, rhp4.WithPriceTableValidity(30*time.Minute))
 
 	var stopListenerFuncs []func() error
 	defer func() {
@@ -448,6 +511,9 @@ func runRootCmd(ctx context.Context, cfg config.Config, walletKey types.PrivateK
 		api.WithWebhooks(wr),
 		api.WithSQLite3Store(store),
 	}
+	if diskMonitor != nil {
+		apiOpts = append(apiOpts, api.WithDiskMonitor(diskMonitor))
+	}
 	if !cfg.Explorer.Disable {
 		ex := explorer.New(cfg.Explorer.URL)
 		pm, err := pin.NewManager(store, sm, ex, pin.WithLogger(log.Named("pin")))
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"time"
 
+	"go.sia.tech/core/types"
 	"gopkg.in/yaml.v3"
 )
 
@@ -80,6 +82,53 @@ type (
 		File   LogFile `yaml:"file,omitempty"`
 	}
 
+	// DiskMonitor contains the configuration for the filesystem monitor.
+	// A threshold of zero disables the check.
+	DiskMonitor struct {
+		Interval             time.Duration `yaml:"interval,omitempty"`
+		MinFreeBytes         uint64        `yaml:"minFreeBytes,omitempty"`
+		MinFreePercent       float64       `yaml:"minFreePercent,omitempty"`
+		MinFreeInodesPercent float64       `yaml:"minFreeInodesPercent,omitempty"`
+	}
+
+	// Tiering contains the configuration for moving sectors between fast and
+	// bulk volumes. An interval of zero disables tiering.
+	Tiering struct {
+		Interval   time.Duration `yaml:"interval,omitempty"`
+		HotReads   uint64        `yaml:"hotReads,omitempty"`
+		HotWindow  time.Duration `yaml:"hotWindow,omitempty"`
+		ColdAge    time.Duration `yaml:"coldAge,omitempty"`
+		MaxSectors uint64        `yaml:"maxSectors,omitempty"`
+		MaxRate    uint64        `yaml:"maxRate,omitempty"`
+	}
+
+	// Sync contains the configuration for flushing sector data to disk.
+	// Mode is one of "immediate", "group", or "background".
+	Sync struct {
+		Mode     string        `yaml:"mode,omitempty"`
+		Window   time.Duration `yaml:"window,omitempty"`
+		MaxBatch int           `yaml:"maxBatch,omitempty"`
+		Interval time.Duration `yaml:"interval,omitempty"`
+	}
+
+	// IntegrityChecks contains the configuration for scheduled contract
+	// integrity checks. Each check reads every sector of a contract from
+	// disk, so every stored sector is read once per interval. An interval
+	// of zero disables scheduled checks.
+	IntegrityChecks struct {
+		Interval    time.Duration `yaml:"interval,omitempty"`
+		Concurrency int           `yaml:"concurrency,omitempty"`
+	}
+
+	// RenterQuota contains the default quota applied to renters without an
+	// override. A zero value disables the corresponding limit.
+	RenterQuota struct {
+		MaxSectors        uint64         `yaml:"maxSectors,omitempty"`
+		MaxCollateral     types.Currency `yaml:"maxCollateral,omitempty"`
+		MaxContracts      uint64         `yaml:"maxContracts,omitempty"`
+		MaxAccountBalance types.Currency `yaml:"maxAccountBalance,omitempty"`
+	}
+
 	// Config contains the configuration for the host.
 	Config struct {
 		Name           string `yaml:"name,omitempty"`
@@ -95,6 +144,13 @@ type (
 		RHP3      RHP3         `yaml:"rhp3,omitempty"`
 		RHP4      RHP4         `yaml:"rhp4,omitempty"`
 		Log       Log          `yaml:"log,omitempty"`
+
+		DiskMonitor DiskMonitor `yaml:"diskMonitor,omitempty"`
+		Tiering     Tiering     `yaml:"tiering,omitempty"`
+		Sync        Sync        `yaml:"sync,omitempty"`
+		RenterQuota RenterQuota `yaml:"renterQuota,omitempty"`
+
+		IntegrityChecks IntegrityChecks `yaml:"integrityChecks,omitempty"`
 	}
 )
 
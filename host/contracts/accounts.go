@@ -13,7 +13,18 @@ func (cm *Manager) AccountBalance(account proto4.Account) (types.Currency, error
 // CreditAccountsWithContract atomically revises a contract and credits the accounts
 // returning the new balance of each account.
 func (cm *Manager) CreditAccountsWithContract(deposits []proto4.AccountDeposit, contractID types.FileContractID, revision types.V2FileContract, usage proto4.Usage) ([]types.Currency, error) {
-	return cm.store.RHP4CreditAccounts(deposits, contractID, revision, usage)
+	res, err := cm.reserveRenterQuota(revision.RenterPublicKey, RenterUsage{AccountBalance: depositTotal(deposits)})
+	if err != nil {
+		return nil, err
+	}
+	defer res.release()
+
+	balances, err := cm.store.RHP4CreditAccounts(deposits, contractID, revision, usage)
+	if err != nil {
+		return nil, err
+	}
+	res.commit()
+	return balances, nil
 }
 
 // DebitAccount debits an account.
@@ -0,0 +1,130 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/metrics"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidRenterSort is returned when renters are sorted by an unknown
+// field.
+var ErrInvalidRenterSort = errors.New("invalid renter sort field")
+
+// fields that renters can be sorted by.
+const (
+	RenterSortActiveContracts  = "activeContracts"
+	RenterSortStoredSectors    = "storedSectors"
+	RenterSortPotentialRevenue = "potentialRevenue"
+	RenterSortEarnedRevenue    = "earnedRevenue"
+	RenterSortLockedCollateral = "lockedCollateral"
+	RenterSortFailureRate      = "failureRate"
+	RenterSortAccountBalance   = "accountBalance"
+)
+
+type (
+	// RenterMetrics aggregates the v1 and v2 contracts formed by a renter.
+	RenterMetrics struct {
+		PublicKey types.PublicKey `json:"publicKey"`
+
+		// ActiveContracts counts the renter's pending and active contracts
+		// that have not been renewed. Successful contracts include v2
+		// contracts that were renewed. RenewedContracts counts every contract
+		// that was renewed regardless of its status.
+		ActiveContracts     uint64 `json:"activeContracts"`
+		SuccessfulContracts uint64 `json:"successfulContracts"`
+		FailedContracts     uint64 `json:"failedContracts"`
+		RejectedContracts   uint64 `json:"rejectedContracts"`
+		RenewedContracts    uint64 `json:"renewedContracts"`
+		// FailureRate is the fraction of the renter's resolved contracts
+		// that failed.
+		FailureRate float64 `json:"failureRate"`
+
+		// StoredSectors is the number of sectors stored in the renter's
+		// active contracts. Renewed contracts are not counted.
+		StoredSectors uint64 `json:"storedSectors"`
+
+		LockedCollateral types.Currency `json:"lockedCollateral"`
+		RiskedCollateral types.Currency `json:"riskedCollateral"`
+
+		// Potential is the usage of the renter's pending and active
+		// contracts. Earned is the usage of the renter's successful
+		// contracts.
+		Potential Usage `json:"potential"`
+		Earned    Usage `json:"earned"`
+
+		// AccountBalance is the combined balance of the accounts funded by
+		// the renter's contracts.
+		AccountBalance types.Currency `json:"accountBalance"`
+
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	// RenterFilter defines the pagination and sorting of a renter query.
+	RenterFilter struct {
+		Limit  int `json:"limit"`
+		Offset int `json:"offset"`
+
+		SortField string `json:"sortField"`
+		SortDesc  bool   `json:"sortDesc"`
+	}
+)
+
+// Renters returns a paginated list of metrics for every renter that has
+// formed a contract with the host and the total number of renters.
+func (cm *Manager) Renters(filter RenterFilter) ([]RenterMetrics, int, error) {
+	renters, total, err := cm.store.Renters(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range renters {
+		renters[i].Timestamp = now
+	}
+	return renters, total, nil
+}
+
+// RenterMetrics returns the current metrics for a renter. Renters without
+// any contracts have empty metrics.
+func (cm *Manager) RenterMetrics(pk types.PublicKey) (RenterMetrics, error) {
+	rm, err := cm.store.RenterMetrics(pk)
+	if err != nil {
+		return RenterMetrics{}, err
+	}
+	rm.Timestamp = time.Now()
+	return rm, nil
+}
+
+// RenterPeriodMetrics returns a renter's metrics for n periods starting at
+// start. Metrics are recorded every renter metrics interval, periods shorter
+// than the interval repeat the previous value.
+func (cm *Manager) RenterPeriodMetrics(pk types.PublicKey, start time.Time, n int, interval metrics.Interval) ([]RenterMetrics, error) {
+	start, err := metrics.Normalize(start, interval)
+	if err != nil {
+		return nil, err
+	}
+	return cm.store.RenterPeriodMetrics(pk, start, n, interval)
+}
+
+// watchRenterMetrics periodically records the metrics of every renter.
+func (cm *Manager) watchRenterMetrics() {
+	ctx, done, err := cm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cm.renterMetricsInterval):
+			if err := cm.store.RecordRenterMetrics(time.Now()); err != nil {
+				cm.log.Error("failed to record renter metrics", zap.Error(err))
+			}
+		}
+	}
+}
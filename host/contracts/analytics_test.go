@@ -0,0 +1,177 @@
+package contracts_test
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/metrics"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/testutil"
+	"go.uber.org/zap/zaptest"
+	"lukechampine.com/frand"
+)
+
+func TestRenterMetrics(t *testing.T) {
+	log := zaptest.NewLogger(t)
+
+	hostKey := types.GeneratePrivateKey()
+	renterA, renterB := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	network, genesis := testutil.V1Network()
+	node := testutil.NewHostNode(t, hostKey, network, genesis, log)
+
+	// create a fake volume so disk space is not used
+	id, err := node.Store.AddVolume("test", false)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := node.Store.GrowVolume(id, 10); err != nil {
+		t.Fatal(err)
+	} else if err := node.Store.SetAvailable(id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	formContract := func(renterKey types.PrivateKey, collateral types.Currency, usage contracts.Usage) contracts.SignedRevision {
+		uc := types.UnlockConditions{
+			PublicKeys: []types.UnlockKey{
+				renterKey.PublicKey().UnlockKey(),
+				hostKey.PublicKey().UnlockKey(),
+			},
+			SignaturesRequired: 2,
+		}
+		rev := contracts.SignedRevision{
+			Revision: types.FileContractRevision{
+				FileContract: types.FileContract{
+					UnlockHash:  uc.UnlockHash(),
+					WindowStart: 100,
+					WindowEnd:   200,
+				},
+				ParentID:         frand.Entropy256(),
+				UnlockConditions: uc,
+			},
+		}
+		if err := node.Contracts.AddContract(rev, nil, collateral, usage); err != nil {
+			t.Fatal(err)
+		}
+		return rev
+	}
+
+	appendSector := func(rev contracts.SignedRevision) {
+		root := frand.Entropy256()
+		if err := node.Store.StoreSector(root, func(loc storage.SectorLocation) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+
+		updater, err := node.Contracts.ReviseContract(rev.Revision.ParentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer updater.Close()
+
+		updater.AppendSector(root)
+		if err := updater.Commit(rev, contracts.Usage{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rev := formContract(renterA, types.Siacoins(10), contracts.Usage{RPCRevenue: types.Siacoins(1)})
+	appendSector(rev)
+	appendSector(rev)
+	formContract(renterA, types.Siacoins(5), contracts.Usage{StorageRevenue: types.Siacoins(2)})
+	formContract(renterB, types.Siacoins(20), contracts.Usage{RPCRevenue: types.Siacoins(1)})
+
+	rm, err := node.Contracts.RenterMetrics(renterA.PublicKey())
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case rm.PublicKey != renterA.PublicKey():
+		t.Fatalf("expected public key %v, got %v", renterA.PublicKey(), rm.PublicKey)
+	case rm.ActiveContracts != 2:
+		t.Fatalf("expected 2 active contracts, got %d", rm.ActiveContracts)
+	case rm.StoredSectors != 2:
+		t.Fatalf("expected 2 stored sectors, got %d", rm.StoredSectors)
+	case !rm.LockedCollateral.Equals(types.Siacoins(15)):
+		t.Fatalf("expected 15 SC locked collateral, got %v", rm.LockedCollateral)
+	case !rm.Potential.RPCRevenue.Equals(types.Siacoins(1)) || !rm.Potential.StorageRevenue.Equals(types.Siacoins(2)):
+		t.Fatalf("unexpected potential revenue: %+v", rm.Potential)
+	case !rm.Potential.Revenue().Equals(types.Siacoins(3)):
+		t.Fatalf("expected 3 SC potential revenue, got %v", rm.Potential.Revenue())
+	case !rm.Earned.Revenue().IsZero():
+		t.Fatalf("expected no earned revenue, got %v", rm.Earned.Revenue())
+	case rm.FailureRate != 0:
+		t.Fatalf("expected failure rate 0, got %v", rm.FailureRate)
+	}
+
+	// renters without contracts have empty metrics
+	if rm, err := node.Contracts.RenterMetrics(types.GeneratePrivateKey().PublicKey()); err != nil {
+		t.Fatal(err)
+	} else if rm.ActiveContracts != 0 || !rm.LockedCollateral.IsZero() {
+		t.Fatalf("expected empty metrics, got %+v", rm)
+	}
+
+	renters, total, err := node.Contracts.Renters(contracts.RenterFilter{
+		Limit:     1,
+		SortField: contracts.RenterSortLockedCollateral,
+		SortDesc:  true,
+	})
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case total != 2:
+		t.Fatalf("expected 2 renters, got %d", total)
+	case len(renters) != 1:
+		t.Fatalf("expected 1 renter, got %d", len(renters))
+	case renters[0].PublicKey != renterB.PublicKey():
+		t.Fatalf("expected renter %v, got %v", renterB.PublicKey(), renters[0].PublicKey)
+	}
+
+	renters, _, err = node.Contracts.Renters(contracts.RenterFilter{
+		Limit:     1,
+		Offset:    1,
+		SortField: contracts.RenterSortLockedCollateral,
+		SortDesc:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(renters) != 1 || renters[0].PublicKey != renterA.PublicKey() {
+		t.Fatalf("expected renter %v, got %+v", renterA.PublicKey(), renters)
+	}
+
+	if _, _, err := node.Contracts.Renters(contracts.RenterFilter{SortField: "foo"}); err == nil {
+		t.Fatal("expected invalid sort field to fail")
+	}
+
+	// record a snapshot, add a sector, and record another snapshot in the
+	// next period
+	start := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	if err := node.Store.RecordRenterMetrics(start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	appendSector(rev)
+	// the cached metrics are refreshed when the contract is revised
+	if rm, err := node.Contracts.RenterMetrics(renterA.PublicKey()); err != nil {
+		t.Fatal(err)
+	} else if rm.StoredSectors != 3 {
+		t.Fatalf("expected 3 stored sectors, got %d", rm.StoredSectors)
+	}
+	if err := node.Store.RecordRenterMetrics(start.Add(time.Hour + time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	periods, err := node.Contracts.RenterPeriodMetrics(renterA.PublicKey(), start, 3, metrics.IntervalHourly)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(periods) != 3 {
+		t.Fatalf("expected 3 periods, got %d", len(periods))
+	}
+	for i, expected := range []uint64{2, 3, 3} {
+		switch {
+		case !periods[i].Timestamp.Equal(start.Add(time.Duration(i) * time.Hour)):
+			t.Fatalf("period %d: expected timestamp %v, got %v", i, start.Add(time.Duration(i)*time.Hour), periods[i].Timestamp)
+		case periods[i].StoredSectors != expected:
+			t.Fatalf("period %d: expected %d stored sectors, got %d", i, expected, periods[i].StoredSectors)
+		case !periods[i].LockedCollateral.Equals(types.Siacoins(15)):
+			t.Fatalf("period %d: expected 15 SC locked collateral, got %v", i, periods[i].LockedCollateral)
+		}
+	}
+}
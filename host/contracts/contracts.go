@@ -270,6 +270,18 @@ func (a Usage) Sub(b Usage) (c Usage) {
 	}
 }
 
+// Revenue returns the sum of every revenue category of the usage. Risked
+// collateral is not included.
+func (a Usage) Revenue() types.Currency {
+	return a.RPCRevenue.
+		Add(a.StorageRevenue).
+		Add(a.EgressRevenue).
+		Add(a.IngressRevenue).
+		Add(a.RegistryRead).
+		Add(a.RegistryWrite).
+		Add(a.AccountFunding)
+}
+
 // String returns the string representation of a ContractStatus.
 func (c ContractStatus) String() string {
 	switch c {
@@ -421,12 +433,26 @@ func (cu *ContractUpdater) Commit(revision SignedRevision, usage Usage) error {
 		panic("contract updater used with wrong contract")
 	}
 
+	var delta RenterUsage
+	if len(cu.sectorRoots) > len(cu.oldRoots) {
+		delta.Sectors = uint64(len(cu.sectorRoots) - len(cu.oldRoots))
+	}
+	res, err := cu.manager.reserveRenterQuota(revision.RenterKey(), delta)
+	if err != nil {
+		return err
+	}
+	defer res.release()
+
 	start := time.Now()
 	// revise the contract
-	err := cu.store.ReviseContract(revision, cu.oldRoots, usage, cu.sectorActions)
+	err = cu.store.ReviseContract(revision, cu.oldRoots, usage, cu.sectorActions)
 	if err != nil {
 		return err
 	}
+	res.commit()
+	if len(cu.sectorRoots) < len(cu.oldRoots) {
+		cu.manager.releaseRenterSectors(revision.RenterKey(), uint64(len(cu.oldRoots)-len(cu.sectorRoots)))
+	}
 
 	// clear the committed sector actions
 	cu.sectorActions = cu.sectorActions[:0]
@@ -0,0 +1,101 @@
+package contracts
+
+import (
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/webhooks"
+	"go.uber.org/zap"
+)
+
+// events broadcast when a contract's lifecycle changes. Applied and reverted
+// events are broadcast after the chain update is committed.
+const (
+	EventContractApplied           = "contractApplied"
+	EventContractReverted          = "contractReverted"
+	EventContractRevisionBroadcast = "contractRevisionBroadcast"
+	EventContractProofBroadcast    = "contractProofBroadcast"
+)
+
+type (
+	// A ContractEvent is the data of a contract lifecycle event.
+	ContractEvent struct {
+		ContractID types.FileContractID `json:"contractID"`
+		Index      types.ChainIndex     `json:"index"`
+	}
+
+	contractEvent struct {
+		event string
+		scope string
+		data  ContractEvent
+	}
+)
+
+// appendContractEvents appends an event for every contract in the state
+// changes to events.
+func appendContractEvents(events []contractEvent, index types.ChainIndex, state StateChanges, reverted bool) []contractEvent {
+	event := EventContractApplied
+	if reverted {
+		event = EventContractReverted
+	}
+
+	add := func(scope string, id types.FileContractID) {
+		events = append(events, contractEvent{
+			event: event,
+			scope: scope,
+			data:  ContractEvent{ContractID: id, Index: index},
+		})
+	}
+
+	for _, fce := range state.Confirmed {
+		add(webhooks.ScopeContractsFormed, types.FileContractID(fce.ID))
+	}
+	for _, fce := range state.Revised {
+		add(webhooks.ScopeContractsRevised, types.FileContractID(fce.ID))
+	}
+	for _, id := range state.Successful {
+		add(webhooks.ScopeContractsSuccessful, id)
+	}
+	for _, id := range state.Renewed {
+		add(webhooks.ScopeContractsRenewed, id)
+	}
+	for _, id := range state.Failed {
+		add(webhooks.ScopeContractsFailed, id)
+	}
+
+	for _, fce := range state.ConfirmedV2 {
+		add(webhooks.ScopeContractsV2Formed, types.FileContractID(fce.ID))
+	}
+	for _, fce := range state.RevisedV2 {
+		add(webhooks.ScopeContractsV2Revised, types.FileContractID(fce.ID))
+	}
+	for _, id := range state.SuccessfulV2 {
+		add(webhooks.ScopeContractsV2Successful, id)
+	}
+	for _, id := range state.RenewedV2 {
+		add(webhooks.ScopeContractsV2Renewed, id)
+	}
+	for _, id := range state.FailedV2 {
+		add(webhooks.ScopeContractsV2Failed, id)
+	}
+	return events
+}
+
+// broadcastEvent broadcasts a contract event. Failures are logged since
+// the contract state has already been committed.
+func (cm *Manager) broadcastEvent(e contractEvent) {
+	if err := cm.events.BroadcastEvent(e.event, e.scope, e.data); err != nil {
+		cm.log.Error("failed to broadcast contract event", zap.String("event", e.event), zap.String("scope", e.scope), zap.Stringer("contractID", e.data.ContractID), zap.Error(err))
+	}
+}
+
+// broadcastPendingEvents broadcasts the events of the last committed chain
+// update.
+func (cm *Manager) broadcastPendingEvents() {
+	cm.eventsMu.Lock()
+	events := cm.pendingEvents
+	cm.pendingEvents = nil
+	cm.eventsMu.Unlock()
+
+	for _, e := range events {
+		cm.broadcastEvent(e)
+	}
+}
@@ -0,0 +1,342 @@
+package contracts_test
+
+import (
+	"sync"
+	"testing"
+
+	proto4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/settings"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/index"
+	"go.sia.tech/hostd/internal/testutil"
+	"go.sia.tech/hostd/webhooks"
+	"go.uber.org/zap/zaptest"
+)
+
+type recordedEvent struct {
+	event string
+	scope string
+	data  contracts.ContractEvent
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+func (er *eventRecorder) BroadcastEvent(event, scope string, data any) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	er.events = append(er.events, recordedEvent{event, scope, data.(contracts.ContractEvent)})
+	return nil
+}
+
+func (er *eventRecorder) find(scope string, id types.FileContractID) (recordedEvent, bool) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	for _, e := range er.events {
+		if e.scope == scope && e.data.ContractID == id {
+			return e, true
+		}
+	}
+	return recordedEvent{}, false
+}
+
+func TestContractEvents(t *testing.T) {
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	log := zaptest.NewLogger(t)
+
+	network, genesis := testutil.V2Network()
+	cn := testutil.NewConsensusNode(t, network, genesis, log)
+
+	wm, err := wallet.NewSingleAddressWallet(hostKey, cn.Chain, cn.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wm.Close()
+
+	vm, err := storage.NewVolumeManager(cn.Store, storage.WithLogger(log.Named("storage")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	events := new(eventRecorder)
+	cm, err := contracts.NewManager(cn.Store, vm, cn.Chain, cn.Syncer, wm, contracts.WithLog(log.Named("contracts")), contracts.WithEventReporter(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+
+	sm, err := settings.NewConfigManager(hostKey, cn.Store, cn.Chain, cn.Syncer, vm, wm, settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	idx, err := index.NewManager(cn.Store, cn.Chain, cm, wm, sm, vm, index.WithLog(log.Named("index")), index.WithBatchSize(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	mineAndSync := func(addr types.Address, n int) {
+		for i := 0; i < n; i++ {
+			testutil.MineBlocks(t, cn, addr, 1)
+			testutil.WaitForSync(t, cn.Chain, idx)
+		}
+	}
+
+	// fund the wallet
+	mineAndSync(wm.Address(), int(network.MaturityDelay+5))
+
+	contractID, _ := formV2Contract(t, cn.Chain, cm, wm, cn.Syncer, renterKey, hostKey, types.Siacoins(10), types.Siacoins(20), 10, true)
+	if _, ok := events.find(webhooks.ScopeContractsV2Formed, contractID); ok {
+		t.Fatal("expected no event before the formation is confirmed")
+	}
+
+	mineAndSync(types.VoidAddress, 1)
+
+	e, ok := events.find(webhooks.ScopeContractsV2Formed, contractID)
+	switch {
+	case !ok:
+		t.Fatal("expected formation event")
+	case e.event != contracts.EventContractApplied:
+		t.Fatalf("expected event %q, got %q", contracts.EventContractApplied, e.event)
+	case e.data.Index != cn.Chain.Tip():
+		t.Fatalf("expected index %v, got %v", cn.Chain.Tip(), e.data.Index)
+	}
+
+	if _, ok := events.find(webhooks.ScopeContractsFormed, contractID); ok {
+		t.Fatal("expected v2 contract to not use the v1 scope")
+	}
+}
+
+func TestV1RenewalEvent(t *testing.T) {
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	log := zaptest.NewLogger(t)
+
+	network, genesis := testutil.V1Network()
+	cn := testutil.NewConsensusNode(t, network, genesis, log)
+
+	wm, err := wallet.NewSingleAddressWallet(hostKey, cn.Chain, cn.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wm.Close()
+
+	vm, err := storage.NewVolumeManager(cn.Store, storage.WithLogger(log.Named("storage")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	events := new(eventRecorder)
+	cm, err := contracts.NewManager(cn.Store, vm, cn.Chain, cn.Syncer, wm, contracts.WithLog(log.Named("contracts")), contracts.WithEventReporter(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+
+	sm, err := settings.NewConfigManager(hostKey, cn.Store, cn.Chain, cn.Syncer, vm, wm, settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	idx, err := index.NewManager(cn.Store, cn.Chain, cm, wm, sm, vm, index.WithLog(log.Named("index")), index.WithBatchSize(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	mineAndSync := func(addr types.Address, n int) {
+		for i := 0; i < n; i++ {
+			testutil.MineBlocks(t, cn, addr, 1)
+			testutil.WaitForSync(t, cn.Chain, idx)
+		}
+	}
+
+	// fund the wallet
+	mineAndSync(wm.Address(), int(network.MaturityDelay+5))
+
+	existing := formContract(t, cn.Chain, cm, wm, cn.Syncer, sm, renterKey, hostKey, types.Siacoins(10), types.Siacoins(20), 10, true)
+	mineAndSync(types.VoidAddress, 1)
+
+	// clear the existing contract
+	clearing := existing.Revision
+	clearing.RevisionNumber = types.MaxRevisionNumber
+	clearing.Filesize = 0
+	clearing.FileMerkleRoot = types.Hash256{}
+	clearingHash := hashRevision(clearing)
+	cleared := contracts.SignedRevision{
+		Revision:        clearing,
+		HostSignature:   hostKey.SignHash(clearingHash),
+		RenterSignature: renterKey.SignHash(clearingHash),
+	}
+
+	renewal := existing.Revision.FileContract
+	renewal.WindowStart += 10
+	renewal.WindowEnd += 10
+	renewal.RevisionNumber = 0
+	txn := types.Transaction{
+		FileContracts: []types.FileContract{renewal},
+	}
+	toSign, err := wm.FundTransaction(&txn, renewal.Payout, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm.SignTransaction(&txn, toSign, types.CoveredFields{WholeTransaction: true})
+	renewalSet := append(cn.Chain.UnconfirmedParents(txn), txn)
+	if _, err := cn.Chain.AddPoolTransactions(renewalSet); err != nil {
+		t.Fatal(err)
+	}
+
+	renewalRevision := types.FileContractRevision{
+		ParentID:         txn.FileContractID(0),
+		UnlockConditions: existing.Revision.UnlockConditions,
+		FileContract:     renewal,
+	}
+	renewalRevision.RevisionNumber = 1
+	renewalHash := hashRevision(renewalRevision)
+	renewed := contracts.SignedRevision{
+		Revision:        renewalRevision,
+		HostSignature:   hostKey.SignHash(renewalHash),
+		RenterSignature: renterKey.SignHash(renewalHash),
+	}
+	if err := cm.RenewContract(renewed, cleared, renewalSet, types.ZeroCurrency, contracts.Usage{}, contracts.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := events.find(webhooks.ScopeContractsRenewed, existing.Revision.ParentID); ok {
+		t.Fatal("expected no event before the renewal is confirmed")
+	}
+
+	mineAndSync(types.VoidAddress, 1)
+
+	e, ok := events.find(webhooks.ScopeContractsRenewed, existing.Revision.ParentID)
+	switch {
+	case !ok:
+		t.Fatal("expected renewal event")
+	case e.event != contracts.EventContractApplied:
+		t.Fatalf("expected event %q, got %q", contracts.EventContractApplied, e.event)
+	case e.data.Index != cn.Chain.Tip():
+		t.Fatalf("expected index %v, got %v", cn.Chain.Tip(), e.data.Index)
+	}
+	if _, ok := events.find(webhooks.ScopeContractsFormed, renewed.Revision.ParentID); !ok {
+		t.Fatal("expected formation event for the renewal")
+	}
+}
+
+func TestRevisionBroadcastEvents(t *testing.T) {
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	log := zaptest.NewLogger(t)
+
+	network, genesis := testutil.V1Network()
+	cn := testutil.NewConsensusNode(t, network, genesis, log)
+
+	wm, err := wallet.NewSingleAddressWallet(hostKey, cn.Chain, cn.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wm.Close()
+
+	vm, err := storage.NewVolumeManager(cn.Store, storage.WithLogger(log.Named("storage")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	events := new(eventRecorder)
+	cm, err := contracts.NewManager(cn.Store, vm, cn.Chain, cn.Syncer, wm, contracts.WithLog(log.Named("contracts")), contracts.WithEventReporter(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+
+	sm, err := settings.NewConfigManager(hostKey, cn.Store, cn.Chain, cn.Syncer, vm, wm, settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	idx, err := index.NewManager(cn.Store, cn.Chain, cm, wm, sm, vm, index.WithLog(log.Named("index")), index.WithBatchSize(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	mineAndSync := func(addr types.Address, n int) {
+		for i := 0; i < n; i++ {
+			testutil.MineBlocks(t, cn, addr, 1)
+			testutil.WaitForSync(t, cn.Chain, idx)
+		}
+	}
+
+	assertRevisionEvent := func(t *testing.T, scope string, id types.FileContractID) {
+		t.Helper()
+
+		e, ok := events.find(scope, id)
+		switch {
+		case !ok:
+			t.Fatal("expected revision broadcast event")
+		case e.event != contracts.EventContractRevisionBroadcast:
+			t.Fatalf("expected event %q, got %q", contracts.EventContractRevisionBroadcast, e.event)
+		case e.data.Index != cn.Chain.Tip():
+			t.Fatalf("expected index %v, got %v", cn.Chain.Tip(), e.data.Index)
+		}
+	}
+
+	// fund the wallet
+	mineAndSync(wm.Address(), int(network.MaturityDelay+5))
+
+	t.Run("v1", func(t *testing.T) {
+		rev := formContract(t, cn.Chain, cm, wm, cn.Syncer, sm, renterKey, hostKey, types.Siacoins(10), types.Siacoins(20), 10, true)
+
+		// revise the contract without broadcasting the revision
+		rev.Revision.RevisionNumber++
+		sigHash := hashRevision(rev.Revision)
+		rev.HostSignature = hostKey.SignHash(sigHash)
+		rev.RenterSignature = renterKey.SignHash(sigHash)
+		updater, err := cm.ReviseContract(rev.Revision.ParentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer updater.Close()
+		if err := updater.Commit(rev, contracts.Usage{}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the revision should be broadcast once the contract is confirmed
+		// since it is within the revision submission buffer
+		mineAndSync(types.VoidAddress, 1)
+		assertRevisionEvent(t, webhooks.ScopeContractsRevision, rev.Revision.ParentID)
+		if _, ok := events.find(webhooks.ScopeContractsV2Revision, rev.Revision.ParentID); ok {
+			t.Fatal("expected v1 contract to not use the v2 scope")
+		}
+	})
+
+	// mine until v2 contracts can be formed
+	mineAndSync(types.VoidAddress, int(network.HardforkV2.AllowHeight-cn.Chain.Tip().Height)+1)
+
+	t.Run("v2", func(t *testing.T) {
+		contractID, fc := formV2Contract(t, cn.Chain, cm, wm, cn.Syncer, renterKey, hostKey, types.Siacoins(10), types.Siacoins(20), 10, true)
+
+		// revise the contract without broadcasting the revision
+		fc.RevisionNumber++
+		sigHash := cn.Chain.TipState().ContractSigHash(fc)
+		fc.HostSignature = hostKey.SignHash(sigHash)
+		fc.RenterSignature = renterKey.SignHash(sigHash)
+		if err := cm.ReviseV2Contract(contractID, fc, nil, proto4.Usage{}); err != nil {
+			t.Fatal(err)
+		}
+
+		mineAndSync(types.VoidAddress, 1)
+		assertRevisionEvent(t, webhooks.ScopeContractsV2Revision, contractID)
+		if _, ok := events.find(webhooks.ScopeContractsRevision, contractID); ok {
+			t.Fatal("expected v2 contract to not use the v1 scope")
+		}
+	})
+}
@@ -23,6 +23,23 @@ type (
 		ActualRoot   types.Hash256 `json:"actualRoot"`
 		Error        error         `json:"error"`
 	}
+
+	// An IntegrityCheck is the stored result of a completed contract
+	// integrity check.
+	IntegrityCheck struct {
+		ID         int64                `json:"id"`
+		ContractID types.FileContractID `json:"contractID"`
+		V2         bool                 `json:"v2"`
+
+		StartTime time.Time `json:"startTime"`
+		EndTime   time.Time `json:"endTime"`
+
+		TotalSectors   uint64            `json:"totalSectors"`
+		CheckedSectors uint64            `json:"checkedSectors"`
+		MissingSectors uint64            `json:"missingSectors"`
+		CorruptSectors uint64            `json:"corruptSectors"`
+		BadSectors     []IntegrityResult `json:"badSectors"`
+	}
 )
 
 // MarshalJSON implements a custom json.Marshaler to handle the error interface.
@@ -63,24 +80,89 @@ func (i *IntegrityResult) UnmarshalJSON(b []byte) error {
 // result of every checked sector is sent on the returned channel. The channel is closed
 // when all checks are complete.
 func (cm *Manager) CheckIntegrity(ctx context.Context, contractID types.FileContractID) (<-chan IntegrityResult, uint64, error) {
+	roots, err := cm.integrityRoots(ctx, contractID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cm.checkSectors(ctx, contractID, roots, true), uint64(len(roots)), nil
+}
+
+// CheckV2Integrity checks the integrity of a v2 contract's sector roots on
+// disk. The result of every checked sector is sent on the returned channel.
+// The channel is closed when all checks are complete.
+func (cm *Manager) CheckV2Integrity(ctx context.Context, contractID types.FileContractID) (<-chan IntegrityResult, uint64, error) {
+	roots, err := cm.v2IntegrityRoots(ctx, contractID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cm.checkSectors(ctx, contractID, roots, true), uint64(len(roots)), nil
+}
+
+// integrityRoots returns the sector roots of a contract after validating
+// them against the contract's latest revision.
+func (cm *Manager) integrityRoots(ctx context.Context, contractID types.FileContractID) ([]types.Hash256, error) {
 	// lock the contract to ensure it doesn't get modified before the sector
 	// roots are retrieved.
 	contract, err := cm.Lock(ctx, contractID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to lock contract: %w", err)
+		return nil, fmt.Errorf("failed to lock contract: %w", err)
 	}
 	defer cm.Unlock(contractID)
 
 	expectedRoots := contract.Revision.Filesize / rhp2.SectorSize
 
-	roots := cm.getSectorRoots(contractID)
-	if uint64(len(roots)) != expectedRoots {
-		return nil, 0, fmt.Errorf("expected %v sector roots, got %v", expectedRoots, len(roots))
+	roots, err := cm.getSectorRoots(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sector roots: %w", err)
+	} else if uint64(len(roots)) != expectedRoots {
+		return nil, fmt.Errorf("expected %v sector roots, got %v", expectedRoots, len(roots))
 	} else if calculated := rhp2.MetaRoot(roots); contract.Revision.FileMerkleRoot != calculated {
-		return nil, 0, fmt.Errorf("expected Merkle root %v, got %v", contract.Revision.FileMerkleRoot, calculated)
+		return nil, fmt.Errorf("expected Merkle root %v, got %v", contract.Revision.FileMerkleRoot, calculated)
+	}
+	return roots, nil
+}
+
+// v2IntegrityRoots returns the sector roots of a v2 contract after
+// validating them against the contract's latest revision.
+func (cm *Manager) v2IntegrityRoots(ctx context.Context, contractID types.FileContractID) ([]types.Hash256, error) {
+	done, err := cm.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// lock the contract to ensure it doesn't get modified before the sector
+	// roots are retrieved.
+	if err := cm.locks.Lock(ctx, contractID); err != nil {
+		return nil, fmt.Errorf("failed to lock contract: %w", err)
+	}
+	defer cm.locks.Unlock(contractID)
+
+	contract, err := cm.store.V2Contract(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contract: %w", err)
+	} else if contract.Status != V2ContractStatusActive && contract.Status != V2ContractStatusPending {
+		return nil, fmt.Errorf("contract status is %v, integrity cannot be checked", contract.Status)
+	}
+
+	expectedRoots := contract.Filesize / rhp2.SectorSize
+
+	roots, err := cm.getSectorRoots(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sector roots: %w", err)
+	} else if uint64(len(roots)) != expectedRoots {
+		return nil, fmt.Errorf("expected %v sector roots, got %v", expectedRoots, len(roots))
+	} else if calculated := rhp2.MetaRoot(roots); contract.FileMerkleRoot != calculated {
+		return nil, fmt.Errorf("expected Merkle root %v, got %v", contract.FileMerkleRoot, calculated)
 	}
+	return roots, nil
+}
 
-	// register an alert to track progress
+// checkSectors reads each sector from disk and verifies its Merkle root. The
+// result of every checked sector is sent on the returned channel. The
+// channel is closed when all checks are complete. If trackProgress is true,
+// an alert is registered to track the progress of the check.
+func (cm *Manager) checkSectors(ctx context.Context, contractID types.FileContractID, roots []types.Hash256, trackProgress bool) <-chan IntegrityResult {
 	alert := alerts.Alert{
 		ID:       frand.Entropy256(),
 		Severity: alerts.SeverityInfo,
@@ -94,7 +176,9 @@ func (cm *Manager) CheckIntegrity(ctx context.Context, contractID types.FileCont
 		},
 		Timestamp: time.Now(),
 	}
-	cm.alerts.Register(alert)
+	if trackProgress {
+		cm.alerts.Register(alert)
+	}
 
 	results := make(chan IntegrityResult, 1)
 	// start a goroutine to check each sector
@@ -133,7 +217,9 @@ func (cm *Manager) CheckIntegrity(ctx context.Context, contractID types.FileCont
 			alert.Data["checked"] = i + 1
 			alert.Data["missing"] = missing
 			alert.Data["corrupt"] = corrupt
-			cm.alerts.Register(alert)
+			if trackProgress {
+				cm.alerts.Register(alert)
+			}
 			time.Sleep(time.Millisecond) // sleep to allow other transactions to proceed
 		}
 
@@ -143,7 +229,9 @@ func (cm *Manager) CheckIntegrity(ctx context.Context, contractID types.FileCont
 		if corrupt > 0 || missing > 0 {
 			alert.Severity = alerts.SeverityError
 		}
-		cm.alerts.Register(alert)
+		if trackProgress {
+			cm.alerts.Register(alert)
+		}
 	}()
-	return results, uint64(len(roots)), nil
+	return results
 }
@@ -8,10 +8,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	rhp2 "go.sia.tech/core/rhp/v2"
+	proto4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
 	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/internal/testutil"
 	"go.uber.org/zap/zaptest"
 	"lukechampine.com/frand"
@@ -195,3 +198,210 @@ func TestCheckIntegrity(t *testing.T) {
 		t.Fatalf("expected %v issues, got %v", 2, issues)
 	}
 }
+
+func TestCheckV2Integrity(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	n, genesis := testutil.V2Network()
+	host := testutil.NewHostNode(t, hostKey, n, genesis, log)
+
+	volumePath := filepath.Join(t.TempDir(), "data.dat")
+	result := make(chan error, 1)
+	if _, err := host.Volumes.AddVolume(context.Background(), volumePath, 10, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	// mine enough for the wallet to have some funds
+	testutil.MineAndSync(t, host, host.Wallet.Address(), int(n.MaturityDelay+5))
+
+	contractID, fc := formV2Contract(t, host.Chain, host.Contracts, host.Wallet, host.Syncer, renterKey, hostKey, types.Siacoins(10), types.Siacoins(20), 10, true)
+	testutil.MineAndSync(t, host, types.VoidAddress, 1)
+
+	var roots []types.Hash256
+	for i := 0; i < 5; i++ {
+		var sector [rhp2.SectorSize]byte
+		frand.Read(sector[:256])
+		root := rhp2.SectorRoot(&sector)
+		if err := host.Volumes.Write(root, &sector); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	fc.Filesize = uint64(len(roots)) * proto4.SectorSize
+	fc.Capacity = fc.Filesize
+	fc.FileMerkleRoot = proto4.MetaRoot(roots)
+	fc.RevisionNumber++
+	sigHash := host.Chain.TipState().ContractSigHash(fc)
+	fc.HostSignature = hostKey.SignHash(sigHash)
+	fc.RenterSignature = renterKey.SignHash(sigHash)
+
+	if err := host.Contracts.ReviseV2Contract(contractID, fc, roots, proto4.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// helper func to serialize integrity check
+	checkIntegrity := func() (issues, checked, sectors uint64, err error) {
+		results, sectors, err := host.Contracts.CheckV2Integrity(context.Background(), contractID)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to check integrity: %w", err)
+		}
+
+		for result := range results {
+			if result.Error != nil {
+				issues++
+			}
+			checked++
+		}
+		return issues, checked, sectors, nil
+	}
+
+	// check for issues, should be none
+	issues, checked, sectors, err := checkIntegrity()
+	if err != nil {
+		t.Fatal(err)
+	} else if checked != uint64(len(roots)) {
+		t.Fatalf("expected %v checked, got %v", len(roots), checked)
+	} else if sectors != uint64(len(roots)) {
+		t.Fatalf("expected %v sectors, got %v", len(roots), sectors)
+	} else if issues != 0 {
+		t.Fatalf("expected %v issues, got %v", 0, issues)
+	}
+
+	// delete a sector
+	if err := host.Volumes.RemoveSector(roots[3]); err != nil {
+		t.Fatal(err)
+	}
+
+	// check for issues, should be one
+	issues, checked, sectors, err = checkIntegrity()
+	if err != nil {
+		t.Fatal(err)
+	} else if checked != uint64(len(roots)) {
+		t.Fatalf("expected %v checked, got %v", len(roots), checked)
+	} else if sectors != uint64(len(roots)) {
+		t.Fatalf("expected %v sectors, got %v", len(roots), sectors)
+	} else if issues != 1 {
+		t.Fatalf("expected %v issues, got %v", 1, issues)
+	}
+}
+
+func TestScheduledIntegrityCheck(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	n, genesis := testutil.V2Network()
+	host := testutil.NewHostNode(t, hostKey, n, genesis, log)
+
+	volumePath := filepath.Join(t.TempDir(), "data.dat")
+	result := make(chan error, 1)
+	if _, err := host.Volumes.AddVolume(context.Background(), volumePath, 10, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	// mine enough for the wallet to have some funds
+	testutil.MineAndSync(t, host, host.Wallet.Address(), int(n.MaturityDelay+5))
+
+	contractID, fc := formV2Contract(t, host.Chain, host.Contracts, host.Wallet, host.Syncer, renterKey, hostKey, types.Siacoins(10), types.Siacoins(20), 10, true)
+	testutil.MineAndSync(t, host, types.VoidAddress, 1)
+
+	var roots []types.Hash256
+	for i := 0; i < 5; i++ {
+		var sector [rhp2.SectorSize]byte
+		frand.Read(sector[:256])
+		root := rhp2.SectorRoot(&sector)
+		if err := host.Volumes.Write(root, &sector); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	fc.Filesize = uint64(len(roots)) * proto4.SectorSize
+	fc.Capacity = fc.Filesize
+	fc.FileMerkleRoot = proto4.MetaRoot(roots)
+	fc.RevisionNumber++
+	sigHash := host.Chain.TipState().ContractSigHash(fc)
+	fc.HostSignature = hostKey.SignHash(sigHash)
+	fc.RenterSignature = renterKey.SignHash(sigHash)
+
+	if err := host.Contracts.ReviseV2Contract(contractID, fc, roots, proto4.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// delete a sector
+	if err := host.Volumes.RemoveSector(roots[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt a sector on disk
+	loc, err := host.Store.SectorLocation(roots[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(volumePath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(frand.Bytes(256), int64(loc.Index*rhp2.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+
+	// start a second manager with a short check interval
+	cm, err := contracts.NewManager(host.Store, host.Volumes, host.Chain, host.Syncer, host.Wallet, contracts.WithLog(log.Named("scheduled")), contracts.WithIntegrityCheckInterval(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+
+	var checks []contracts.IntegrityCheck
+	for i := 0; i < 100; i++ {
+		checks, err = cm.ContractIntegrityChecks(contractID, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(checks) > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if len(checks) == 0 {
+		t.Fatal("expected scheduled integrity check")
+	}
+	check := checks[0]
+	switch {
+	case !check.V2:
+		t.Fatal("expected v2 check")
+	case check.TotalSectors != uint64(len(roots)):
+		t.Fatalf("expected %v sectors, got %v", len(roots), check.TotalSectors)
+	case check.CheckedSectors != uint64(len(roots)):
+		t.Fatalf("expected %v checked, got %v", len(roots), check.CheckedSectors)
+	case check.MissingSectors != 1:
+		t.Fatalf("expected 1 missing sector, got %v", check.MissingSectors)
+	case check.CorruptSectors != 1:
+		t.Fatalf("expected 1 corrupt sector, got %v", check.CorruptSectors)
+	case len(check.BadSectors) != 2 || check.BadSectors[0].ExpectedRoot != roots[1] || check.BadSectors[1].ExpectedRoot != roots[2]:
+		t.Fatalf("expected bad sectors %v, got %v", roots[1:3], check.BadSectors)
+	}
+
+	// the corrupt sector should be reported as lost without replacing the
+	// cause of the removed sector
+	lost, _, err := host.Volumes.LostSectors(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	causes := make(map[types.Hash256]storage.LostSectorCause)
+	for _, sector := range lost {
+		causes[sector.Root] = sector.Cause
+	}
+	if len(causes) != 2 {
+		t.Fatalf("expected 2 lost sectors, got %v", lost)
+	} else if causes[roots[1]] != storage.LostSectorCauseRemoved {
+		t.Fatalf("expected removed sector cause %q, got %q", storage.LostSectorCauseRemoved, causes[roots[1]])
+	} else if causes[roots[2]] != storage.LostSectorCauseIntegrityCheck {
+		t.Fatalf("expected corrupt sector cause %q, got %q", storage.LostSectorCauseIntegrityCheck, causes[roots[2]])
+	}
+}
@@ -0,0 +1,239 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/alerts"
+	"go.sia.tech/hostd/host/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// integrityCheckBatchSize is the number of contracts retrieved from the
+	// store at a time when scheduling integrity checks.
+	integrityCheckBatchSize = 100
+
+	// integrityCheckRetention is the duration completed integrity checks
+	// are kept in the store.
+	integrityCheckRetention = 90 * 24 * time.Hour
+)
+
+type scheduledIntegrityCheck struct {
+	ID types.FileContractID
+	V2 bool
+}
+
+// integrityAlertID returns a deterministic alert ID for a contract's
+// scheduled integrity checks.
+func integrityAlertID(contractID types.FileContractID) types.Hash256 {
+	return types.HashBytes(append([]byte("integrityCheck"), contractID[:]...))
+}
+
+// IntegrityChecks returns a paginated list of completed integrity checks
+// sorted by end time descending.
+func (cm *Manager) IntegrityChecks(limit, offset int) ([]IntegrityCheck, error) {
+	return cm.store.IntegrityChecks(limit, offset)
+}
+
+// ContractIntegrityChecks returns a paginated list of completed integrity
+// checks for a contract sorted by end time descending.
+func (cm *Manager) ContractIntegrityChecks(contractID types.FileContractID, limit, offset int) ([]IntegrityCheck, error) {
+	return cm.store.ContractIntegrityChecks(contractID, limit, offset)
+}
+
+// dueIntegrityChecks returns all active v1 and v2 contracts that have not
+// been checked since the cutoff.
+func (cm *Manager) dueIntegrityChecks(cutoff time.Time) ([]scheduledIntegrityCheck, error) {
+	lastChecked, err := cm.store.LastIntegrityChecks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last integrity checks: %w", err)
+	}
+
+	var due []scheduledIntegrityCheck
+	for offset := 0; ; offset += integrityCheckBatchSize {
+		contracts, _, err := cm.store.Contracts(ContractFilter{
+			Statuses: []ContractStatus{ContractStatusActive},
+			Limit:    integrityCheckBatchSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get contracts: %w", err)
+		}
+		for _, c := range contracts {
+			if lastChecked[c.Revision.ParentID].Before(cutoff) {
+				due = append(due, scheduledIntegrityCheck{ID: c.Revision.ParentID})
+			}
+		}
+		if len(contracts) < integrityCheckBatchSize {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += integrityCheckBatchSize {
+		contracts, _, err := cm.store.V2Contracts(V2ContractFilter{
+			Statuses: []V2ContractStatus{V2ContractStatusActive},
+			Limit:    integrityCheckBatchSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get v2 contracts: %w", err)
+		}
+		for _, c := range contracts {
+			if lastChecked[c.ID].Before(cutoff) {
+				due = append(due, scheduledIntegrityCheck{ID: c.ID, V2: true})
+			}
+		}
+		if len(contracts) < integrityCheckBatchSize {
+			break
+		}
+	}
+	return due, nil
+}
+
+// runIntegrityCheck checks the integrity of a single contract and stores the
+// result. An alert is registered if any sectors are missing or corrupt.
+func (cm *Manager) runIntegrityCheck(ctx context.Context, sc scheduledIntegrityCheck) error {
+	start := time.Now()
+
+	var roots []types.Hash256
+	var err error
+	if sc.V2 {
+		roots, err = cm.v2IntegrityRoots(ctx, sc.ID)
+	} else {
+		roots, err = cm.integrityRoots(ctx, sc.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	check := IntegrityCheck{
+		ContractID:   sc.ID,
+		V2:           sc.V2,
+		StartTime:    start,
+		TotalSectors: uint64(len(roots)),
+	}
+	for result := range cm.checkSectors(ctx, sc.ID, roots, false) {
+		check.CheckedSectors++
+		if result.Error == nil {
+			continue
+		} else if result.ActualRoot == (types.Hash256{}) {
+			check.MissingSectors++
+		} else {
+			check.CorruptSectors++
+		}
+		check.BadSectors = append(check.BadSectors, result)
+	}
+	// do not store incomplete checks
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	check.EndTime = time.Now()
+
+	if len(check.BadSectors) > 0 {
+		lost := make([]types.Hash256, 0, len(check.BadSectors))
+		for _, result := range check.BadSectors {
+			lost = append(lost, result.ExpectedRoot)
+		}
+		if err := cm.storage.MarkSectorsLost(lost, storage.LostSectorCauseIntegrityCheck); err != nil {
+			return fmt.Errorf("failed to record lost sectors: %w", err)
+		}
+	}
+
+	check.ID, err = cm.store.AddIntegrityCheck(check)
+	if err != nil {
+		return fmt.Errorf("failed to store integrity check: %w", err)
+	}
+
+	if len(check.BadSectors) == 0 {
+		cm.alerts.Dismiss(integrityAlertID(sc.ID))
+		return nil
+	}
+	cm.alerts.Register(alerts.Alert{
+		ID:       integrityAlertID(sc.ID),
+		Severity: alerts.SeverityError,
+		Message:  "Contract integrity check failed",
+		Data: map[string]any{
+			"contractID": sc.ID,
+			"checkID":    check.ID,
+			"checked":    check.CheckedSectors,
+			"missing":    check.MissingSectors,
+			"corrupt":    check.CorruptSectors,
+			"total":      check.TotalSectors,
+		},
+		Timestamp: check.EndTime,
+	})
+	return nil
+}
+
+// runScheduledIntegrityChecks checks the integrity of all contracts that are
+// due for a check. At most integrityCheckConcurrency contracts are checked
+// at the same time.
+func (cm *Manager) runScheduledIntegrityChecks(ctx context.Context) error {
+	due, err := cm.dueIntegrityChecks(time.Now().Add(-cm.integrityCheckInterval))
+	if err != nil {
+		return err
+	}
+
+	log := cm.log.Named("integrityCheck")
+	if len(due) > 0 {
+		log.Debug("running scheduled integrity checks", zap.Int("contracts", len(due)))
+	}
+
+	sema := make(chan struct{}, cm.integrityCheckConcurrency)
+	var wg sync.WaitGroup
+loop:
+	for _, sc := range due {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sema <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(sc scheduledIntegrityCheck) {
+			defer func() {
+				<-sema
+				wg.Done()
+			}()
+
+			if err := cm.runIntegrityCheck(ctx, sc); err != nil && !errors.Is(err, context.Canceled) {
+				log.Error("integrity check failed", zap.Stringer("contractID", sc.ID), zap.Bool("v2", sc.V2), zap.Error(err))
+			}
+		}(sc)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	} else if err := cm.store.PruneIntegrityChecks(time.Now().Add(-integrityCheckRetention)); err != nil {
+		return fmt.Errorf("failed to prune integrity checks: %w", err)
+	}
+	return nil
+}
+
+// scheduleIntegrityChecks periodically checks the integrity of all active
+// contracts.
+func (cm *Manager) scheduleIntegrityChecks() {
+	ctx, done, err := cm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer done()
+
+	frequency := min(cm.integrityCheckInterval, time.Hour)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(frequency):
+			if err := cm.runScheduledIntegrityChecks(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				cm.log.Error("failed to run scheduled integrity checks", zap.Error(err))
+			}
+		}
+	}
+}
@@ -135,12 +135,18 @@ func (cm *Manager) LockV2Contract(id types.FileContractID) (rev rhp4.RevisionSta
 		maxRevisionHeight = contract.ProofHeight - cm.revisionSubmissionBuffer
 	}
 	revisable := !renewed && cm.chain.Tip().Height < maxRevisionHeight
+
+	roots, err := cm.getSectorRoots(id)
+	if err != nil {
+		cm.locks.Unlock(id)
+		return rhp4.RevisionState{}, nil, fmt.Errorf("failed to get sector roots: %w", err)
+	}
 	return rhp4.RevisionState{
-			Revision:  contract.V2FileContract,
-			Renewed:   renewed,
-			Revisable: revisable,
-			Roots:     cm.getSectorRoots(id),
-		}, func() {
-			cm.locks.Unlock(id)
-		}, nil
+		Revision:  contract.V2FileContract,
+		Renewed:   renewed,
+		Revisable: revisable,
+		Roots:     roots,
+	}, func() {
+		cm.locks.Unlock(id)
+	}, nil
 }
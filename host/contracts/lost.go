@@ -0,0 +1,84 @@
+package contracts
+
+import (
+	"sort"
+
+	"go.sia.tech/core/types"
+)
+
+type (
+	// A LostSectorContract is an unresolved contract that references
+	// sectors the host no longer stores.
+	LostSectorContract struct {
+		ContractID types.FileContractID `json:"contractID"`
+		V2         bool                 `json:"v2"`
+		RenterKey  types.PublicKey      `json:"renterKey"`
+
+		LostSectors  uint64 `json:"lostSectors"`
+		TotalSectors uint64 `json:"totalSectors"`
+
+		ProofWindowStart uint64 `json:"proofWindowStart"`
+		ProofWindowEnd   uint64 `json:"proofWindowEnd"`
+		// CollateralAtRisk is the value the host forfeits if it does not
+		// submit a valid storage proof.
+		CollateralAtRisk types.Currency `json:"collateralAtRisk"`
+	}
+
+	// A LostSectorRisk is the risk of a contract failing its storage proof
+	// because of lost sectors.
+	LostSectorRisk struct {
+		LostSectorContract
+
+		// BlocksUntilProof is the number of blocks until the contract's
+		// proof window opens. It is zero if the window is already open.
+		BlocksUntilProof uint64 `json:"blocksUntilProof"`
+		// ProofFailureProbability is the probability that the storage
+		// proof challenges a lost sector.
+		ProofFailureProbability float64 `json:"proofFailureProbability"`
+	}
+)
+
+// proofFailureProbability returns the probability that a storage proof
+// challenges a lost sector. The challenged segment is chosen uniformly at
+// random, so each sector is equally likely to be challenged.
+func proofFailureProbability(lost, total uint64) float64 {
+	switch {
+	case lost == 0:
+		return 0
+	case lost >= total:
+		return 1
+	}
+	return float64(lost) / float64(total)
+}
+
+// LostSectorRisks returns the unresolved contracts that reference lost
+// sectors sorted by the start of their proof window.
+func (cm *Manager) LostSectorRisks() ([]LostSectorRisk, error) {
+	done, err := cm.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	contracts, err := cm.store.LostSectorContracts()
+	if err != nil {
+		return nil, err
+	}
+
+	height := cm.chain.Tip().Height
+	risks := make([]LostSectorRisk, 0, len(contracts))
+	for _, c := range contracts {
+		risk := LostSectorRisk{
+			LostSectorContract:      c,
+			ProofFailureProbability: proofFailureProbability(c.LostSectors, c.TotalSectors),
+		}
+		if c.ProofWindowStart > height {
+			risk.BlocksUntilProof = c.ProofWindowStart - height
+		}
+		risks = append(risks, risk)
+	}
+	sort.SliceStable(risks, func(i, j int) bool {
+		return risks[i].ProofWindowStart < risks[j].ProofWindowStart
+	})
+	return risks, nil
+}
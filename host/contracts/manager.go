@@ -13,7 +13,9 @@ import (
 	"go.sia.tech/core/types"
 	rhp4 "go.sia.tech/coreutils/rhp/v4"
 	"go.sia.tech/hostd/alerts"
+	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/internal/threadgroup"
+	"go.sia.tech/hostd/webhooks"
 	"go.uber.org/zap"
 )
 
@@ -52,6 +54,8 @@ type (
 	StorageManager interface {
 		// Read reads a sector from the store
 		ReadSector(root types.Hash256) (*[rhp2.SectorSize]byte, error)
+		// MarkSectorsLost records that the sectors are missing or corrupt.
+		MarkSectorsLost(roots []types.Hash256, cause storage.LostSectorCause) error
 	}
 
 	// Alerts registers and dismisses global alerts.
@@ -60,16 +64,34 @@ type (
 		Dismiss(...types.Hash256)
 	}
 
+	// An EventReporter broadcasts events to subscribers.
+	EventReporter interface {
+		BroadcastEvent(event string, scope string, data any) error
+	}
+
+	// RenterAccess checks whether a renter may form or renew contracts with
+	// the host.
+	RenterAccess interface {
+		CheckRenterAccess(types.PublicKey) error
+	}
+
 	// A Manager manages contracts' lifecycle
 	Manager struct {
 		rejectBuffer             uint64
 		revisionSubmissionBuffer uint64
 
+		integrityCheckInterval    time.Duration
+		integrityCheckConcurrency int
+
+		renterMetricsInterval time.Duration
+
 		store ContractStore
 		tg    *threadgroup.ThreadGroup
 		log   *zap.Logger
 
 		alerts  Alerts
+		events  EventReporter
+		access  RenterAccess // optional, nil allows every renter
 		storage StorageManager
 		chain   ChainManager
 		syncer  Syncer
@@ -77,31 +99,25 @@ type (
 
 		locks *locker // contracts must be locked while they are being modified
 
-		mu sync.Mutex // guards the following fields
-		// caches the sector roots of all contracts to avoid long reads from
-		// the store
-		sectorRoots map[types.FileContractID][]types.Hash256
-	}
-)
+		defaultQuota RenterQuota
+		// quotaMu protects the cached usage of renters with a quota. The
+		// usage epoch is incremented after every chain update to reload the
+		// usage from the store.
+		quotaMu    sync.Mutex
+		usageEpoch uint64
+		usage      map[types.PublicKey]*cachedRenterUsage
 
-func (cm *Manager) getSectorRoots(id types.FileContractID) []types.Hash256 {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
+		// eventsMu protects pendingEvents, the events of the last chain
+		// update, which are broadcast once the update is committed
+		eventsMu      sync.Mutex
+		pendingEvents []contractEvent
 
-	roots, ok := cm.sectorRoots[id]
-	if !ok {
-		return nil
+		// caches the sector roots of recently used contracts to avoid long
+		// reads from the store
+		rootCacheSize uint64
+		roots         *rootCache
 	}
-	// return a deep copy of the roots
-	return append([]types.Hash256(nil), roots...)
-}
-
-func (cm *Manager) setSectorRoots(id types.FileContractID, roots []types.Hash256) {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-	// deep copy the roots
-	cm.sectorRoots[id] = append([]types.Hash256(nil), roots...)
-}
+)
 
 // Contracts returns a paginated list of contracts matching the filter and the
 // total number of contracts matching the filter.
@@ -114,6 +130,12 @@ func (cm *Manager) Contract(id types.FileContractID) (Contract, error) {
 	return cm.store.Contract(id)
 }
 
+// V2Contracts returns a paginated list of v2 contracts matching the filter and
+// the total number of contracts matching the filter.
+func (cm *Manager) V2Contracts(filter V2ContractFilter) ([]V2Contract, int, error) {
+	return cm.store.V2Contracts(filter)
+}
+
 // V2Contract returns the v2 contract with the given ID.
 func (cm *Manager) V2Contract(id types.FileContractID) (V2Contract, error) {
 	return cm.store.V2Contract(id)
@@ -133,9 +155,17 @@ func (cm *Manager) AddContract(revision SignedRevision, formationSet []types.Tra
 		return err
 	}
 	defer done()
+
+	res, err := cm.reserveRenterQuota(revision.RenterKey(), RenterUsage{Contracts: 1, LockedCollateral: lockedCollateral})
+	if err != nil {
+		return err
+	}
+	defer res.release()
+
 	if err := cm.store.AddContract(revision, formationSet, lockedCollateral, initialUsage, cm.chain.TipState().Index.Height); err != nil {
 		return err
 	}
+	res.commit()
 	cm.log.Debug("contract formed", zap.Stringer("contractID", revision.Revision.ParentID))
 	return nil
 }
@@ -149,8 +179,12 @@ func (cm *Manager) RenewContract(renewal SignedRevision, existing SignedRevision
 	}
 	defer done()
 
+	existingRoots, err := cm.getSectorRoots(existing.Revision.ParentID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing sector roots: %w", err)
+	}
+
 	// sanity checks
-	existingRoots := cm.getSectorRoots(existing.Revision.ParentID)
 	if existing.Revision.FileMerkleRoot != (types.Hash256{}) {
 		return errors.New("existing contract must be cleared")
 	} else if existing.Revision.Filesize != 0 {
@@ -163,9 +197,20 @@ func (cm *Manager) RenewContract(renewal SignedRevision, existing SignedRevision
 		return errors.New("renewal root does not match existing roots")
 	}
 
+	existingContract, err := cm.store.Contract(existing.Revision.ParentID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing contract: %w", err)
+	}
+	res, err := cm.reserveRenterQuota(renewal.RenterKey(), renewalUsage(existingContract, lockedCollateral))
+	if err != nil {
+		return err
+	}
+	defer res.release()
+
 	if err := cm.store.RenewContract(renewal, existing, formationSet, lockedCollateral, clearingUsage, initialUsage, cm.chain.TipState().Index.Height); err != nil {
 		return err
 	}
+	res.commit()
 	cm.setSectorRoots(renewal.Revision.ParentID, existingRoots)
 	cm.log.Debug("contract renewed", zap.Stringer("renewalID", renewal.Revision.ParentID), zap.Stringer("existingID", existing.Revision.ParentID))
 	return nil
@@ -189,7 +234,10 @@ func (cm *Manager) ReviseV2Contract(contractID types.FileContractID, revision ty
 		return errors.New("renewed contracts cannot be revised")
 	}
 
-	oldRoots := cm.getSectorRoots(contractID)
+	oldRoots, err := cm.getSectorRoots(contractID)
+	if err != nil {
+		return fmt.Errorf("failed to get sector roots: %w", err)
+	}
 
 	// validate the contract revision fields
 	switch {
@@ -221,11 +269,25 @@ func (cm *Manager) ReviseV2Contract(contractID types.FileContractID, revision ty
 		return errors.New("revision root does not match")
 	}
 
+	var delta RenterUsage
+	if len(newRoots) > len(oldRoots) {
+		delta.Sectors = uint64(len(newRoots) - len(oldRoots))
+	}
+	res, err := cm.reserveRenterQuota(revision.RenterPublicKey, delta)
+	if err != nil {
+		return err
+	}
+	defer res.release()
+
 	// revise the contract in the store
 	err = cm.store.ReviseV2Contract(contractID, revision, oldRoots, newRoots, usage)
 	if err != nil {
 		return err
 	}
+	res.commit()
+	if len(newRoots) < len(oldRoots) {
+		cm.releaseRenterSectors(revision.RenterPublicKey, uint64(len(oldRoots)-len(newRoots)))
+	}
 	// update the sector roots cache
 	cm.setSectorRoots(contractID, newRoots)
 	cm.log.Debug("contract revised", zap.Stringer("contractID", contractID), zap.Uint64("revisionNumber", revision.RevisionNumber))
@@ -252,6 +314,10 @@ func (cm *Manager) AddV2Contract(formation rhp4.TransactionSet, usage proto4.Usa
 	fc := formationTxn.FileContracts[0]
 	contractID := formationTxn.V2FileContractID(formationTxn.ID(), 0)
 
+	if err := cm.checkRenterAccess(fc.RenterPublicKey); err != nil {
+		return err
+	}
+
 	contract := V2Contract{
 		V2FileContract: fc,
 
@@ -261,9 +327,16 @@ func (cm *Manager) AddV2Contract(formation rhp4.TransactionSet, usage proto4.Usa
 		Usage:             usage,
 	}
 
+	res, err := cm.reserveRenterQuota(fc.RenterPublicKey, RenterUsage{Contracts: 1, LockedCollateral: fc.TotalCollateral})
+	if err != nil {
+		return err
+	}
+	defer res.release()
+
 	if err := cm.store.AddV2Contract(contract, formation); err != nil {
 		return err
 	}
+	res.commit()
 	cm.log.Debug("contract formed", zap.Stringer("contractID", contractID))
 	return nil
 }
@@ -297,6 +370,10 @@ func (cm *Manager) RenewV2Contract(renewal rhp4.TransactionSet, usage proto4.Usa
 	}
 	fc := resolution.NewContract
 
+	if err := cm.checkRenterAccess(fc.RenterPublicKey); err != nil {
+		return err
+	}
+
 	// sanity checks
 	if fc.Filesize != existing.Filesize {
 		return errors.New("renewal contract must have same file size as existing contract")
@@ -307,8 +384,10 @@ func (cm *Manager) RenewV2Contract(renewal rhp4.TransactionSet, usage proto4.Usa
 	}
 
 	existingID := types.FileContractID(existing.ID)
-	existingRoots := cm.getSectorRoots(existingID)
-	if fc.FileMerkleRoot != rhp2.MetaRoot(existingRoots) {
+	existingRoots, err := cm.getSectorRoots(existingID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing sector roots: %w", err)
+	} else if fc.FileMerkleRoot != rhp2.MetaRoot(existingRoots) {
 		return errors.New("renewal root does not match existing roots")
 	}
 
@@ -322,9 +401,16 @@ func (cm *Manager) RenewV2Contract(renewal rhp4.TransactionSet, usage proto4.Usa
 		Usage:             usage,
 	}
 
+	res, err := cm.reserveRenterQuota(fc.RenterPublicKey, v2RenewalUsage(existing, fc))
+	if err != nil {
+		return err
+	}
+	defer res.release()
+
 	if err := cm.store.RenewV2Contract(contract, renewal, existingID, existingRoots); err != nil {
 		return err
 	}
+	res.commit()
 	cm.setSectorRoots(contract.ID, existingRoots)
 	cm.log.Debug("contract renewed", zap.Stringer("formedID", contract.ID), zap.Stringer("existingID", existingID))
 	return nil
@@ -332,7 +418,12 @@ func (cm *Manager) RenewV2Contract(renewal rhp4.TransactionSet, usage proto4.Usa
 
 // SectorRoots returns the roots of all sectors stored by the contract.
 func (cm *Manager) SectorRoots(id types.FileContractID) []types.Hash256 {
-	return cm.getSectorRoots(id)
+	roots, err := cm.getSectorRoots(id)
+	if err != nil {
+		cm.log.Error("failed to get sector roots", zap.Stringer("contractID", id), zap.Error(err))
+		return nil
+	}
+	return roots
 }
 
 // ReviseContract initializes a new contract updater for the given contract.
@@ -342,14 +433,18 @@ func (cm *Manager) ReviseContract(contractID types.FileContractID) (*ContractUpd
 		return nil, err
 	}
 
-	roots := cm.getSectorRoots(contractID)
+	roots, err := cm.getSectorRoots(contractID)
+	if err != nil {
+		done()
+		return nil, fmt.Errorf("failed to get sector roots: %w", err)
+	}
 	return &ContractUpdater{
 		manager: cm,
 		store:   cm.store,
 		log:     cm.log.Named("contractUpdater"),
 
 		contractID:  contractID,
-		sectorRoots: roots, // roots is already a deep copy
+		sectorRoots: roots,
 		oldRoots:    append([]types.Hash256(nil), roots...),
 
 		done: done, // decrements the threadgroup counter after the updater is closed
@@ -388,23 +483,31 @@ func NewManager(store ContractStore, storage StorageManager, chain ChainManager,
 		rejectBuffer:             18,
 		revisionSubmissionBuffer: 144,
 
+		integrityCheckInterval:    0, // each check reads every sector, opt-in
+		integrityCheckConcurrency: 2,
+		renterMetricsInterval:     15 * time.Minute,
+
 		alerts: alerts.NewNop(),
+		events: webhooks.NewNop(),
 		tg:     threadgroup.New(),
 		log:    zap.NewNop(),
 
 		locks: newLocker(),
+		usage: make(map[types.PublicKey]*cachedRenterUsage),
+
+		rootCacheSize: defaultRootCacheSize,
 	}
 
 	for _, opt := range opts {
 		opt(cm)
 	}
+	cm.roots = newRootCache(cm.rootCacheSize, rootsPageSize)
 
-	start := time.Now()
-	roots, err := store.SectorRoots()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get sector roots: %w", err)
+	if cm.integrityCheckInterval > 0 {
+		go cm.scheduleIntegrityChecks()
+	}
+	if cm.renterMetricsInterval > 0 {
+		go cm.watchRenterMetrics()
 	}
-	cm.sectorRoots = roots
-	cm.log.Debug("loaded sector roots", zap.Duration("elapsed", time.Since(start)))
 	return cm, nil
 }
@@ -1,6 +1,10 @@
 package contracts
 
-import "go.uber.org/zap"
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
 
 // A ManagerOption sets options on a Manager.
 type ManagerOption func(*Manager)
@@ -28,9 +32,69 @@ func WithAlerter(a Alerts) ManagerOption {
 	}
 }
 
+// WithEventReporter sets the event reporter used to broadcast contract
+// lifecycle events.
+func WithEventReporter(e EventReporter) ManagerOption {
+	return func(m *Manager) {
+		m.events = e
+	}
+}
+
+// WithRenterAccess sets the access control used to refuse contracts from
+// renters. By default, every renter is allowed.
+func WithRenterAccess(ra RenterAccess) ManagerOption {
+	return func(m *Manager) {
+		m.access = ra
+	}
+}
+
+// WithDefaultRenterQuota sets the quota applied to renters without an
+// override. By default, renters are not limited.
+func WithDefaultRenterQuota(q RenterQuota) ManagerOption {
+	return func(m *Manager) {
+		m.defaultQuota = q
+	}
+}
+
 // WithLog sets the logger for the Manager.
 func WithLog(l *zap.Logger) ManagerOption {
 	return func(m *Manager) {
 		m.log = l
 	}
 }
+
+// WithIntegrityCheckInterval sets the minimum time between scheduled
+// integrity checks of an active contract. Each check reads every sector of
+// the contract from disk. An interval of 0 disables scheduled integrity
+// checks, which is the default.
+func WithIntegrityCheckInterval(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.integrityCheckInterval = d
+	}
+}
+
+// WithIntegrityCheckConcurrency sets the maximum number of contracts that
+// are checked at the same time by the integrity check scheduler. Values
+// less than 1 are treated as 1.
+func WithIntegrityCheckConcurrency(n int) ManagerOption {
+	return func(m *Manager) {
+		m.integrityCheckConcurrency = max(n, 1)
+	}
+}
+
+// WithRenterMetricsInterval sets the interval between snapshots of each
+// renter's metrics. An interval of 0 disables the snapshots.
+func WithRenterMetricsInterval(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.renterMetricsInterval = d
+	}
+}
+
+// WithSectorRootCacheSize sets the maximum number of sector roots the
+// Manager keeps in memory. Roots that are not cached are loaded from the
+// store when they are needed.
+func WithSectorRootCacheSize(n uint64) ManagerOption {
+	return func(m *Manager) {
+		m.rootCacheSize = n
+	}
+}
@@ -1,9 +1,12 @@
 package contracts
 
 import (
+	"time"
+
 	proto4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
 	rhp4 "go.sia.tech/coreutils/rhp/v4"
+	"go.sia.tech/hostd/host/metrics"
 )
 
 type (
@@ -16,9 +19,14 @@ type (
 		// ContractChainIndexElement returns the chain index element for the given height.
 		ContractChainIndexElement(types.ChainIndex) (types.ChainIndexElement, error)
 
-		// SectorRoots returns the sector roots for a contract. If limit is 0, all roots
-		// are returned.
-		SectorRoots() (map[types.FileContractID][]types.Hash256, error)
+		// LostSectorContracts returns the unresolved v1 and v2 contracts
+		// that reference lost sectors.
+		LostSectorContracts() ([]LostSectorContract, error)
+
+		// ContractSectorRoots returns up to limit sector roots of a v1 or v2
+		// contract starting at offset. If the contract does not exist,
+		// ErrNotFound is returned.
+		ContractSectorRoots(id types.FileContractID, offset, limit uint64) ([]types.Hash256, error)
 
 		// Contracts returns a paginated list of contracts sorted by expiration
 		// asc.
@@ -41,6 +49,9 @@ type (
 
 		// V2ContractElement returns the latest v2 state element with the given ID.
 		V2ContractElement(types.FileContractID) (types.ChainIndex, types.V2FileContractElement, error)
+		// V2Contracts returns a paginated list of v2 contracts matching the
+		// filter and the total number of matching contracts.
+		V2Contracts(V2ContractFilter) ([]V2Contract, int, error)
 		// V2Contract returns the v2 contract with the given ID.
 		V2Contract(types.FileContractID) (V2Contract, error)
 		// AddV2Contract stores the provided contract, should error if the contract
@@ -56,6 +67,47 @@ type (
 		// rejected or past their proof window.
 		ExpireV2ContractSectors(height uint64) error
 
+		// RenterUsage returns the resources used by the renter's active
+		// contracts.
+		RenterUsage(types.PublicKey) (RenterUsage, error)
+		// RenterQuota returns the renter's quota override. The bool is false
+		// if the renter does not have an override.
+		RenterQuota(types.PublicKey) (RenterQuota, bool, error)
+		// SetRenterQuota sets the renter's quota override.
+		SetRenterQuota(types.PublicKey, RenterQuota) error
+		// RemoveRenterQuota removes the renter's quota override. If the
+		// renter does not have an override, ErrRenterQuotaNotFound is
+		// returned.
+		RemoveRenterQuota(types.PublicKey) error
+
+		// Renters returns a paginated list of metrics for every renter and
+		// the total number of renters.
+		Renters(RenterFilter) ([]RenterMetrics, int, error)
+		// RenterMetrics returns the current metrics for a renter.
+		RenterMetrics(types.PublicKey) (RenterMetrics, error)
+		// RenterPeriodMetrics returns a renter's recorded metrics for n
+		// periods starting at start.
+		RenterPeriodMetrics(pk types.PublicKey, start time.Time, n int, interval metrics.Interval) ([]RenterMetrics, error)
+		// RecordRenterMetrics records the current metrics of every renter
+		// whose metrics changed since they were last recorded.
+		RecordRenterMetrics(timestamp time.Time) error
+
+		// AddIntegrityCheck stores the result of a completed integrity check
+		// and returns its ID.
+		AddIntegrityCheck(IntegrityCheck) (int64, error)
+		// IntegrityChecks returns a paginated list of completed integrity
+		// checks sorted by end time descending.
+		IntegrityChecks(limit, offset int) ([]IntegrityCheck, error)
+		// ContractIntegrityChecks returns a paginated list of completed
+		// integrity checks for a contract sorted by end time descending.
+		ContractIntegrityChecks(id types.FileContractID, limit, offset int) ([]IntegrityCheck, error)
+		// LastIntegrityChecks returns the end time of the most recent
+		// integrity check for each contract.
+		LastIntegrityChecks() (map[types.FileContractID]time.Time, error)
+		// PruneIntegrityChecks removes integrity checks that completed before
+		// the given time.
+		PruneIntegrityChecks(before time.Time) error
+
 		// RHP4AccountBalance returns the balance of an account.
 		RHP4AccountBalance(proto4.Account) (types.Currency, error)
 		// RHP4CreditAccounts atomically revises a contract and credits the accounts
@@ -0,0 +1,278 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	proto4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+)
+
+var (
+	// ErrRenterQuotaExceeded is returned when a request would exceed one of
+	// the renter's quotas.
+	ErrRenterQuotaExceeded = errors.New("renter quota exceeded")
+	// ErrRenterQuotaNotFound is returned when a renter does not have a quota
+	// override.
+	ErrRenterQuotaNotFound = errors.New("renter quota not found")
+)
+
+type (
+	// A RenterQuota limits the resources a single renter can use. A zero
+	// value for any field means the resource is not limited.
+	RenterQuota struct {
+		// MaxSectors is the maximum number of sectors stored in the renter's
+		// active contracts.
+		MaxSectors uint64 `json:"maxSectors"`
+		// MaxCollateral is the maximum collateral locked in the renter's
+		// active contracts.
+		MaxCollateral types.Currency `json:"maxCollateral"`
+		// MaxContracts is the maximum number of active contracts the renter
+		// can have with the host.
+		MaxContracts uint64 `json:"maxContracts"`
+		// MaxAccountBalance is the maximum combined balance of the accounts
+		// funded by the renter's contracts.
+		MaxAccountBalance types.Currency `json:"maxAccountBalance"`
+	}
+
+	// RenterUsage is the amount of each limited resource used by a renter.
+	// Contracts that have been renewed, rejected or resolved are not
+	// counted.
+	RenterUsage struct {
+		Sectors          uint64         `json:"sectors"`
+		LockedCollateral types.Currency `json:"lockedCollateral"`
+		Contracts        uint64         `json:"contracts"`
+		AccountBalance   types.Currency `json:"accountBalance"`
+	}
+
+	// A Renter is a renter's usage compared to its effective quota.
+	Renter struct {
+		PublicKey types.PublicKey `json:"publicKey"`
+		Usage     RenterUsage     `json:"usage"`
+		// Quota is the quota applied to the renter. It is either the
+		// renter's override or the host's default quota.
+		Quota RenterQuota `json:"quota"`
+		// Override is the renter's quota override, if one is set.
+		Override *RenterQuota `json:"override,omitempty"`
+	}
+
+	// cachedRenterUsage is the usage of a renter with a quota. It is loaded
+	// from the store once per usage epoch and updated as the renter's
+	// changes are committed to avoid counting the renter's sectors on every
+	// revision.
+	cachedRenterUsage struct {
+		mu     sync.Mutex // serializes changes by the renter
+		loaded bool
+		epoch  uint64
+		usage  RenterUsage
+	}
+
+	// A quotaReservation holds a renter's usage lock while a change is
+	// committed.
+	quotaReservation struct {
+		ru    *cachedRenterUsage // nil if the change is not limited
+		delta RenterUsage
+	}
+)
+
+// Unlimited returns true if the quota does not limit any resource.
+func (rq RenterQuota) Unlimited() bool {
+	return rq == (RenterQuota{})
+}
+
+// check returns ErrRenterQuotaExceeded if adding delta to usage would exceed
+// the quota. Only resources that are increased by delta are checked so that
+// renters above a lowered quota can still use their existing contracts.
+func (rq RenterQuota) check(usage, delta RenterUsage) error {
+	switch {
+	case rq.MaxContracts > 0 && delta.Contracts > 0 && usage.Contracts+delta.Contracts > rq.MaxContracts:
+		return fmt.Errorf("%w: renter would have %d active contracts, max %d", ErrRenterQuotaExceeded, usage.Contracts+delta.Contracts, rq.MaxContracts)
+	case !rq.MaxCollateral.IsZero() && !delta.LockedCollateral.IsZero() && usage.LockedCollateral.Add(delta.LockedCollateral).Cmp(rq.MaxCollateral) > 0:
+		return fmt.Errorf("%w: renter would have %v locked collateral, max %v", ErrRenterQuotaExceeded, usage.LockedCollateral.Add(delta.LockedCollateral), rq.MaxCollateral)
+	case rq.MaxSectors > 0 && delta.Sectors > 0 && usage.Sectors+delta.Sectors > rq.MaxSectors:
+		return fmt.Errorf("%w: renter would store %d sectors, max %d", ErrRenterQuotaExceeded, usage.Sectors+delta.Sectors, rq.MaxSectors)
+	case !rq.MaxAccountBalance.IsZero() && !delta.AccountBalance.IsZero() && usage.AccountBalance.Add(delta.AccountBalance).Cmp(rq.MaxAccountBalance) > 0:
+		return fmt.Errorf("%w: renter accounts would have a balance of %v, max %v", ErrRenterQuotaExceeded, usage.AccountBalance.Add(delta.AccountBalance), rq.MaxAccountBalance)
+	}
+	return nil
+}
+
+// renterQuota returns the quota applied to the renter and the renter's
+// override, if one is set.
+func (cm *Manager) renterQuota(pk types.PublicKey) (RenterQuota, *RenterQuota, error) {
+	override, ok, err := cm.store.RenterQuota(pk)
+	if err != nil {
+		return RenterQuota{}, nil, fmt.Errorf("failed to get renter quota: %w", err)
+	} else if !ok {
+		return cm.defaultQuota, nil, nil
+	}
+	return override, &override, nil
+}
+
+// limits returns true if delta increases a resource limited by the quota.
+func (rq RenterQuota) limits(delta RenterUsage) bool {
+	return (rq.MaxContracts > 0 && delta.Contracts > 0) ||
+		(!rq.MaxCollateral.IsZero() && !delta.LockedCollateral.IsZero()) ||
+		(rq.MaxSectors > 0 && delta.Sectors > 0) ||
+		(!rq.MaxAccountBalance.IsZero() && !delta.AccountBalance.IsZero())
+}
+
+// add returns the usage increased by delta.
+func (ru RenterUsage) add(delta RenterUsage) RenterUsage {
+	return RenterUsage{
+		Sectors:          ru.Sectors + delta.Sectors,
+		LockedCollateral: ru.LockedCollateral.Add(delta.LockedCollateral),
+		Contracts:        ru.Contracts + delta.Contracts,
+		AccountBalance:   ru.AccountBalance.Add(delta.AccountBalance),
+	}
+}
+
+// renterUsage returns the cached usage of the renter, adding it to the cache
+// if necessary, and the current usage epoch.
+func (cm *Manager) renterUsage(pk types.PublicKey) (*cachedRenterUsage, uint64) {
+	cm.quotaMu.Lock()
+	defer cm.quotaMu.Unlock()
+	ru, ok := cm.usage[pk]
+	if !ok {
+		ru = new(cachedRenterUsage)
+		cm.usage[pk] = ru
+	}
+	return ru, cm.usageEpoch
+}
+
+// invalidateRenterUsage forces the usage of every renter to be reloaded from
+// the store. It is called after chain updates since contracts may have been
+// confirmed, renewed or resolved.
+func (cm *Manager) invalidateRenterUsage() {
+	cm.quotaMu.Lock()
+	defer cm.quotaMu.Unlock()
+	cm.usageEpoch++
+}
+
+// releaseRenterSectors removes sectors from the renter's cached usage after
+// they have been removed from one of the renter's contracts.
+func (cm *Manager) releaseRenterSectors(pk types.PublicKey, sectors uint64) {
+	cm.quotaMu.Lock()
+	ru, ok := cm.usage[pk]
+	cm.quotaMu.Unlock()
+	if !ok {
+		return
+	}
+
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	if sectors > ru.usage.Sectors {
+		ru.loaded = false
+		return
+	}
+	ru.usage.Sectors -= sectors
+}
+
+// commit adds the reserved delta to the renter's cached usage. It must be
+// called after the change has been committed to the store.
+func (qr *quotaReservation) commit() {
+	if qr.ru == nil {
+		return
+	}
+	qr.ru.usage = qr.ru.usage.add(qr.delta)
+}
+
+// release releases the renter's usage lock.
+func (qr *quotaReservation) release() {
+	if qr.ru == nil {
+		return
+	}
+	qr.ru.mu.Unlock()
+}
+
+// reserveRenterQuota checks that adding delta to the renter's usage does not
+// exceed the renter's quota. If the check passes, the renter's usage is
+// locked until the reservation is released so that concurrent requests from
+// limited renters observe each other's usage. commit must be called once the
+// change has been committed to the store.
+func (cm *Manager) reserveRenterQuota(pk types.PublicKey, delta RenterUsage) (*quotaReservation, error) {
+	if delta == (RenterUsage{}) {
+		return &quotaReservation{}, nil
+	}
+
+	quota, _, err := cm.renterQuota(pk)
+	if err != nil {
+		return nil, err
+	} else if !quota.limits(delta) {
+		return &quotaReservation{}, nil
+	}
+
+	ru, epoch := cm.renterUsage(pk)
+	ru.mu.Lock()
+	// account balances are debited outside of the manager, so they are
+	// always reloaded when they are checked
+	if !ru.loaded || ru.epoch != epoch || !delta.AccountBalance.IsZero() {
+		usage, err := cm.store.RenterUsage(pk)
+		if err != nil {
+			ru.mu.Unlock()
+			return nil, fmt.Errorf("failed to get renter usage: %w", err)
+		}
+		ru.usage, ru.epoch, ru.loaded = usage, epoch, true
+	}
+
+	if err := quota.check(ru.usage, delta); err != nil {
+		ru.mu.Unlock()
+		return nil, refusalError{err}
+	}
+	return &quotaReservation{ru: ru, delta: delta}, nil
+}
+
+// CheckRenterQuota returns ErrRenterQuotaExceeded if adding delta to the
+// renter's current usage would exceed the renter's quota.
+func (cm *Manager) CheckRenterQuota(pk types.PublicKey, delta RenterUsage) error {
+	res, err := cm.reserveRenterQuota(pk, delta)
+	if err != nil {
+		return err
+	}
+	res.release()
+	return nil
+}
+
+// Renter returns the renter's current usage and quota.
+func (cm *Manager) Renter(pk types.PublicKey) (Renter, error) {
+	quota, override, err := cm.renterQuota(pk)
+	if err != nil {
+		return Renter{}, err
+	}
+	usage, err := cm.store.RenterUsage(pk)
+	if err != nil {
+		return Renter{}, fmt.Errorf("failed to get renter usage: %w", err)
+	}
+	return Renter{
+		PublicKey: pk,
+		Usage:     usage,
+		Quota:     quota,
+		Override:  override,
+	}, nil
+}
+
+// DefaultRenterQuota returns the quota applied to renters without an
+// override.
+func (cm *Manager) DefaultRenterQuota() RenterQuota {
+	return cm.defaultQuota
+}
+
+// SetRenterQuota overrides the default quota for a renter.
+func (cm *Manager) SetRenterQuota(pk types.PublicKey, quota RenterQuota) error {
+	return cm.store.SetRenterQuota(pk, quota)
+}
+
+// RemoveRenterQuota removes a renter's quota override. The default quota is
+// applied to the renter afterwards.
+func (cm *Manager) RemoveRenterQuota(pk types.PublicKey) error {
+	return cm.store.RemoveRenterQuota(pk)
+}
+
+// depositTotal returns the sum of the deposits.
+func depositTotal(deposits []proto4.AccountDeposit) (total types.Currency) {
+	for _, deposit := range deposits {
+		total = total.Add(deposit.Amount)
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package contracts_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/testutil"
+	"go.uber.org/zap/zaptest"
+	"lukechampine.com/frand"
+)
+
+func TestRenterQuotas(t *testing.T) {
+	log := zaptest.NewLogger(t)
+
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	network, genesis := testutil.V1Network()
+	node := testutil.NewHostNode(t, hostKey, network, genesis, log)
+
+	// create a fake volume so disk space is not used
+	id, err := node.Store.AddVolume("test", false)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := node.Store.GrowVolume(id, 10); err != nil {
+		t.Fatal(err)
+	} else if err := node.Store.SetAvailable(id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	newRevision := func() contracts.SignedRevision {
+		uc := types.UnlockConditions{
+			PublicKeys: []types.UnlockKey{
+				renterKey.PublicKey().UnlockKey(),
+				hostKey.PublicKey().UnlockKey(),
+			},
+			SignaturesRequired: 2,
+		}
+		return contracts.SignedRevision{
+			Revision: types.FileContractRevision{
+				FileContract: types.FileContract{
+					UnlockHash:  uc.UnlockHash(),
+					WindowStart: 100,
+					WindowEnd:   200,
+				},
+				ParentID:         frand.Entropy256(),
+				UnlockConditions: uc,
+			},
+		}
+	}
+
+	appendSector := func(rev contracts.SignedRevision) error {
+		root := frand.Entropy256()
+		if err := node.Store.StoreSector(root, func(loc storage.SectorLocation) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+
+		updater, err := node.Contracts.ReviseContract(rev.Revision.ParentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer updater.Close()
+
+		updater.AppendSector(root)
+		return updater.Commit(rev, contracts.Usage{})
+	}
+
+	// renters are not limited by default
+	rev := newRevision()
+	if err := node.Contracts.AddContract(rev, nil, types.Siacoins(10), contracts.Usage{}); err != nil {
+		t.Fatal(err)
+	} else if err := appendSector(rev); err != nil {
+		t.Fatal(err)
+	}
+
+	quota := contracts.RenterQuota{
+		MaxSectors:    2,
+		MaxCollateral: types.Siacoins(15),
+		MaxContracts:  2,
+	}
+	if err := node.Contracts.SetRenterQuota(renterKey.PublicKey(), quota); err != nil {
+		t.Fatal(err)
+	}
+
+	renter, err := node.Contracts.Renter(renterKey.PublicKey())
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case renter.Override == nil || *renter.Override != quota || renter.Quota != quota:
+		t.Fatalf("expected quota %+v, got %+v", quota, renter)
+	case renter.Usage.Contracts != 1:
+		t.Fatalf("expected 1 contract, got %d", renter.Usage.Contracts)
+	case renter.Usage.Sectors != 1:
+		t.Fatalf("expected 1 sector, got %d", renter.Usage.Sectors)
+	case !renter.Usage.LockedCollateral.Equals(types.Siacoins(10)):
+		t.Fatalf("expected 10 SC locked collateral, got %v", renter.Usage.LockedCollateral)
+	}
+
+	// the collateral quota is checked before the contract quota is reached
+	if err := node.Contracts.AddContract(newRevision(), nil, types.Siacoins(10), contracts.Usage{}); !errors.Is(err, contracts.ErrRenterQuotaExceeded) {
+		t.Fatalf("expected ErrRenterQuotaExceeded, got %v", err)
+	}
+
+	rev2 := newRevision()
+	if err := node.Contracts.AddContract(rev2, nil, types.Siacoins(5), contracts.Usage{}); err != nil {
+		t.Fatal(err)
+	} else if err := node.Contracts.AddContract(newRevision(), nil, types.ZeroCurrency, contracts.Usage{}); !errors.Is(err, contracts.ErrRenterQuotaExceeded) {
+		t.Fatalf("expected ErrRenterQuotaExceeded, got %v", err)
+	}
+
+	// the sector quota applies across the renter's contracts
+	if err := appendSector(rev2); err != nil {
+		t.Fatal(err)
+	} else if err := appendSector(rev); !errors.Is(err, contracts.ErrRenterQuotaExceeded) {
+		t.Fatalf("expected ErrRenterQuotaExceeded, got %v", err)
+	}
+
+	// removing a sector frees space for another
+	updater, err := node.Contracts.ReviseContract(rev2.Revision.ParentID)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := updater.TrimSectors(1); err != nil {
+		t.Fatal(err)
+	} else if err := updater.Commit(rev2, contracts.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+	updater.Close()
+	if err := appendSector(rev); err != nil {
+		t.Fatal(err)
+	} else if err := appendSector(rev2); !errors.Is(err, contracts.ErrRenterQuotaExceeded) {
+		t.Fatalf("expected ErrRenterQuotaExceeded, got %v", err)
+	}
+
+	// other renters use the default quota
+	other, err := node.Contracts.Renter(types.GeneratePrivateKey().PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	} else if other.Override != nil || !other.Quota.Unlimited() || other.Usage != (contracts.RenterUsage{}) {
+		t.Fatalf("unexpected renter: %+v", other)
+	}
+
+	if err := node.Contracts.RemoveRenterQuota(renterKey.PublicKey()); err != nil {
+		t.Fatal(err)
+	} else if err := node.Contracts.RemoveRenterQuota(renterKey.PublicKey()); !errors.Is(err, contracts.ErrRenterQuotaNotFound) {
+		t.Fatalf("expected ErrRenterQuotaNotFound, got %v", err)
+	} else if err := appendSector(rev); err != nil {
+		t.Fatal(err)
+	}
+}
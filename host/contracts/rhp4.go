@@ -0,0 +1,149 @@
+package contracts
+
+import (
+	"fmt"
+
+	proto4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+	rhp4 "go.sia.tech/coreutils/rhp/v4"
+)
+
+type (
+	// A refusalError is returned when a renter's request is refused because
+	// of the renter access list or the renter's quota. It unwraps to an RPC
+	// error so RHP4 renters receive the reason instead of an internal error.
+	refusalError struct {
+		err error
+	}
+
+	// The RHP4 server only passes contracts to the manager after the host's
+	// inputs have been signed, sent to the renter and added to the
+	// transaction pool. rhp4Wallet and rhp4Chain check formations and
+	// renewals against the renter access list and quotas at the earliest
+	// point the server exposes the contract: formations before the host
+	// funds them and renewals before they are added to the pool.
+	rhp4Wallet struct {
+		rhp4.Wallet
+		cm *Manager
+	}
+
+	rhp4Chain struct {
+		rhp4.ChainManager
+		cm *Manager
+	}
+)
+
+// Error implements error.
+func (re refusalError) Error() string {
+	return re.err.Error()
+}
+
+// Unwrap returns the refusal reason and the RPC error sent to the renter.
+func (re refusalError) Unwrap() []error {
+	return []error{re.err, proto4.NewRPCError(proto4.ErrorCodeBadRequest, re.err.Error())}
+}
+
+// FundV2Transaction implements rhp4.Wallet. Transactions forming a contract
+// are checked before they are funded.
+func (w rhp4Wallet) FundV2Transaction(txn *types.V2Transaction, amount types.Currency, useUnconfirmed bool) (types.ChainIndex, []int, error) {
+	if len(txn.FileContracts) == 1 {
+		if err := w.cm.checkV2Formation(txn.FileContracts[0]); err != nil {
+			return types.ChainIndex{}, nil, err
+		}
+	}
+	return w.Wallet.FundV2Transaction(txn, amount, useUnconfirmed)
+}
+
+// AddV2PoolTransactions implements rhp4.ChainManager. Transaction sets
+// renewing a contract are checked before they are added to the pool.
+func (c rhp4Chain) AddV2PoolTransactions(basis types.ChainIndex, txns []types.V2Transaction) (bool, error) {
+	if len(txns) > 0 {
+		txn := txns[len(txns)-1]
+		if len(txn.FileContractResolutions) == 1 {
+			if renewal, ok := txn.FileContractResolutions[0].Resolution.(*types.V2FileContractRenewal); ok {
+				existingID := types.FileContractID(txn.FileContractResolutions[0].Parent.ID)
+				if err := c.cm.checkV2Renewal(existingID, renewal.NewContract); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+	return c.ChainManager.AddV2PoolTransactions(basis, txns)
+}
+
+// RHP4Wallet wraps the wallet used by the RHP4 server so contract formations
+// are refused before the host funds them if the renter is not allowed to
+// form contracts or would exceed its quota.
+func (cm *Manager) RHP4Wallet(w rhp4.Wallet) rhp4.Wallet {
+	return rhp4Wallet{Wallet: w, cm: cm}
+}
+
+// RHP4Chain wraps the chain manager used by the RHP4 server so contract
+// renewals are refused before they are added to the transaction pool if the
+// renter is not allowed to renew contracts or would exceed its quota.
+func (cm *Manager) RHP4Chain(c rhp4.ChainManager) rhp4.ChainManager {
+	return rhp4Chain{ChainManager: c, cm: cm}
+}
+
+// checkRenterAccess returns an error if the renter is not allowed to form or
+// renew contracts with the host.
+func (cm *Manager) checkRenterAccess(pk types.PublicKey) error {
+	if cm.access == nil {
+		return nil
+	} else if err := cm.access.CheckRenterAccess(pk); err != nil {
+		return refusalError{err}
+	}
+	return nil
+}
+
+// checkV2Formation returns an error if the renter is not allowed to form the
+// contract or forming it would exceed the renter's quota.
+func (cm *Manager) checkV2Formation(fc types.V2FileContract) error {
+	if err := cm.checkRenterAccess(fc.RenterPublicKey); err != nil {
+		return err
+	}
+	return cm.CheckRenterQuota(fc.RenterPublicKey, RenterUsage{Contracts: 1, LockedCollateral: fc.TotalCollateral})
+}
+
+// checkV2Renewal returns an error if the renter is not allowed to renew the
+// existing contract or renewing it would exceed the renter's quota.
+func (cm *Manager) checkV2Renewal(existingID types.FileContractID, fc types.V2FileContract) error {
+	if err := cm.checkRenterAccess(fc.RenterPublicKey); err != nil {
+		return err
+	}
+	existing, err := cm.store.V2Contract(existingID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing contract: %w", err)
+	}
+	return cm.CheckRenterQuota(fc.RenterPublicKey, v2RenewalUsage(existing, fc))
+}
+
+// renewalUsage returns the usage added by renewing a contract with the locked
+// collateral. The existing contract's collateral is released by the renewal,
+// so only the additional collateral counts against the renter's quota.
+func renewalUsage(existing Contract, lockedCollateral types.Currency) RenterUsage {
+	additional, underflow := lockedCollateral.SubWithUnderflow(existing.LockedCollateral)
+	if underflow {
+		additional = types.ZeroCurrency
+	}
+	return RenterUsage{LockedCollateral: additional}
+}
+
+// v2RenewalUsage returns the usage added by renewing a v2 contract.
+func v2RenewalUsage(existing V2Contract, renewal types.V2FileContract) RenterUsage {
+	additional, underflow := renewal.TotalCollateral.SubWithUnderflow(existing.TotalCollateral)
+	if underflow {
+		additional = types.ZeroCurrency
+	}
+	return RenterUsage{LockedCollateral: additional}
+}
+
+// CheckRenewalQuota returns ErrRenterQuotaExceeded if renewing the existing
+// contract with the locked collateral would exceed the renter's quota.
+func (cm *Manager) CheckRenewalQuota(pk types.PublicKey, existingID types.FileContractID, lockedCollateral types.Currency) error {
+	existing, err := cm.store.Contract(existingID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing contract: %w", err)
+	}
+	return cm.CheckRenterQuota(pk, renewalUsage(existing, lockedCollateral))
+}
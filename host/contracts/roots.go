@@ -0,0 +1,477 @@
+package contracts
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	rhp2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+)
+
+const (
+	// defaultRootCacheSize is the default maximum number of sector roots
+	// kept in memory. Each root is 32 bytes, so the default uses about
+	// 128 MiB and covers 16 TiB of contract data.
+	defaultRootCacheSize = 1 << 22
+
+	// rootsPageSize is the number of sector roots in a cached page. It must
+	// be a power of two so each full page is a complete subtree of the
+	// contract's Merkle tree.
+	rootsPageSize = 1 << 16
+)
+
+type (
+	// A rootCacheEntry is either a page of a contract's sector roots or the
+	// contract's metadata.
+	rootCacheEntry struct {
+		id   types.FileContractID
+		page uint64
+		meta *rootMeta
+		// roots is the page's sector roots. It is nil for metadata entries.
+		roots []types.Hash256
+		// size is the number of roots counted against the cache size
+		size uint64
+	}
+
+	// rootMeta is the number of sector roots in a contract and the Merkle
+	// roots of the contract's pages that are known.
+	rootMeta struct {
+		count     uint64
+		pageRoots map[uint64]types.Hash256
+	}
+
+	// contractRootEntries tracks the cache entries of a single contract.
+	contractRootEntries struct {
+		meta  *list.Element
+		pages map[uint64]*list.Element
+	}
+
+	// rootLoad tracks in-progress loads of a contract's sector roots. Loads
+	// that were started before the contract's roots were replaced are
+	// marked stale and not added to the cache.
+	rootLoad struct {
+		refs  int
+		stale bool
+	}
+
+	// rootLoadFn loads up to limit of a contract's sector roots starting at
+	// offset.
+	rootLoadFn func(id types.FileContractID, offset, limit uint64) ([]types.Hash256, error)
+
+	// A rootCache is a bounded LRU cache of contract sector roots. Roots are
+	// cached in fixed-size pages so large contracts are partially cached and
+	// storage proofs only need the page containing the proven sector. The
+	// size of the cache is the total number of roots rather than the number
+	// of contracts. Cached slices are shared between callers and must not be
+	// modified.
+	rootCache struct {
+		maxRoots uint64
+		pageSize uint64
+
+		mu        sync.Mutex
+		size      uint64
+		lru       *list.List // front is the most recently used
+		contracts map[types.FileContractID]*contractRootEntries
+		loading   map[types.FileContractID]*rootLoad
+	}
+)
+
+// pages returns the number of pages needed to store n roots.
+func (rc *rootCache) pages(n uint64) uint64 {
+	return (n + rc.pageSize - 1) / rc.pageSize
+}
+
+// fits returns true if all of a contract's roots fit in the cache.
+func (rc *rootCache) fits(count uint64) bool {
+	return count+2*rc.pages(count)+1 <= rc.maxRoots
+}
+
+// insert adds an entry to the cache, evicting the least recently used
+// entries until the cache is within its size limit. Each entry costs at least
+// one root so that empty pages and metadata are also bounded. Metadata costs
+// one root for each of the contract's pages so its page roots are bounded.
+// The caller must hold the lock.
+func (rc *rootCache) insert(e rootCacheEntry) {
+	if e.meta != nil {
+		rc.removeMeta(e.id)
+		e.size = rc.pages(e.meta.count) + 1
+	} else {
+		rc.removePage(e.id, e.page)
+		e.size = uint64(len(e.roots)) + 1
+	}
+	if e.size > rc.maxRoots {
+		return
+	}
+
+	ce, ok := rc.contracts[e.id]
+	if !ok {
+		ce = &contractRootEntries{pages: make(map[uint64]*list.Element)}
+		rc.contracts[e.id] = ce
+	}
+	el := rc.lru.PushFront(e)
+	if e.meta != nil {
+		ce.meta = el
+	} else {
+		ce.pages[e.page] = el
+	}
+	rc.size += e.size
+	for rc.size > rc.maxRoots {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// remove removes an element from the cache. The caller must hold the lock.
+func (rc *rootCache) remove(el *list.Element) {
+	e := rc.lru.Remove(el).(rootCacheEntry)
+	rc.size -= e.size
+	ce := rc.contracts[e.id]
+	if e.meta != nil {
+		ce.meta = nil
+	} else {
+		delete(ce.pages, e.page)
+	}
+	if ce.meta == nil && len(ce.pages) == 0 {
+		delete(rc.contracts, e.id)
+	}
+}
+
+// removeMeta removes a contract's metadata from the cache. The caller must
+// hold the lock.
+func (rc *rootCache) removeMeta(id types.FileContractID) {
+	if ce, ok := rc.contracts[id]; ok && ce.meta != nil {
+		rc.remove(ce.meta)
+	}
+}
+
+// removePage removes a page of a contract's roots from the cache. The caller
+// must hold the lock.
+func (rc *rootCache) removePage(id types.FileContractID, page uint64) {
+	if ce, ok := rc.contracts[id]; ok {
+		if el, ok := ce.pages[page]; ok {
+			rc.remove(el)
+		}
+	}
+}
+
+// cachedMeta returns a contract's cached metadata. The caller must hold the
+// lock.
+func (rc *rootCache) cachedMeta(id types.FileContractID) (*rootMeta, bool) {
+	ce, ok := rc.contracts[id]
+	if !ok || ce.meta == nil {
+		return nil, false
+	}
+	rc.lru.MoveToFront(ce.meta)
+	return ce.meta.Value.(rootCacheEntry).meta, true
+}
+
+// cachedPage returns a cached page of a contract's roots. The caller must
+// hold the lock.
+func (rc *rootCache) cachedPage(id types.FileContractID, page uint64) ([]types.Hash256, bool) {
+	ce, ok := rc.contracts[id]
+	if !ok {
+		return nil, false
+	}
+	el, ok := ce.pages[page]
+	if !ok {
+		return nil, false
+	}
+	rc.lru.MoveToFront(el)
+	return el.Value.(rootCacheEntry).roots, true
+}
+
+// beginLoad registers a load of a contract's roots.
+func (rc *rootCache) beginLoad(id types.FileContractID) *rootLoad {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	l, ok := rc.loading[id]
+	if !ok {
+		l = new(rootLoad)
+		rc.loading[id] = l
+	}
+	l.refs++
+	return l
+}
+
+// endLoad unregisters a load and returns true if the contract's roots were
+// replaced during the load.
+func (rc *rootCache) endLoad(id types.FileContractID, l *rootLoad) (stale bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	l.refs--
+	if l.refs == 0 && rc.loading[id] == l {
+		delete(rc.loading, id)
+	}
+	return l.stale
+}
+
+// page returns a page of a contract's roots, loading it if it is not cached.
+// Loaded pages are only added to the cache if cache is true.
+func (rc *rootCache) page(id types.FileContractID, page uint64, cache bool, l *rootLoad, load rootLoadFn) ([]types.Hash256, error) {
+	rc.mu.Lock()
+	roots, ok := rc.cachedPage(id, page)
+	rc.mu.Unlock()
+	if ok {
+		return roots, nil
+	}
+
+	roots, err := load(id, page*rc.pageSize, rc.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	roots = slices.Clip(roots)
+
+	if cache {
+		rc.mu.Lock()
+		if !l.stale {
+			rc.insert(rootCacheEntry{id: id, page: page, roots: roots})
+		}
+		rc.mu.Unlock()
+	}
+	return roots, nil
+}
+
+// meta returns a contract's metadata. If the metadata is not cached, the
+// number of roots is found by reading the contract's pages and the pages
+// that were read are also returned. The pages are only cached if the whole
+// contract fits in the cache.
+func (rc *rootCache) meta(id types.FileContractID, l *rootLoad, load rootLoadFn) (*rootMeta, [][]types.Hash256, error) {
+	rc.mu.Lock()
+	meta, ok := rc.cachedMeta(id)
+	rc.mu.Unlock()
+	if ok {
+		return meta, nil, nil
+	}
+
+	meta = &rootMeta{pageRoots: make(map[uint64]types.Hash256)}
+	var pages [][]types.Hash256
+	for p := uint64(0); ; p++ {
+		roots, err := rc.page(id, p, false, l, load)
+		if err != nil {
+			return nil, nil, err
+		}
+		pages = append(pages, roots)
+		meta.count += uint64(len(roots))
+		if uint64(len(roots)) < rc.pageSize {
+			break
+		}
+	}
+
+	rc.mu.Lock()
+	if !l.stale {
+		if rc.fits(meta.count) {
+			for p, roots := range pages {
+				if _, ok := rc.cachedPage(id, uint64(p)); !ok {
+					rc.insert(rootCacheEntry{id: id, page: uint64(p), roots: roots})
+				}
+			}
+		}
+		rc.insert(rootCacheEntry{id: id, meta: meta})
+	}
+	rc.mu.Unlock()
+	return meta, pages, nil
+}
+
+// pageRoot returns the Merkle root of a page of a contract's roots.
+func (rc *rootCache) pageRoot(id types.FileContractID, meta *rootMeta, page uint64, l *rootLoad, load rootLoadFn) (types.Hash256, error) {
+	rc.mu.Lock()
+	root, ok := meta.pageRoots[page]
+	rc.mu.Unlock()
+	if ok {
+		return root, nil
+	}
+
+	roots, err := rc.page(id, page, rc.fits(meta.count), l, load)
+	if err != nil {
+		return types.Hash256{}, err
+	}
+	root = rhp2.MetaRoot(roots)
+
+	rc.mu.Lock()
+	if !l.stale {
+		meta.pageRoots[page] = root
+	}
+	rc.mu.Unlock()
+	return root, nil
+}
+
+// Get returns the sector roots of a contract, calling load for any pages
+// that are not in the cache. The returned slice is owned by the caller.
+// Only the metadata of contracts with more roots than the cache can hold is
+// cached; their roots are read from the store on every call so that they do
+// not evict every other contract.
+func (rc *rootCache) Get(id types.FileContractID, load rootLoadFn) ([]types.Hash256, error) {
+	for {
+		l := rc.beginLoad(id)
+		roots, err := func() ([]types.Hash256, error) {
+			meta, pages, err := rc.meta(id, l, load)
+			if err != nil {
+				return nil, err
+			}
+			roots := make([]types.Hash256, 0, meta.count)
+			for p := uint64(0); p < rc.pages(meta.count); p++ {
+				var page []types.Hash256
+				if p < uint64(len(pages)) {
+					page = pages[p]
+				} else if page, err = rc.page(id, p, rc.fits(meta.count), l, load); err != nil {
+					return nil, err
+				}
+				roots = append(roots, page...)
+			}
+			return roots, nil
+		}()
+		if stale := rc.endLoad(id, l); err != nil {
+			return nil, err
+		} else if stale {
+			// the roots were replaced during the load and may be
+			// inconsistent. Retry to get the new roots.
+			continue
+		}
+		return roots, nil
+	}
+}
+
+// Proof returns a sector root of a contract, a Merkle proof of the sector
+// root in leaf-to-root order, the contract's Merkle root and the number of
+// roots in the contract. Only the page containing the sector and the Merkle
+// roots of the other pages are needed to build the proof.
+func (rc *rootCache) Proof(id types.FileContractID, index uint64, load rootLoadFn) (sectorRoot types.Hash256, proof []types.Hash256, contractRoot types.Hash256, count uint64, err error) {
+	for {
+		l := rc.beginLoad(id)
+		err = func() error {
+			meta, _, err := rc.meta(id, l, load)
+			if err != nil {
+				return err
+			}
+			count = meta.count
+			if index >= count {
+				return nil
+			}
+
+			pageRoots := make([]types.Hash256, rc.pages(count))
+			for p := range pageRoots {
+				pageRoots[p], err = rc.pageRoot(id, meta, uint64(p), l, load)
+				if err != nil {
+					return err
+				}
+			}
+			contractRoot = rhp2.MetaRoot(pageRoots)
+
+			// full pages are complete subtrees of the contract's tree, so
+			// the proof is the proof within the page followed by the proof
+			// of the page's root.
+			page, pageIndex := index/rc.pageSize, index%rc.pageSize
+			roots, err := rc.page(id, page, true, l, load)
+			if err != nil {
+				return err
+			} else if pageIndex >= uint64(len(roots)) {
+				return fmt.Errorf("page %d has %d roots, expected at least %d", page, len(roots), pageIndex+1)
+			}
+			sectorRoot = roots[pageIndex]
+			proof = append(rhp2.ConvertProofOrdering(rhp2.BuildSectorRangeProof(roots, pageIndex, pageIndex+1), pageIndex),
+				rhp2.ConvertProofOrdering(rhp2.BuildSectorRangeProof(pageRoots, page, page+1), page)...)
+			return nil
+		}()
+		if stale := rc.endLoad(id, l); err != nil {
+			return types.Hash256{}, nil, types.Hash256{}, 0, err
+		} else if stale {
+			continue
+		}
+		return sectorRoot, proof, contractRoot, count, nil
+	}
+}
+
+// Set replaces the cached roots of a contract. The cache takes ownership of
+// roots. Pages that were already cached are replaced. Other pages are only
+// added if the whole contract fits in the cache so that updating a large
+// contract does not evict every other contract.
+func (rc *rootCache) Set(id types.FileContractID, roots []types.Hash256) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if l, ok := rc.loading[id]; ok {
+		l.stale = true
+		delete(rc.loading, id)
+	}
+
+	var oldPageRoots map[uint64]types.Hash256
+	if meta, ok := rc.cachedMeta(id); ok {
+		oldPageRoots = meta.pageRoots
+	}
+	oldPages := make(map[uint64][]types.Hash256)
+	if ce, ok := rc.contracts[id]; ok {
+		for p, el := range ce.pages {
+			oldPages[p] = el.Value.(rootCacheEntry).roots
+		}
+	}
+
+	count := uint64(len(roots))
+	fits := rc.fits(count)
+	meta := &rootMeta{count: count, pageRoots: make(map[uint64]types.Hash256)}
+	for p := uint64(0); p < rc.pages(count); p++ {
+		page := slices.Clip(roots[p*rc.pageSize : min((p+1)*rc.pageSize, count)])
+		old, cached := oldPages[p]
+		if root, ok := oldPageRoots[p]; ok && cached && slices.Equal(old, page) {
+			meta.pageRoots[p] = root
+		}
+		if cached || fits {
+			rc.insert(rootCacheEntry{id: id, page: p, roots: page})
+		}
+	}
+	for p := range oldPages {
+		if p >= rc.pages(count) {
+			rc.removePage(id, p)
+		}
+	}
+	rc.insert(rootCacheEntry{id: id, meta: meta})
+}
+
+// Len returns the number of contracts with cached roots and the total size
+// of the cache.
+func (rc *rootCache) Len() (contracts int, size uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.contracts), rc.size
+}
+
+func newRootCache(maxRoots, pageSize uint64) *rootCache {
+	return &rootCache{
+		maxRoots:  maxRoots,
+		pageSize:  pageSize,
+		lru:       list.New(),
+		contracts: make(map[types.FileContractID]*contractRootEntries),
+		loading:   make(map[types.FileContractID]*rootLoad),
+	}
+}
+
+// loadSectorRoots loads a page of a contract's sector roots from the store.
+func (cm *Manager) loadSectorRoots(id types.FileContractID, offset, limit uint64) ([]types.Hash256, error) {
+	roots, err := cm.store.ContractSectorRoots(id, offset, limit)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load sector roots: %w", err)
+	}
+	return roots, nil
+}
+
+// getSectorRoots returns the sector roots of a contract. The returned slice
+// is owned by the caller.
+func (cm *Manager) getSectorRoots(id types.FileContractID) ([]types.Hash256, error) {
+	return cm.roots.Get(id, cm.loadSectorRoots)
+}
+
+// sectorRootProof returns a contract's sector root at index, its Merkle proof
+// in leaf-to-root order, the contract's Merkle root and its number of
+// sectors without loading all of the contract's roots.
+func (cm *Manager) sectorRootProof(id types.FileContractID, index uint64) (types.Hash256, []types.Hash256, types.Hash256, uint64, error) {
+	return cm.roots.Proof(id, index, cm.loadSectorRoots)
+}
+
+// setSectorRoots updates the cached sector roots of a contract.
+func (cm *Manager) setSectorRoots(id types.FileContractID, roots []types.Hash256) {
+	// copy the roots since the caller may continue to modify them
+	cm.roots.Set(id, append(make([]types.Hash256, 0, len(roots)), roots...))
+}
@@ -0,0 +1,374 @@
+package contracts
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	rhp2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"lukechampine.com/frand"
+)
+
+// rootStore is a ContractStore that only stores sector roots.
+type rootStore struct {
+	ContractStore
+
+	roots map[types.FileContractID][]types.Hash256
+	loads atomic.Uint64
+}
+
+func (rs *rootStore) ContractSectorRoots(id types.FileContractID, offset, limit uint64) ([]types.Hash256, error) {
+	rs.loads.Add(1)
+	roots, ok := rs.roots[id]
+	if !ok {
+		return nil, ErrNotFound
+	} else if offset >= uint64(len(roots)) {
+		return nil, nil
+	}
+	roots = roots[offset:]
+	if uint64(len(roots)) > limit {
+		roots = roots[:limit]
+	}
+	return append([]types.Hash256(nil), roots...), nil
+}
+
+func newRootStore(contracts, roots int) *rootStore {
+	rs := &rootStore{
+		roots: make(map[types.FileContractID][]types.Hash256),
+	}
+	for i := 0; i < contracts; i++ {
+		contractRoots := make([]types.Hash256, roots)
+		for j := range contractRoots {
+			contractRoots[j] = frand.Entropy256()
+		}
+		rs.roots[frand.Entropy256()] = contractRoots
+	}
+	return rs
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+func TestRootCache(t *testing.T) {
+	rs := newRootStore(4, 10)
+	cm, err := NewManager(rs, nil, nil, nil, nil, WithIntegrityCheckInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+	// each contract uses 3 pages and 17 roots of the cache
+	cm.roots = newRootCache(40, 4)
+
+	var ids []types.FileContractID
+	for id := range rs.roots {
+		ids = append(ids, id)
+	}
+
+	checkRoots := func(id types.FileContractID) {
+		t.Helper()
+		roots, err := cm.getSectorRoots(id)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(roots) != len(rs.roots[id]) {
+			t.Fatalf("expected %d roots, got %d", len(rs.roots[id]), len(roots))
+		}
+		for i := range roots {
+			if roots[i] != rs.roots[id][i] {
+				t.Fatalf("root %d mismatch", i)
+			}
+		}
+	}
+
+	checkLoads := func(id types.FileContractID, expected uint64) {
+		t.Helper()
+		loads := rs.loads.Load()
+		checkRoots(id)
+		if n := rs.loads.Load() - loads; n != expected {
+			t.Fatalf("expected %d loads, got %d", expected, n)
+		}
+	}
+
+	// two contracts and the metadata of a third fit in the cache
+	for _, id := range ids {
+		checkLoads(id, 3)
+	}
+	if n, size := cm.roots.Len(); n != 3 || size != 38 {
+		t.Fatalf("expected 3 contracts and size 38, got %d and %d", n, size)
+	}
+
+	// the most recently used contract should be cached
+	checkLoads(ids[3], 0)
+	// the least recently used contract should have been evicted
+	checkLoads(ids[0], 3)
+
+	// unknown contracts have no roots
+	if roots, err := cm.getSectorRoots(frand.Entropy256()); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 0 {
+		t.Fatalf("expected no roots, got %d", len(roots))
+	}
+
+	// the pages of contracts larger than the cache are not cached and do not
+	// evict other contracts
+	large := make([]types.Hash256, 50)
+	for i := range large {
+		large[i] = frand.Entropy256()
+	}
+	cm.setSectorRoots(ids[1], large)
+	rs.roots[ids[1]] = large
+	checkLoads(ids[1], 13)
+	checkLoads(ids[1], 13)
+	checkLoads(ids[0], 0)
+	if _, size := cm.roots.Len(); size > 40 {
+		t.Fatalf("expected cache size at most 40, got %d", size)
+	}
+
+	// modifying the roots passed to setSectorRoots should not modify the
+	// cache
+	roots := append([]types.Hash256(nil), rs.roots[ids[2]]...)
+	cm.setSectorRoots(ids[2], roots)
+	roots[0] = frand.Entropy256()
+	checkRoots(ids[2])
+
+	// modifying the roots returned by SectorRoots should not modify the
+	// cache
+	cm.SectorRoots(ids[2])[0] = frand.Entropy256()
+	checkRoots(ids[2])
+}
+
+func TestRootCacheProof(t *testing.T) {
+	const pageSize = 8
+
+	for _, n := range []int{1, pageSize - 1, pageSize, pageSize + 1, 2 * pageSize, 3*pageSize + 5, 8 * pageSize} {
+		rs := newRootStore(1, n)
+		var id types.FileContractID
+		for id = range rs.roots {
+		}
+		roots := rs.roots[id]
+		load := func(id types.FileContractID, offset, limit uint64) ([]types.Hash256, error) {
+			return rs.ContractSectorRoots(id, offset, limit)
+		}
+
+		// the cache only fits a single page so each proof reads at most
+		// one page after the page roots are known
+		rc := newRootCache(pageSize+1, pageSize)
+		expectedRoot := rhp2.MetaRoot(roots)
+		for i := range roots {
+			sectorRoot, proof, contractRoot, count, err := rc.Proof(id, uint64(i), load)
+			if err != nil {
+				t.Fatal(err)
+			} else if count != uint64(n) {
+				t.Fatalf("%d roots: expected count %d, got %d", n, n, count)
+			} else if contractRoot != expectedRoot {
+				t.Fatalf("%d roots: contract root mismatch", n)
+			} else if sectorRoot != roots[i] {
+				t.Fatalf("%d roots: sector root %d mismatch", n, i)
+			}
+			expectedProof := rhp2.ConvertProofOrdering(rhp2.BuildSectorRangeProof(roots, uint64(i), uint64(i+1)), uint64(i))
+			if !slices.Equal(proof, expectedProof) {
+				t.Fatalf("%d roots: proof %d mismatch", n, i)
+			}
+		}
+
+		if _, _, _, count, err := rc.Proof(id, uint64(n), load); err != nil {
+			t.Fatal(err)
+		} else if count != uint64(n) {
+			t.Fatalf("expected count %d, got %d", n, count)
+		}
+	}
+}
+
+func TestRootCacheStaleLoad(t *testing.T) {
+	rc := newRootCache(100, 4)
+	id := types.FileContractID(frand.Entropy256())
+	updated := []types.Hash256{frand.Entropy256()}
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	var loads atomic.Uint64
+	load := func(types.FileContractID, uint64, uint64) ([]types.Hash256, error) {
+		if loads.Add(1) == 1 {
+			close(loading)
+			<-release
+		}
+		return []types.Hash256{frand.Entropy256(), frand.Entropy256()}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]types.Hash256, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i > 0 {
+				<-loading
+			}
+			roots, err := rc.Get(id, load)
+			if err != nil {
+				panic(err)
+			}
+			results[i] = roots
+		}(i)
+	}
+
+	// replace the roots while they are being loaded
+	<-loading
+	rc.Set(id, updated)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("expected 1 load, got %d", n)
+	}
+	for i, roots := range results {
+		if len(roots) != 1 || roots[0] != updated[0] {
+			t.Fatalf("result %d: expected updated roots, got %v", i, roots)
+		}
+	}
+}
+
+// BenchmarkSectorRootsStartup compares loading the sector roots of every
+// contract at startup with loading them lazily.
+func BenchmarkSectorRootsStartup(b *testing.B) {
+	const contracts, roots = 1000, 1024
+
+	rs := newRootStore(contracts, roots)
+	ids := make([]types.FileContractID, 0, len(rs.roots))
+	for id := range rs.roots {
+		ids = append(ids, id)
+	}
+
+	b.Run("eager", func(b *testing.B) {
+		b.ReportAllocs()
+		var all map[types.FileContractID][]types.Hash256
+		base := heapInUse()
+		for i := 0; i < b.N; i++ {
+			all = make(map[types.FileContractID][]types.Hash256, len(ids))
+			for _, id := range ids {
+				contractRoots, err := rs.ContractSectorRoots(id, 0, roots)
+				if err != nil {
+					b.Fatal(err)
+				}
+				all[id] = contractRoots
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric((float64(heapInUse())-float64(base))/(1<<20), "MiB")
+		runtime.KeepAlive(all)
+	})
+
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		var cm *Manager
+		base := heapInUse()
+		for i := 0; i < b.N; i++ {
+			if cm != nil {
+				cm.Close()
+			}
+			var err error
+			cm, err = NewManager(rs, nil, nil, nil, nil, WithIntegrityCheckInterval(0))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric((float64(heapInUse())-float64(base))/(1<<20), "MiB")
+		cm.Close()
+	})
+}
+
+// BenchmarkSectorRootsAccess measures random access to the sector roots of
+// many contracts with caches of different sizes.
+func BenchmarkSectorRootsAccess(b *testing.B) {
+	const contracts, roots = 1000, 1024
+
+	rs := newRootStore(contracts, roots)
+	ids := make([]types.FileContractID, 0, len(rs.roots))
+	for id := range rs.roots {
+		ids = append(ids, id)
+	}
+
+	for _, cached := range []int{contracts, contracts / 10, 0} {
+		// each contract uses a single page and its metadata
+		b.Run(fmt.Sprintf("%d cached", cached), func(b *testing.B) {
+			base := heapInUse()
+			cm, err := NewManager(rs, nil, nil, nil, nil, WithIntegrityCheckInterval(0), WithSectorRootCacheSize(uint64(cached*(roots+3))))
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := cm.getSectorRoots(ids[frand.Intn(len(ids))]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric((float64(heapInUse())-float64(base))/(1<<20), "MiB")
+			cm.Close()
+		})
+	}
+}
+
+// BenchmarkSectorRootsLargeContract measures reading the roots of a contract
+// and building storage proofs for a contract with more roots than the cache
+// can hold.
+func BenchmarkSectorRootsLargeContract(b *testing.B) {
+	const roots = 1 << 20
+
+	rs := newRootStore(1, roots)
+	var id types.FileContractID
+	for id = range rs.roots {
+	}
+
+	b.Run("get", func(b *testing.B) {
+		base := heapInUse()
+		cm, err := NewManager(rs, nil, nil, nil, nil, WithIntegrityCheckInterval(0), WithSectorRootCacheSize(roots/4))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+		b.ReportAllocs()
+		loads := rs.loads.Load()
+		for i := 0; i < b.N; i++ {
+			if _, err := cm.getSectorRoots(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(rs.loads.Load()-loads)/float64(b.N), "loads/op")
+		b.ReportMetric((float64(heapInUse())-float64(base))/(1<<20), "MiB")
+		cm.Close()
+	})
+
+	b.Run("proof", func(b *testing.B) {
+		base := heapInUse()
+		cm, err := NewManager(rs, nil, nil, nil, nil, WithIntegrityCheckInterval(0), WithSectorRootCacheSize(roots/4))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+		b.ReportAllocs()
+		loads := rs.loads.Load()
+		for i := 0; i < b.N; i++ {
+			if _, _, _, _, err := cm.sectorRootProof(id, frand.Uint64n(roots)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(rs.loads.Load()-loads)/float64(b.N), "loads/op")
+		b.ReportMetric((float64(heapInUse())-float64(base))/(1<<20), "MiB")
+		cm.Close()
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"go.sia.tech/coreutils/chain"
 	rhp4 "go.sia.tech/coreutils/rhp/v4"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/webhooks"
 	"go.uber.org/zap"
 )
 
@@ -48,6 +49,9 @@ type (
 		Revised    []types.FileContractElement
 		Successful []types.FileContractID
 		Failed     []types.FileContractID
+		// Renewed contains the IDs of the contracts whose renewal was
+		// confirmed
+		Renewed []types.FileContractID
 
 		// V2 changes
 		ConfirmedV2  []types.V2FileContractElement
@@ -69,6 +73,10 @@ type (
 		// V2ContractRelevant returns whether the v2 contract with the
 		// provided id is relevant to the host
 		V2ContractRelevant(id types.FileContractID) (bool, error)
+		// ContractRenewedFrom returns the ID of the contract renewed by the
+		// contract with the provided id. If the contract is not a renewal,
+		// false is returned.
+		ContractRenewedFrom(id types.FileContractID) (types.FileContractID, bool, error)
 		// ApplyContracts applies relevant contract changes to the contract
 		// store
 		ApplyContracts(types.ChainIndex, StateChanges) error
@@ -105,17 +113,18 @@ func (cm *Manager) buildStorageProof(revision types.FileContractRevision, index
 	sectorIndex := index / rhp2.LeavesPerSector
 	segmentIndex := index % rhp2.LeavesPerSector
 
-	roots := cm.getSectorRoots(revision.ParentID)
-	contractRoot := rhp2.MetaRoot(roots)
-	if contractRoot != revision.FileMerkleRoot {
+	sectorRoot, sectorProof, contractRoot, count, err := cm.sectorRootProof(revision.ParentID, sectorIndex)
+	if err != nil {
+		log.Error("failed to get sector roots", zap.Error(err))
+		return types.StorageProof{}, fmt.Errorf("failed to get sector roots: %w", err)
+	} else if sectorIndex >= count {
+		log.Error("unexpected proof index", zap.Uint64("sectorIndex", sectorIndex), zap.Uint64("segmentIndex", segmentIndex), zap.Uint64("rootsLength", count))
+		return types.StorageProof{}, fmt.Errorf("invalid root index")
+	} else if contractRoot != revision.FileMerkleRoot {
 		log.Error("unexpected contract merkle root", zap.Stringer("expectedRoot", revision.FileMerkleRoot), zap.Stringer("actualRoot", contractRoot))
 		return types.StorageProof{}, fmt.Errorf("merkle root mismatch")
-	} else if uint64(len(roots)) < sectorIndex {
-		log.Error("unexpected proof index", zap.Uint64("sectorIndex", sectorIndex), zap.Uint64("segmentIndex", segmentIndex), zap.Int("rootsLength", len(roots)))
-		return types.StorageProof{}, fmt.Errorf("invalid root index")
 	}
 
-	sectorRoot := roots[sectorIndex]
 	sector, err := cm.storage.ReadSector(sectorRoot)
 	if err != nil {
 		log.Error("failed to read sector data", zap.Error(err), zap.Stringer("sectorRoot", sectorRoot))
@@ -125,7 +134,6 @@ func (cm *Manager) buildStorageProof(revision types.FileContractRevision, index
 		return types.StorageProof{}, fmt.Errorf("invalid sector root")
 	}
 	segmentProof := rhp2.ConvertProofOrdering(rhp2.BuildProof(sector, segmentIndex, segmentIndex+1, nil), segmentIndex)
-	sectorProof := rhp2.ConvertProofOrdering(rhp2.BuildSectorRangeProof(roots, sectorIndex, sectorIndex+1), sectorIndex)
 	sp := types.StorageProof{
 		ParentID: revision.ParentID,
 		Proof:    append(segmentProof, sectorProof...),
@@ -148,17 +156,18 @@ func (cm *Manager) buildV2StorageProof(cs consensus.State, fce types.V2FileContr
 	sectorIndex := leafIndex / rhp2.LeavesPerSector
 	segmentIndex := leafIndex % rhp2.LeavesPerSector
 
-	roots := cm.getSectorRoots(contractID)
-	contractRoot := rhp2.MetaRoot(roots)
-	if contractRoot != revision.FileMerkleRoot {
+	sectorRoot, sectorProof, contractRoot, count, err := cm.sectorRootProof(contractID, sectorIndex)
+	if err != nil {
+		log.Error("failed to get sector roots", zap.Error(err))
+		return types.V2StorageProof{}, fmt.Errorf("failed to get sector roots: %w", err)
+	} else if sectorIndex >= count {
+		log.Error("unexpected root index", zap.Uint64("sectorIndex", sectorIndex), zap.Uint64("segmentIndex", segmentIndex), zap.Uint64("rootsLength", count))
+		return types.V2StorageProof{}, fmt.Errorf("invalid root index")
+	} else if contractRoot != revision.FileMerkleRoot {
 		log.Error("unexpected contract root", zap.Stringer("expectedRoot", revision.FileMerkleRoot), zap.Stringer("actualRoot", contractRoot))
 		return types.V2StorageProof{}, fmt.Errorf("merkle root mismatch")
-	} else if uint64(len(roots)) < sectorIndex {
-		log.Error("unexpected root index", zap.Uint64("sectorIndex", sectorIndex), zap.Uint64("segmentIndex", segmentIndex), zap.Int("rootsLength", len(roots)))
-		return types.V2StorageProof{}, fmt.Errorf("invalid root index")
 	}
 
-	sectorRoot := roots[sectorIndex]
 	sector, err := cm.storage.ReadSector(sectorRoot)
 	if err != nil {
 		log.Error("failed to read sector data", zap.Error(err), zap.Stringer("sectorRoot", sectorRoot))
@@ -168,7 +177,6 @@ func (cm *Manager) buildV2StorageProof(cs consensus.State, fce types.V2FileContr
 		return types.V2StorageProof{}, fmt.Errorf("invalid sector root")
 	}
 	segmentProof := rhp2.ConvertProofOrdering(rhp2.BuildProof(sector, segmentIndex, segmentIndex+1, nil), segmentIndex)
-	sectorProof := rhp2.ConvertProofOrdering(rhp2.BuildSectorRangeProof(roots, sectorIndex, sectorIndex+1), sectorIndex)
 	sp := types.V2StorageProof{
 		ProofIndex: pi,
 		Proof:      append(segmentProof, sectorProof...),
@@ -182,6 +190,12 @@ func (cm *Manager) buildV2StorageProof(cs consensus.State, fce types.V2FileContr
 func (cm *Manager) ProcessActions(index types.ChainIndex) error {
 	log := cm.log.Named("lifecycle").With(zap.Stringer("index", index))
 
+	// the chain update has been committed, broadcast its events before
+	// processing the actions
+	cm.broadcastPendingEvents()
+	// contracts may have been confirmed, renewed or resolved
+	cm.invalidateRenterUsage()
+
 	revisionBroadcastHeight := index.Height + cm.revisionSubmissionBuffer
 	actions, err := cm.store.ContractActions(index, revisionBroadcastHeight)
 	if err != nil {
@@ -243,6 +257,11 @@ func (cm *Manager) ProcessActions(index types.ChainIndex) error {
 		}
 		cm.syncer.BroadcastTransactionSet(revisionTxnSet)
 		log.Debug("broadcast revision transaction", zap.String("transactionID", revisionTxn.ID().String()))
+		cm.broadcastEvent(contractEvent{
+			event: EventContractRevisionBroadcast,
+			scope: webhooks.ScopeContractsRevision,
+			data:  ContractEvent{ContractID: revision.Revision.ParentID, Index: index},
+		})
 	}
 
 	cs := cm.chain.TipState()
@@ -302,6 +321,11 @@ func (cm *Manager) ProcessActions(index types.ChainIndex) error {
 		}
 		cm.syncer.BroadcastTransactionSet(resolutionTxnSet)
 		log.Debug("broadcast transaction", zap.String("transactionID", resolutionTxnSet[1].ID().String()))
+		cm.broadcastEvent(contractEvent{
+			event: EventContractProofBroadcast,
+			scope: webhooks.ScopeContractsProof,
+			data:  ContractEvent{ContractID: revision.Revision.ParentID, Index: index},
+		})
 	}
 
 	for _, formationSet := range actions.RebroadcastV2Formation {
@@ -347,6 +371,11 @@ func (cm *Manager) ProcessActions(index types.ChainIndex) error {
 		}
 		cm.syncer.BroadcastV2TransactionSet(basis, revisionTxnSet)
 		log.Debug("broadcast transaction", zap.Stringer("transactionID", revisionTxn.ID()))
+		cm.broadcastEvent(contractEvent{
+			event: EventContractRevisionBroadcast,
+			scope: webhooks.ScopeContractsV2Revision,
+			data:  ContractEvent{ContractID: types.FileContractID(fcr.Parent.ID), Index: index},
+		})
 	}
 
 	for _, fce := range actions.BroadcastV2Proof {
@@ -398,6 +427,11 @@ func (cm *Manager) ProcessActions(index types.ChainIndex) error {
 		}
 		cm.syncer.BroadcastV2TransactionSet(basis, resolutionTxnSet)
 		log.Debug("broadcast transaction", zap.String("transactionID", resolutionTxn.ID().String()))
+		cm.broadcastEvent(contractEvent{
+			event: EventContractProofBroadcast,
+			scope: webhooks.ScopeContractsV2Proof,
+			data:  ContractEvent{ContractID: types.FileContractID(fce.ID), Index: index},
+		})
 	}
 
 	for _, fce := range actions.BroadcastV2Expiration {
@@ -463,6 +497,13 @@ func buildContractState(tx UpdateStateTx, u stateUpdater, revert bool, log *zap.
 		case created:
 			state.Confirmed = append(state.Confirmed, fce)
 			log.Debug("confirmed contract")
+
+			if parentID, ok, err := tx.ContractRenewedFrom(types.FileContractID(fce.ID)); err != nil {
+				log.Fatal("failed to check contract renewal", zap.Error(err))
+			} else if ok {
+				state.Renewed = append(state.Renewed, parentID)
+				log.Debug("renewed contract", zap.Stringer("renewedFrom", parentID))
+			}
 		case rev != nil:
 			if revert {
 				state.Revised = append(state.Revised, fce)
@@ -539,6 +580,9 @@ func buildContractState(tx UpdateStateTx, u stateUpdater, revert bool, log *zap.
 func (cm *Manager) UpdateChainState(tx UpdateStateTx, reverted []chain.RevertUpdate, applied []chain.ApplyUpdate) error {
 	log := cm.log.Named("updateChainState")
 
+	// events are only broadcast after the update is committed. If the
+	// update fails, the events are replaced when it is retried.
+	var events []contractEvent
 	for _, cru := range reverted {
 		revertedIndex := types.ChainIndex{
 			ID:     cru.Block.ID(),
@@ -549,7 +593,10 @@ func (cm *Manager) UpdateChainState(tx UpdateStateTx, reverted []chain.RevertUpd
 		state := buildContractState(tx, cru, true, log.Named("revert").With(zap.Stringer("index", revertedIndex)))
 		if err := tx.RevertContracts(revertedIndex, state); err != nil {
 			return fmt.Errorf("failed to revert contracts: %w", err)
-		} else if err := tx.RevertContractChainIndexElement(revertedIndex); err != nil {
+		}
+		events = appendContractEvents(events, revertedIndex, state, true)
+
+		if err := tx.RevertContractChainIndexElement(revertedIndex); err != nil {
 			return fmt.Errorf("failed to revert chain index state element: %w", err)
 		} else if err := tx.UpdateChainIndexElementProofs(cru); err != nil {
 			return fmt.Errorf("failed to update chain index elements: %w", err)
@@ -564,6 +611,7 @@ func (cm *Manager) UpdateChainState(tx UpdateStateTx, reverted []chain.RevertUpd
 		if err := tx.ApplyContracts(cau.State.Index, state); err != nil {
 			return fmt.Errorf("failed to revert contracts: %w", err)
 		}
+		events = appendContractEvents(events, cau.State.Index, state, false)
 
 		if err := tx.UpdateChainIndexElementProofs(cau); err != nil {
 			return fmt.Errorf("failed to update chain index elements: %w", err)
@@ -588,6 +636,12 @@ func (cm *Manager) UpdateChainState(tx UpdateStateTx, reverted []chain.RevertUpd
 			if len(rejectedV2) > 0 {
 				log.Debug("rejected v2 contracts", zap.Int("count", len(rejectedV2)), zap.Stringers("contracts", rejectedV2))
 			}
+			for _, id := range rejectedV1 {
+				events = append(events, contractEvent{event: EventContractApplied, scope: webhooks.ScopeContractsRejected, data: ContractEvent{ContractID: id, Index: index}})
+			}
+			for _, id := range rejectedV2 {
+				events = append(events, contractEvent{event: EventContractApplied, scope: webhooks.ScopeContractsV2Rejected, data: ContractEvent{ContractID: id, Index: index}})
+			}
 		}
 
 		// delete any chain index elements outside of the proof window buffer
@@ -598,6 +652,10 @@ func (cm *Manager) UpdateChainState(tx UpdateStateTx, reverted []chain.RevertUpd
 			}
 		}
 	}
+
+	cm.eventsMu.Lock()
+	cm.pendingEvents = events
+	cm.eventsMu.Unlock()
 	return nil
 }
 
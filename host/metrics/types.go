@@ -93,6 +93,9 @@ type (
 
 		SectorCacheHits   uint64 `json:"sectorCacheHits"`
 		SectorCacheMisses uint64 `json:"sectorCacheMisses"`
+
+		SectorDiskCacheHits   uint64 `json:"sectorDiskCacheHits"`
+		SectorDiskCacheMisses uint64 `json:"sectorDiskCacheMisses"`
 	}
 
 	// RevenueMetrics is a collection of metrics related to revenue.
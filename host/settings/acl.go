@@ -0,0 +1,153 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.uber.org/zap"
+)
+
+// Renter ACL modes control which renters may form and renew contracts.
+const (
+	// RenterACLModeDeny allows every renter except those with an entry in
+	// the ACL. This is the default mode.
+	RenterACLModeDeny = "deny"
+	// RenterACLModeAllow only allows renters with an entry in the ACL.
+	RenterACLModeAllow = "allow"
+)
+
+var (
+	// ErrRenterNotAllowed is returned when a renter is not allowed to form
+	// or renew contracts with the host.
+	ErrRenterNotAllowed = errors.New("renter is not allowed to form contracts with this host")
+	// ErrInvalidRenterACLMode is returned when a renter ACL mode is not
+	// recognized.
+	ErrInvalidRenterACLMode = errors.New("invalid renter ACL mode")
+	// ErrRenterACLEntryNotFound is returned when a renter does not have an
+	// entry in the ACL.
+	ErrRenterACLEntryNotFound = errors.New("renter ACL entry not found")
+)
+
+type (
+	// A RenterACLEntry adds a renter's public key to the access control
+	// list.
+	RenterACLEntry struct {
+		PublicKey types.PublicKey `json:"publicKey"`
+		Note      string          `json:"note"`
+		// Expiration is the time the entry is no longer applied. A zero
+		// time means the entry does not expire.
+		Expiration time.Time `json:"expiration"`
+		CreatedAt  time.Time `json:"createdAt"`
+	}
+
+	// A RenterACL controls which renters may form and renew contracts with
+	// the host.
+	RenterACL struct {
+		Mode    string           `json:"mode"`
+		Entries []RenterACLEntry `json:"entries"`
+	}
+)
+
+// Expired returns true if the entry has expired.
+func (e RenterACLEntry) Expired(now time.Time) bool {
+	return !e.Expiration.IsZero() && !now.Before(e.Expiration)
+}
+
+// ValidateRenterACLMode returns an error if the mode is not recognized.
+func ValidateRenterACLMode(mode string) error {
+	switch mode {
+	case RenterACLModeDeny, RenterACLModeAllow:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidRenterACLMode, mode)
+	}
+}
+
+// loadRenterACL loads the renter ACL from the store into memory.
+func (m *ConfigManager) loadRenterACL() error {
+	acl, err := m.store.RenterACL()
+	if err != nil {
+		return fmt.Errorf("failed to load renter ACL: %w", err)
+	}
+	m.aclMode = acl.Mode
+	m.aclEntries = make(map[types.PublicKey]RenterACLEntry, len(acl.Entries))
+	for _, entry := range acl.Entries {
+		m.aclEntries[entry.PublicKey] = entry
+	}
+	return nil
+}
+
+// RenterACL returns the renter access control list.
+func (m *ConfigManager) RenterACL() (RenterACL, error) {
+	return m.store.RenterACL()
+}
+
+// SetRenterACLMode sets the mode of the renter access control list.
+func (m *ConfigManager) SetRenterACLMode(mode string) error {
+	if err := ValidateRenterACLMode(mode); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.store.SetRenterACLMode(mode); err != nil {
+		return fmt.Errorf("failed to set renter ACL mode: %w", err)
+	}
+	m.aclMode = mode
+	m.log.Info("renter ACL mode changed", zap.String("mode", mode))
+	return nil
+}
+
+// UpdateRenterACLEntry adds a renter to the access control list or updates
+// the renter's existing entry.
+func (m *ConfigManager) UpdateRenterACLEntry(pk types.PublicKey, note string, expiration time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.aclEntries[pk]
+	if !ok {
+		entry = RenterACLEntry{
+			PublicKey: pk,
+			CreatedAt: time.Now(),
+		}
+	}
+	entry.Note = note
+	entry.Expiration = expiration
+
+	if err := m.store.UpdateRenterACLEntry(entry); err != nil {
+		return fmt.Errorf("failed to update renter ACL entry: %w", err)
+	}
+	m.aclEntries[pk] = entry
+	return nil
+}
+
+// RemoveRenterACLEntry removes a renter from the access control list.
+func (m *ConfigManager) RemoveRenterACLEntry(pk types.PublicKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.RemoveRenterACLEntry(pk); err != nil {
+		return fmt.Errorf("failed to remove renter ACL entry: %w", err)
+	}
+	delete(m.aclEntries, pk)
+	return nil
+}
+
+// CheckRenterAccess returns ErrRenterNotAllowed if the renter is not allowed
+// to form or renew contracts with the host. Expired entries are ignored.
+func (m *ConfigManager) CheckRenterAccess(pk types.PublicKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.aclEntries[pk]
+	listed := ok && !entry.Expired(time.Now())
+	switch {
+	case m.aclMode == RenterACLModeAllow && !listed:
+		return ErrRenterNotAllowed
+	case m.aclMode != RenterACLModeAllow && listed:
+		return ErrRenterNotAllowed
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package settings_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/host/settings"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/testutil"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRenterACL(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	network, genesisBlock := testutil.V1Network()
+	hostKey := types.GeneratePrivateKey()
+
+	node := testutil.NewConsensusNode(t, network, genesisBlock, log)
+
+	wm, err := wallet.NewSingleAddressWallet(hostKey, node.Chain, node.Store)
+	if err != nil {
+		t.Fatal("failed to create wallet:", err)
+	}
+	defer wm.Close()
+
+	vm, err := storage.NewVolumeManager(node.Store, storage.WithLogger(log.Named("storage")))
+	if err != nil {
+		t.Fatal("failed to create volume manager:", err)
+	}
+	defer vm.Close()
+
+	sm, err := settings.NewConfigManager(hostKey, node.Store, node.Chain, node.Syncer, vm, wm, settings.WithLog(log.Named("settings")), settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	blocked := types.GeneratePrivateKey().PublicKey()
+	expired := types.GeneratePrivateKey().PublicKey()
+	other := types.GeneratePrivateKey().PublicKey()
+
+	// every renter is allowed by default
+	if acl, err := sm.RenterACL(); err != nil {
+		t.Fatal(err)
+	} else if acl.Mode != settings.RenterACLModeDeny || len(acl.Entries) != 0 {
+		t.Fatalf("unexpected default ACL: %+v", acl)
+	} else if err := sm.CheckRenterAccess(blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sm.UpdateRenterACLEntry(blocked, "abusive", time.Time{}); err != nil {
+		t.Fatal(err)
+	} else if err := sm.UpdateRenterACLEntry(expired, "temporary", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// deny mode only refuses renters with an active entry
+	if err := sm.CheckRenterAccess(blocked); !errors.Is(err, settings.ErrRenterNotAllowed) {
+		t.Fatalf("expected ErrRenterNotAllowed, got %v", err)
+	} else if err := sm.CheckRenterAccess(expired); err != nil {
+		t.Fatalf("expected expired entry to be ignored, got %v", err)
+	} else if err := sm.CheckRenterAccess(other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sm.SetRenterACLMode("maybe"); !errors.Is(err, settings.ErrInvalidRenterACLMode) {
+		t.Fatalf("expected ErrInvalidRenterACLMode, got %v", err)
+	} else if err := sm.SetRenterACLMode(settings.RenterACLModeAllow); err != nil {
+		t.Fatal(err)
+	}
+
+	// allow mode only accepts renters with an active entry
+	if err := sm.CheckRenterAccess(blocked); err != nil {
+		t.Fatal(err)
+	} else if err := sm.CheckRenterAccess(expired); !errors.Is(err, settings.ErrRenterNotAllowed) {
+		t.Fatalf("expected ErrRenterNotAllowed, got %v", err)
+	} else if err := sm.CheckRenterAccess(other); !errors.Is(err, settings.ErrRenterNotAllowed) {
+		t.Fatalf("expected ErrRenterNotAllowed, got %v", err)
+	}
+
+	if err := sm.RemoveRenterACLEntry(blocked); err != nil {
+		t.Fatal(err)
+	} else if err := sm.RemoveRenterACLEntry(blocked); !errors.Is(err, settings.ErrRenterACLEntryNotFound) {
+		t.Fatalf("expected ErrRenterACLEntryNotFound, got %v", err)
+	} else if err := sm.CheckRenterAccess(blocked); !errors.Is(err, settings.ErrRenterNotAllowed) {
+		t.Fatalf("expected ErrRenterNotAllowed, got %v", err)
+	}
+
+	// the ACL should be reloaded from the store
+	sm2, err := settings.NewConfigManager(hostKey, node.Store, node.Chain, node.Syncer, vm, wm, settings.WithLog(log.Named("settings")), settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm2.Close()
+
+	acl, err := sm2.RenterACL()
+	if err != nil {
+		t.Fatal(err)
+	} else if acl.Mode != settings.RenterACLModeAllow {
+		t.Fatalf("expected allow mode, got %q", acl.Mode)
+	} else if len(acl.Entries) != 1 || acl.Entries[0].PublicKey != expired || acl.Entries[0].Note != "temporary" {
+		t.Fatalf("unexpected entries: %+v", acl.Entries)
+	} else if err := sm2.CheckRenterAccess(other); !errors.Is(err, settings.ErrRenterNotAllowed) {
+		t.Fatalf("expected ErrRenterNotAllowed, got %v", err)
+	}
+}
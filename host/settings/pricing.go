@@ -0,0 +1,187 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	proto3 "go.sia.tech/core/rhp/v3"
+	"go.sia.tech/core/types"
+)
+
+var (
+	// ErrPricingProfileNotFound is returned when a renter does not have a
+	// pricing profile.
+	ErrPricingProfileNotFound = errors.New("pricing profile not found")
+	// ErrInvalidPricingProfile is returned when a pricing profile would
+	// charge a renter more than the host's public prices.
+	ErrInvalidPricingProfile = errors.New("invalid pricing profile")
+)
+
+// A PricingProfile discounts the host's prices for a single renter. Prices
+// that are nil use the host's settings. A renter may not have received its
+// prices before paying, so a profile can only lower prices or raise
+// collateral; overrides that would be worse for the renter than the public
+// prices are ignored.
+type PricingProfile struct {
+	PublicKey types.PublicKey `json:"publicKey"`
+	Note      string          `json:"note"`
+
+	ContractPrice *types.Currency `json:"contractPrice,omitempty"`
+	StoragePrice  *types.Currency `json:"storagePrice,omitempty"`
+	IngressPrice  *types.Currency `json:"ingressPrice,omitempty"`
+	EgressPrice   *types.Currency `json:"egressPrice,omitempty"`
+	// Collateral is the collateral per byte per block the host risks for
+	// the renter's data.
+	Collateral *types.Currency `json:"collateral,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// discount returns the override if it is lower than the public price.
+func discount(public types.Currency, override *types.Currency) types.Currency {
+	if override != nil && override.Cmp(public) < 0 {
+		return *override
+	}
+	return public
+}
+
+// bonus returns the override if it is higher than the public collateral.
+func bonus(public types.Currency, override *types.Currency) types.Currency {
+	if override != nil && override.Cmp(public) > 0 {
+		return *override
+	}
+	return public
+}
+
+// ApplyRHP2 returns the RHP2 settings with the profile's prices applied.
+func (p PricingProfile) ApplyRHP2(hs proto2.HostSettings) proto2.HostSettings {
+	hs.ContractPrice = discount(hs.ContractPrice, p.ContractPrice)
+	hs.StoragePrice = discount(hs.StoragePrice, p.StoragePrice)
+	hs.UploadBandwidthPrice = discount(hs.UploadBandwidthPrice, p.IngressPrice)
+	hs.DownloadBandwidthPrice = discount(hs.DownloadBandwidthPrice, p.EgressPrice)
+	hs.Collateral = bonus(hs.Collateral, p.Collateral)
+	return hs
+}
+
+// ApplyRHP3 returns the RHP3 price table with the profile's prices applied.
+func (p PricingProfile) ApplyRHP3(pt proto3.HostPriceTable) proto3.HostPriceTable {
+	pt.ContractPrice = discount(pt.ContractPrice, p.ContractPrice)
+	pt.WriteStoreCost = discount(pt.WriteStoreCost, p.StoragePrice)
+	pt.UploadBandwidthCost = discount(pt.UploadBandwidthCost, p.IngressPrice)
+	pt.DownloadBandwidthCost = discount(pt.DownloadBandwidthCost, p.EgressPrice)
+	pt.CollateralCost = bonus(pt.CollateralCost, p.Collateral)
+	return pt
+}
+
+// validate returns an error if the profile charges more or risks less
+// collateral than the host's public settings.
+func (p PricingProfile) validate(hs proto2.HostSettings) error {
+	check := func(name string, public types.Currency, override *types.Currency) error {
+		if override != nil && override.Cmp(public) > 0 {
+			return fmt.Errorf("%w: %s %v is higher than the public price %v", ErrInvalidPricingProfile, name, *override, public)
+		}
+		return nil
+	}
+	if err := check("contract price", hs.ContractPrice, p.ContractPrice); err != nil {
+		return err
+	} else if err := check("storage price", hs.StoragePrice, p.StoragePrice); err != nil {
+		return err
+	} else if err := check("ingress price", hs.UploadBandwidthPrice, p.IngressPrice); err != nil {
+		return err
+	} else if err := check("egress price", hs.DownloadBandwidthPrice, p.EgressPrice); err != nil {
+		return err
+	} else if p.Collateral != nil && p.Collateral.Cmp(hs.Collateral) < 0 {
+		return fmt.Errorf("%w: collateral %v is lower than the public collateral %v", ErrInvalidPricingProfile, *p.Collateral, hs.Collateral)
+	}
+	return nil
+}
+
+// loadPricingProfiles loads the pricing profiles from the store into memory.
+func (m *ConfigManager) loadPricingProfiles() error {
+	profiles, err := m.store.PricingProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to load pricing profiles: %w", err)
+	}
+	m.pricingProfiles = make(map[types.PublicKey]PricingProfile, len(profiles))
+	for _, p := range profiles {
+		m.pricingProfiles[p.PublicKey] = p
+	}
+	return nil
+}
+
+// PricingProfiles returns every renter's pricing profile.
+func (m *ConfigManager) PricingProfiles() ([]PricingProfile, error) {
+	return m.store.PricingProfiles()
+}
+
+// PricingProfile returns the renter's pricing profile. The bool is false if
+// the renter does not have a profile.
+func (m *ConfigManager) PricingProfile(pk types.PublicKey) (PricingProfile, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.pricingProfiles[pk]
+	return p, ok
+}
+
+// UpdatePricingProfile adds or replaces a renter's pricing profile. The
+// profile's prices must not be higher than the host's current prices and its
+// collateral must not be lower than the host's current collateral.
+func (m *ConfigManager) UpdatePricingProfile(p PricingProfile) error {
+	hs, err := m.RHP2Settings()
+	if err != nil {
+		return fmt.Errorf("failed to get host settings: %w", err)
+	} else if err := p.validate(hs); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.pricingProfiles[p.PublicKey]; ok {
+		p.CreatedAt = existing.CreatedAt
+	} else {
+		p.CreatedAt = time.Now()
+	}
+
+	if err := m.store.UpdatePricingProfile(p); err != nil {
+		return fmt.Errorf("failed to update pricing profile: %w", err)
+	}
+	m.pricingProfiles[p.PublicKey] = p
+	return nil
+}
+
+// RemovePricingProfile removes a renter's pricing profile. The host's
+// settings are used for the renter afterwards.
+func (m *ConfigManager) RemovePricingProfile(pk types.PublicKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.RemovePricingProfile(pk); err != nil {
+		return fmt.Errorf("failed to remove pricing profile: %w", err)
+	}
+	delete(m.pricingProfiles, pk)
+	return nil
+}
+
+// RHP2RenterSettings returns the host's RHP2 settings with the renter's
+// pricing profile applied.
+func (m *ConfigManager) RHP2RenterSettings(pk types.PublicKey) (proto2.HostSettings, error) {
+	hs, err := m.RHP2Settings()
+	if err != nil {
+		return proto2.HostSettings{}, err
+	} else if p, ok := m.PricingProfile(pk); ok {
+		hs = p.ApplyRHP2(hs)
+	}
+	return hs, nil
+}
+
+// RHP3RenterPriceTable returns the price table with the renter's pricing
+// profile applied. Prices not covered by the profile are unchanged.
+func (m *ConfigManager) RHP3RenterPriceTable(pk types.PublicKey, pt proto3.HostPriceTable) proto3.HostPriceTable {
+	if p, ok := m.PricingProfile(pk); ok {
+		pt = p.ApplyRHP3(pt)
+	}
+	return pt
+}
@@ -0,0 +1,148 @@
+package settings_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/host/settings"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/testutil"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPricingProfiles(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	network, genesisBlock := testutil.V1Network()
+	hostKey := types.GeneratePrivateKey()
+
+	node := testutil.NewConsensusNode(t, network, genesisBlock, log)
+
+	wm, err := wallet.NewSingleAddressWallet(hostKey, node.Chain, node.Store)
+	if err != nil {
+		t.Fatal("failed to create wallet:", err)
+	}
+	defer wm.Close()
+
+	vm, err := storage.NewVolumeManager(node.Store, storage.WithLogger(log.Named("storage")))
+	if err != nil {
+		t.Fatal("failed to create volume manager:", err)
+	}
+	defer vm.Close()
+
+	sm, err := settings.NewConfigManager(hostKey, node.Store, node.Chain, node.Syncer, vm, wm, settings.WithLog(log.Named("settings")), settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+
+	renter := types.GeneratePrivateKey().PublicKey()
+	other := types.GeneratePrivateKey().PublicKey()
+
+	public, err := sm.RHP2Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPT, err := sm.RHP3PriceTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storagePrice, egressPrice := types.NewCurrency64(1), types.NewCurrency64(2)
+	err = sm.UpdatePricingProfile(settings.PricingProfile{
+		PublicKey:    renter,
+		Note:         "discount",
+		StoragePrice: &storagePrice,
+		EgressPrice:  &egressPrice,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the overridden prices should change
+	rs, err := sm.RHP2RenterSettings(renter)
+	switch {
+	case err != nil:
+		t.Fatal(err)
+	case !rs.StoragePrice.Equals(storagePrice):
+		t.Fatalf("expected storage price %v, got %v", storagePrice, rs.StoragePrice)
+	case !rs.DownloadBandwidthPrice.Equals(egressPrice):
+		t.Fatalf("expected egress price %v, got %v", egressPrice, rs.DownloadBandwidthPrice)
+	case !rs.UploadBandwidthPrice.Equals(public.UploadBandwidthPrice):
+		t.Fatalf("expected ingress price %v, got %v", public.UploadBandwidthPrice, rs.UploadBandwidthPrice)
+	case !rs.ContractPrice.Equals(public.ContractPrice):
+		t.Fatalf("expected contract price %v, got %v", public.ContractPrice, rs.ContractPrice)
+	case !rs.Collateral.Equals(public.Collateral):
+		t.Fatalf("expected collateral %v, got %v", public.Collateral, rs.Collateral)
+	}
+
+	pt := sm.RHP3RenterPriceTable(renter, publicPT)
+	if !pt.WriteStoreCost.Equals(storagePrice) || !pt.DownloadBandwidthCost.Equals(egressPrice) {
+		t.Fatalf("expected price table overrides to be applied, got %+v", pt)
+	} else if pt.UID != publicPT.UID || !pt.UploadBandwidthCost.Equals(publicPT.UploadBandwidthCost) {
+		t.Fatalf("expected other price table fields to be unchanged, got %+v", pt)
+	}
+
+	// renters without a profile get the host's prices
+	if rs, err := sm.RHP2RenterSettings(other); err != nil {
+		t.Fatal(err)
+	} else if !rs.StoragePrice.Equals(public.StoragePrice) {
+		t.Fatalf("expected storage price %v, got %v", public.StoragePrice, rs.StoragePrice)
+	} else if pt := sm.RHP3RenterPriceTable(other, publicPT); !pt.WriteStoreCost.Equals(publicPT.WriteStoreCost) {
+		t.Fatalf("expected storage price %v, got %v", publicPT.WriteStoreCost, pt.WriteStoreCost)
+	}
+
+	// profiles must not be worse for the renter than the public prices
+	surcharge := public.StoragePrice.Add(types.NewCurrency64(1))
+	if err := sm.UpdatePricingProfile(settings.PricingProfile{PublicKey: other, StoragePrice: &surcharge}); !errors.Is(err, settings.ErrInvalidPricingProfile) {
+		t.Fatalf("expected ErrInvalidPricingProfile, got %v", err)
+	}
+	lowCollateral := public.Collateral.Sub(types.NewCurrency64(1))
+	if err := sm.UpdatePricingProfile(settings.PricingProfile{PublicKey: other, Collateral: &lowCollateral}); !errors.Is(err, settings.ErrInvalidPricingProfile) {
+		t.Fatalf("expected ErrInvalidPricingProfile, got %v", err)
+	} else if _, ok := sm.PricingProfile(other); ok {
+		t.Fatal("expected invalid profile to be rejected")
+	}
+
+	// the profiles should be reloaded from the store
+	sm2, err := settings.NewConfigManager(hostKey, node.Store, node.Chain, node.Syncer, vm, wm, settings.WithLog(log.Named("settings")), settings.WithValidateNetAddress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm2.Close()
+
+	profile, ok := sm2.PricingProfile(renter)
+	switch {
+	case !ok:
+		t.Fatal("expected pricing profile to be loaded")
+	case profile.Note != "discount":
+		t.Fatalf("expected note %q, got %q", "discount", profile.Note)
+	case profile.StoragePrice == nil || !profile.StoragePrice.Equals(storagePrice):
+		t.Fatalf("expected storage price %v, got %v", storagePrice, profile.StoragePrice)
+	case profile.IngressPrice != nil || profile.ContractPrice != nil || profile.Collateral != nil:
+		t.Fatalf("expected unset prices to be nil, got %+v", profile)
+	}
+
+	// lowering the public price below the profile's price should not
+	// increase the renter's price
+	hs := sm2.Settings()
+	hs.EgressPrice = types.NewCurrency64(1)
+	if err := sm2.UpdateSettings(hs); err != nil {
+		t.Fatal(err)
+	} else if rs, err := sm2.RHP2RenterSettings(renter); err != nil {
+		t.Fatal(err)
+	} else if !rs.DownloadBandwidthPrice.Equals(hs.EgressPrice) {
+		t.Fatalf("expected egress price %v, got %v", hs.EgressPrice, rs.DownloadBandwidthPrice)
+	}
+
+	if err := sm2.RemovePricingProfile(renter); err != nil {
+		t.Fatal(err)
+	} else if err := sm2.RemovePricingProfile(renter); !errors.Is(err, settings.ErrPricingProfileNotFound) {
+		t.Fatalf("expected ErrPricingProfileNotFound, got %v", err)
+	} else if rs, err := sm2.RHP2RenterSettings(renter); err != nil {
+		t.Fatal(err)
+	} else if !rs.StoragePrice.Equals(public.StoragePrice) {
+		t.Fatalf("expected storage price %v, got %v", public.StoragePrice, rs.StoragePrice)
+	}
+}
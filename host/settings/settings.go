@@ -47,6 +47,27 @@ type (
 		LastAnnouncement() (Announcement, error)
 		// LastV2AnnouncementHash returns the hash of the last v2 announcement.
 		LastV2AnnouncementHash() (types.Hash256, types.ChainIndex, error)
+
+		// RenterACL returns the renter access control list.
+		RenterACL() (RenterACL, error)
+		// SetRenterACLMode sets the mode of the renter access control list.
+		SetRenterACLMode(mode string) error
+		// UpdateRenterACLEntry adds or updates an entry in the renter
+		// access control list.
+		UpdateRenterACLEntry(RenterACLEntry) error
+		// RemoveRenterACLEntry removes an entry from the renter access
+		// control list. If the entry does not exist,
+		// ErrRenterACLEntryNotFound must be returned.
+		RemoveRenterACLEntry(types.PublicKey) error
+
+		// PricingProfiles returns every renter's pricing profile.
+		PricingProfiles() ([]PricingProfile, error)
+		// UpdatePricingProfile adds or replaces a renter's pricing profile.
+		UpdatePricingProfile(PricingProfile) error
+		// RemovePricingProfile removes a renter's pricing profile. If the
+		// profile does not exist, ErrPricingProfileNotFound must be
+		// returned.
+		RemovePricingProfile(types.PublicKey) error
 	}
 
 	// ChainManager defines the interface required by the contract manager to
@@ -131,6 +152,12 @@ type (
 		DDNS DNSSettings `json:"ddns"`
 
 		SectorCacheSize uint32 `json:"sectorCacheSize"`
+		// SectorCachePath is the path of the file used for the second-level
+		// sector cache. The file should be on a fast disk, such as an SSD.
+		SectorCachePath string `json:"sectorCachePath"`
+		// SectorCacheDiskSize is the maximum number of sectors stored in the
+		// second-level sector cache. If 0, the disk cache is disabled.
+		SectorCacheDiskSize uint64 `json:"sectorCacheDiskSize"`
 
 		Revision uint64 `json:"revision"`
 	}
@@ -155,6 +182,12 @@ type (
 		settings   Settings   // in-memory cache of the host's settings
 		scanHeight uint64     // track the last block height that was scanned for announcements
 
+		// in-memory cache of the renter access control list
+		aclMode    string
+		aclEntries map[types.PublicKey]RenterACLEntry
+		// in-memory cache of the renters' pricing profiles
+		pricingProfiles map[types.PublicKey]PricingProfile
+
 		ingressLimit *rate.Limiter
 		egressLimit  *rate.Limiter
 
@@ -467,6 +500,11 @@ func NewConfigManager(hostKey types.PrivateKey, store Store, cm ChainManager, s
 	}
 
 	m.settings = settings
+	if err := m.loadRenterACL(); err != nil {
+		return nil, err
+	} else if err := m.loadPricingProfiles(); err != nil {
+		return nil, err
+	}
 	// update the global rate limiters from settings
 	m.setRateLimit(settings.IngressLimit, settings.EgressLimit)
 	// initialize the DDNS update timer
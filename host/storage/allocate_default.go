@@ -0,0 +1,16 @@
+//go:build !linux
+
+package storage
+
+import "os"
+
+// preallocate is not supported on this platform. Volumes are grown using
+// truncate instead.
+func preallocate(*os.File, int64, int64) error {
+	return errPreallocateNotSupported
+}
+
+// allocatedBytes is not supported on this platform.
+func allocatedBytes(string) (uint64, bool) {
+	return 0, false
+}
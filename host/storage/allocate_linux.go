@@ -0,0 +1,36 @@
+//go:build linux
+
+package storage
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate reserves disk space for the byte range [offset, offset+length)
+// of f without changing the size of the file.
+func preallocate(f *os.File, offset, length int64) error {
+	for {
+		err := unix.Fallocate(int(f.Fd()), unix.FALLOC_FL_KEEP_SIZE, offset, length)
+		switch {
+		case errors.Is(err, unix.EINTR):
+			continue
+		case errors.Is(err, unix.EOPNOTSUPP), errors.Is(err, unix.ENOSYS):
+			return errPreallocateNotSupported
+		}
+		return err
+	}
+}
+
+// allocatedBytes returns the number of bytes allocated on disk for the file
+// at path.
+func allocatedBytes(path string) (uint64, bool) {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return 0, false
+	}
+	// st_blocks is always in 512-byte units
+	return uint64(stat.Blocks) * 512, true
+}
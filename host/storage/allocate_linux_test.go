@@ -0,0 +1,79 @@
+//go:build linux
+
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rhp2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/internal/disk"
+	"go.sia.tech/hostd/persist/sqlite"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sys/unix"
+)
+
+func TestAddVolumePreallocate(t *testing.T) {
+	dir := t.TempDir()
+
+	// skip the test if the filesystem does not support preallocation
+	probe, err := os.Create(filepath.Join(dir, "probe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = unix.Fallocate(int(probe.Fd()), unix.FALLOC_FL_KEEP_SIZE, 0, 4096)
+	probe.Close()
+	if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.ENOSYS) {
+		t.Skip("filesystem does not support preallocation")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	// the volume's space should be allocated, not sparse
+	const sectors = 20
+	volumePath := filepath.Join(dir, "hostdata.dat")
+	result := make(chan error, 1)
+	if _, err := vm.AddVolume(context.Background(), volumePath, sectors, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	var stat unix.Stat_t
+	if err := unix.Stat(volumePath, &stat); err != nil {
+		t.Fatal(err)
+	} else if stat.Size != sectors*rhp2.SectorSize {
+		t.Fatalf("expected size %d, got %d", sectors*rhp2.SectorSize, stat.Size)
+	} else if allocated := stat.Blocks * 512; allocated < sectors*rhp2.SectorSize {
+		t.Fatalf("expected at least %d bytes allocated, got %d", sectors*rhp2.SectorSize, allocated)
+	}
+
+	// adding a volume larger than the free space should fail immediately
+	free, _, err := disk.Usage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tooLarge := free/rhp2.SectorSize + 256
+	if _, err := vm.AddVolume(context.Background(), filepath.Join(dir, "toolarge.dat"), tooLarge, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; !errors.Is(err, storage.ErrNotEnoughStorage) {
+		t.Fatalf("expected ErrNotEnoughStorage, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/hostd/alerts"
+	"go.sia.tech/hostd/internal/disk"
+	"go.uber.org/zap"
+)
+
+// autoGrowMinChange is the minimum number of sectors a volume is
+// automatically resized by. It prevents volumes on busy disks from being
+// resized every time the free space changes slightly.
+const autoGrowMinChange = resizeBatchSize
+
+// An AutoGrowPolicy automatically resizes a volume to use the free space
+// on its disk.
+type AutoGrowPolicy struct {
+	// MaxSectors is the maximum size of the volume. If MaxSectors is 0,
+	// the volume is not automatically resized.
+	MaxSectors uint64 `json:"maxSectors"`
+	// ReservedBytes is the amount of free space that must remain on the
+	// volume's disk for other software.
+	ReservedBytes uint64 `json:"reservedBytes"`
+}
+
+// Enabled returns true if the volume should be automatically resized.
+func (p AutoGrowPolicy) Enabled() bool {
+	return p.MaxSectors > 0
+}
+
+// autoGrowTarget returns the number of sectors the volume should be resized
+// to given the free space available to it. breached is true if the free
+// space is below the policy's reserve.
+func autoGrowTarget(vol Volume, free uint64) (target uint64, breached bool) {
+	policy := vol.AutoGrow
+	target = vol.TotalSectors
+	if free >= policy.ReservedBytes {
+		target += (free - policy.ReservedBytes) / proto2.SectorSize
+	} else {
+		breached = true
+		shrink := (policy.ReservedBytes - free + proto2.SectorSize - 1) / proto2.SectorSize
+		if shrink < target {
+			target -= shrink
+		} else {
+			target = 0
+		}
+	}
+	// never shrink below the sectors in use
+	return max(min(target, policy.MaxSectors), vol.UsedSectors, 1), breached
+}
+
+// availableBytes returns the free space on a volume's disk that is not
+// already committed to the volume. Space in a sparse volume file that has
+// not been written yet still counts against the free space.
+func availableBytes(vol Volume) (uint64, error) {
+	free, _, err := disk.Usage(vol.LocalPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	size := vol.TotalSectors * proto2.SectorSize
+	// if the allocated size is unknown, assume the unused sectors are not
+	// allocated
+	unallocated := (vol.TotalSectors - vol.UsedSectors) * proto2.SectorSize
+	if allocated, ok := allocatedBytes(vol.LocalPath); ok {
+		unallocated = size - min(allocated, size)
+	}
+	if unallocated >= free {
+		return 0, nil
+	}
+	return free - unallocated, nil
+}
+
+// autoGrowVolume resizes a volume according to its auto-grow policy.
+func (vm *VolumeManager) autoGrowVolume(ctx context.Context, vol Volume) error {
+	log := vm.log.Named("autogrow").With(zap.Int64("volumeID", vol.ID), zap.String("path", vol.LocalPath))
+
+	v, ok := vm.loadedVolume(vol.ID)
+	if !ok {
+		return fmt.Errorf("volume %v not found", vol.ID)
+	}
+
+	free, err := availableBytes(vol)
+	if err != nil {
+		return err
+	}
+
+	target, breached := autoGrowTarget(vol, free)
+	alertID := v.alertID("autogrow")
+	if breached {
+		log.Warn("disk free space is below the volume's reserve", zap.Uint64("free", free), zap.Uint64("reserved", vol.AutoGrow.ReservedBytes))
+		vm.alerts.Register(alerts.Alert{
+			ID:       alertID,
+			Severity: alerts.SeverityWarning,
+			Message:  "Disk free space is below the volume's reserve",
+			Data: map[string]any{
+				"volumeID":      vol.ID,
+				"volume":        vol.LocalPath,
+				"freeBytes":     free,
+				"reservedBytes": vol.AutoGrow.ReservedBytes,
+				"totalSectors":  vol.TotalSectors,
+				"targetSectors": target,
+			},
+			Timestamp: time.Now(),
+		})
+	} else {
+		vm.alerts.Dismiss(alertID)
+	}
+
+	// small changes are skipped unless the volume needs to shrink to
+	// restore the reserve or its maximum size
+	current := vol.TotalSectors
+	switch {
+	case target == current:
+		return nil
+	case target > current && target-current < autoGrowMinChange && target != vol.AutoGrow.MaxSectors:
+		return nil
+	case target < current && current-target < autoGrowMinChange && !breached && current <= vol.AutoGrow.MaxSectors:
+		return nil
+	}
+
+	// skip the volume if it is busy
+	vm.mu.Lock()
+	if v.Status() != VolumeStatusReady {
+		vm.mu.Unlock()
+		log.Debug("skipping busy volume", zap.String("status", v.Status()))
+		return nil
+	} else if err := v.SetStatus(VolumeStatusResizing); err != nil {
+		vm.mu.Unlock()
+		return fmt.Errorf("failed to set volume status: %w", err)
+	}
+	vm.mu.Unlock()
+	defer v.SetStatus(VolumeStatusReady)
+
+	start := time.Now()
+	if target > current {
+		err = vm.growVolume(ctx, vol.ID, v, current, target)
+	} else {
+		if !vol.ReadOnly {
+			// prevent new sectors from being added while the volume is
+			// shrinking
+			if err := vm.vs.SetReadOnly(vol.ID, true); err != nil {
+				return fmt.Errorf("failed to set volume to read-only: %w", err)
+			}
+			defer func() {
+				if err := vm.vs.SetReadOnly(vol.ID, false); err != nil {
+					log.Error("failed to set volume to read-write", zap.Error(err))
+				}
+			}()
+		}
+		err = vm.shrinkVolume(ctx, vol.ID, v, current, target)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resize volume from %d to %d sectors: %w", current, target, err)
+	}
+	log.Info("automatically resized volume", zap.Uint64("oldSectors", current), zap.Uint64("newSectors", target), zap.Uint64("freeBytes", free), zap.Duration("elapsed", time.Since(start)))
+	return nil
+}
+
+// autoGrowVolumes resizes every available volume with an auto-grow policy.
+func (vm *VolumeManager) autoGrowVolumes(ctx context.Context) {
+	volumes, err := vm.vs.Volumes()
+	if err != nil {
+		vm.log.Error("failed to get volumes", zap.Error(err))
+		return
+	}
+
+	for _, vol := range volumes {
+		if !vol.Available || !vol.AutoGrow.Enabled() {
+			continue
+		} else if err := vm.autoGrowVolume(ctx, vol); err != nil {
+			vm.log.Error("failed to auto-grow volume", zap.Int64("volumeID", vol.ID), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+}
+
+// watchAutoGrow periodically resizes volumes according to their auto-grow
+// policies.
+func (vm *VolumeManager) watchAutoGrow() {
+	ctx, cancel, err := vm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vm.autoGrowInterval):
+		}
+
+		vm.autoGrowVolumes(ctx)
+	}
+}
+
+// SetVolumeAutoGrow sets the auto-grow policy of a volume. A policy with
+// MaxSectors of 0 disables automatic resizing.
+func (vm *VolumeManager) SetVolumeAutoGrow(id int64, policy AutoGrowPolicy) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := vm.vs.SetVolumeAutoGrow(id, policy); err != nil {
+		return fmt.Errorf("failed to set auto-grow policy: %w", err)
+	}
+
+	v, ok := vm.loadedVolume(id)
+	if ok && !policy.Enabled() {
+		vm.alerts.Dismiss(v.alertID("autogrow"))
+	}
+	return nil
+}
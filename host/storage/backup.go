@@ -0,0 +1,400 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.uber.org/zap"
+)
+
+// BackupStatus is the status of a volume backup or restore.
+const (
+	BackupStatusRunning   = "running"
+	BackupStatusComplete  = "complete"
+	BackupStatusFailed    = "failed"
+	BackupStatusCancelled = "cancelled"
+)
+
+// BackupOperation is the type of a volume backup job.
+const (
+	BackupOperationBackup  = "backup"
+	BackupOperationRestore = "restore"
+)
+
+// backupBatchSize is the number of sector locations retrieved from the store
+// at a time.
+const backupBatchSize = 64
+
+type (
+	// A VolumeBackup tracks the progress of backing up a volume to, or
+	// restoring a volume from, a backup directory.
+	VolumeBackup struct {
+		VolumeID  int64  `json:"volumeID"`
+		Path      string `json:"path"`
+		Operation string `json:"operation"`
+		Status    string `json:"status"`
+		Error     string `json:"error,omitempty"`
+
+		// TotalSectors is the number of sectors in the volume for a backup or
+		// the number of sectors in the manifest for a restore.
+		TotalSectors   uint64 `json:"totalSectors"`
+		CheckedSectors uint64 `json:"checkedSectors"`
+		// CopiedSectors is the number of sectors written to the backup or
+		// restored to the host's volumes.
+		CopiedSectors uint64 `json:"copiedSectors"`
+		FailedSectors uint64 `json:"failedSectors"`
+
+		StartTime time.Time `json:"startTime"`
+		EndTime   time.Time `json:"endTime"`
+	}
+
+	// A BackupSector is a sector recorded in a backup manifest.
+	BackupSector struct {
+		Index uint64        `json:"index"`
+		Root  types.Hash256 `json:"root"`
+	}
+
+	// A BackupManifest lists the sectors stored in a volume at the time of
+	// its last backup. Sector data is stored separately in content-addressed
+	// files so it can be shared between runs and volumes.
+	BackupManifest struct {
+		VolumeID   int64          `json:"volumeID"`
+		Location   string         `json:"location"`
+		BackupTime time.Time      `json:"backupTime"`
+		Sectors    []BackupSector `json:"sectors"`
+	}
+)
+
+var (
+	// ErrBackupNotFound is returned when a volume has no backup job.
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupRunning is returned when trying to start a backup or restore
+	// for a volume that already has one running.
+	ErrBackupRunning = errors.New("backup already running")
+)
+
+// backupManifestPath returns the path of a volume's manifest in a backup
+// directory.
+func backupManifestPath(dir string, volumeID int64) string {
+	return filepath.Join(dir, fmt.Sprintf("volume-%d.json", volumeID))
+}
+
+// backupSectorPath returns the path of a sector's data in a backup directory.
+func backupSectorPath(dir string, root types.Hash256) string {
+	hex := root.String()
+	return filepath.Join(dir, "sectors", hex[:2], hex)
+}
+
+// writeFileAtomic writes data to a temporary file, syncs it, and renames it
+// to path so partially written files are never left at path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	} else if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadBackupManifest reads the manifest of a volume from a backup directory.
+func ReadBackupManifest(dir string, volumeID int64) (BackupManifest, error) {
+	buf, err := os.ReadFile(backupManifestPath(dir, volumeID))
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to decode manifest: %w", err)
+	} else if manifest.VolumeID != volumeID {
+		return BackupManifest{}, fmt.Errorf("manifest is for volume %d, expected %d", manifest.VolumeID, volumeID)
+	}
+	return manifest, nil
+}
+
+// backupSector copies a sector to the backup directory if it has not been
+// copied by a previous run. Sectors that were moved or removed while they
+// were being read are skipped.
+func (vm *VolumeManager) backupSector(dir string, vol *volume, loc SectorLocation) (copied bool, err error) {
+	path := backupSectorPath(dir, loc.Root)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to stat sector file: %w", err)
+	}
+
+	sector, err := vol.ReadSector(loc.Index)
+	if err == nil && proto2.SectorRoot(sector) == loc.Root {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return false, fmt.Errorf("failed to create sector directory: %w", err)
+		} else if err := writeFileAtomic(path, sector[:]); err != nil {
+			return false, fmt.Errorf("failed to write sector file: %w", err)
+		}
+		return true, nil
+	} else if err == nil {
+		err = ErrSectorRootMismatch
+	}
+
+	// the sector may have been moved or removed after its location was
+	// retrieved. Confirm the location before reporting an error.
+	current, lookupErr := vm.vs.SectorLocation(loc.Root)
+	if errors.Is(lookupErr, ErrSectorNotFound) || (lookupErr == nil && (current.Volume != loc.Volume || current.Index != loc.Index)) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to read sector %v: %w", loc.Root, err)
+}
+
+// backupVolume copies every sector stored in a volume that is not already in
+// the backup directory and writes a new manifest.
+func (vm *VolumeManager) backupVolume(ctx context.Context, vol *volume, dir string, progress *VolumeBackup) error {
+	log := vm.log.Named("backup").With(zap.Int64("volumeID", progress.VolumeID), zap.String("path", dir))
+
+	manifest := BackupManifest{
+		VolumeID:   progress.VolumeID,
+		Location:   vol.Location(),
+		BackupTime: time.Now(),
+	}
+
+	var nextIndex uint64
+	for {
+		locations, err := vm.vs.ScrubSectors(progress.VolumeID, nextIndex, backupBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get sectors: %w", err)
+		} else if len(locations) == 0 {
+			break
+		}
+
+		for _, loc := range locations {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			copied, err := vm.backupSector(dir, vol, loc)
+			if err != nil {
+				log.Warn("failed to back up sector", zap.Stringer("root", loc.Root), zap.Uint64("index", loc.Index), zap.Error(err))
+				vm.updateBackup(progress, func(b *VolumeBackup) { b.FailedSectors++ })
+				continue
+			}
+			manifest.Sectors = append(manifest.Sectors, BackupSector{Index: loc.Index, Root: loc.Root})
+			vm.updateBackup(progress, func(b *VolumeBackup) {
+				b.CheckedSectors++
+				if copied {
+					b.CopiedSectors++
+				}
+			})
+		}
+		nextIndex = locations[len(locations)-1].Index + 1
+	}
+
+	buf, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	} else if err := writeFileAtomic(backupManifestPath(dir, progress.VolumeID), buf); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// restoreVolume writes every sector in a volume's backup manifest that is
+// still referenced, but no longer stored, back to the host's volumes.
+func (vm *VolumeManager) restoreVolume(ctx context.Context, manifest BackupManifest, dir string, progress *VolumeBackup) error {
+	log := vm.log.Named("restore").With(zap.Int64("volumeID", manifest.VolumeID), zap.String("path", dir))
+
+	var sector [proto2.SectorSize]byte
+	for _, s := range manifest.Sectors {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		copied, err := func() (bool, error) {
+			if _, err := vm.vs.SectorLocation(s.Root); err == nil {
+				return false, nil // sector is still stored
+			} else if !errors.Is(err, ErrSectorNotFound) {
+				return false, fmt.Errorf("failed to get sector location: %w", err)
+			}
+
+			refs, err := vm.vs.SectorReferences(s.Root)
+			if err != nil {
+				return false, fmt.Errorf("failed to get sector references: %w", err)
+			} else if len(refs.Contracts) == 0 && refs.TempStorage == 0 {
+				return false, nil // sector is no longer needed
+			}
+
+			f, err := os.Open(backupSectorPath(dir, s.Root))
+			if err != nil {
+				return false, fmt.Errorf("failed to open sector file: %w", err)
+			}
+			defer f.Close()
+			if _, err := f.ReadAt(sector[:], 0); err != nil {
+				return false, fmt.Errorf("failed to read sector file: %w", err)
+			} else if err := vm.RestoreSector(s.Root, &sector); err != nil {
+				return false, err
+			}
+			return true, nil
+		}()
+		if err != nil {
+			log.Warn("failed to restore sector", zap.Stringer("root", s.Root), zap.Error(err))
+			vm.updateBackup(progress, func(b *VolumeBackup) { b.FailedSectors++ })
+			continue
+		}
+		vm.updateBackup(progress, func(b *VolumeBackup) {
+			b.CheckedSectors++
+			if copied {
+				b.CopiedSectors++
+			}
+		})
+	}
+	return nil
+}
+
+// updateBackup applies fn to the backup's progress and publishes it.
+func (vm *VolumeManager) updateBackup(progress *VolumeBackup, fn func(*VolumeBackup)) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	fn(progress)
+	vm.backups[progress.VolumeID] = *progress
+}
+
+// startBackup registers a backup job and runs fn in a new goroutine. The
+// result of fn is sent on result.
+func (vm *VolumeManager) startBackup(ctx context.Context, progress VolumeBackup, result chan<- error, fn func(context.Context, *VolumeBackup) error) error {
+	vm.mu.Lock()
+	if b, ok := vm.backups[progress.VolumeID]; ok && b.Status == BackupStatusRunning {
+		vm.mu.Unlock()
+		return ErrBackupRunning
+	}
+	vm.backups[progress.VolumeID] = progress
+	vm.mu.Unlock()
+
+	go func() {
+		log := vm.log.Named(progress.Operation).With(zap.Int64("volumeID", progress.VolumeID), zap.String("path", progress.Path))
+		ctx, cancel, err := vm.tg.AddContext(ctx)
+		if err == nil {
+			err = fn(ctx, &progress)
+			cancel()
+		}
+
+		vm.updateBackup(&progress, func(b *VolumeBackup) {
+			b.EndTime = time.Now()
+			switch {
+			case err == nil:
+				b.Status = BackupStatusComplete
+			case errors.Is(err, context.Canceled):
+				b.Status = BackupStatusCancelled
+			default:
+				b.Status = BackupStatusFailed
+				b.Error = err.Error()
+			}
+		})
+		if err != nil {
+			log.Error("volume "+progress.Operation+" failed", zap.Error(err))
+		} else {
+			log.Info("volume "+progress.Operation+" complete", zap.Uint64("checked", progress.CheckedSectors), zap.Uint64("copied", progress.CopiedSectors), zap.Uint64("failed", progress.FailedSectors), zap.Duration("elapsed", time.Since(progress.StartTime)))
+		}
+		select {
+		case result <- err:
+		default:
+		}
+	}()
+	return nil
+}
+
+// BackupVolume copies the sectors stored in a volume to a backup directory.
+// Sectors are stored as content-addressed files, so only sectors added since
+// the last run are copied. A manifest mapping the volume's indices to sector
+// roots is written when the backup completes. Cancelling the context stops
+// the backup; sectors already copied are kept and skipped by the next run.
+func (vm *VolumeManager) BackupVolume(ctx context.Context, id int64, dir string, result chan<- error) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	vol, ok := vm.loadedVolume(id)
+	if !ok {
+		return fmt.Errorf("volume %v not found", id)
+	}
+
+	stat, err := vm.vs.Volume(id)
+	if err != nil {
+		return fmt.Errorf("failed to get volume: %w", err)
+	} else if err := os.MkdirAll(filepath.Join(dir, "sectors"), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	progress := VolumeBackup{
+		VolumeID:     id,
+		Path:         dir,
+		Operation:    BackupOperationBackup,
+		Status:       BackupStatusRunning,
+		TotalSectors: stat.UsedSectors,
+		StartTime:    time.Now(),
+	}
+	return vm.startBackup(ctx, progress, result, func(ctx context.Context, progress *VolumeBackup) error {
+		return vm.backupVolume(ctx, vol, dir, progress)
+	})
+}
+
+// RestoreVolumeBackup restores the sectors in a volume's backup manifest
+// that are still referenced by contracts or temporary storage, but are no
+// longer stored by the host. The volume does not need to exist; sectors are
+// written to any volume with free space. Cancelling the context stops the
+// restore.
+func (vm *VolumeManager) RestoreVolumeBackup(ctx context.Context, id int64, dir string, result chan<- error) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	manifest, err := ReadBackupManifest(dir, id)
+	if err != nil {
+		return err
+	}
+
+	progress := VolumeBackup{
+		VolumeID:     id,
+		Path:         dir,
+		Operation:    BackupOperationRestore,
+		Status:       BackupStatusRunning,
+		TotalSectors: uint64(len(manifest.Sectors)),
+		StartTime:    time.Now(),
+	}
+	return vm.startBackup(ctx, progress, result, func(ctx context.Context, progress *VolumeBackup) error {
+		return vm.restoreVolume(ctx, manifest, dir, progress)
+	})
+}
+
+// VolumeBackup returns the progress of the most recent backup or restore of
+// a volume since the volume manager was started.
+func (vm *VolumeManager) VolumeBackup(id int64) (VolumeBackup, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	b, ok := vm.backups[id]
+	if !ok {
+		return VolumeBackup{}, ErrBackupNotFound
+	}
+	return b, nil
+}
@@ -0,0 +1,149 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rhp2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/persist/sqlite"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestVolumeBackup(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backup")
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	volumePath := filepath.Join(dir, "hostdata.dat")
+	volume, err := vm.AddVolume(context.Background(), volumePath, 10, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	var roots []types.Hash256
+	storeSectors := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			root, err := storeRandomSector(vm, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roots = append(roots, root)
+		}
+	}
+
+	backup := func(checked, copied uint64) {
+		t.Helper()
+		if err := vm.BackupVolume(context.Background(), volume.ID, backupDir, result); err != nil {
+			t.Fatal(err)
+		} else if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+
+		progress, err := vm.VolumeBackup(volume.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if progress.Status != storage.BackupStatusComplete {
+			t.Fatalf("expected backup to be complete, got %q", progress.Status)
+		} else if progress.CheckedSectors != checked || progress.CopiedSectors != copied || progress.FailedSectors != 0 {
+			t.Fatalf("expected %d checked and %d copied sectors, got %+v", checked, copied, progress)
+		}
+	}
+
+	storeSectors(3)
+	backup(3, 3)
+
+	// only the new sectors should be copied
+	storeSectors(2)
+	backup(5, 2)
+
+	manifest, err := storage.ReadBackupManifest(backupDir, volume.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(manifest.Sectors) != len(roots) {
+		t.Fatalf("expected %d sectors in the manifest, got %d", len(roots), len(manifest.Sectors))
+	}
+
+	// simulate a failed disk by removing the volume file
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := os.Remove(volumePath); err != nil {
+		t.Fatal(err)
+	}
+
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	// add a replacement volume and force remove the failed volume so its
+	// sectors are lost
+	if _, err := vm.AddVolume(context.Background(), filepath.Join(dir, "hostdata2.dat"), 10, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.RemoveVolume(context.Background(), volume.ID, true, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, count, err := vm.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != len(roots) {
+		t.Fatalf("expected %d lost sectors, got %d", len(roots), count)
+	}
+
+	// restore the lost sectors from the backup
+	if err := vm.RestoreVolumeBackup(context.Background(), volume.ID, backupDir, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := vm.VolumeBackup(volume.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if progress.Operation != storage.BackupOperationRestore || progress.Status != storage.BackupStatusComplete {
+		t.Fatalf("expected restore to be complete, got %+v", progress)
+	} else if progress.CopiedSectors != uint64(len(roots)) {
+		t.Fatalf("expected %d restored sectors, got %d", len(roots), progress.CopiedSectors)
+	}
+
+	if _, count, err := vm.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Fatalf("expected no lost sectors, got %d", count)
+	}
+
+	for _, root := range roots {
+		sector, err := vm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		} else if rhp2.SectorRoot(sector) != root {
+			t.Fatal("restored sector does not match")
+		}
+	}
+}
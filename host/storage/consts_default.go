@@ -4,4 +4,7 @@ package storage
 
 const (
 	resizeBatchSize = 64 // 256 MiB
+	// allocateBatchSize is the number of sectors added to a volume at a time
+	// after its space has been preallocated
+	allocateBatchSize = 1024 // 4 GiB
 )
@@ -6,4 +6,7 @@ const (
 	cleanupInterval = 0
 
 	resizeBatchSize = 4 // 16 MiB
+	// allocateBatchSize is the number of sectors added to a volume at a time
+	// after its space has been preallocated
+	allocateBatchSize = 16 // 64 MiB
 )
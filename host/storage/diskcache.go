@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+)
+
+// ghostFactor is the number of recently missed roots tracked by the disk
+// cache's admission filter relative to its capacity.
+const ghostFactor = 4
+
+type (
+	diskCacheEntry struct {
+		root types.Hash256
+		slot uint64
+	}
+
+	// A diskCache is a second-level sector cache backed by a file on a fast
+	// disk. Sectors are evicted in least-recently-used order. To avoid
+	// filling the cache with sectors that are only read once, a sector is
+	// only admitted after it has missed the cache at least twice within a
+	// window of recent misses.
+	diskCache struct {
+		path  string
+		slots uint64
+
+		mu      sync.Mutex
+		f       *os.File
+		entries map[types.Hash256]*list.Element
+		lru     *list.List // front is the most recently used
+		free    []uint64
+		// generation is incremented every time a slot is reused so readers
+		// can detect a slot being overwritten during a read
+		generation []uint64
+		// ghosts is the set of roots that recently missed the cache
+		ghosts *lru.Cache[types.Hash256, struct{}]
+	}
+)
+
+// errDiskCacheClosed is returned when the disk cache has been closed.
+var errDiskCacheClosed = errors.New("disk cache closed")
+
+// Get returns the sector with the given root if it is in the cache.
+func (dc *diskCache) Get(root types.Hash256) (*[proto2.SectorSize]byte, bool, error) {
+	dc.mu.Lock()
+	if dc.f == nil {
+		dc.mu.Unlock()
+		return nil, false, errDiskCacheClosed
+	}
+	el, ok := dc.entries[root]
+	if !ok {
+		dc.mu.Unlock()
+		return nil, false, nil
+	}
+	dc.lru.MoveToFront(el)
+	f := dc.f
+	slot := el.Value.(diskCacheEntry).slot
+	gen := dc.generation[slot]
+	dc.mu.Unlock()
+
+	var sector [proto2.SectorSize]byte
+	if _, err := f.ReadAt(sector[:], int64(slot*proto2.SectorSize)); err != nil {
+		dc.Remove(root)
+		return nil, false, fmt.Errorf("failed to read cached sector: %w", err)
+	}
+
+	// the slot may have been evicted and overwritten while it was read
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.generation[slot] != gen {
+		return nil, false, nil
+	}
+	return &sector, true, nil
+}
+
+// Add adds a sector to the cache if it passes the admission filter.
+func (dc *diskCache) Add(root types.Hash256, sector *[proto2.SectorSize]byte) error {
+	dc.mu.Lock()
+	if dc.f == nil {
+		dc.mu.Unlock()
+		return errDiskCacheClosed
+	} else if _, ok := dc.entries[root]; ok {
+		dc.mu.Unlock()
+		return nil
+	} else if !dc.ghosts.Contains(root) {
+		// only admit sectors that have been requested more than once
+		dc.ghosts.Add(root, struct{}{})
+		dc.mu.Unlock()
+		return nil
+	}
+	dc.ghosts.Remove(root)
+
+	// reserve a slot, evicting the least recently used sector if the cache
+	// is full
+	var slot uint64
+	if n := len(dc.free); n > 0 {
+		slot = dc.free[n-1]
+		dc.free = dc.free[:n-1]
+	} else if el := dc.lru.Back(); el != nil {
+		entry := dc.lru.Remove(el).(diskCacheEntry)
+		delete(dc.entries, entry.root)
+		slot = entry.slot
+	} else {
+		dc.mu.Unlock()
+		return nil
+	}
+	dc.generation[slot]++
+	f := dc.f
+	dc.mu.Unlock()
+
+	// the reserved slot is not referenced by any entry, so it can be written
+	// without holding the lock
+	_, err := f.WriteAt(sector[:], int64(slot*proto2.SectorSize))
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if err != nil {
+		dc.free = append(dc.free, slot)
+		return fmt.Errorf("failed to write cached sector: %w", err)
+	} else if _, ok := dc.entries[root]; ok {
+		// the sector was added concurrently
+		dc.free = append(dc.free, slot)
+		return nil
+	}
+	dc.entries[root] = dc.lru.PushFront(diskCacheEntry{root: root, slot: slot})
+	return nil
+}
+
+// Remove removes a sector from the cache.
+func (dc *diskCache) Remove(root types.Hash256) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	el, ok := dc.entries[root]
+	if !ok {
+		return
+	}
+	entry := dc.lru.Remove(el).(diskCacheEntry)
+	delete(dc.entries, root)
+	dc.generation[entry.slot]++
+	dc.free = append(dc.free, entry.slot)
+}
+
+// Len returns the number of sectors in the cache.
+func (dc *diskCache) Len() int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return len(dc.entries)
+}
+
+// Close closes and removes the cache file.
+func (dc *diskCache) Close() error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.f == nil {
+		return nil
+	}
+	err := dc.f.Close()
+	dc.f = nil
+	if err := os.Remove(dc.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cache file: %w", err)
+	}
+	return err
+}
+
+// openDiskCache creates a disk cache at path that can hold up to slots
+// sectors. The cache file is recreated empty every time it is opened.
+func openDiskCache(path string, slots uint64) (*diskCache, error) {
+	if slots == 0 {
+		return nil, errors.New("disk cache size must be greater than 0")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file: %w", err)
+	} else if err := f.Truncate(int64(slots * proto2.SectorSize)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to resize cache file: %w", err)
+	}
+
+	ghosts, err := lru.New[types.Hash256, struct{}](int(slots * ghostFactor))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to initialize admission filter: %w", err)
+	}
+
+	free := make([]uint64, slots)
+	for i := range free {
+		// reverse order so slots are used from the start of the file
+		free[i] = slots - uint64(i) - 1
+	}
+	return &diskCache{
+		path:  path,
+		slots: slots,
+
+		f:          f,
+		entries:    make(map[types.Hash256]*list.Element),
+		lru:        list.New(),
+		free:       free,
+		generation: make([]uint64, slots),
+		ghosts:     ghosts,
+	}, nil
+}
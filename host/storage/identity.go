@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.sia.tech/core/types"
+	"lukechampine.com/frand"
+)
+
+// identityFileSuffix is appended to a volume's path to get the path of its
+// identity sidecar file.
+const identityFileSuffix = ".identity"
+
+// ErrVolumeIdentityMismatch is returned when the identity of a volume file
+// does not match the identity recorded in the database.
+var ErrVolumeIdentityMismatch = errors.New("volume identity mismatch")
+
+// volumeIdentity is the persistent identity of a volume. It is stored in a
+// sidecar file next to the volume file.
+type volumeIdentity struct {
+	UUID            string `json:"uuid"`
+	HostFingerprint string `json:"hostFingerprint"`
+}
+
+// identityPath returns the path of the identity sidecar for a volume.
+func identityPath(localPath string) string {
+	return localPath + identityFileSuffix
+}
+
+// newVolumeUUID returns a random version 4 UUID.
+func newVolumeUUID() string {
+	b := frand.Bytes(16)
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// hostFingerprint returns a short fingerprint of a host's public key.
+func hostFingerprint(hostKey types.PublicKey) string {
+	if hostKey == (types.PublicKey{}) {
+		return ""
+	}
+	h := types.HashBytes(hostKey[:])
+	return hex.EncodeToString(h[:8])
+}
+
+// readVolumeIdentity reads the identity sidecar of a volume.
+func readVolumeIdentity(localPath string) (id volumeIdentity, err error) {
+	buf, err := os.ReadFile(identityPath(localPath))
+	if err != nil {
+		return volumeIdentity{}, err
+	} else if err := json.Unmarshal(buf, &id); err != nil {
+		return volumeIdentity{}, fmt.Errorf("failed to decode volume identity: %w", err)
+	}
+	return id, nil
+}
+
+// writeVolumeIdentity atomically writes the identity sidecar of a volume.
+func writeVolumeIdentity(localPath string, id volumeIdentity) error {
+	buf, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Errorf("failed to encode volume identity: %w", err)
+	}
+
+	fp := identityPath(localPath)
+	tmp := fp + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create identity file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf); err != nil {
+		return fmt.Errorf("failed to write identity file: %w", err)
+	} else if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync identity file: %w", err)
+	} else if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close identity file: %w", err)
+	} else if err := os.Rename(tmp, fp); err != nil {
+		return fmt.Errorf("failed to rename identity file: %w", err)
+	}
+
+	// sync the parent directory so the rename is durable
+	if dir, err := os.Open(filepath.Dir(fp)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// initVolumeIdentity creates a new identity for a volume, writes it to the
+// volume's sidecar, and records it in the volume store.
+func (vm *VolumeManager) initVolumeIdentity(volumeID int64, localPath string) (string, error) {
+	id := volumeIdentity{
+		UUID:            newVolumeUUID(),
+		HostFingerprint: hostFingerprint(vm.hostKey),
+	}
+	if err := writeVolumeIdentity(localPath, id); err != nil {
+		return "", err
+	} else if err := vm.vs.SetVolumeUUID(volumeID, id.UUID); err != nil {
+		return "", fmt.Errorf("failed to set volume identity: %w", err)
+	}
+	return id.UUID, nil
+}
+
+// checkVolumeIdentity checks that the identity sidecar of a volume matches
+// the identity recorded in the volume store. Volumes without a recorded
+// identity are assigned one. If the identity does not match,
+// ErrVolumeIdentityMismatch is returned.
+func (vm *VolumeManager) checkVolumeIdentity(vol Volume) error {
+	id, err := readVolumeIdentity(vol.LocalPath)
+	if vol.UUID == "" {
+		// the volume was added before identities were introduced. Adopt
+		// an existing sidecar if it belongs to this host, otherwise create
+		// a new identity.
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			_, err := vm.initVolumeIdentity(vol.ID, vol.LocalPath)
+			return err
+		case err != nil:
+			return fmt.Errorf("failed to read volume identity: %w", err)
+		case id.HostFingerprint != hostFingerprint(vm.hostKey):
+			return fmt.Errorf("%w: volume belongs to host %q", ErrVolumeIdentityMismatch, id.HostFingerprint)
+		}
+		return vm.vs.SetVolumeUUID(vol.ID, id.UUID)
+	}
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%w: identity file %q not found", ErrVolumeIdentityMismatch, identityPath(vol.LocalPath))
+	case err != nil:
+		return fmt.Errorf("failed to read volume identity: %w", err)
+	case id.UUID != vol.UUID:
+		return fmt.Errorf("%w: expected volume %q, found %q", ErrVolumeIdentityMismatch, vol.UUID, id.UUID)
+	case id.HostFingerprint != hostFingerprint(vm.hostKey):
+		return fmt.Errorf("%w: volume belongs to host %q", ErrVolumeIdentityMismatch, id.HostFingerprint)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.sia.tech/hostd/alerts"
+	"go.uber.org/zap"
+)
+
+const (
+	// slowVolumeFactor is the factor by which a volume's recent latency must
+	// exceed its baseline latency for the volume to be considered slow.
+	slowVolumeFactor = 5
+	// minBaselineSamples is the number of operations that must be observed
+	// before a volume's baseline latency is trusted.
+	minBaselineSamples = 100
+
+	// baselineAlpha and recentAlpha are the smoothing factors of the
+	// baseline and recent latency averages. The baseline adapts slowly so
+	// that a gradually degrading disk is still compared against its
+	// historical performance.
+	baselineAlpha = 0.001
+	recentAlpha   = 0.05
+)
+
+// LatencyBuckets are the upper bounds of the buckets of a LatencyHistogram.
+// The histogram has an additional bucket for observations greater than the
+// last bound.
+var LatencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type (
+	// A LatencyHistogram counts the latency of volume operations.
+	// Buckets[i] is the number of observations less than or equal to
+	// LatencyBuckets[i] and greater than the previous bound. The final bucket
+	// counts observations greater than the last bound.
+	LatencyHistogram struct {
+		Count   uint64                          `json:"count"`
+		Sum     time.Duration                   `json:"sum"`
+		Buckets [len(LatencyBuckets) + 1]uint64 `json:"buckets"`
+	}
+
+	// latencyBaseline tracks a volume's recent latency relative to its own
+	// historical latency.
+	latencyBaseline struct {
+		samples  uint64
+		baseline float64
+		recent   float64
+	}
+)
+
+// Observe adds an observation to the histogram.
+func (lh *LatencyHistogram) Observe(d time.Duration) {
+	lh.Count++
+	lh.Sum += d
+	for i, bound := range LatencyBuckets {
+		if d <= bound {
+			lh.Buckets[i]++
+			return
+		}
+	}
+	lh.Buckets[len(LatencyBuckets)]++
+}
+
+// Mean returns the mean latency of the observations in the histogram.
+func (lh LatencyHistogram) Mean() time.Duration {
+	if lh.Count == 0 {
+		return 0
+	}
+	return lh.Sum / time.Duration(lh.Count)
+}
+
+// observe adds an observation to the baseline. The baseline is not updated
+// while the volume is slow so that it does not adapt to a failing disk.
+func (lb *latencyBaseline) observe(d time.Duration) {
+	v := float64(d)
+	lb.samples++
+	if lb.samples == 1 {
+		lb.baseline, lb.recent = v, v
+		return
+	}
+
+	lb.recent += recentAlpha * (v - lb.recent)
+	if lb.samples < minBaselineSamples {
+		// learn the baseline quickly until enough samples have been observed
+		lb.baseline += (v - lb.baseline) / float64(lb.samples)
+	} else if lb.recent <= lb.baseline*slowVolumeFactor {
+		lb.baseline += baselineAlpha * (v - lb.baseline)
+	}
+}
+
+// health returns a score between 0 and 100 comparing the recent latency to
+// the baseline. A score of 100 means the volume is performing at or better
+// than its baseline.
+func (lb latencyBaseline) health() float64 {
+	if lb.samples < minBaselineSamples || lb.recent <= lb.baseline || lb.recent == 0 {
+		return 100
+	}
+	return 100 * lb.baseline / lb.recent
+}
+
+// checkVolumeHealth registers an alert for any volume whose recent latency
+// is far beyond its baseline and dismisses the alert for volumes that have
+// recovered.
+func (vm *VolumeManager) checkVolumeHealth() {
+	for id, v := range vm.loadedVolumes() {
+		stats := v.Stats()
+		alertID := v.alertID("health")
+		if stats.Health >= 100/slowVolumeFactor {
+			vm.alerts.Dismiss(alertID)
+			continue
+		}
+
+		vm.log.Warn("volume is responding slowly", zap.Int64("volumeID", id), zap.String("path", v.Location()), zap.Float64("health", stats.Health))
+		vm.alerts.Register(alerts.Alert{
+			ID:       alertID,
+			Severity: alerts.SeverityWarning,
+			Message:  "Volume is responding slowly",
+			Data: map[string]any{
+				"volumeID":         id,
+				"volume":           v.Location(),
+				"health":           stats.Health,
+				"meanReadLatency":  stats.ReadLatency.Mean().String(),
+				"meanWriteLatency": stats.WriteLatency.Mean().String(),
+			},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// watchVolumeHealth periodically checks the health of all volumes.
+func (vm *VolumeManager) watchVolumeHealth() {
+	ctx, cancel, err := vm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vm.healthCheckInterval):
+		}
+
+		vm.checkVolumeHealth()
+	}
+}
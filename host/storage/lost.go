@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.uber.org/zap"
+)
+
+// LostSectorCause describes why a sector was lost.
+type LostSectorCause string
+
+const (
+	// LostSectorCauseVolumeRemoved indicates the sector was stored in a
+	// volume that was force removed before the sector could be migrated.
+	LostSectorCauseVolumeRemoved LostSectorCause = "volumeRemoved"
+	// LostSectorCauseRemoved indicates the sector was manually removed.
+	LostSectorCauseRemoved LostSectorCause = "removed"
+	// LostSectorCauseScrubCorrupt indicates a volume scrub found that the
+	// sector's data does not match its root.
+	LostSectorCauseScrubCorrupt LostSectorCause = "scrubCorrupt"
+	// LostSectorCauseScrubReadFailed indicates a volume scrub failed to read
+	// the sector.
+	LostSectorCauseScrubReadFailed LostSectorCause = "scrubReadFailed"
+	// LostSectorCauseIntegrityCheck indicates a contract integrity check
+	// found the sector missing or corrupt.
+	LostSectorCauseIntegrityCheck LostSectorCause = "integrityCheck"
+)
+
+// ErrSectorRootMismatch is returned when the data of a restored sector does
+// not match the expected sector root.
+var ErrSectorRootMismatch = errors.New("sector root mismatch")
+
+// A LostSector is a sector that is no longer stored by the host.
+type LostSector struct {
+	Root      types.Hash256   `json:"root"`
+	VolumeID  int64           `json:"volumeID"`
+	Cause     LostSectorCause `json:"cause"`
+	Timestamp time.Time       `json:"timestamp"`
+	// Contracts is the number of unresolved contracts that reference the
+	// sector.
+	Contracts int `json:"contracts"`
+}
+
+// LostSectors returns a paginated list of sectors that have been lost and
+// not restored, sorted by the time they were lost, and the total number of
+// lost sectors.
+func (vm *VolumeManager) LostSectors(limit, offset int) ([]LostSector, int, error) {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer done()
+
+	return vm.vs.LostSectors(limit, offset)
+}
+
+// MarkSectorsLost records that the sectors are missing or corrupt. Sectors
+// that are still indexed remain lost until they are restored.
+func (vm *VolumeManager) MarkSectorsLost(roots []types.Hash256, cause LostSectorCause) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return vm.vs.MarkSectorsLost(roots, cause)
+}
+
+// RestoreSector writes a sector supplied by the operator back to a volume.
+// If the sector is lost, it is written to a new location and reattached to
+// its existing contract and temporary storage references. Otherwise, the
+// data at its current location is replaced to repair corruption.
+func (vm *VolumeManager) RestoreSector(root types.Hash256, sector *[proto2.SectorSize]byte) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if actual := proto2.SectorRoot(sector); actual != root {
+		return fmt.Errorf("expected root %v, got %v: %w", root, actual, ErrSectorRootMismatch)
+	}
+
+	vm.mu.Lock()
+	loc, err := vm.vs.SectorLocation(root)
+	if errors.Is(err, ErrSectorNotFound) {
+		vm.mu.Unlock()
+		if err := vm.writeSector(root, sector); err != nil {
+			return fmt.Errorf("failed to restore sector: %w", err)
+		} else if err := vm.vs.ClearLostSector(root); err != nil {
+			return fmt.Errorf("failed to clear lost sector: %w", err)
+		}
+		vm.log.Info("restored lost sector", zap.Stringer("root", root))
+		return nil
+	} else if err != nil {
+		vm.mu.Unlock()
+		return fmt.Errorf("failed to locate sector: %w", err)
+	}
+	vol, ok := vm.loadedVolume(loc.Volume)
+	vm.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("volume %v not found", loc.Volume)
+	} else if err := vol.WriteSectorSync(sector, loc.Index); err != nil {
+		return fmt.Errorf("failed to write sector: %w", err)
+	} else if err := vm.vs.ClearLostSector(root); err != nil {
+		return fmt.Errorf("failed to clear lost sector: %w", err)
+	}
+
+	// eject any corrupt copies from the caches
+	vm.cache.Remove(root)
+	if dc := vm.diskCache.Load(); dc != nil {
+		dc.Remove(root)
+	}
+	vm.log.Info("rewrote sector", zap.Stringer("root", root), zap.Int64("volume", loc.Volume), zap.Uint64("index", loc.Index))
+	return nil
+}
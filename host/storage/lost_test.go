@@ -0,0 +1,148 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rhp2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/persist/sqlite"
+	"go.uber.org/zap/zaptest"
+	"lukechampine.com/frand"
+)
+
+func TestRestoreLostSector(t *testing.T) {
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	if _, err := vm.AddVolume(context.Background(), filepath.Join(dir, "hostdata.dat"), 10, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	var sector [rhp2.SectorSize]byte
+	frand.Read(sector[:256])
+	root := rhp2.SectorRoot(&sector)
+	if err := vm.StoreSector(root, &sector, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// remove the sector so that it is lost
+	if err := vm.RemoveSector(root); err != nil {
+		t.Fatal(err)
+	}
+
+	lost, count, err := vm.LostSectors(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if count != 1 || len(lost) != 1 {
+		t.Fatalf("expected 1 lost sector, got %d", count)
+	} else if lost[0].Root != root || lost[0].Cause != storage.LostSectorCauseRemoved {
+		t.Fatalf("unexpected lost sector %+v", lost[0])
+	}
+
+	// restoring with the wrong data should fail
+	var corrupt [rhp2.SectorSize]byte
+	frand.Read(corrupt[:256])
+	if err := vm.RestoreSector(root, &corrupt); !errors.Is(err, storage.ErrSectorRootMismatch) {
+		t.Fatalf("expected ErrSectorRootMismatch, got %v", err)
+	}
+
+	if err := vm.RestoreSector(root, &sector); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, count, err := vm.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Fatalf("expected no lost sectors, got %d", count)
+	}
+
+	read, err := vm.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	} else if rhp2.SectorRoot(read) != root {
+		t.Fatal("restored sector does not match")
+	}
+}
+
+func TestRestoreCorruptSector(t *testing.T) {
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	volumePath := filepath.Join(dir, "hostdata.dat")
+	result := make(chan error, 1)
+	if _, err := vm.AddVolume(context.Background(), volumePath, 10, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	var sector [rhp2.SectorSize]byte
+	frand.Read(sector[:256])
+	root := rhp2.SectorRoot(&sector)
+	if err := vm.StoreSector(root, &sector, 10); err != nil {
+		t.Fatal(err)
+	} else if err := vm.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt the sector on disk
+	f, err := os.OpenFile(volumePath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(frand.Bytes(256), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.RestoreSector(root, &sector); err != nil {
+		t.Fatal(err)
+	}
+
+	// the data on disk should be repaired
+	buf := make([]byte, rhp2.SectorSize)
+	if _, err := f.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(buf, sector[:]) {
+		t.Fatal("sector was not repaired")
+	}
+
+	// repairing a sector should not mark it as lost
+	if _, count, err := vm.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Fatalf("expected no lost sectors, got %d", count)
+	}
+}
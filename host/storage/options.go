@@ -3,6 +3,7 @@ package storage
 import (
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.uber.org/zap"
 )
 
@@ -31,9 +32,82 @@ func WithCacheSize(cacheSize int) VolumeManagerOption {
 }
 
 // WithPruneInterval sets the time between cleaning up dereferenced
-// sectors.
+// sectors. An interval of 0 disables pruning.
 func WithPruneInterval(d time.Duration) VolumeManagerOption {
 	return func(vm *VolumeManager) {
 		vm.pruneInterval = d
 	}
 }
+
+// WithHostKey sets the host's public key. A fingerprint of the key is stored
+// in each volume's identity file to detect volumes that belong to a
+// different host.
+func WithHostKey(pk types.PublicKey) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.hostKey = pk
+	}
+}
+
+// WithEventReporter sets the event reporter for the manager.
+func WithEventReporter(e EventReporter) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.events = e
+	}
+}
+
+// WithReconnectInterval sets the interval at which the manager attempts to
+// reopen unavailable volumes. An interval of 0 disables reconnection.
+func WithReconnectInterval(d time.Duration) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.reconnectInterval = d
+	}
+}
+
+// WithAutoGrowInterval sets the interval at which the manager resizes
+// volumes according to their auto-grow policies. An interval of 0 disables
+// automatic resizing.
+func WithAutoGrowInterval(d time.Duration) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.autoGrowInterval = d
+	}
+}
+
+// WithHealthCheckInterval sets the interval at which the manager checks
+// volume latency and disk health. An interval of 0 disables health checks.
+func WithHealthCheckInterval(d time.Duration) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.healthCheckInterval = d
+	}
+}
+
+// WithResumeScrubs sets whether scrubs that were running when the manager
+// was last closed are resumed on startup.
+func WithResumeScrubs(resume bool) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.resumeScrubs = resume
+	}
+}
+
+// WithTieringPolicy sets the policy used to move sectors between fast and
+// bulk volumes. A policy with an interval of 0 disables automatic tiering
+// and sector read tracking.
+func WithTieringPolicy(policy TieringPolicy) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.tieringPolicy = policy
+	}
+}
+
+// WithScrubRate sets the maximum rate, in bytes per second, that a volume
+// is read while it is being scrubbed. A rate of 0 disables the limit.
+func WithScrubRate(bytesPerSecond uint64) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.scrubRate = bytesPerSecond
+	}
+}
+
+// WithSyncPolicy sets the policy used to flush sector data to disk.
+func WithSyncPolicy(policy SyncPolicy) VolumeManagerOption {
+	return func(vm *VolumeManager) {
+		vm.syncPolicy = policy
+	}
+}
@@ -45,6 +45,8 @@ type (
 		SetReadOnly(volumeID int64, readOnly bool) error
 		// SetAvailable sets the available flag on a volume.
 		SetAvailable(volumeID int64, available bool) error
+		// SetVolumeUUID sets the persistent identity of a volume.
+		SetVolumeUUID(volumeID int64, uuid string) error
 
 		// PruneSectors removes all sectors that have not been accessed since
 		// lastAccess and are no longer referenced by a contract or temp storage.
@@ -57,12 +59,26 @@ type (
 		// location and synced to disk during migrateFn. If migrateFn returns an
 		// error, migration will continue, but that sector is not migrated.
 		MigrateSectors(ctx context.Context, volumeID int64, min uint64, fn MigrateFunc) (migrated, failed int, err error)
+		// EvacuateSectors returns a new location in the destination volume
+		// for each occupied sector of a volume. The sector data should be
+		// copied to the new location and synced to disk during fn. If fn
+		// returns an error, migration will continue, but that sector is not
+		// migrated.
+		EvacuateSectors(ctx context.Context, volumeID, destID int64, fn MigrateFunc) (migrated, failed int, err error)
+		// MoveSectors moves up to count occupied sectors of a volume to the
+		// destination volume. The sector data should be copied to the new
+		// location and synced to disk during fn.
+		MoveSectors(ctx context.Context, volumeID, destID int64, count int, fn MigrateFunc) (migrated, failed int, err error)
 		// StoreSector calls fn with an empty location in a writable volume. If
 		// the sector root already exists, nil is returned. The sector should be
 		// written to disk within fn. If fn returns an error, the metadata is
 		// rolled back and the error is returned. If no space is available,
 		// ErrNotEnoughStorage is returned.
 		StoreSector(root types.Hash256, fn StoreFunc) error
+		// RecoverSector sets the location of a sector to the given volume
+		// index, replacing any previous location. false is returned if the
+		// sector is already stored at the location.
+		RecoverSector(volumeID int64, index uint64, root types.Hash256) (bool, error)
 		// RemoveSector removes the metadata of a sector and returns its
 		// location in the volume.
 		RemoveSector(root types.Hash256) error
@@ -85,9 +101,79 @@ type (
 		// the given height.
 		ExpireTempSectors(height uint64) error
 		// IncrementSectorStats increments sector stats
-		IncrementSectorStats(reads, writes, cacheHit, cacheMiss uint64) error
+		IncrementSectorStats(reads, writes, cacheHit, cacheMiss, diskCacheHit, diskCacheMiss uint64) error
+		// RecordSectorReads adds to the read count of each sector and sets
+		// its last read time.
+		RecordSectorReads(reads map[types.Hash256]uint64, timestamp time.Time) error
 		// SectorReferences returns the references to a sector
 		SectorReferences(types.Hash256) (SectorReference, error)
+
+		// ScrubSectors returns up to limit occupied sector locations in a
+		// volume with an index greater than or equal to min, sorted by
+		// index.
+		ScrubSectors(volumeID int64, min uint64, limit int) ([]SectorLocation, error)
+		// VolumeScrubs returns the most recent scrub of every volume.
+		VolumeScrubs() ([]VolumeScrub, error)
+		// VolumeScrub returns the most recent scrub of a volume. If the
+		// volume has never been scrubbed, ErrScrubNotFound is returned.
+		VolumeScrub(volumeID int64) (VolumeScrub, error)
+		// UpdateVolumeScrub stores the progress of a volume scrub.
+		UpdateVolumeScrub(VolumeScrub) error
+		// AddScrubError records a sector that failed verification.
+		AddScrubError(ScrubError) error
+		// ScrubErrors returns the sectors that failed verification during
+		// the most recent scrub of a volume.
+		ScrubErrors(volumeID int64, limit, offset int) ([]ScrubError, error)
+		// ClearScrubErrors removes all scrub errors for a volume.
+		ClearScrubErrors(volumeID int64) error
+
+		// SetVolumePlacement sets the placement weight and priority of a
+		// volume.
+		SetVolumePlacement(volumeID int64, weight uint64, priority int64) error
+		// SetVolumeAutoGrow sets the auto-grow policy of a volume.
+		SetVolumeAutoGrow(volumeID int64, policy AutoGrowPolicy) error
+		// SetVolumeTier sets the storage tier of a volume.
+		SetVolumeTier(volumeID int64, tier string) error
+		// PlacementStrategy returns the strategy used to place new sectors.
+		PlacementStrategy() (string, error)
+		// SetPlacementStrategy sets the strategy used to place new sectors.
+		SetPlacementStrategy(strategy string) error
+
+		// LostSectors returns a paginated list of sectors that have been
+		// lost and not restored, sorted by the time they were lost, and the
+		// total number of lost sectors.
+		LostSectors(limit, offset int) ([]LostSector, int, error)
+		// MarkSectorsLost records that the sectors are missing or corrupt.
+		// Sectors that are not stored are ignored.
+		MarkSectorsLost(roots []types.Hash256, cause LostSectorCause) error
+		// ClearLostSector removes the lost record of a restored sector.
+		ClearLostSector(root types.Hash256) error
+		// ClearVerifiedSectors removes the lost records of sectors that were
+		// found missing or corrupt in place and have since been read
+		// correctly at the same location.
+		ClearVerifiedSectors(locations []SectorLocation) error
+
+		// HotSectors returns up to limit sectors stored in bulk volumes that
+		// have been read at least minReads times and were last read after
+		// readAfter, sorted by read count.
+		HotSectors(minReads uint64, readAfter time.Time, limit int) ([]SectorLocation, error)
+		// ColdSectors returns up to limit sectors stored in fast volumes that
+		// have not been read or written since accessBefore, sorted by last
+		// access.
+		ColdSectors(accessBefore time.Time, limit int) ([]SectorLocation, error)
+		// TierSector moves a sector to an empty location in a volume of the
+		// given tier. The sector data should be copied to the new location
+		// and synced to disk during fn. If no volume in the tier has space,
+		// ErrNotEnoughStorage is returned.
+		TierSector(from SectorLocation, tier string, fn MigrateFunc) error
+		// VolumeTierStats returns the number of hot and cold sectors stored
+		// in each volume.
+		VolumeTierStats(minReads uint64, readAfter, accessBefore time.Time) ([]VolumeTierStats, error)
+		// DecaySectorReads halves the read count of sectors last read
+		// after readAfter so that older reads have less influence than
+		// recent reads. The read count of sectors last read between
+		// resetAfter and readAfter is reset.
+		DecaySectorReads(readAfter, resetAfter time.Time) error
 	}
 )
 
@@ -0,0 +1,431 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/hostd/alerts"
+	"go.uber.org/zap"
+	"lukechampine.com/frand"
+)
+
+// Placement strategies control which volume new sectors are stored in.
+// Regardless of the strategy, sectors are only stored in the volumes with the
+// highest priority that still have free space.
+const (
+	// PlacementLeastWritten stores new sectors in the empty slot that has
+	// been written to the least. This is the default strategy.
+	PlacementLeastWritten = "leastWritten"
+	// PlacementFillFirst fills volumes one at a time, preferring volumes with
+	// a higher weight.
+	PlacementFillFirst = "fillFirst"
+	// PlacementProportional stores new sectors in a random volume weighted
+	// by each volume's free space multiplied by its weight.
+	PlacementProportional = "proportional"
+	// PlacementRoundRobin alternates between volumes in proportion to
+	// their weight.
+	PlacementRoundRobin = "roundRobin"
+)
+
+// DefaultVolumeWeight is the placement weight of a newly added volume.
+const DefaultVolumeWeight = 1
+
+// ErrInvalidPlacementStrategy is returned when a placement strategy is not
+// recognized.
+var ErrInvalidPlacementStrategy = errors.New("invalid placement strategy")
+
+// A Placer chooses the volume new sectors are stored in. The zero value is
+// ready to use.
+type Placer struct {
+	mu sync.Mutex
+	// current is the current weight of each volume for smooth weighted
+	// round-robin
+	current map[int64]int64
+}
+
+// ValidatePlacementStrategy returns an error if the strategy is not
+// recognized.
+func ValidatePlacementStrategy(strategy string) error {
+	switch strategy {
+	case PlacementLeastWritten, PlacementFillFirst, PlacementProportional, PlacementRoundRobin:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidPlacementStrategy, strategy)
+	}
+}
+
+// placementTier returns the writable volumes with free space and a non-zero
+// weight in the highest priority tier.
+func placementTier(volumes []Volume) (tier []Volume) {
+	for _, v := range volumes {
+		if !v.Available || v.ReadOnly || v.Weight == 0 || v.UsedSectors >= v.TotalSectors {
+			continue
+		}
+
+		switch {
+		case len(tier) == 0 || v.Priority == tier[0].Priority:
+			tier = append(tier, v)
+		case v.Priority > tier[0].Priority:
+			tier = append(tier[:0], v)
+		}
+	}
+	return
+}
+
+// PlacementVolumes returns the IDs of the volumes new sectors may be stored
+// in: the writable volumes with free space and a non-zero weight in the
+// highest priority tier. If no such volume exists, nil is returned.
+func PlacementVolumes(volumes []Volume) []int64 {
+	tier := placementTier(volumes)
+	if len(tier) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(tier))
+	for _, v := range tier {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+// Choose returns the ID of the volume the next sector should be stored in.
+// If no volume has free space, false is returned. Choose should not be called
+// for PlacementLeastWritten since it is implemented by the volume store.
+func (p *Placer) Choose(strategy string, volumes []Volume) (int64, bool) {
+	tier := placementTier(volumes)
+	if len(tier) == 0 {
+		return 0, false
+	}
+
+	switch strategy {
+	case PlacementFillFirst:
+		best := tier[0]
+		for _, v := range tier[1:] {
+			if v.Weight > best.Weight || (v.Weight == best.Weight && v.ID < best.ID) {
+				best = v
+			}
+		}
+		return best.ID, true
+	case PlacementProportional:
+		var total uint64
+		for _, v := range tier {
+			total += (v.TotalSectors - v.UsedSectors) * v.Weight
+		}
+		n := frand.Uint64n(total)
+		for _, v := range tier {
+			share := (v.TotalSectors - v.UsedSectors) * v.Weight
+			if n < share {
+				return v.ID, true
+			}
+			n -= share
+		}
+		return tier[len(tier)-1].ID, true
+	case PlacementRoundRobin:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.current == nil {
+			p.current = make(map[int64]int64)
+		}
+
+		// smooth weighted round-robin
+		var total int64
+		best := tier[0].ID
+		for _, v := range tier {
+			p.current[v.ID] += int64(v.Weight)
+			total += int64(v.Weight)
+			if p.current[v.ID] > p.current[best] || (p.current[v.ID] == p.current[best] && v.ID < best) {
+				best = v.ID
+			}
+		}
+		p.current[best] -= total
+		return best, true
+	default:
+		panic("unsupported placement strategy " + strategy) // developer error
+	}
+}
+
+// distributeSectors distributes n sectors between volumes in proportion to
+// share without exceeding a volume's total sectors. Any sectors that do not
+// fit are returned.
+func distributeSectors(n uint64, volumes []Volume, share func(Volume) uint64, targets map[int64]uint64) uint64 {
+	active := make([]Volume, 0, len(volumes))
+	for _, v := range volumes {
+		if share(v) > 0 {
+			active = append(active, v)
+		}
+	}
+
+	for n > 0 && len(active) > 0 {
+		var total float64
+		for _, v := range active {
+			total += float64(share(v))
+		}
+
+		// cap any volume whose share exceeds its capacity and redistribute
+		// the remainder
+		capped := false
+		remaining := active[:0]
+		for _, v := range active {
+			if v.TotalSectors <= n && float64(n)*float64(share(v))/total >= float64(v.TotalSectors) {
+				targets[v.ID] = v.TotalSectors
+				n -= v.TotalSectors
+				capped = true
+				continue
+			}
+			remaining = append(remaining, v)
+		}
+		active = remaining
+		if capped {
+			continue
+		}
+
+		var assigned uint64
+		for _, v := range active {
+			t := uint64(float64(n) * float64(share(v)) / total)
+			targets[v.ID] = t
+			assigned += t
+		}
+		// distribute the rounding error one sector at a time
+		for progress := true; assigned < n && progress; {
+			progress = false
+			for _, v := range active {
+				if assigned < n && targets[v.ID] < v.TotalSectors {
+					targets[v.ID]++
+					assigned++
+					progress = true
+				}
+			}
+		}
+		n -= assigned
+		break
+	}
+	return n
+}
+
+// PlacementTargets returns the number of sectors each writable volume should
+// store for the sectors currently stored in writable volumes to match the
+// placement strategy. Sectors in read-only or unavailable volumes are not
+// moved and are not included.
+func PlacementTargets(strategy string, volumes []Volume) map[int64]uint64 {
+	var used uint64
+	targets := make(map[int64]uint64)
+	tiers := make(map[int64][]Volume)
+	var priorities []int64
+	var drained []Volume
+	for _, v := range volumes {
+		if !v.Available || v.ReadOnly {
+			continue
+		}
+		used += v.UsedSectors
+		targets[v.ID] = 0
+		if v.Weight == 0 {
+			drained = append(drained, v)
+			continue
+		}
+		if _, ok := tiers[v.Priority]; !ok {
+			priorities = append(priorities, v.Priority)
+		}
+		tiers[v.Priority] = append(tiers[v.Priority], v)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] > priorities[j] })
+
+	for _, priority := range priorities {
+		tier := tiers[priority]
+		switch strategy {
+		case PlacementFillFirst:
+			sort.Slice(tier, func(i, j int) bool {
+				if tier[i].Weight != tier[j].Weight {
+					return tier[i].Weight > tier[j].Weight
+				}
+				return tier[i].ID < tier[j].ID
+			})
+			for _, v := range tier {
+				n := min(used, v.TotalSectors)
+				targets[v.ID] = n
+				used -= n
+			}
+		case PlacementRoundRobin:
+			used = distributeSectors(used, tier, func(v Volume) uint64 { return v.Weight }, targets)
+		default:
+			used = distributeSectors(used, tier, func(v Volume) uint64 { return v.Weight * v.TotalSectors }, targets)
+		}
+	}
+
+	// volumes with a weight of zero only store sectors that do not fit
+	// anywhere else
+	for _, v := range drained {
+		n := min(used, v.TotalSectors)
+		targets[v.ID] = n
+		used -= n
+	}
+	return targets
+}
+
+// PlacementStrategy returns the current placement strategy.
+func (vm *VolumeManager) PlacementStrategy() (string, error) {
+	return vm.vs.PlacementStrategy()
+}
+
+// SetPlacementStrategy sets the placement strategy for new sectors.
+func (vm *VolumeManager) SetPlacementStrategy(strategy string) error {
+	if err := ValidatePlacementStrategy(strategy); err != nil {
+		return err
+	}
+	return vm.vs.SetPlacementStrategy(strategy)
+}
+
+// SetVolumePlacement sets the placement weight and priority of a volume.
+// Volumes with a higher priority are filled before volumes with a lower
+// priority. A weight of 0 prevents new sectors from being stored in the volume
+// unless no other volume has space.
+func (vm *VolumeManager) SetVolumePlacement(id int64, weight uint64, priority int64) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if _, err := vm.vs.Volume(id); err != nil {
+		return fmt.Errorf("failed to get volume: %w", err)
+	}
+	return vm.vs.SetVolumePlacement(id, weight, priority)
+}
+
+// RebalanceVolumes moves sectors between writable volumes to match the
+// distribution of the current placement strategy. The rebalance runs in the
+// background and its result is sent to result.
+func (vm *VolumeManager) RebalanceVolumes(ctx context.Context, result chan<- error) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	strategy, err := vm.vs.PlacementStrategy()
+	if err != nil {
+		return fmt.Errorf("failed to get placement strategy: %w", err)
+	}
+	volumes, err := vm.vs.Volumes()
+	if err != nil {
+		return fmt.Errorf("failed to get volumes: %w", err)
+	}
+	targets := PlacementTargets(strategy, volumes)
+
+	type move struct {
+		from, to int64
+		count    uint64
+	}
+	var sources, dests []Volume
+	for _, v := range volumes {
+		target, ok := targets[v.ID]
+		if !ok {
+			continue
+		} else if v.UsedSectors > target {
+			v.UsedSectors -= target // excess
+			sources = append(sources, v)
+		} else if v.UsedSectors < target {
+			v.UsedSectors = target - v.UsedSectors // deficit
+			dests = append(dests, v)
+		}
+	}
+
+	var moves []move
+	var total uint64
+	for _, src := range sources {
+		for i := range dests {
+			if src.UsedSectors == 0 {
+				break
+			} else if dests[i].UsedSectors == 0 {
+				continue
+			}
+			n := min(src.UsedSectors, dests[i].UsedSectors)
+			moves = append(moves, move{from: src.ID, to: dests[i].ID, count: n})
+			src.UsedSectors -= n
+			dests[i].UsedSectors -= n
+			total += n
+		}
+	}
+
+	log := vm.log.Named("rebalance").With(zap.String("strategy", strategy))
+	alert := alerts.Alert{
+		ID:       frand.Entropy256(),
+		Message:  "Rebalancing volumes",
+		Severity: alerts.SeverityInfo,
+		Data: map[string]any{
+			"strategy": strategy,
+			"total":    total,
+			"migrated": 0,
+			"failed":   0,
+		},
+		Timestamp: time.Now(),
+	}
+	vm.alerts.Register(alert)
+
+	go func() {
+		ctx, cancel, err := vm.tg.AddContext(ctx)
+		if err != nil {
+			vm.alerts.Dismiss(alert.ID)
+			select {
+			case result <- err:
+			default:
+			}
+			return
+		}
+		defer cancel()
+
+		start := time.Now()
+		var migrated, failed int
+		for _, m := range moves {
+			src, srcOK := vm.loadedVolume(m.from)
+			dest, destOK := vm.loadedVolume(m.to)
+			// skip volumes that were removed or are busy
+			if !srcOK || !destOK || src.Status() != VolumeStatusReady || dest.Status() != VolumeStatusReady {
+				log.Debug("skipping busy volumes", zap.Int64("from", m.from), zap.Int64("to", m.to))
+				continue
+			}
+
+			_, _, err = vm.vs.MoveSectors(ctx, m.from, m.to, int(m.count), func(from, to SectorLocation) error {
+				err := vm.migrateSector(from, to)
+				if err != nil {
+					failed++
+				} else {
+					migrated++
+				}
+				// update the alert
+				alert.Data["migrated"] = migrated
+				alert.Data["failed"] = failed
+				vm.alerts.Register(alert)
+				return err
+			})
+			if err != nil {
+				break
+			}
+		}
+		if err == nil && failed > 0 {
+			err = ErrMigrationFailed
+		}
+
+		alert.Data["migrated"] = migrated
+		alert.Data["failed"] = failed
+		alert.Data["elapsed"] = time.Since(start)
+		if err != nil {
+			log.Error("failed to rebalance volumes", zap.Int("migrated", migrated), zap.Int("failed", failed), zap.Error(err))
+			alert.Message = "Volume rebalance failed"
+			alert.Severity = alerts.SeverityError
+			alert.Data["error"] = err.Error()
+		} else {
+			log.Info("rebalanced volumes", zap.Int("migrated", migrated), zap.Duration("elapsed", time.Since(start)))
+			alert.Message = "Volumes rebalanced"
+		}
+		alert.Timestamp = time.Now()
+		vm.alerts.Register(alert)
+		select {
+		case result <- err:
+		default:
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/hostd/alerts"
+	"go.sia.tech/hostd/webhooks"
+	"go.uber.org/zap"
+)
+
+// EventVolumeAvailable is the event broadcast when an unavailable volume is
+// reconnected.
+const EventVolumeAvailable = "volumeAvailable"
+
+// openVolume opens a volume's file and validates that it is the file that was
+// added to the host. If validation fails, the volume is closed so it cannot be
+// read from or written to.
+func (vm *VolumeManager) openVolume(v *volume, vol Volume, reload bool) error {
+	if err := v.OpenVolume(vol.LocalPath, reload); err != nil {
+		return fmt.Errorf("failed to open volume: %w", err)
+	}
+
+	validate := func() error {
+		// the volume file may be larger than the recorded size if a resize
+		// was interrupted, but it should never be smaller
+		stat, err := os.Stat(vol.LocalPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat volume: %w", err)
+		} else if expected := int64(vol.TotalSectors) * proto2.SectorSize; stat.Size() < expected {
+			return fmt.Errorf("volume file is %d bytes, expected at least %d bytes", stat.Size(), expected)
+		}
+		// check that the volume file is the one that was added to the host
+		return vm.checkVolumeIdentity(vol)
+	}
+
+	if err := validate(); err != nil {
+		if err := v.Close(); err != nil {
+			vm.log.Error("failed to close volume", zap.Error(err), zap.Int64("id", vol.ID))
+		}
+		return err
+	}
+	vm.alerts.Dismiss(v.alertID("open"), v.alertID("identity"))
+	return nil
+}
+
+// setVolumeUnavailable marks a volume that failed to open as unavailable and
+// registers an alert.
+func (vm *VolumeManager) setVolumeUnavailable(v *volume, vol Volume, err error) error {
+	v.appendError(err)
+	if err := vm.vs.SetAvailable(vol.ID, false); err != nil {
+		return fmt.Errorf("failed to mark volume '%v' as unavailable: %w", vol.LocalPath, err)
+	}
+
+	alert := alerts.Alert{
+		ID:       v.alertID("open"),
+		Severity: alerts.SeverityError,
+		Message:  "Failed to open volume",
+		Data: map[string]any{
+			"volumeID": vol.ID,
+			"volume":   vol.LocalPath,
+			"error":    err.Error(),
+		},
+		Timestamp: time.Now(),
+	}
+	if errors.Is(err, ErrVolumeIdentityMismatch) {
+		alert.ID = v.alertID("identity")
+		alert.Severity = alerts.SeverityCritical
+		alert.Message = "Volume identity mismatch"
+		alert.Data["uuid"] = vol.UUID
+	}
+	vm.alerts.Register(alert)
+	return nil
+}
+
+// reconnectVolumes attempts to open any unavailable volumes. Volumes that
+// open and pass validation are marked as available.
+func (vm *VolumeManager) reconnectVolumes() error {
+	volumes, err := vm.vs.Volumes()
+	if err != nil {
+		return fmt.Errorf("failed to get volumes: %w", err)
+	}
+
+	for _, vol := range volumes {
+		v, ok := vm.loadedVolume(vol.ID)
+		if !ok || v.Status() != VolumeStatusUnavailable {
+			continue
+		}
+
+		log := vm.log.Named("reconnect").With(zap.Int64("volumeID", vol.ID), zap.String("path", vol.LocalPath))
+		if err := vm.openVolume(v, vol, false); err != nil {
+			log.Debug("volume still unavailable", zap.Error(err))
+			// update the alert if the volume is now the wrong file
+			if errors.Is(err, ErrVolumeIdentityMismatch) {
+				if err := vm.setVolumeUnavailable(v, vol, err); err != nil {
+					log.Error("failed to mark volume as unavailable", zap.Error(err))
+				}
+			}
+			continue
+		}
+
+		if err := vm.vs.SetAvailable(vol.ID, true); err != nil {
+			return fmt.Errorf("failed to mark volume '%v' as available: %w", vol.LocalPath, err)
+		} else if err := v.SetStatus(VolumeStatusReady); err != nil {
+			// the volume's status was changed while it was being opened
+			log.Debug("failed to set volume status", zap.Error(err))
+			continue
+		}
+		log.Info("reconnected volume")
+
+		meta := VolumeMeta{
+			Volume:      vol,
+			VolumeStats: v.Stats(),
+		}
+		meta.Available = true
+		if err := vm.events.BroadcastEvent(EventVolumeAvailable, webhooks.ScopeVolumesAvailable, meta); err != nil {
+			log.Error("failed to broadcast volume event", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// watchUnavailableVolumes periodically attempts to reconnect unavailable
+// volumes.
+func (vm *VolumeManager) watchUnavailableVolumes() {
+	ctx, cancel, err := vm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vm.reconnectInterval):
+		}
+
+		if err := vm.reconnectVolumes(); err != nil {
+			vm.log.Error("failed to reconnect volumes", zap.Error(err))
+		}
+	}
+}
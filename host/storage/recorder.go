@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.uber.org/zap"
 )
 
@@ -13,13 +14,22 @@ type (
 	sectorAccessRecorder struct {
 		store VolumeStore
 		log   *zap.Logger
+		// trackReads enables tracking the reads of each sector. Reads are
+		// only used by sector tiering.
+		trackReads bool
 
 		mu sync.Mutex
 		r  uint64
 		w  uint64
 
-		cacheHit  uint64
-		cacheMiss uint64
+		cacheHit      uint64
+		cacheMiss     uint64
+		diskCacheHit  uint64
+		diskCacheMiss uint64
+
+		// sectorReads tracks the number of times each sector was read
+		// since the last flush
+		sectorReads map[types.Hash256]uint64
 	}
 )
 
@@ -28,16 +38,26 @@ func (sr *sectorAccessRecorder) Flush() {
 	sr.mu.Lock()
 	r, w := sr.r, sr.w
 	cacheHit, cacheMiss := sr.cacheHit, sr.cacheMiss
+	diskCacheHit, diskCacheMiss := sr.diskCacheHit, sr.diskCacheMiss
 	sr.r, sr.w = 0, 0
 	sr.cacheHit, sr.cacheMiss = 0, 0
+	sr.diskCacheHit, sr.diskCacheMiss = 0, 0
+	sectorReads := sr.sectorReads
+	sr.sectorReads = nil
 	sr.mu.Unlock()
 
+	if len(sectorReads) > 0 {
+		if err := sr.store.RecordSectorReads(sectorReads, time.Now()); err != nil {
+			sr.log.Error("failed to persist sector reads", zap.Error(err))
+		}
+	}
+
 	// no need to persist if there is no change
-	if r == 0 && w == 0 {
+	if r == 0 && w == 0 && cacheHit == 0 && cacheMiss == 0 && diskCacheHit == 0 && diskCacheMiss == 0 {
 		return
 	}
 
-	if err := sr.store.IncrementSectorStats(r, w, cacheHit, cacheMiss); err != nil {
+	if err := sr.store.IncrementSectorStats(r, w, cacheHit, cacheMiss, diskCacheHit, diskCacheMiss); err != nil {
 		sr.log.Error("failed to persist sector access", zap.Error(err))
 		return
 	}
@@ -57,6 +77,21 @@ func (sr *sectorAccessRecorder) AddWrite() {
 	sr.w++
 }
 
+// AddSectorRead records a read of a sector. Reads are used to track how
+// frequently and recently each sector is accessed. Reads are ignored if read
+// tracking is disabled.
+func (sr *sectorAccessRecorder) AddSectorRead(root types.Hash256) {
+	if !sr.trackReads {
+		return
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.sectorReads == nil {
+		sr.sectorReads = make(map[types.Hash256]uint64)
+	}
+	sr.sectorReads[root]++
+}
+
 func (sr *sectorAccessRecorder) AddCacheHit() {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
@@ -69,6 +104,18 @@ func (sr *sectorAccessRecorder) AddCacheMiss() {
 	sr.cacheMiss++
 }
 
+func (sr *sectorAccessRecorder) AddDiskCacheHit() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.diskCacheHit++
+}
+
+func (sr *sectorAccessRecorder) AddDiskCacheMiss() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.diskCacheMiss++
+}
+
 // Run starts the recorder, flushing data at regular intervals.
 func (sr *sectorAccessRecorder) Run(stop <-chan struct{}) {
 	t := time.NewTicker(flushInterval)
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.uber.org/zap"
+)
+
+type (
+	// An UnmatchedSector is a non-empty slot in a volume whose data did not
+	// match any known sector root during recovery.
+	UnmatchedSector struct {
+		Index uint64        `json:"index"`
+		Root  types.Hash256 `json:"root"`
+	}
+
+	// A SectorRecovery is the result of rebuilding the sector index of a
+	// volume from its data.
+	SectorRecovery struct {
+		VolumeID int64 `json:"volumeID"`
+
+		TotalSlots     uint64 `json:"totalSlots"`
+		EmptySlots     uint64 `json:"emptySlots"`
+		Recovered      uint64 `json:"recovered"`
+		AlreadyIndexed uint64 `json:"alreadyIndexed"`
+		// Unreferenced is the number of recovered sectors that are not
+		// referenced by a contract or temporary storage. Unreferenced
+		// sectors will be pruned unless a temporary expiration was set.
+		Unreferenced uint64            `json:"unreferenced"`
+		Unmatched    []UnmatchedSector `json:"unmatched"`
+	}
+)
+
+// recoveryVolume returns the volume stored at localPath. If the volume is not
+// known to the store, it is added using the size of the volume file.
+func (vm *VolumeManager) recoveryVolume(localPath string) (Volume, *volume, error) {
+	volumes, err := vm.vs.Volumes()
+	if err != nil {
+		return Volume{}, nil, fmt.Errorf("failed to get volumes: %w", err)
+	}
+	for _, v := range volumes {
+		if v.LocalPath != localPath {
+			continue
+		}
+
+		vol, ok := vm.loadedVolume(v.ID)
+		if !ok {
+			return Volume{}, nil, fmt.Errorf("volume %v not found", v.ID)
+		} else if status := vol.Status(); status != VolumeStatusReady {
+			return Volume{}, nil, fmt.Errorf("volume is %v", status)
+		}
+		return v, vol, nil
+	}
+
+	// the volume is not in the database, add it using the size of the
+	// existing volume file
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return Volume{}, nil, fmt.Errorf("failed to stat volume file: %w", err)
+	} else if stat.Size()%proto2.SectorSize != 0 {
+		return Volume{}, nil, fmt.Errorf("volume file size %v is not a multiple of the sector size", stat.Size())
+	}
+	maxSectors := uint64(stat.Size() / proto2.SectorSize)
+	if maxSectors == 0 {
+		return Volume{}, nil, errors.New("volume file is empty")
+	}
+
+	f, err := os.OpenFile(localPath, os.O_RDWR, 0700)
+	if err != nil {
+		return Volume{}, nil, fmt.Errorf("failed to open volume file: %w", err)
+	}
+
+	volumeID, err := vm.vs.AddVolume(localPath, false)
+	if err != nil {
+		f.Close()
+		return Volume{}, nil, fmt.Errorf("failed to add volume to store: %w", err)
+	}
+
+	vm.mu.Lock()
+	vol := vm.initVolume(volumeID, VolumeStatusReady, f)
+	vm.mu.Unlock()
+
+	// add the volume's indices in batches to avoid holding a lock for too
+	// long
+	for current := uint64(0); current < maxSectors; current += resizeBatchSize {
+		target := current + resizeBatchSize
+		if target > maxSectors {
+			target = maxSectors
+		}
+		if err := vm.vs.GrowVolume(volumeID, target); err != nil {
+			return Volume{}, nil, fmt.Errorf("failed to expand volume metadata: %w", err)
+		}
+	}
+	// adopt the volume's existing identity if it belongs to this host
+	if err := vm.checkVolumeIdentity(Volume{ID: volumeID, LocalPath: localPath}); err != nil {
+		return Volume{}, nil, fmt.Errorf("failed to initialize volume identity: %w", err)
+	} else if err := vm.vs.SetAvailable(volumeID, true); err != nil {
+		return Volume{}, nil, fmt.Errorf("failed to mark volume as available: %w", err)
+	}
+	v, err := vm.vs.Volume(volumeID)
+	if err != nil {
+		return Volume{}, nil, fmt.Errorf("failed to get volume: %w", err)
+	}
+	vm.log.Info("added volume for recovery", zap.Int64("volumeID", volumeID), zap.String("path", localPath), zap.Uint64("sectors", maxSectors))
+	return v, vol, nil
+}
+
+// RecoverSectors rebuilds the sector index of the volume stored at localPath
+// by hashing every non-empty slot in the volume file. A slot is matched if its
+// root is referenced by a contract or temporary storage in the store, or if it
+// is in roots. Matched sectors are added to the store at their slot. If
+// expiration is greater than zero, matched sectors that are not referenced by
+// a contract or temporary storage are added to temporary storage until that
+// height to prevent them from being pruned. The volume file is added to the
+// store if it is not already known.
+//
+// Recovery is not safe to run while the host is accepting new sectors.
+func (vm *VolumeManager) RecoverSectors(ctx context.Context, localPath string, roots []types.Hash256, expiration uint64) (SectorRecovery, error) {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return SectorRecovery{}, err
+	}
+	defer done()
+
+	v, vol, err := vm.recoveryVolume(localPath)
+	if err != nil {
+		return SectorRecovery{}, err
+	}
+	log := vm.log.Named("recover").With(zap.Int64("volumeID", v.ID), zap.String("path", localPath))
+
+	supplied := make(map[types.Hash256]bool, len(roots))
+	for _, root := range roots {
+		supplied[root] = true
+	}
+
+	result := SectorRecovery{
+		VolumeID:   v.ID,
+		TotalSlots: v.TotalSectors,
+	}
+
+	var empty [proto2.SectorSize]byte
+	lastLog := time.Now()
+	for index := uint64(0); index < v.TotalSectors; index++ {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		if time.Since(lastLog) > 30*time.Second {
+			log.Info("recovering sectors", zap.Uint64("index", index), zap.Uint64("recovered", result.Recovered), zap.Int("unmatched", len(result.Unmatched)))
+			lastLog = time.Now()
+		}
+
+		sector, err := vol.ReadSector(index)
+		if err != nil {
+			return result, fmt.Errorf("failed to read sector %v: %w", index, err)
+		} else if *sector == empty {
+			result.EmptySlots++
+			continue
+		}
+
+		root := proto2.SectorRoot(sector)
+		referenced, err := vm.vs.HasSector(root)
+		if err != nil {
+			return result, fmt.Errorf("failed to check sector %v: %w", root, err)
+		} else if !referenced && !supplied[root] {
+			result.Unmatched = append(result.Unmatched, UnmatchedSector{Index: index, Root: root})
+			continue
+		}
+
+		recovered, err := vm.vs.RecoverSector(v.ID, index, root)
+		if err != nil {
+			return result, fmt.Errorf("failed to recover sector %v: %w", root, err)
+		} else if !recovered {
+			result.AlreadyIndexed++
+			continue
+		}
+		result.Recovered++
+
+		if !referenced {
+			result.Unreferenced++
+			if expiration > 0 {
+				if err := vm.vs.AddTemporarySectors([]TempSector{{Root: root, Expiration: expiration}}); err != nil {
+					return result, fmt.Errorf("failed to add temporary sector %v: %w", root, err)
+				}
+			}
+		}
+		log.Debug("recovered sector", zap.Uint64("index", index), zap.Stringer("root", root))
+	}
+
+	log.Info("sector recovery complete", zap.Uint64("recovered", result.Recovered), zap.Uint64("alreadyIndexed", result.AlreadyIndexed), zap.Uint64("empty", result.EmptySlots), zap.Int("unmatched", len(result.Unmatched)))
+	return result, nil
+}
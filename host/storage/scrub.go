@@ -0,0 +1,411 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/alerts"
+	"go.uber.org/zap"
+)
+
+// ScrubStatus is the status of a volume scrub.
+const (
+	ScrubStatusRunning  = "running"
+	ScrubStatusPaused   = "paused"
+	ScrubStatusComplete = "complete"
+)
+
+// scrubBatchSize is the number of sector locations retrieved from the store
+// at a time. Progress is persisted after every batch.
+const scrubBatchSize = 64
+
+type (
+	// A VolumeScrub tracks the progress of verifying every stored sector in
+	// a volume.
+	VolumeScrub struct {
+		VolumeID int64  `json:"volumeID"`
+		Status   string `json:"status"`
+		// NextIndex is the volume index the scrub will resume from.
+		NextIndex uint64 `json:"nextIndex"`
+
+		CheckedSectors uint64 `json:"checkedSectors"`
+		CorruptSectors uint64 `json:"corruptSectors"`
+		FailedReads    uint64 `json:"failedReads"`
+
+		StartTime  time.Time `json:"startTime"`
+		UpdateTime time.Time `json:"updateTime"`
+		EndTime    time.Time `json:"endTime"`
+	}
+
+	// A ScrubError is a sector that failed verification during a volume
+	// scrub.
+	ScrubError struct {
+		VolumeID   int64         `json:"volumeID"`
+		Index      uint64        `json:"index"`
+		Root       types.Hash256 `json:"root"`
+		ActualRoot types.Hash256 `json:"actualRoot"`
+		Error      string        `json:"error"`
+		Timestamp  time.Time     `json:"timestamp"`
+	}
+
+	scrubJob struct {
+		cancel context.CancelFunc
+		paused bool
+		done   chan struct{}
+	}
+)
+
+var (
+	// ErrScrubNotFound is returned when a volume has never been scrubbed.
+	ErrScrubNotFound = errors.New("scrub not found")
+	// ErrScrubRunning is returned when trying to start a scrub on a volume
+	// that is already being scrubbed.
+	ErrScrubRunning = errors.New("scrub already running")
+	// ErrScrubNotRunning is returned when trying to pause a scrub that is
+	// not running.
+	ErrScrubNotRunning = errors.New("scrub not running")
+)
+
+// scrubSector reads a sector from the volume and verifies its Merkle root.
+// verified is true if the sector's data matches its root. A nil ScrubError is
+// returned if the sector is valid or if the sector was moved or removed while
+// it was being checked.
+func (vm *VolumeManager) scrubSector(vol *volume, loc SectorLocation) (verified bool, _ *ScrubError, _ error) {
+	var scrubErr *ScrubError
+	sector, err := vol.ReadSector(loc.Index)
+	if err != nil {
+		scrubErr = &ScrubError{Error: err.Error()}
+	} else if root := proto2.SectorRoot(sector); root != loc.Root {
+		scrubErr = &ScrubError{ActualRoot: root, Error: "sector data corrupt"}
+	} else {
+		return true, nil, nil
+	}
+
+	// the sector may have been moved or removed after its location was
+	// retrieved. Confirm the location before recording an error.
+	current, err := vm.vs.SectorLocation(loc.Root)
+	if errors.Is(err, ErrSectorNotFound) {
+		return false, nil, nil
+	} else if err != nil {
+		return false, nil, fmt.Errorf("failed to get sector location: %w", err)
+	} else if current.Volume != loc.Volume || current.Index != loc.Index {
+		return false, nil, nil
+	}
+
+	scrubErr.VolumeID = loc.Volume
+	scrubErr.Index = loc.Index
+	scrubErr.Root = loc.Root
+	scrubErr.Timestamp = time.Now()
+	return false, scrubErr, nil
+}
+
+// registerScrubAlert registers an alert with the progress of a volume scrub
+// and the contracts affected by any bad sectors.
+func (vm *VolumeManager) registerScrubAlert(vol *volume, scrub VolumeScrub, message string) {
+	alert := alerts.Alert{
+		ID:       vol.alertID("scrub"),
+		Severity: alerts.SeverityInfo,
+		Message:  message,
+		Data: map[string]any{
+			"volumeID":       scrub.VolumeID,
+			"volume":         vol.Location(),
+			"checkedSectors": scrub.CheckedSectors,
+			"corruptSectors": scrub.CorruptSectors,
+			"failedReads":    scrub.FailedReads,
+		},
+		Timestamp: time.Now(),
+	}
+
+	if scrub.CorruptSectors > 0 || scrub.FailedReads > 0 {
+		alert.Severity = alerts.SeverityError
+
+		scrubErrs, err := vm.vs.ScrubErrors(scrub.VolumeID, 1000, 0)
+		if err != nil {
+			vm.log.Error("failed to get scrub errors", zap.Int64("volumeID", scrub.VolumeID), zap.Error(err))
+		}
+		contracts := make(map[types.FileContractID]bool)
+		sectors := make([]types.Hash256, 0, len(scrubErrs))
+		for _, se := range scrubErrs {
+			sectors = append(sectors, se.Root)
+			refs, err := vm.vs.SectorReferences(se.Root)
+			if err != nil {
+				vm.log.Error("failed to get sector references", zap.Stringer("root", se.Root), zap.Error(err))
+				continue
+			}
+			for _, id := range refs.Contracts {
+				contracts[id] = true
+			}
+		}
+		contractIDs := make([]types.FileContractID, 0, len(contracts))
+		for id := range contracts {
+			contractIDs = append(contractIDs, id)
+		}
+		alert.Data["sectors"] = sectors
+		alert.Data["contracts"] = contractIDs
+	}
+	vm.alerts.Register(alert)
+}
+
+// scrubVolume verifies every occupied sector in a volume starting at
+// scrub.NextIndex. Progress is persisted after every batch.
+func (vm *VolumeManager) scrubVolume(ctx context.Context, vol *volume, scrub *VolumeScrub) error {
+	log := vm.log.Named("scrub").With(zap.Int64("volumeID", scrub.VolumeID))
+
+	// delay is the minimum time spent on each sector to limit the I/O rate
+	var delay time.Duration
+	if vm.scrubRate > 0 {
+		delay = time.Duration(float64(time.Second) * proto2.SectorSize / float64(vm.scrubRate))
+	}
+
+	for {
+		locations, err := vm.vs.ScrubSectors(scrub.VolumeID, scrub.NextIndex, scrubBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get sectors: %w", err)
+		} else if len(locations) == 0 {
+			return nil
+		}
+
+		var foundErrors bool
+		var verified []SectorLocation
+		for _, loc := range locations {
+			start := time.Now()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			ok, scrubErr, err := vm.scrubSector(vol, loc)
+			if err != nil {
+				return err
+			} else if ok {
+				verified = append(verified, loc)
+			} else if scrubErr != nil {
+				log.Warn("bad sector", zap.Stringer("root", loc.Root), zap.Uint64("index", loc.Index), zap.String("error", scrubErr.Error))
+				cause := LostSectorCauseScrubCorrupt
+				if scrubErr.ActualRoot == (types.Hash256{}) {
+					scrub.FailedReads++
+					cause = LostSectorCauseScrubReadFailed
+				} else {
+					scrub.CorruptSectors++
+				}
+				if err := vm.vs.AddScrubError(*scrubErr); err != nil {
+					return fmt.Errorf("failed to record scrub error: %w", err)
+				} else if err := vm.vs.MarkSectorsLost([]types.Hash256{loc.Root}, cause); err != nil {
+					return fmt.Errorf("failed to record lost sector: %w", err)
+				}
+				foundErrors = true
+			}
+			scrub.CheckedSectors++
+			scrub.NextIndex = loc.Index + 1
+
+			if wait := delay - time.Since(start); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		// sectors that were previously found missing or corrupt in place,
+		// for example because of a transient read failure, are no longer
+		// lost if they were read correctly
+		if err := vm.vs.ClearVerifiedSectors(verified); err != nil {
+			return fmt.Errorf("failed to clear verified sectors: %w", err)
+		}
+
+		scrub.UpdateTime = time.Now()
+		if err := vm.vs.UpdateVolumeScrub(*scrub); err != nil {
+			return fmt.Errorf("failed to update scrub progress: %w", err)
+		}
+		log.Debug("scrubbed sectors", zap.Uint64("checked", scrub.CheckedSectors), zap.Uint64("nextIndex", scrub.NextIndex))
+		if foundErrors {
+			vm.registerScrubAlert(vol, *scrub, "Scrubbing volume")
+		}
+	}
+}
+
+// startScrub starts a goroutine to scrub the volume. The volume manager's
+// mutex must be held before calling this function.
+func (vm *VolumeManager) startScrub(vol *volume, scrub VolumeScrub) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &scrubJob{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	vm.scrubs[scrub.VolumeID] = job
+
+	go func() {
+		defer close(job.done)
+		defer cancel()
+
+		log := vm.log.Named("scrub").With(zap.Int64("volumeID", scrub.VolumeID))
+		ctx, done, err := vm.tg.AddContext(ctx)
+		if err != nil {
+			vm.mu.Lock()
+			delete(vm.scrubs, scrub.VolumeID)
+			vm.mu.Unlock()
+			return
+		}
+		defer done()
+
+		vm.registerScrubAlert(vol, scrub, "Scrubbing volume")
+		err = vm.scrubVolume(ctx, vol, &scrub)
+
+		vm.mu.Lock()
+		paused := job.paused
+		delete(vm.scrubs, scrub.VolumeID)
+		vm.mu.Unlock()
+
+		var message string
+		switch {
+		case err == nil:
+			scrub.Status = ScrubStatusComplete
+			scrub.EndTime = time.Now()
+			message = "Volume scrub complete"
+			log.Info("volume scrub complete", zap.Uint64("checked", scrub.CheckedSectors), zap.Uint64("corrupt", scrub.CorruptSectors), zap.Uint64("failedReads", scrub.FailedReads))
+		case paused:
+			scrub.Status = ScrubStatusPaused
+			message = "Volume scrub paused"
+		case errors.Is(err, context.Canceled):
+			// the volume manager is shutting down. Leave the status as
+			// running so the scrub resumes on the next start.
+			message = "Volume scrub interrupted"
+		default:
+			scrub.Status = ScrubStatusPaused
+			message = "Volume scrub failed"
+			log.Error("volume scrub failed", zap.Error(err))
+		}
+
+		scrub.UpdateTime = time.Now()
+		if err := vm.vs.UpdateVolumeScrub(scrub); err != nil {
+			log.Error("failed to update scrub progress", zap.Error(err))
+		}
+		vm.registerScrubAlert(vol, scrub, message)
+	}()
+}
+
+// stopScrub pauses the scrub of a volume and waits for it to stop. If the
+// volume is not being scrubbed, ErrScrubNotRunning is returned.
+func (vm *VolumeManager) stopScrub(id int64) error {
+	vm.mu.Lock()
+	job, ok := vm.scrubs[id]
+	if ok {
+		job.paused = true
+		job.cancel()
+	}
+	vm.mu.Unlock()
+	if !ok {
+		return ErrScrubNotRunning
+	}
+	<-job.done
+	return nil
+}
+
+// resumeVolumeScrubs restarts any scrubs that were running when the volume
+// manager was last closed.
+func (vm *VolumeManager) resumeVolumeScrubs() error {
+	scrubs, err := vm.vs.VolumeScrubs()
+	if err != nil {
+		return fmt.Errorf("failed to get volume scrubs: %w", err)
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	for _, scrub := range scrubs {
+		if scrub.Status != ScrubStatusRunning {
+			continue
+		}
+		vol, ok := vm.loadedVolume(scrub.VolumeID)
+		if !ok || vol.Status() != VolumeStatusReady {
+			continue
+		}
+		vm.log.Debug("resuming volume scrub", zap.Int64("volumeID", scrub.VolumeID), zap.Uint64("nextIndex", scrub.NextIndex))
+		vm.startScrub(vol, scrub)
+	}
+	return nil
+}
+
+// StartScrub starts verifying every stored sector in a volume against its
+// Merkle root. If the volume's previous scrub was paused, it is resumed.
+func (vm *VolumeManager) StartScrub(id int64) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	vol, ok := vm.loadedVolume(id)
+	if !ok {
+		return fmt.Errorf("volume %v not found", id)
+	} else if status := vol.Status(); status != VolumeStatusReady {
+		return fmt.Errorf("volume is %v", status)
+	} else if _, ok := vm.scrubs[id]; ok {
+		return ErrScrubRunning
+	}
+
+	scrub, err := vm.vs.VolumeScrub(id)
+	if errors.Is(err, ErrScrubNotFound) || (err == nil && scrub.Status == ScrubStatusComplete) {
+		// start a new scrub from the beginning of the volume
+		scrub = VolumeScrub{
+			VolumeID:  id,
+			StartTime: time.Now(),
+		}
+		if err := vm.vs.ClearScrubErrors(id); err != nil {
+			return fmt.Errorf("failed to clear scrub errors: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get volume scrub: %w", err)
+	}
+
+	scrub.Status = ScrubStatusRunning
+	scrub.UpdateTime = time.Now()
+	if err := vm.vs.UpdateVolumeScrub(scrub); err != nil {
+		return fmt.Errorf("failed to update volume scrub: %w", err)
+	}
+	vm.startScrub(vol, scrub)
+	return nil
+}
+
+// PauseScrub pauses a running volume scrub. The scrub can be resumed with
+// StartScrub.
+func (vm *VolumeManager) PauseScrub(id int64) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return vm.stopScrub(id)
+}
+
+// VolumeScrub returns the progress of a volume's most recent scrub.
+func (vm *VolumeManager) VolumeScrub(id int64) (VolumeScrub, error) {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return VolumeScrub{}, err
+	}
+	defer done()
+
+	return vm.vs.VolumeScrub(id)
+}
+
+// ScrubErrors returns the sectors that failed verification during a volume's
+// most recent scrub.
+func (vm *VolumeManager) ScrubErrors(id int64, limit, offset int) ([]ScrubError, error) {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return vm.vs.ScrubErrors(id, limit, offset)
+}
@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/hostd/alerts"
 	"go.sia.tech/hostd/internal/threadgroup"
+	"go.sia.tech/hostd/webhooks"
 	"go.uber.org/zap"
 	"lukechampine.com/frand"
 )
@@ -31,7 +32,11 @@ const (
 	VolumeStatusCreating    = "creating"
 	VolumeStatusResizing    = "resizing"
 	VolumeStatusRemoving    = "removing"
-	VolumeStatusReady       = "ready"
+	VolumeStatusEvacuating  = "evacuating"
+	// VolumeStatusReceiving is the status of the destination of an
+	// evacuation.
+	VolumeStatusReceiving = "receiving"
+	VolumeStatusReady     = "ready"
 )
 
 type (
@@ -41,6 +46,11 @@ type (
 		Dismiss(...types.Hash256)
 	}
 
+	// An EventReporter broadcasts events to subscribers.
+	EventReporter interface {
+		BroadcastEvent(event string, scope string, data any) error
+	}
+
 	// A SectorLocation is a location of a sector within a volume.
 	SectorLocation struct {
 		ID     int64
@@ -65,31 +75,97 @@ type (
 
 	// A VolumeManager manages storage using local volumes.
 	VolumeManager struct {
-		cacheHits     uint64 // ensure 64-bit alignment on 32-bit systems
-		cacheMisses   uint64
-		cacheSize     int
-		pruneInterval time.Duration
+		cacheHits       uint64 // ensure 64-bit alignment on 32-bit systems
+		cacheMisses     uint64
+		diskCacheHits   uint64
+		diskCacheMisses uint64
+		cacheSize       int
+		pruneInterval   time.Duration
+		scrubRate       uint64 // bytes per second
+		hostKey         types.PublicKey
+
+		reconnectInterval   time.Duration
+		autoGrowInterval    time.Duration
+		healthCheckInterval time.Duration
+		resumeScrubs        bool
+		tieringPolicy       TieringPolicy
+		syncPolicy          SyncPolicy
 
 		vs       VolumeStore
 		recorder *sectorAccessRecorder
 
 		alerts Alerts
+		events EventReporter
 		tg     *threadgroup.ThreadGroup
 		log    *zap.Logger
 
-		mu      sync.Mutex // protects the following fields
-		volumes map[int64]*volume
-		// changedVolumes tracks volumes that need to be fsynced
-		changedVolumes map[int64]bool
-		cache          *lru.Cache[types.Hash256, *[proto2.SectorSize]byte] // Added cache
+		// volumes is a copy-on-write map of the loaded volumes. It is read
+		// without locking so that I/O to different volumes does not
+		// contend on the volume manager's mutex. Changes to the map must be
+		// made while holding mu.
+		volumes atomic.Pointer[map[int64]*volume]
+		cache   *lru.Cache[types.Hash256, *[proto2.SectorSize]byte] // Added cache
+		// diskCache is an optional second-level cache on a fast disk.
+		// Changes must be made while holding mu.
+		diskCache atomic.Pointer[diskCache]
+
+		mu sync.Mutex // serializes volume changes and protects the following fields
+
+		// scrubs tracks volumes that are being scrubbed
+		scrubs map[int64]*scrubJob
+		// backups tracks the progress of volume backups and restores
+		backups map[int64]VolumeBackup
+		tiering tieringState
+
+		syncMu sync.Mutex // protects the following fields
+		// syncBatch is the pending group commit in the group sync mode
+		syncBatch   *syncBatch
+		syncMetrics SyncMetrics
 	}
 )
 
+// loadedVolume returns the loaded volume with the given ID.
+func (vm *VolumeManager) loadedVolume(id int64) (*volume, bool) {
+	v, ok := (*vm.volumes.Load())[id]
+	return v, ok
+}
+
+// loadedVolumes returns a snapshot of the loaded volumes. The returned map
+// must not be modified.
+func (vm *VolumeManager) loadedVolumes() map[int64]*volume {
+	return *vm.volumes.Load()
+}
+
+// setLoadedVolume adds or replaces a loaded volume. The volume mutex must be
+// held before calling this function.
+func (vm *VolumeManager) setLoadedVolume(id int64, v *volume) {
+	current := vm.loadedVolumes()
+	volumes := make(map[int64]*volume, len(current)+1)
+	for k, existing := range current {
+		volumes[k] = existing
+	}
+	volumes[id] = v
+	vm.volumes.Store(&volumes)
+}
+
+// removeLoadedVolume removes a loaded volume. The volume mutex must be held
+// before calling this function.
+func (vm *VolumeManager) removeLoadedVolume(id int64) {
+	current := vm.loadedVolumes()
+	volumes := make(map[int64]*volume, len(current))
+	for k, existing := range current {
+		if k != id {
+			volumes[k] = existing
+		}
+	}
+	vm.volumes.Store(&volumes)
+}
+
 // initVolume adds a volume to the volume manager. If the volume is already
 // added, it is returned. The volume mutex must be held before calling this
 // function.
 func (vm *VolumeManager) initVolume(id int64, status string, d volumeData) *volume {
-	if v, ok := vm.volumes[id]; ok {
+	if v, ok := vm.loadedVolume(id); ok {
 		return v
 	}
 	v := &volume{
@@ -99,7 +175,7 @@ func (vm *VolumeManager) initVolume(id int64, status string, d volumeData) *volu
 		},
 		data: d,
 	}
-	vm.volumes[id] = v
+	vm.setLoadedVolume(id, v)
 	return v
 }
 
@@ -121,28 +197,14 @@ func (vm *VolumeManager) loadVolumes() error {
 	for _, vol := range volumes {
 		// if the volume has not been loaded yet, create a new volume
 		v := vm.initVolume(vol.ID, VolumeStatusUnavailable, nil)
-		if err := v.OpenVolume(vol.LocalPath, false); err != nil {
-			v.appendError(fmt.Errorf("failed to open volume: %w", err))
+		if err := vm.openVolume(v, vol, false); err != nil {
 			vm.log.Error("unable to open volume", zap.Error(err), zap.Int64("id", vol.ID), zap.String("path", vol.LocalPath))
-			// mark the volume as unavailable
-			if err := vm.vs.SetAvailable(vol.ID, false); err != nil {
-				return fmt.Errorf("failed to mark volume '%v' as unavailable: %w", vol.LocalPath, err)
+			if err := vm.setVolumeUnavailable(v, vol, err); err != nil {
+				return err
 			}
-
-			// register an alert
-			vm.alerts.Register(alerts.Alert{
-				ID:       frand.Entropy256(),
-				Severity: alerts.SeverityError,
-				Message:  "Failed to open volume",
-				Data: map[string]any{
-					"volume": vol.LocalPath,
-					"error":  err.Error(),
-				},
-				Timestamp: time.Now(),
-			})
-
 			continue
 		}
+
 		// mark the volume as available
 		if err := vm.vs.SetAvailable(vol.ID, true); err != nil {
 			return fmt.Errorf("failed to mark volume '%v' as available: %w", vol.LocalPath, err)
@@ -173,9 +235,7 @@ func (vm *VolumeManager) migrateSector(from, to SectorLocation) error {
 		panic("migrateSector called with mismatched roots")
 	}
 
-	vm.mu.Lock()
-	vol, ok := vm.volumes[to.Volume]
-	vm.mu.Unlock()
+	vol, ok := vm.loadedVolume(to.Volume)
 	if !ok {
 		return fmt.Errorf("volume %v not found", from.Volume)
 	}
@@ -188,8 +248,10 @@ func (vm *VolumeManager) migrateSector(from, to SectorLocation) error {
 	return nil
 }
 
-// growVolume grows a volume by adding sectors to the end of the volume.
-func (vm *VolumeManager) growVolume(ctx context.Context, id int64, volume *volume, oldMaxSectors, newMaxSectors uint64) error {
+// growVolume grows a volume by adding sectors to the end of the volume. If
+// the filesystem supports it, the space for the new sectors is reserved
+// before the volume is grown.
+func (vm *VolumeManager) growVolume(ctx context.Context, id int64, volume *volume, oldMaxSectors, newMaxSectors uint64) (err error) {
 	log := vm.log.Named("grow").With(zap.Int64("volumeID", id), zap.Uint64("start", oldMaxSectors), zap.Uint64("end", newMaxSectors))
 	if oldMaxSectors > newMaxSectors { // sanity check
 		log.Panic("old sectors must be less than new sectors")
@@ -213,7 +275,34 @@ func (vm *VolumeManager) growVolume(ctx context.Context, id int64, volume *volum
 	// responsibility to register a completion alert
 	defer vm.alerts.Dismiss(alert.ID)
 
-	for current := oldMaxSectors; current < newMaxSectors; current += resizeBatchSize {
+	// reserve the space for the new sectors up front. This fails early if
+	// the filesystem does not have enough free space instead of leaving a
+	// sparse file that fails on a later write.
+	batchSize := uint64(resizeBatchSize)
+	current := oldMaxSectors
+	err = volume.Preallocate(oldMaxSectors, newMaxSectors)
+	switch {
+	case err == nil:
+		// the space is already reserved, so the volume can be grown in
+		// larger batches
+		batchSize = allocateBatchSize
+		defer func() {
+			if err != nil {
+				// release the space reserved past the end of the volume
+				if err := volume.Resize(current); err != nil {
+					log.Warn("failed to release preallocated space", zap.Error(err))
+				}
+			}
+		}()
+	case errors.Is(err, errPreallocateNotSupported):
+		log.Debug("preallocation not supported, growing volume with truncate")
+	case isNotEnoughStorageErr(err):
+		return fmt.Errorf("not enough free space to add %d sectors: %w", newMaxSectors-oldMaxSectors, ErrNotEnoughStorage)
+	default:
+		return fmt.Errorf("failed to preallocate volume space: %w", err)
+	}
+
+	for ; current < newMaxSectors; current += batchSize {
 		// stop early if the context is cancelled
 		select {
 		case <-ctx.Done():
@@ -221,7 +310,7 @@ func (vm *VolumeManager) growVolume(ctx context.Context, id int64, volume *volum
 		default:
 		}
 
-		target := current + resizeBatchSize
+		target := current + batchSize
 		if target > newMaxSectors {
 			target = newMaxSectors
 		}
@@ -330,9 +419,7 @@ func (vm *VolumeManager) writeSector(root types.Hash256, data *[proto4.SectorSiz
 	return vm.vs.StoreSector(root, func(loc SectorLocation) error {
 		start := time.Now()
 
-		vm.mu.Lock()
-		vol, ok := vm.volumes[loc.Volume]
-		vm.mu.Unlock()
+		vol, ok := vm.loadedVolume(loc.Volume)
 		if !ok {
 			return fmt.Errorf("volume %v not found", loc.Volume)
 		}
@@ -359,19 +446,13 @@ func (vm *VolumeManager) writeSector(root types.Hash256, data *[proto4.SectorSiz
 
 		// Add newly written sector to cache
 		vm.cache.Add(root, data)
-
-		// mark the volume as changed
-		vm.mu.Lock()
-		vm.changedVolumes[loc.Volume] = true
-		vm.mu.Unlock()
 		return nil
 	})
 }
 
-// volumeStats returns the stats for a volume. A lock must be held on the volume
-// manager before this function is called.
+// volumeStats returns the stats for a volume.
 func (vm *VolumeManager) volumeStats(id int64) VolumeStats {
-	v, ok := vm.volumes[id]
+	v, ok := vm.loadedVolume(id)
 	if !ok {
 		return VolumeStats{
 			Status: VolumeStatusUnavailable,
@@ -390,13 +471,18 @@ func (vm *VolumeManager) Close() error {
 	// flush any pending metrics
 	vm.recorder.Flush()
 	// sync and close all open volumes
-	for id, vol := range vm.volumes {
+	for id, vol := range vm.loadedVolumes() {
 		if err := vol.Sync(); err != nil {
 			vm.log.Error("failed to sync volume", zap.Int64("id", id), zap.Error(err))
 		} else if err := vol.Close(); err != nil {
 			vm.log.Error("failed to close volume", zap.Int64("id", id), zap.Error(err))
 		}
-		delete(vm.volumes, id)
+	}
+	vm.volumes.Store(&map[int64]*volume{})
+	if dc := vm.diskCache.Swap(nil); dc != nil {
+		if err := dc.Close(); err != nil {
+			vm.log.Error("failed to close disk cache", zap.Error(err))
+		}
 	}
 	return nil
 }
@@ -429,8 +515,6 @@ func (vm *VolumeManager) Volumes() ([]VolumeMeta, error) {
 		return nil, fmt.Errorf("failed to get volumes: %w", err)
 	}
 
-	vm.mu.Lock()
-	defer vm.mu.Unlock()
 	var results []VolumeMeta
 	for _, vol := range volumes {
 		meta := VolumeMeta{
@@ -456,9 +540,6 @@ func (vm *VolumeManager) Volume(id int64) (VolumeMeta, error) {
 		return VolumeMeta{}, fmt.Errorf("failed to get volume: %w", err)
 	}
 
-	vm.mu.Lock()
-	defer vm.mu.Unlock()
-
 	return VolumeMeta{
 		Volume:      vol,
 		VolumeStats: vm.volumeStats(vol.ID),
@@ -492,6 +573,8 @@ func (vm *VolumeManager) AddVolume(ctx context.Context, localPath string, maxSec
 	volumeID, err := vm.vs.AddVolume(localPath, false)
 	if err != nil {
 		return Volume{}, fmt.Errorf("failed to add volume to store: %w", err)
+	} else if _, err := vm.initVolumeIdentity(volumeID, localPath); err != nil {
+		return Volume{}, fmt.Errorf("failed to initialize volume identity: %w", err)
 	}
 
 	// add the new volume to the volume map
@@ -543,9 +626,7 @@ func (vm *VolumeManager) SetReadOnly(id int64, readOnly bool) error {
 	defer done()
 
 	// check that the volume is available and not busy
-	vm.mu.Lock()
-	vol, ok := vm.volumes[id]
-	vm.mu.Unlock()
+	vol, ok := vm.loadedVolume(id)
 	if !ok {
 		return fmt.Errorf("volume %v not found", id)
 	} else if vol.Status() != VolumeStatusReady {
@@ -567,13 +648,16 @@ func (vm *VolumeManager) RemoveVolume(ctx context.Context, id int64, force bool,
 	}
 	defer done()
 
-	vm.mu.Lock()
-	vol, ok := vm.volumes[id]
-	vm.mu.Unlock()
+	vol, ok := vm.loadedVolume(id)
 	if !ok {
 		return fmt.Errorf("volume %v not found", id)
 	}
 
+	// stop any running scrub before the volume's sectors are migrated
+	if err := vm.stopScrub(id); err != nil && !errors.Is(err, ErrScrubNotRunning) {
+		return fmt.Errorf("failed to stop volume scrub: %w", err)
+	}
+
 	oldStatus := vol.Status()
 	if err := vol.SetStatus(VolumeStatusRemoving); err != nil {
 		return fmt.Errorf("failed to set volume status: %w", err)
@@ -645,7 +729,9 @@ func (vm *VolumeManager) RemoveVolume(ctx context.Context, id int64, force bool,
 				updateRemovalAlert("Failed to remove volume", alerts.SeverityError, err)
 				return err
 			}
-			delete(vm.volumes, id)
+			vm.mu.Lock()
+			vm.removeLoadedVolume(id)
+			vm.mu.Unlock()
 
 			// close the volume file and remove it from disk
 			if err := vol.Close(); err != nil {
@@ -656,6 +742,10 @@ func (vm *VolumeManager) RemoveVolume(ctx context.Context, id int64, force bool,
 				log.Error("failed to remove volume file", zap.Error(err))
 				updateRemovalAlert("Failed to delete volume file", alerts.SeverityError, err)
 				return err
+			} else if err := os.Remove(identityPath(stat.LocalPath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Error("failed to remove volume identity file", zap.Error(err))
+				updateRemovalAlert("Failed to delete volume identity file", alerts.SeverityError, err)
+				return err
 			}
 			updateRemovalAlert("Volume removed", alerts.SeverityInfo, nil)
 			return nil
@@ -672,6 +762,139 @@ func (vm *VolumeManager) RemoveVolume(ctx context.Context, id int64, force bool,
 	return nil
 }
 
+// EvacuateVolume migrates all sectors stored in a volume to the destination
+// volume. The source volume is set to read-only to prevent new sectors from
+// being added, but remains readable for the duration of the evacuation. If
+// the evacuation succeeds, the source volume is left read-only so it can be
+// safely removed.
+func (vm *VolumeManager) EvacuateVolume(ctx context.Context, id, destID int64, result chan<- error) error {
+	log := vm.log.Named("evacuate").With(zap.Int64("volumeID", id), zap.Int64("destID", destID))
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if id == destID {
+		return errors.New("source and destination volumes must be different")
+	}
+
+	vol, ok := vm.loadedVolume(id)
+	dest, destOK := vm.loadedVolume(destID)
+	if !ok {
+		return fmt.Errorf("volume %v not found", id)
+	} else if !destOK {
+		return fmt.Errorf("destination volume %v not found", destID)
+	}
+
+	stat, err := vm.vs.Volume(id)
+	if err != nil {
+		return fmt.Errorf("failed to get volume: %w", err)
+	}
+	destStat, err := vm.vs.Volume(destID)
+	if err != nil {
+		return fmt.Errorf("failed to get destination volume: %w", err)
+	} else if destStat.ReadOnly {
+		return errors.New("destination volume is read-only")
+	} else if destStat.TotalSectors-destStat.UsedSectors < stat.UsedSectors {
+		return fmt.Errorf("destination volume has %v free sectors, %v required: %w", destStat.TotalSectors-destStat.UsedSectors, stat.UsedSectors, ErrNotEnoughStorage)
+	}
+
+	// the destination is busy for the whole evacuation so it cannot be
+	// resized, removed, or evacuated while sectors are moved to it
+	if err := dest.SetStatus(VolumeStatusReceiving); err != nil {
+		return fmt.Errorf("failed to set destination volume status: %w", err)
+	} else if err := vol.SetStatus(VolumeStatusEvacuating); err != nil {
+		dest.SetStatus(VolumeStatusReady)
+		return fmt.Errorf("failed to set volume status: %w", err)
+	}
+
+	// set the volume to read-only to prevent new sectors from being added
+	if !stat.ReadOnly {
+		if err := vm.vs.SetReadOnly(id, true); err != nil {
+			vol.SetStatus(VolumeStatusReady)
+			dest.SetStatus(VolumeStatusReady)
+			return fmt.Errorf("failed to set volume %v to read-only: %w", id, err)
+		}
+	}
+
+	alert := alerts.Alert{
+		ID:       frand.Entropy256(),
+		Message:  "Evacuating volume",
+		Severity: alerts.SeverityInfo,
+		Data: map[string]interface{}{
+			"volumeID":      id,
+			"destinationID": destID,
+			"used":          stat.UsedSectors,
+			"migrated":      0,
+			"failed":        0,
+		},
+		Timestamp: time.Now(),
+	}
+	vm.alerts.Register(alert)
+
+	go func() {
+		ctx, cancel, err := vm.tg.AddContext(ctx)
+		if err != nil {
+			vol.SetStatus(VolumeStatusReady)
+			dest.SetStatus(VolumeStatusReady)
+			select {
+			case result <- err:
+			default:
+			}
+			return
+		}
+		defer cancel()
+
+		start := time.Now()
+		var migrated, failed int
+		migrated, failed, err = vm.vs.EvacuateSectors(ctx, id, destID, func(from, to SectorLocation) error {
+			err := vm.migrateSector(from, to)
+			if err != nil {
+				failed++
+			} else {
+				migrated++
+			}
+			// update the alert
+			alert.Data["migrated"] = migrated
+			alert.Data["failed"] = failed
+			vm.alerts.Register(alert)
+			return err
+		})
+		if err == nil && failed > 0 {
+			err = ErrMigrationFailed
+		}
+
+		alert.Data["migrated"] = migrated
+		alert.Data["failed"] = failed
+		alert.Data["elapsed"] = time.Since(start)
+		if err != nil {
+			log.Error("failed to evacuate volume", zap.Int("migrated", migrated), zap.Int("failed", failed), zap.Error(err))
+			alert.Message = "Volume evacuation failed"
+			alert.Severity = alerts.SeverityError
+			alert.Data["error"] = err.Error()
+			if !stat.ReadOnly {
+				// reset the volume to read-write
+				if err := vm.vs.SetReadOnly(id, false); err != nil {
+					log.Error("failed to set volume to read-write", zap.Error(err))
+				}
+			}
+		} else {
+			log.Info("evacuated volume", zap.Int("migrated", migrated), zap.Duration("elapsed", time.Since(start)))
+			alert.Message = "Volume evacuated"
+		}
+		alert.Timestamp = time.Now()
+		vm.alerts.Register(alert)
+		vol.SetStatus(VolumeStatusReady)
+		dest.SetStatus(VolumeStatusReady)
+		select {
+		case result <- err:
+		default:
+		}
+	}()
+	return nil
+}
+
 // ResizeVolume resizes a volume to the specified size.
 func (vm *VolumeManager) ResizeVolume(ctx context.Context, id int64, maxSectors uint64, result chan<- error) error {
 	done, err := vm.tg.Add()
@@ -686,15 +909,16 @@ func (vm *VolumeManager) ResizeVolume(ctx context.Context, id int64, maxSectors
 	}
 
 	vm.mu.Lock()
-	defer vm.mu.Unlock()
-
-	vol, ok := vm.volumes[id]
+	vol, ok := vm.loadedVolume(id)
 	if !ok {
+		vm.mu.Unlock()
 		return fmt.Errorf("volume %v not found", id)
 	}
 
 	// check that the volume is not already being resized
-	if err := vol.SetStatus(VolumeStatusResizing); err != nil {
+	err = vol.SetStatus(VolumeStatusResizing)
+	vm.mu.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to set volume status: %w", err)
 	}
 
@@ -703,6 +927,7 @@ func (vm *VolumeManager) ResizeVolume(ctx context.Context, id int64, maxSectors
 		// set the volume to read-only to prevent new sectors from being added
 		// while the volume is being shrunk
 		if err := vm.vs.SetReadOnly(id, true); err != nil {
+			vol.SetStatus(VolumeStatusReady)
 			return fmt.Errorf("failed to set volume %v to read-only: %w", id, err)
 		}
 		resetReadOnly = true
@@ -777,36 +1002,40 @@ func (vm *VolumeManager) RemoveSector(root types.Hash256) error {
 	}
 	defer done()
 
+	// the manager's mutex is only held while the sector is removed from the
+	// index so removals do not serialize I/O to unrelated volumes
 	vm.mu.Lock()
-	defer vm.mu.Unlock()
-
 	// get the sector's current location
 	loc, err := vm.vs.SectorLocation(root)
 	if err != nil {
+		vm.mu.Unlock()
 		return fmt.Errorf("failed to locate sector %v: %w", root, err)
 	}
 
 	// remove the sector from the volume store
 	if err := vm.vs.RemoveSector(root); err != nil {
+		vm.mu.Unlock()
 		return fmt.Errorf("failed to remove sector %v: %w", root, err)
 	}
 
 	// get the volume from memory
-	vol, ok := vm.volumes[loc.Volume]
+	vol, ok := vm.loadedVolume(loc.Volume)
+	vm.mu.Unlock()
 	if !ok {
 		return fmt.Errorf("volume %v not found", loc.Volume)
 	}
 
 	// zero the sector and immediately sync the volume
 	var zeroes [proto2.SectorSize]byte
-	if err := vol.WriteSector(&zeroes, loc.Index); err != nil {
+	if err := vol.WriteSectorSync(&zeroes, loc.Index); err != nil {
 		return fmt.Errorf("failed to zero sector %v: %w", root, err)
-	} else if err := vol.Sync(); err != nil {
-		return fmt.Errorf("failed to sync volume %v: %w", loc.Volume, err)
 	}
 
-	// eject the sector from the cache
+	// eject the sector from the caches
 	vm.cache.Remove(root)
+	if dc := vm.diskCache.Load(); dc != nil {
+		dc.Remove(root)
+	}
 	return nil
 }
 
@@ -815,14 +1044,98 @@ func (vm *VolumeManager) CacheStats() (hits, misses uint64) {
 	return atomic.LoadUint64(&vm.cacheHits), atomic.LoadUint64(&vm.cacheMisses)
 }
 
-func (vm *VolumeManager) readLocation(loc SectorLocation) (*[proto2.SectorSize]byte, error) {
+// DiskCacheStats returns the number of disk cache hits and misses.
+func (vm *VolumeManager) DiskCacheStats() (hits, misses uint64) {
+	return atomic.LoadUint64(&vm.diskCacheHits), atomic.LoadUint64(&vm.diskCacheMisses)
+}
+
+// ConfigureDiskCache configures the second-level sector cache. The cache is
+// stored in a file at path and holds up to sectors sectors. If path is empty
+// or sectors is 0, the disk cache is disabled. The cache is emptied when it
+// is reconfigured.
+func (vm *VolumeManager) ConfigureDiskCache(path string, sectors uint64) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	vm.mu.Lock()
-	v, ok := vm.volumes[loc.Volume]
+	defer vm.mu.Unlock()
+
+	if dc := vm.diskCache.Load(); dc != nil {
+		if dc.path == path && dc.slots == sectors {
+			return nil
+		}
+		vm.diskCache.Store(nil)
+		if err := dc.Close(); err != nil {
+			vm.log.Error("failed to close disk cache", zap.Error(err))
+		}
+	}
+
+	if path == "" || sectors == 0 {
+		return nil
+	}
+	for _, v := range vm.loadedVolumes() {
+		if v.Location() == path {
+			return fmt.Errorf("disk cache path %q is a volume", path)
+		}
+	}
+
+	dc, err := openDiskCache(path, sectors)
+	if err != nil {
+		return fmt.Errorf("failed to open disk cache: %w", err)
+	}
+	vm.diskCache.Store(dc)
+	vm.log.Info("disk cache enabled", zap.String("path", path), zap.Uint64("sectors", sectors))
+	return nil
+}
+
+// readDiskCache reads a sector from the disk cache.
+func (vm *VolumeManager) readDiskCache(root types.Hash256) (*[proto2.SectorSize]byte, bool) {
+	dc := vm.diskCache.Load()
+	if dc == nil {
+		return nil, false
+	}
+
+	sector, ok, err := dc.Get(root)
+	if err != nil && !errors.Is(err, errDiskCacheClosed) {
+		vm.log.Warn("failed to read from disk cache", zap.Stringer("root", root), zap.Error(err))
+	}
+	if !ok {
+		vm.recorder.AddDiskCacheMiss()
+		atomic.AddUint64(&vm.diskCacheMisses, 1)
+		return nil, false
+	}
+	vm.recorder.AddDiskCacheHit()
+	atomic.AddUint64(&vm.diskCacheHits, 1)
+	return sector, true
+}
+
+// addDiskCache adds a sector to the disk cache in the background.
+func (vm *VolumeManager) addDiskCache(root types.Hash256, sector *[proto2.SectorSize]byte) {
+	dc := vm.diskCache.Load()
+	if dc == nil {
+		return
+	}
+
+	done, err := vm.tg.Add()
+	if err != nil {
+		return
+	}
+	go func() {
+		defer done()
+		if err := dc.Add(root, sector); err != nil && !errors.Is(err, errDiskCacheClosed) {
+			vm.log.Warn("failed to add sector to disk cache", zap.Stringer("root", root), zap.Error(err))
+		}
+	}()
+}
+
+func (vm *VolumeManager) readLocation(loc SectorLocation) (*[proto2.SectorSize]byte, error) {
+	v, ok := vm.loadedVolume(loc.Volume)
 	if !ok {
-		vm.mu.Unlock()
 		return nil, fmt.Errorf("volume %v not found", loc.Volume)
 	}
-	vm.mu.Unlock()
 	sector, err := v.ReadSector(loc.Index)
 	if err != nil {
 		stats := v.Stats()
@@ -860,48 +1173,30 @@ func (vm *VolumeManager) ReadSector(root types.Hash256) (*[proto2.SectorSize]byt
 	// Check the cache first
 	if sector, ok := vm.cache.Get(root); ok {
 		vm.recorder.AddCacheHit()
+		vm.recorder.AddSectorRead(root)
 		atomic.AddUint64(&vm.cacheHits, 1)
 		return sector, nil
 	}
 
+	// check the disk cache before reading from the volume
+	if sector, ok := vm.readDiskCache(root); ok {
+		vm.recorder.AddSectorRead(root)
+		vm.cache.Add(root, sector)
+		return sector, nil
+	}
+
 	// Cache miss, read from disk
 	loc, err := vm.vs.SectorLocation(root)
 	if err != nil {
 		return nil, fmt.Errorf("failed to locate sector: %w", err)
 	}
-	return vm.readLocation(loc)
-}
-
-// Sync syncs the data files of changed volumes.
-func (vm *VolumeManager) Sync() error {
-	done, err := vm.tg.Add()
+	sector, err := vm.readLocation(loc)
 	if err != nil {
-		return err
-	}
-	defer done()
-
-	vm.mu.Lock()
-	var toSync []int64
-	for id := range vm.changedVolumes {
-		toSync = append(toSync, id)
-	}
-	vm.mu.Unlock()
-
-	for _, id := range toSync {
-		vm.mu.Lock()
-		vol, ok := vm.volumes[id]
-		vm.mu.Unlock()
-		if !ok {
-			continue
-		}
-		if err := vol.Sync(); err != nil {
-			return fmt.Errorf("failed to sync volume %v: %w", id, err)
-		}
-		vm.mu.Lock()
-		delete(vm.changedVolumes, id)
-		vm.mu.Unlock()
+		return nil, err
 	}
-	return nil
+	vm.recorder.AddSectorRead(root)
+	vm.addDiskCache(root, sector)
+	return sector, nil
 }
 
 // HasSector returns true if the host is storing a sector
@@ -969,43 +1264,63 @@ func (vm *VolumeManager) ProcessActions(index types.ChainIndex) error {
 	return vm.vs.ExpireTempSectors(index.Height)
 }
 
+// watchPrune periodically removes sectors that are no longer referenced.
+func (vm *VolumeManager) watchPrune() {
+	ctx, cancel, err := vm.tg.AddContext(context.Background())
+	if err != nil {
+		vm.log.Debug("failed to start pruning thread", zap.Error(err))
+		return
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vm.pruneInterval):
+			if err := vm.vs.PruneSectors(ctx, time.Now().Add(-1*vm.pruneInterval)); err != nil && !errors.Is(err, context.Canceled) {
+				vm.log.Error("failed to prune sectors", zap.Error(err))
+			}
+		}
+	}
+}
+
 // NewVolumeManager creates a new VolumeManager.
 func NewVolumeManager(vs VolumeStore, opts ...VolumeManagerOption) (*VolumeManager, error) {
 	vm := &VolumeManager{
-		pruneInterval: 5 * time.Minute,
-		vs:            vs,
+		pruneInterval:       5 * time.Minute,
+		reconnectInterval:   time.Minute,
+		autoGrowInterval:    10 * time.Minute,
+		healthCheckInterval: time.Minute,
+		resumeScrubs:        true,
+		scrubRate:           32 << 20, // 32 MiB/s
+		syncPolicy:          SyncPolicy{Mode: SyncModeImmediate},
+		vs:                  vs,
 
 		log:    zap.NewNop(),
 		alerts: alerts.NewNop(),
+		events: webhooks.NewNop(),
 		tg:     threadgroup.New(),
 
-		volumes:        make(map[int64]*volume),
-		changedVolumes: make(map[int64]bool),
+		scrubs:  make(map[int64]*scrubJob),
+		backups: make(map[int64]VolumeBackup),
+		tiering: tieringState{
+			moves: make(map[int64]*VolumeTierStats),
+		},
 	}
+	vm.volumes.Store(&map[int64]*volume{})
 
 	for _, opt := range opts {
 		opt(vm)
 	}
 
-	go func() {
-		ctx, cancel, err := vm.tg.AddContext(context.Background())
-		if err != nil {
-			vm.log.Debug("failed to start pruning thread", zap.Error(err))
-			return
-		}
-		defer cancel()
+	if err := ValidateSyncPolicy(vm.syncPolicy); err != nil {
+		return nil, err
+	}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(vm.pruneInterval):
-				if err := vm.vs.PruneSectors(ctx, time.Now().Add(-1*vm.pruneInterval)); err != nil && !errors.Is(err, context.Canceled) {
-					vm.log.Error("failed to prune sectors", zap.Error(err))
-				}
-			}
-		}
-	}()
+	if vm.pruneInterval > 0 {
+		go vm.watchPrune()
+	}
 
 	// Initialize cache with LRU eviction and a max capacity of 64
 	cache, err := lru.New[types.Hash256, *[proto2.SectorSize]byte](64)
@@ -1018,13 +1333,35 @@ func NewVolumeManager(vs VolumeStore, opts ...VolumeManagerOption) (*VolumeManag
 	vm.cache = cache
 
 	vm.recorder = &sectorAccessRecorder{
-		store: vs,
-		log:   vm.log.Named("recorder"),
+		store:      vs,
+		log:        vm.log.Named("recorder"),
+		trackReads: vm.tieringPolicy.Interval > 0,
 	}
 
 	if err := vm.loadVolumes(); err != nil {
 		return nil, err
 	}
+	if vm.resumeScrubs {
+		if err := vm.resumeVolumeScrubs(); err != nil {
+			return nil, err
+		}
+	}
+
+	if vm.reconnectInterval > 0 {
+		go vm.watchUnavailableVolumes()
+	}
+	if vm.autoGrowInterval > 0 {
+		go vm.watchAutoGrow()
+	}
+	if vm.tieringPolicy.Interval > 0 {
+		go vm.watchTiering()
+	}
+	if vm.syncPolicy.Mode == SyncModeBackground {
+		go vm.watchBackgroundSync()
+	}
+	if vm.healthCheckInterval > 0 {
+		go vm.watchVolumeHealth()
+	}
 	go vm.recorder.Run(vm.tg.Done())
 	return vm, nil
 }
@@ -7,11 +7,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	rhp2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/alerts"
 	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/persist/sqlite"
 	"go.uber.org/zap/zaptest"
@@ -105,6 +108,240 @@ func TestVolumeLoad(t *testing.T) {
 	}
 }
 
+func TestVolumeIdentity(t *testing.T) {
+	const expectedSectors = 10
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	hostKey := types.GeneratePrivateKey().PublicKey()
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	volume, err := vm.AddVolume(context.Background(), volumePath, expectedSectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	} else if volume.UUID == "" {
+		t.Fatal("expected volume to have an identity")
+	}
+
+	root, err := storeRandomSector(vm, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	identityPath := volumePath + ".identity"
+	identity, err := os.ReadFile(identityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertAvailable := func(t *testing.T, vm *storage.VolumeManager, available bool) {
+		t.Helper()
+
+		vol, err := vm.Volume(volume.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if vol.Available != available {
+			t.Fatalf("expected available %v, got %v", available, vol.Available)
+		}
+
+		_, err = vm.ReadSector(root)
+		if available {
+			if vol.Status != storage.VolumeStatusReady {
+				t.Fatalf("expected volume to be ready, got %v", vol.Status)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			if vol.Status != storage.VolumeStatusUnavailable {
+				t.Fatalf("expected volume to be unavailable, got %v", vol.Status)
+			} else if err == nil {
+				t.Fatal("expected read from mismatched volume to fail")
+			}
+		}
+	}
+
+	// reopening with a matching identity should succeed
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAvailable(t, vm, true)
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a volume belonging to a different host should not be loaded
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(types.GeneratePrivateKey().PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAvailable(t, vm, false)
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a volume with a different identity should not be loaded
+	if err := os.WriteFile(identityPath, []byte(`{"uuid":"00000000-0000-4000-8000-000000000000"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAvailable(t, vm, false)
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a volume with a missing identity should not be loaded
+	if err := os.Remove(identityPath); err != nil {
+		t.Fatal(err)
+	}
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAvailable(t, vm, false)
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restoring the identity should make the volume available again
+	if err := os.WriteFile(identityPath, identity, 0600); err != nil {
+		t.Fatal(err)
+	}
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+	assertAvailable(t, vm, true)
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (er *eventRecorder) BroadcastEvent(event, scope string, data any) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	er.events = append(er.events, event)
+	return nil
+}
+
+func (er *eventRecorder) Events() []string {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	return append([]string(nil), er.events...)
+}
+
+func TestVolumeReconnect(t *testing.T) {
+	const expectedSectors = 10
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	hostKey := types.GeneratePrivateKey().PublicKey()
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	volume, err := vm.AddVolume(context.Background(), volumePath, expectedSectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := storeRandomSector(vm, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a disconnected disk by moving the volume out of the way
+	movedPath := volumePath + ".moved"
+	if err := os.Rename(volumePath, movedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	events := new(eventRecorder)
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithHostKey(hostKey), storage.WithEventReporter(events), storage.WithReconnectInterval(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	vol, err := vm.Volume(volume.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if vol.Available {
+		t.Fatal("expected volume to be unavailable")
+	} else if vol.Status != storage.VolumeStatusUnavailable {
+		t.Fatalf("expected volume to be unavailable, got %v", vol.Status)
+	} else if _, err := vm.ReadSector(root); err == nil {
+		t.Fatal("expected read from unavailable volume to fail")
+	}
+
+	// the volume should stay unavailable while the file is missing
+	time.Sleep(300 * time.Millisecond)
+	if vol, err := vm.Volume(volume.ID); err != nil {
+		t.Fatal(err)
+	} else if vol.Available {
+		t.Fatal("expected volume to be unavailable")
+	}
+
+	// reconnect the disk
+	if err := os.Rename(movedPath, volumePath); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; ; i++ {
+		vol, err = vm.Volume(volume.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if vol.Available && vol.Status == storage.VolumeStatusReady {
+			break
+		} else if i >= 50 {
+			t.Fatal("expected volume to be reconnected")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if _, err := vm.ReadSector(root); err != nil {
+		t.Fatal(err)
+	} else if evts := events.Events(); len(evts) != 1 || evts[0] != storage.EventVolumeAvailable {
+		t.Fatalf("expected 1 %q event, got %v", storage.EventVolumeAvailable, evts)
+	}
+}
+
 func TestAddVolume(t *testing.T) {
 	const expectedSectors = 500
 	dir := t.TempDir()
@@ -431,7 +668,7 @@ func TestRemoveCorrupt(t *testing.T) {
 	}
 }
 
-func TestRemoveMissing(t *testing.T) {
+func TestEvacuateVolume(t *testing.T) {
 	const expectedSectors = 50
 	dir := t.TempDir()
 
@@ -443,112 +680,431 @@ func TestRemoveMissing(t *testing.T) {
 	}
 	defer db.Close()
 
-	assertMetrics := func(t *testing.T, physical, lost, total uint64) {
-		t.Helper()
-
-		if m, err := db.Metrics(time.Now()); err != nil {
-			t.Fatal(err)
-		} else if m.Storage.TotalSectors != total {
-			t.Fatalf("expected %v total sectors, got %v", total, m.Storage.TotalSectors)
-		} else if m.Storage.PhysicalSectors != physical {
-			t.Fatalf("expected %v used sectors, got %v", physical, m.Storage.PhysicalSectors)
-		} else if m.Storage.LostSectors != lost {
-			t.Fatalf("expected %v lost sectors, got %v", lost, m.Storage.LostSectors)
-		}
-	}
-
 	// initialize the storage manager
-	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithCacheSize(0))
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer vm.Close()
 
-	assertMetrics(t, 0, 0, 0)
+	addVolume := func(t *testing.T) storage.Volume {
+		t.Helper()
 
-	result := make(chan error, 1)
-	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
-	volume, err := vm.AddVolume(context.Background(), volumePath, expectedSectors, result)
-	if err != nil {
-		t.Fatal(err)
-	} else if err := <-result; err != nil {
-		t.Fatal(err)
+		result := make(chan error, 1)
+		volume, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), expectedSectors, result)
+		if err != nil {
+			t.Fatal(err)
+		} else if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+		return volume
 	}
 
-	assertVolume := func(t *testing.T, volumeID int64, used, total uint64) {
+	assertVolume := func(t *testing.T, volumeID int64, used uint64, readOnly bool) {
 		t.Helper()
 
 		if vol, err := vm.Volume(volumeID); err != nil {
 			t.Fatal(err)
+		} else if vol.Status != storage.VolumeStatusReady {
+			t.Fatalf("expected volume to be ready, got %v", vol.Status)
 		} else if vol.UsedSectors != used {
 			t.Fatalf("expected %v used sectors, got %v", used, vol.UsedSectors)
-		} else if vol.TotalSectors != total {
-			t.Fatalf("expected %v total sectors, got %v", total, vol.TotalSectors)
+		} else if vol.ReadOnly != readOnly {
+			t.Fatalf("expected read-only %v, got %v", readOnly, vol.ReadOnly)
 		}
 	}
 
-	assertMetrics(t, 0, 0, expectedSectors)
-	assertVolume(t, volume.ID, 0, expectedSectors)
-
-	assertVolume(t, volume.ID, 0, expectedSectors)
-	assertMetrics(t, 0, 0, expectedSectors)
-
+	source := addVolume(t)
+	roots := make([]types.Hash256, 0, 10)
 	for i := 0; i < 10; i++ {
-		if _, err := storeRandomSector(vm, 1); err != nil {
+		root, err := storeRandomSector(vm, 1)
+		if err != nil {
 			t.Fatal(err)
 		}
+		roots = append(roots, root)
 	}
 
-	assertMetrics(t, 10, 0, expectedSectors)
-	assertVolume(t, volume.ID, 10, expectedSectors)
+	other := addVolume(t)
+	dest := addVolume(t)
 
-	// attempt to remove the volume. Should return ErrNotEnoughStorage since
-	// there is only one volume.
-	if err := vm.RemoveVolume(context.Background(), volume.ID, false, result); err != nil {
+	result := make(chan error, 1)
+	if err := vm.EvacuateVolume(context.Background(), source.ID, source.ID, result); err == nil {
+		t.Fatal("expected error when evacuating to the source volume")
+	}
+
+	if err := vm.EvacuateVolume(context.Background(), source.ID, dest.ID, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
 		t.Fatal(err)
-	} else if err := <-result; !errors.Is(err, storage.ErrNotEnoughStorage) {
-		t.Fatalf("expected ErrNotEnoughStorage, got %v", err)
 	}
 
-	// check that the volume metrics did not change
-	assertMetrics(t, 10, 0, expectedSectors)
-	assertVolume(t, volume.ID, 10, expectedSectors)
+	// all sectors should have been moved to the destination and the source
+	// should be left read-only
+	assertVolume(t, source.ID, 0, true)
+	assertVolume(t, other.ID, 0, false)
+	assertVolume(t, dest.ID, uint64(len(roots)), false)
 
-	// close the volume manager
-	if err := vm.Close(); err != nil {
-		t.Fatal(err)
+	for _, root := range roots {
+		loc, err := db.SectorLocation(root)
+		if err != nil {
+			t.Fatal(err)
+		} else if loc.Volume != dest.ID {
+			t.Fatalf("expected sector %v to be in volume %v, got %v", root, dest.ID, loc.Volume)
+		}
+
+		sector, err := vm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		} else if rhp2.SectorRoot(sector) != root {
+			t.Fatal("sector corrupt after evacuation")
+		}
 	}
 
-	// remove the volume from disk
-	if err := os.Remove(volumePath); err != nil {
+	// the source volume is now empty and can be removed
+	if err := vm.RemoveVolume(context.Background(), source.ID, false, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
 		t.Fatal(err)
 	}
+}
+
+// blockingAlerts blocks the first registration of an alert with a matching
+// message until release is closed.
+type blockingAlerts struct {
+	message string
+	once    sync.Once
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingAlerts) Register(alert alerts.Alert) {
+	if alert.Message != a.message {
+		return
+	}
+	a.once.Do(func() {
+		close(a.blocked)
+		<-a.release
+	})
+}
 
-	// reload the volume manager
-	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+func (a *blockingAlerts) Dismiss(...types.Hash256) {}
+
+func TestEvacuateVolumeDestinationBusy(t *testing.T) {
+	const expectedSectors = 50
+	dir := t.TempDir()
+
+	// create the database
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer vm.Close()
+	defer db.Close()
 
-	// check that the volume metrics did not change
-	assertMetrics(t, 10, 0, expectedSectors)
-	assertVolume(t, volume.ID, 10, expectedSectors)
+	// block the evacuation after the sectors are migrated, but before the
+	// job finishes
+	alerter := &blockingAlerts{
+		message: "Volume evacuated",
+		blocked: make(chan struct{}),
+		release: make(chan struct{}),
+	}
 
-	// add a volume to accept the data
-	volume2, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), expectedSectors, result)
+	// initialize the storage manager
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithAlerter(alerter), storage.WithCacheSize(0))
 	if err != nil {
 		t.Fatal(err)
-	} else if err := <-result; err != nil {
-		t.Fatal(err)
 	}
+	defer vm.Close()
 
-	// check that the total metrics doubled and the volume metrics did not change
-	assertMetrics(t, 10, 0, expectedSectors*2)
-	assertVolume(t, volume.ID, 10, expectedSectors)
-	assertVolume(t, volume2.ID, 0, expectedSectors)
+	addVolume := func(t *testing.T) storage.Volume {
+		t.Helper()
 
-	// remove the volume
+		result := make(chan error, 1)
+		volume, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), expectedSectors, result)
+		if err != nil {
+			t.Fatal(err)
+		} else if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+		return volume
+	}
+
+	source := addVolume(t)
+	for i := 0; i < 5; i++ {
+		if _, err := storeRandomSector(vm, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := addVolume(t)
+	dest := addVolume(t)
+
+	evacuated := make(chan error, 1)
+	if err := vm.EvacuateVolume(context.Background(), source.ID, dest.ID, evacuated); err != nil {
+		t.Fatal(err)
+	}
+	<-alerter.blocked
+
+	if vol, err := vm.Volume(dest.ID); err != nil {
+		t.Fatal(err)
+	} else if vol.Status != storage.VolumeStatusReceiving {
+		t.Fatalf("expected destination to be %v, got %v", storage.VolumeStatusReceiving, vol.Status)
+	}
+
+	// the destination cannot be changed until the evacuation finishes
+	result := make(chan error, 1)
+	if err := vm.ResizeVolume(context.Background(), dest.ID, expectedSectors*2, result); err == nil {
+		t.Fatal("expected resizing the destination to fail")
+	} else if err := vm.RemoveVolume(context.Background(), dest.ID, false, result); err == nil {
+		t.Fatal("expected removing the destination to fail")
+	} else if err := vm.EvacuateVolume(context.Background(), dest.ID, other.ID, result); err == nil {
+		t.Fatal("expected evacuating the destination to fail")
+	} else if err := vm.EvacuateVolume(context.Background(), other.ID, dest.ID, result); err == nil {
+		t.Fatal("expected a second evacuation to the destination to fail")
+	}
+
+	close(alerter.release)
+	if err := <-evacuated; err != nil {
+		t.Fatal(err)
+	}
+
+	// the destination is ready again once the evacuation finishes
+	if vol, err := vm.Volume(dest.ID); err != nil {
+		t.Fatal(err)
+	} else if vol.Status != storage.VolumeStatusReady {
+		t.Fatalf("expected destination to be ready, got %v", vol.Status)
+	} else if err := vm.ResizeVolume(context.Background(), dest.ID, expectedSectors*2, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecoverSectors(t *testing.T) {
+	const expectedSectors = 50
+	log := zaptest.NewLogger(t)
+
+	// create the original database
+	db, err := sqlite.OpenDatabase(filepath.Join(t.TempDir(), "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	if _, err := vm.AddVolume(context.Background(), volumePath, expectedSectors, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	roots := make([]types.Hash256, 0, 10)
+	for i := 0; i < 10; i++ {
+		root, err := storeRandomSector(vm, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate losing the database by opening a new one
+	db2, err := sqlite.OpenDatabase(filepath.Join(t.TempDir(), "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db2.Close()
+
+	vm2, err := storage.NewVolumeManager(db2, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm2.Close()
+
+	// only supply half of the roots
+	known := roots[:5]
+	recovery, err := vm2.RecoverSectors(context.Background(), volumePath, known, 100)
+	if err != nil {
+		t.Fatal(err)
+	} else if recovery.TotalSlots != expectedSectors {
+		t.Fatalf("expected %d slots, got %d", expectedSectors, recovery.TotalSlots)
+	} else if recovery.EmptySlots != expectedSectors-uint64(len(roots)) {
+		t.Fatalf("expected %d empty slots, got %d", expectedSectors-len(roots), recovery.EmptySlots)
+	} else if recovery.Recovered != uint64(len(known)) {
+		t.Fatalf("expected %d recovered sectors, got %d", len(known), recovery.Recovered)
+	} else if recovery.Unreferenced != uint64(len(known)) {
+		t.Fatalf("expected %d unreferenced sectors, got %d", len(known), recovery.Unreferenced)
+	} else if len(recovery.Unmatched) != len(roots)-len(known) {
+		t.Fatalf("expected %d unmatched sectors, got %d", len(roots)-len(known), len(recovery.Unmatched))
+	}
+
+	unmatched := make(map[types.Hash256]bool)
+	for _, sector := range recovery.Unmatched {
+		unmatched[sector.Root] = true
+	}
+	for _, root := range roots[5:] {
+		if !unmatched[root] {
+			t.Fatalf("expected sector %v to be unmatched", root)
+		}
+	}
+
+	if vol, err := vm2.Volume(recovery.VolumeID); err != nil {
+		t.Fatal(err)
+	} else if vol.UsedSectors != uint64(len(known)) {
+		t.Fatalf("expected %d used sectors, got %d", len(known), vol.UsedSectors)
+	} else if vol.TotalSectors != expectedSectors {
+		t.Fatalf("expected %d total sectors, got %d", expectedSectors, vol.TotalSectors)
+	}
+
+	for _, root := range known {
+		sector, err := vm2.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		} else if rhp2.SectorRoot(sector) != root {
+			t.Fatal("recovered sector is corrupt")
+		}
+	}
+
+	// recovering again should not change the index
+	recovery, err = vm2.RecoverSectors(context.Background(), volumePath, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if recovery.Recovered != 0 {
+		t.Fatalf("expected 0 recovered sectors, got %d", recovery.Recovered)
+	} else if recovery.AlreadyIndexed != uint64(len(known)) {
+		t.Fatalf("expected %d already indexed sectors, got %d", len(known), recovery.AlreadyIndexed)
+	}
+}
+
+func TestRemoveMissing(t *testing.T) {
+	const expectedSectors = 50
+	dir := t.TempDir()
+
+	// create the database
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	assertMetrics := func(t *testing.T, physical, lost, total uint64) {
+		t.Helper()
+
+		if m, err := db.Metrics(time.Now()); err != nil {
+			t.Fatal(err)
+		} else if m.Storage.TotalSectors != total {
+			t.Fatalf("expected %v total sectors, got %v", total, m.Storage.TotalSectors)
+		} else if m.Storage.PhysicalSectors != physical {
+			t.Fatalf("expected %v used sectors, got %v", physical, m.Storage.PhysicalSectors)
+		} else if m.Storage.LostSectors != lost {
+			t.Fatalf("expected %v lost sectors, got %v", lost, m.Storage.LostSectors)
+		}
+	}
+
+	// initialize the storage manager
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	assertMetrics(t, 0, 0, 0)
+
+	result := make(chan error, 1)
+	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	volume, err := vm.AddVolume(context.Background(), volumePath, expectedSectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	assertVolume := func(t *testing.T, volumeID int64, used, total uint64) {
+		t.Helper()
+
+		if vol, err := vm.Volume(volumeID); err != nil {
+			t.Fatal(err)
+		} else if vol.UsedSectors != used {
+			t.Fatalf("expected %v used sectors, got %v", used, vol.UsedSectors)
+		} else if vol.TotalSectors != total {
+			t.Fatalf("expected %v total sectors, got %v", total, vol.TotalSectors)
+		}
+	}
+
+	assertMetrics(t, 0, 0, expectedSectors)
+	assertVolume(t, volume.ID, 0, expectedSectors)
+
+	assertVolume(t, volume.ID, 0, expectedSectors)
+	assertMetrics(t, 0, 0, expectedSectors)
+
+	for i := 0; i < 10; i++ {
+		if _, err := storeRandomSector(vm, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertMetrics(t, 10, 0, expectedSectors)
+	assertVolume(t, volume.ID, 10, expectedSectors)
+
+	// attempt to remove the volume. Should return ErrNotEnoughStorage since
+	// there is only one volume.
+	if err := vm.RemoveVolume(context.Background(), volume.ID, false, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; !errors.Is(err, storage.ErrNotEnoughStorage) {
+		t.Fatalf("expected ErrNotEnoughStorage, got %v", err)
+	}
+
+	// check that the volume metrics did not change
+	assertMetrics(t, 10, 0, expectedSectors)
+	assertVolume(t, volume.ID, 10, expectedSectors)
+
+	// close the volume manager
+	if err := vm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// remove the volume from disk
+	if err := os.Remove(volumePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload the volume manager
+	vm, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	// check that the volume metrics did not change
+	assertMetrics(t, 10, 0, expectedSectors)
+	assertVolume(t, volume.ID, 10, expectedSectors)
+
+	// add a volume to accept the data
+	volume2, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), expectedSectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	// check that the total metrics doubled and the volume metrics did not change
+	assertMetrics(t, 10, 0, expectedSectors*2)
+	assertVolume(t, volume.ID, 10, expectedSectors)
+	assertVolume(t, volume2.ID, 0, expectedSectors)
+
+	// remove the volume
 	if err := vm.RemoveVolume(context.Background(), volume.ID, false, result); err != nil {
 		t.Fatal(err)
 	} else if err := <-result; !errors.Is(err, storage.ErrMigrationFailed) {
@@ -714,6 +1270,101 @@ func TestVolumeConcurrency(t *testing.T) {
 	}
 }
 
+func TestVolumeAutoGrow(t *testing.T) {
+	const initialSectors, maxSectors = 10, 30
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	am := alerts.NewManager(alerts.WithEventReporter(&eventRecorder{}))
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithAlerter(am), storage.WithAutoGrowInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	result := make(chan error, 1)
+	volume, err := vm.AddVolume(context.Background(), volumePath, initialSectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSectors := func(expected uint64) {
+		t.Helper()
+		var vol storage.VolumeMeta
+		for i := 0; i < 100; i++ {
+			vol, err = vm.Volume(volume.ID)
+			if err != nil {
+				t.Fatal(err)
+			} else if vol.TotalSectors == expected && vol.Status == storage.VolumeStatusReady {
+				if err := checkFileSize(volumePath, int64(expected*rhp2.SectorSize)); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("expected %d sectors, got %d", expected, vol.TotalSectors)
+	}
+
+	reserveAlert := func() bool {
+		for _, a := range am.Active() {
+			if a.Message == "Disk free space is below the volume's reserve" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the volume should grow to its maximum size
+	if err := vm.SetVolumeAutoGrow(volume.ID, storage.AutoGrowPolicy{MaxSectors: maxSectors}); err != nil {
+		t.Fatal(err)
+	}
+	waitForSectors(maxSectors)
+	if reserveAlert() {
+		t.Fatal("unexpected reserve alert")
+	}
+
+	// store a sector so the volume cannot shrink below it
+	if _, err := storeRandomSector(vm, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// reserve more space than is available on the disk. The volume should
+	// shrink as much as possible and an alert should be registered.
+	if err := vm.SetVolumeAutoGrow(volume.ID, storage.AutoGrowPolicy{MaxSectors: maxSectors, ReservedBytes: 1 << 62}); err != nil {
+		t.Fatal(err)
+	}
+	waitForSectors(1)
+	if !reserveAlert() {
+		t.Fatal("expected reserve alert")
+	}
+
+	// disabling the policy should dismiss the alert
+	if err := vm.SetVolumeAutoGrow(volume.ID, storage.AutoGrowPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if reserveAlert() {
+		t.Fatal("expected reserve alert to be dismissed")
+	}
+
+	vol, err := vm.Volume(volume.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if vol.AutoGrow.Enabled() {
+		t.Fatal("expected auto-grow to be disabled")
+	} else if vol.ReadOnly {
+		t.Fatal("expected volume to be writable")
+	}
+}
+
 func TestVolumeGrow(t *testing.T) {
 	const initialSectors = 20
 	dir := t.TempDir()
@@ -1012,31 +1663,299 @@ func TestVolumeManagerReadWrite(t *testing.T) {
 			t.Fatalf("expected root %v, got %v", root, retrievedRoot)
 		}
 	}
-}
+}
+
+func TestVolumeLatency(t *testing.T) {
+	const sectors = 10
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	vol, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), sectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < sectors; i++ {
+		if _, err := storeRandomSector(vm, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// stats are updated asynchronously
+	var volume storage.VolumeMeta
+	for i := 0; ; i++ {
+		volume, err = vm.Volume(vol.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if volume.SuccessfulWrites == sectors {
+			break
+		} else if i >= 50 {
+			t.Fatalf("expected %v successful writes, got %v", sectors, volume.SuccessfulWrites)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var buckets uint64
+	for _, n := range volume.WriteLatency.Buckets {
+		buckets += n
+	}
+	if volume.WriteLatency.Count != sectors {
+		t.Fatalf("expected %v write latency observations, got %v", sectors, volume.WriteLatency.Count)
+	} else if buckets != volume.WriteLatency.Count {
+		t.Fatalf("expected bucket total %v to equal count %v", buckets, volume.WriteLatency.Count)
+	} else if volume.WriteLatency.Sum <= 0 {
+		t.Fatal("expected write latency sum to be positive")
+	} else if volume.Health != 100 {
+		t.Fatalf("expected health 100, got %v", volume.Health)
+	}
+}
+
+func storeRandomSector(vm *storage.VolumeManager, expiration uint64) (types.Hash256, error) {
+	var sector [rhp2.SectorSize]byte
+	if _, err := frand.Read(sector[:256]); err != nil {
+		return types.Hash256{}, fmt.Errorf("failed to generate random sector: %w", err)
+	}
+	root := rhp2.SectorRoot(&sector)
+	err := vm.Write(root, &sector)
+	if err != nil {
+		return types.Hash256{}, fmt.Errorf("failed to write sector: %w", err)
+	}
+
+	err = vm.AddTemporarySectors([]storage.TempSector{{Root: root, Expiration: expiration}})
+	if err != nil {
+		return types.Hash256{}, fmt.Errorf("failed to add temporary sector: %w", err)
+	}
+	return root, nil
+}
+
+func TestSectorCache(t *testing.T) {
+	const sectors = 10
+	dir := t.TempDir()
+
+	// create the database
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// initialize the storage manager
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithCacheSize(sectors/2)) // cache half the sectors
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	volumeFilePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	vol, err := vm.AddVolume(context.Background(), volumeFilePath, sectors, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	volume, err := vm.Volume(vol.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkFileSize(volumeFilePath, int64(sectors*rhp2.SectorSize)); err != nil {
+		t.Fatal(err)
+	} else if volume.TotalSectors != sectors {
+		t.Fatalf("expected %v total sectors, got %v", sectors, volume.TotalSectors)
+	} else if volume.UsedSectors != 0 {
+		t.Fatalf("expected 0 used sectors, got %v", volume.UsedSectors)
+	}
+
+	roots := make([]types.Hash256, 0, sectors)
+	// fill the volume
+	for i := 0; i < cap(roots); i++ {
+		root, err := storeRandomSector(vm, uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+
+		// validate the volume stats are correct
+		volumes, err := vm.Volumes()
+		if err != nil {
+			t.Fatal(err)
+		} else if volumes[0].UsedSectors != uint64(i+1) {
+			t.Fatalf("expected %v used sectors, got %v", i+1, volumes[0].UsedSectors)
+		}
+	}
+
+	// read the last 5 sectors all sectors should be cached
+	for i, root := range roots[5:] {
+		_, err := vm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hits, misses := vm.CacheStats()
+		if hits != uint64(i+1) {
+			t.Fatalf("expected %v cache hits, got %v", i+1, hits)
+		} else if misses != 0 {
+			t.Fatalf("expected 0 cache misses, got %v", misses)
+		}
+	}
+
+	// read the first 5 sectors all sectors should be missed
+	for i, root := range roots[:5] {
+		_, err := vm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hits, misses := vm.CacheStats()
+		if hits != 5 {
+			t.Fatalf("expected 5 cache hits, got %v", hits) // existing 5 cache hits
+		} else if misses != uint64(i+1) {
+			t.Fatalf("expected %v cache misses, got %v", i+1, misses)
+		}
+	}
+
+	// read the first 5 sectors again all sectors should be cached
+	for i, root := range roots[:5] {
+		_, err := vm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedHits := 5 + (uint64(i) + 1) // 5 original hits, plus the new hit
+		hits, misses := vm.CacheStats()
+		if hits != expectedHits {
+			t.Fatalf("expected %d cache hits, got %v", expectedHits, hits)
+		} else if misses != 5 {
+			t.Fatalf("expected %v cache misses, got %v", 5, misses) // existing 5 cache misses
+		}
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	const sectors = 10
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the memory cache is disabled by default so every read is served by
+	// the disk cache or the volume
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	if _, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), sectors, result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	root1, err := storeRandomSector(vm, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root2, err := storeRandomSector(vm, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cache a single sector
+	cachePath := filepath.Join(t.TempDir(), "cache.dat")
+	if err := vm.ConfigureDiskCache(cachePath, 1); err != nil {
+		t.Fatal(err)
+	} else if err := checkFileSize(cachePath, rhp2.SectorSize); err != nil {
+		t.Fatal(err)
+	}
+
+	readSector := func(t *testing.T, root types.Hash256) {
+		t.Helper()
+
+		sector, err := vm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		} else if rhp2.SectorRoot(sector) != root {
+			t.Fatal("sector root mismatch")
+		}
+	}
+
+	// waitForHit reads a sector until it is served from the disk cache.
+	// Sectors are added to the cache in the background.
+	waitForHit := func(t *testing.T, root types.Hash256) {
+		t.Helper()
+
+		for i := 0; i < 100; i++ {
+			hits, _ := vm.DiskCacheStats()
+			readSector(t, root)
+			if n, _ := vm.DiskCacheStats(); n > hits {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("expected sector to be served from the disk cache")
+	}
 
-func storeRandomSector(vm *storage.VolumeManager, expiration uint64) (types.Hash256, error) {
-	var sector [rhp2.SectorSize]byte
-	if _, err := frand.Read(sector[:256]); err != nil {
-		return types.Hash256{}, fmt.Errorf("failed to generate random sector: %w", err)
+	// the first read should not admit the sector
+	readSector(t, root1)
+	if hits, misses := vm.DiskCacheStats(); hits != 0 || misses != 1 {
+		t.Fatalf("expected 0 hits and 1 miss, got %v hits and %v misses", hits, misses)
 	}
-	root := rhp2.SectorRoot(&sector)
-	err := vm.Write(root, &sector)
-	if err != nil {
-		return types.Hash256{}, fmt.Errorf("failed to write sector: %w", err)
+	// the second miss should admit the sector
+	readSector(t, root1)
+	waitForHit(t, root1)
+
+	// admitting the second sector should evict the first
+	readSector(t, root2)
+	readSector(t, root2)
+	waitForHit(t, root2)
+
+	hits, misses := vm.DiskCacheStats()
+	readSector(t, root1)
+	if h, m := vm.DiskCacheStats(); h != hits || m != misses+1 {
+		t.Fatalf("expected evicted sector to miss, got %v hits and %v misses", h-hits, m-misses)
 	}
 
-	err = vm.AddTemporarySectors([]storage.TempSector{{Root: root, Expiration: expiration}})
-	if err != nil {
-		return types.Hash256{}, fmt.Errorf("failed to add temporary sector: %w", err)
+	// disabling the cache should remove the cache file
+	if err := vm.ConfigureDiskCache("", 0); err != nil {
+		t.Fatal(err)
+	} else if _, err := os.Stat(cachePath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected cache file to be removed, got %v", err)
+	}
+	hits, misses = vm.DiskCacheStats()
+	readSector(t, root2)
+	if h, m := vm.DiskCacheStats(); h != hits || m != misses {
+		t.Fatal("expected disabled disk cache to not be used")
 	}
-	return root, nil
 }
 
-func TestSectorCache(t *testing.T) {
+func TestSectorPlacement(t *testing.T) {
 	const sectors = 10
 	dir := t.TempDir()
 
-	// create the database
 	log := zaptest.NewLogger(t)
 	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
 	if err != nil {
@@ -1044,96 +1963,138 @@ func TestSectorCache(t *testing.T) {
 	}
 	defer db.Close()
 
-	// initialize the storage manager
-	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithCacheSize(sectors/2)) // cache half the sectors
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer vm.Close()
 
-	result := make(chan error, 1)
-	volumeFilePath := filepath.Join(t.TempDir(), "hostdata.dat")
-	vol, err := vm.AddVolume(context.Background(), volumeFilePath, sectors, result)
-	if err != nil {
-		t.Fatal(err)
-	} else if err := <-result; err != nil {
-		t.Fatal(err)
+	addVolume := func(t *testing.T) storage.Volume {
+		t.Helper()
+
+		result := make(chan error, 1)
+		vol, err := vm.AddVolume(context.Background(), filepath.Join(t.TempDir(), "hostdata.dat"), sectors, result)
+		if err != nil {
+			t.Fatal(err)
+		} else if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+		return vol
 	}
+	vol1, vol2 := addVolume(t), addVolume(t)
 
-	volume, err := vm.Volume(vol.ID)
-	if err != nil {
-		t.Fatal(err)
+	assertUsage := func(t *testing.T, used1, used2 uint64) {
+		t.Helper()
+
+		for _, expected := range []struct {
+			id   int64
+			used uint64
+		}{{vol1.ID, used1}, {vol2.ID, used2}} {
+			vol, err := vm.Volume(expected.id)
+			if err != nil {
+				t.Fatal(err)
+			} else if vol.UsedSectors != expected.used {
+				t.Fatalf("expected volume %v to have %v used sectors, got %v", expected.id, expected.used, vol.UsedSectors)
+			}
+		}
 	}
 
-	if err := checkFileSize(volumeFilePath, int64(sectors*rhp2.SectorSize)); err != nil {
+	if strategy, err := vm.PlacementStrategy(); err != nil {
 		t.Fatal(err)
-	} else if volume.TotalSectors != sectors {
-		t.Fatalf("expected %v total sectors, got %v", sectors, volume.TotalSectors)
-	} else if volume.UsedSectors != 0 {
-		t.Fatalf("expected 0 used sectors, got %v", volume.UsedSectors)
+	} else if strategy != storage.PlacementLeastWritten {
+		t.Fatalf("expected default strategy %q, got %q", storage.PlacementLeastWritten, strategy)
+	} else if err := vm.SetPlacementStrategy("invalid"); !errors.Is(err, storage.ErrInvalidPlacementStrategy) {
+		t.Fatalf("expected ErrInvalidPlacementStrategy, got %v", err)
 	}
 
-	roots := make([]types.Hash256, 0, sectors)
-	// fill the volume
-	for i := 0; i < cap(roots); i++ {
-		root, err := storeRandomSector(vm, uint64(i))
-		if err != nil {
-			t.Fatal(err)
-		}
-		roots = append(roots, root)
+	var roots []types.Hash256
+	storeSectors := func(t *testing.T, n int) {
+		t.Helper()
 
-		// validate the volume stats are correct
-		volumes, err := vm.Volumes()
-		if err != nil {
-			t.Fatal(err)
-		} else if volumes[0].UsedSectors != uint64(i+1) {
-			t.Fatalf("expected %v used sectors, got %v", i+1, volumes[0].UsedSectors)
+		for i := 0; i < n; i++ {
+			root, err := storeRandomSector(vm, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roots = append(roots, root)
 		}
 	}
 
-	// read the last 5 sectors all sectors should be cached
-	for i, root := range roots[5:] {
-		_, err := vm.ReadSector(root)
-		if err != nil {
-			t.Fatal(err)
-		}
+	// the default strategy should not store sectors in a volume with a
+	// weight of zero while another volume has space
+	if err := vm.SetVolumePlacement(vol1.ID, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	storeSectors(t, 2)
+	assertUsage(t, 0, 2)
 
-		hits, misses := vm.CacheStats()
-		if hits != uint64(i+1) {
-			t.Fatalf("expected %v cache hits, got %v", i+1, hits)
-		} else if misses != 0 {
-			t.Fatalf("expected 0 cache misses, got %v", misses)
-		}
+	// new sectors should only be stored in the volume with the highest
+	// priority
+	if err := vm.SetPlacementStrategy(storage.PlacementFillFirst); err != nil {
+		t.Fatal(err)
+	} else if err := vm.SetVolumePlacement(vol1.ID, 1, 0); err != nil {
+		t.Fatal(err)
+	} else if err := vm.SetVolumePlacement(vol2.ID, 1, 1); err != nil {
+		t.Fatal(err)
 	}
+	storeSectors(t, 2)
+	assertUsage(t, 0, 4)
 
-	// read the first 5 sectors all sectors should be missed
-	for i, root := range roots[:5] {
-		_, err := vm.ReadSector(root)
-		if err != nil {
-			t.Fatal(err)
-		}
+	// round-robin should alternate between volumes with the same priority
+	if err := vm.SetPlacementStrategy(storage.PlacementRoundRobin); err != nil {
+		t.Fatal(err)
+	} else if err := vm.SetVolumePlacement(vol2.ID, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	storeSectors(t, 4)
+	assertUsage(t, 2, 6)
 
-		hits, misses := vm.CacheStats()
-		if hits != 5 {
-			t.Fatalf("expected 5 cache hits, got %v", hits) // existing 5 cache hits
-		} else if misses != uint64(i+1) {
-			t.Fatalf("expected %v cache misses, got %v", i+1, misses)
-		}
+	// rebalancing should move sectors to match the proportional strategy
+	if err := vm.SetPlacementStrategy(storage.PlacementProportional); err != nil {
+		t.Fatal(err)
+	}
+	result := make(chan error, 1)
+	if err := vm.RebalanceVolumes(context.Background(), result); err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
 	}
+	assertUsage(t, 4, 4)
 
-	// read the first 5 sectors again all sectors should be cached
-	for i, root := range roots[:5] {
-		_, err := vm.ReadSector(root)
-		if err != nil {
+	for _, root := range roots {
+		if sector, err := vm.ReadSector(root); err != nil {
 			t.Fatal(err)
+		} else if rhp2.SectorRoot(sector) != root {
+			t.Fatal("sector root mismatch")
 		}
+	}
+}
 
-		expectedHits := 5 + (uint64(i) + 1) // 5 original hits, plus the new hit
-		hits, misses := vm.CacheStats()
-		if hits != expectedHits {
-			t.Fatalf("expected %d cache hits, got %v", expectedHits, hits)
-		} else if misses != 5 {
-			t.Fatalf("expected %v cache misses, got %v", 5, misses) // existing 5 cache misses
+func TestPlacementTargets(t *testing.T) {
+	volumes := []storage.Volume{
+		{ID: 1, Available: true, TotalSectors: 10, UsedSectors: 6, Weight: 1},
+		{ID: 2, Available: true, TotalSectors: 30, UsedSectors: 2, Weight: 1},
+		{ID: 3, Available: true, TotalSectors: 10, UsedSectors: 2, Weight: 1, Priority: -1}, // overflow
+		{ID: 4, Available: true, ReadOnly: true, TotalSectors: 10, UsedSectors: 5, Weight: 1},
+	}
+
+	tests := []struct {
+		strategy string
+		expected map[int64]uint64
+	}{
+		{storage.PlacementFillFirst, map[int64]uint64{1: 10, 2: 0, 3: 0}},
+		{storage.PlacementProportional, map[int64]uint64{1: 3, 2: 7, 3: 0}},
+		{storage.PlacementRoundRobin, map[int64]uint64{1: 5, 2: 5, 3: 0}},
+	}
+	for _, test := range tests {
+		targets := storage.PlacementTargets(test.strategy, volumes)
+		if len(targets) != len(test.expected) {
+			t.Fatalf("%s: expected %v targets, got %v", test.strategy, len(test.expected), len(targets))
+		}
+		for id, expected := range test.expected {
+			if targets[id] != expected {
+				t.Fatalf("%s: expected volume %v target %v, got %v", test.strategy, id, expected, targets[id])
+			}
 		}
 	}
 }
@@ -1223,56 +2184,159 @@ func TestStoragePrune(t *testing.T) {
 	assertUsedSectors(t, 0)
 }
 
-func BenchmarkVolumeManagerWrite(b *testing.B) {
-	dir := b.TempDir()
+func TestVolumeScrub(t *testing.T) {
+	const sectors = 10
+	dir := t.TempDir()
 
 	// create the database
-	log := zaptest.NewLogger(b)
+	log := zaptest.NewLogger(t)
 	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	}
 	defer db.Close()
 
 	// initialize the storage manager
-	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithScrubRate(0))
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	}
 	defer vm.Close()
 
 	result := make(chan error, 1)
-	volumeFilePath := filepath.Join(b.TempDir(), "hostdata.dat")
-	_, err = vm.AddVolume(context.Background(), volumeFilePath, uint64(b.N), result)
+	volumePath := filepath.Join(t.TempDir(), "hostdata.dat")
+	volume, err := vm.AddVolume(context.Background(), volumePath, sectors, result)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	} else if err := <-result; err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	}
 
-	sectors := make([][rhp2.SectorSize]byte, b.N)
-	roots := make([]types.Hash256, b.N)
-	for i := range sectors {
-		frand.Read(sectors[i][:256])
-		roots[i] = rhp2.SectorRoot(&sectors[i])
+	if _, err := vm.VolumeScrub(volume.ID); !errors.Is(err, storage.ErrScrubNotFound) {
+		t.Fatalf("expected ErrScrubNotFound, got %v", err)
 	}
 
-	b.ResetTimer()
-	b.ReportAllocs()
-	b.SetBytes(rhp2.SectorSize)
-
-	// fill the volume
-	for i := 0; i < b.N; i++ {
-		root, sector := roots[i], sectors[i]
-		err := vm.Write(root, &sector)
+	roots := make([]types.Hash256, 0, sectors)
+	for i := 0; i < sectors; i++ {
+		root, err := storeRandomSector(vm, 1)
 		if err != nil {
-			b.Fatal(i, err)
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	// corrupt a sector in the volume
+	corruptIndex := frand.Intn(sectors)
+	f, err := os.OpenFile(volumePath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := f.WriteAt(frand.Bytes(512), int64(rhp2.SectorSize*corruptIndex)); err != nil {
+		t.Fatal(err)
+	} else if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	} else if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForScrub := func(t *testing.T) storage.VolumeScrub {
+		t.Helper()
+
+		for i := 0; i < 100; i++ {
+			scrub, err := vm.VolumeScrub(volume.ID)
+			if err != nil {
+				t.Fatal(err)
+			} else if scrub.Status == storage.ScrubStatusComplete {
+				return scrub
+			}
+			time.Sleep(100 * time.Millisecond)
 		}
+		t.Fatal("scrub did not complete")
+		return storage.VolumeScrub{}
+	}
+
+	if err := vm.StartScrub(volume.ID); err != nil {
+		t.Fatal(err)
+	}
+	scrub := waitForScrub(t)
+	if scrub.CheckedSectors != sectors {
+		t.Fatalf("expected %d checked sectors, got %d", sectors, scrub.CheckedSectors)
+	} else if scrub.CorruptSectors != 1 {
+		t.Fatalf("expected 1 corrupt sector, got %d", scrub.CorruptSectors)
+	} else if scrub.FailedReads != 0 {
+		t.Fatalf("expected 0 failed reads, got %d", scrub.FailedReads)
+	} else if scrub.EndTime.IsZero() {
+		t.Fatal("expected end time to be set")
+	}
+
+	scrubErrs, err := vm.ScrubErrors(volume.ID, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(scrubErrs) != 1 {
+		t.Fatalf("expected 1 scrub error, got %d", len(scrubErrs))
+	} else if scrubErrs[0].Root != roots[corruptIndex] {
+		t.Fatalf("expected corrupt sector %v, got %v", roots[corruptIndex], scrubErrs[0].Root)
+	} else if scrubErrs[0].Index != uint64(corruptIndex) {
+		t.Fatalf("expected index %d, got %d", corruptIndex, scrubErrs[0].Index)
+	}
+
+	// the corrupt sector should be reported as lost
+	lost, _, err := vm.LostSectors(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(lost) != 1 {
+		t.Fatalf("expected 1 lost sector, got %d", len(lost))
+	} else if lost[0].Root != roots[corruptIndex] || lost[0].Cause != storage.LostSectorCauseScrubCorrupt || lost[0].VolumeID != volume.ID {
+		t.Fatalf("unexpected lost sector %+v", lost[0])
+	}
+
+	// pausing a completed scrub should fail
+	if err := vm.PauseScrub(volume.ID); !errors.Is(err, storage.ErrScrubNotRunning) {
+		t.Fatalf("expected ErrScrubNotRunning, got %v", err)
+	}
+
+	// starting a new scrub should reset the progress and errors
+	if err := vm.StartScrub(volume.ID); err != nil {
+		t.Fatal(err)
+	}
+	scrub = waitForScrub(t)
+	if scrub.CheckedSectors != sectors {
+		t.Fatalf("expected %d checked sectors, got %d", sectors, scrub.CheckedSectors)
+	} else if scrub.CorruptSectors != 1 {
+		t.Fatalf("expected 1 corrupt sector, got %d", scrub.CorruptSectors)
+	}
+
+	scrubErrs, err = vm.ScrubErrors(volume.ID, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(scrubErrs) != 1 {
+		t.Fatalf("expected 1 scrub error, got %d", len(scrubErrs))
+	}
+
+	// a sector that failed to read once should no longer be lost after a
+	// scrub reads it correctly
+	healthyIndex := (corruptIndex + 1) % sectors
+	if err := vm.MarkSectorsLost([]types.Hash256{roots[healthyIndex]}, storage.LostSectorCauseScrubReadFailed); err != nil {
+		t.Fatal(err)
+	} else if _, count, err := vm.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Fatalf("expected 2 lost sectors, got %d", count)
+	} else if err := vm.StartScrub(volume.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitForScrub(t)
+
+	lost, _, err = vm.LostSectors(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(lost) != 1 || lost[0].Root != roots[corruptIndex] {
+		t.Fatalf("expected only the corrupt sector to be lost, got %+v", lost)
 	}
 }
 
-func BenchmarkNewVolume(b *testing.B) {
-	const sectors = 100
+// newBenchmarkVolumeManager initializes a volume manager with the given
+// number of volumes. Each volume has space for b.N sectors.
+func newBenchmarkVolumeManager(b *testing.B, volumes int) *storage.VolumeManager {
 	dir := b.TempDir()
 
 	// create the database
@@ -1281,32 +2345,98 @@ func BenchmarkNewVolume(b *testing.B) {
 	if err != nil {
 		b.Fatal(err)
 	}
-	defer db.Close()
+	b.Cleanup(func() { db.Close() })
 
 	// initialize the storage manager
 	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")))
 	if err != nil {
 		b.Fatal(err)
 	}
-	defer vm.Close()
-
-	b.ResetTimer()
-	b.ReportMetric(float64(sectors), "sectors")
-	b.SetBytes(sectors * rhp2.SectorSize)
+	b.Cleanup(func() { vm.Close() })
 
 	result := make(chan error, 1)
-	for i := 0; i < b.N; i++ {
-		volumeFilePath := filepath.Join(b.TempDir(), "hostdata.dat")
-		_, err = vm.AddVolume(context.Background(), volumeFilePath, sectors, result)
+	for i := 0; i < volumes; i++ {
+		volumeFilePath := filepath.Join(b.TempDir(), fmt.Sprintf("hostdata%d.dat", i))
+		_, err = vm.AddVolume(context.Background(), volumeFilePath, uint64(b.N), result)
 		if err != nil {
 			b.Fatal(err)
 		} else if err := <-result; err != nil {
 			b.Fatal(err)
 		}
 	}
+	return vm
 }
 
-func BenchmarkVolumeManagerRead(b *testing.B) {
+func BenchmarkVolumeManagerWrite(b *testing.B) {
+	benchmarks := []struct {
+		name     string
+		volumes  int
+		parallel bool
+		// remove alternates between writing a sector and removing the
+		// previously written sector
+		remove bool
+	}{
+		{"1 volume", 1, false, false},
+		{"1 volume parallel", 1, true, false},
+		{"4 volumes parallel", 4, true, false},
+		{"8 volumes parallel", 8, true, false},
+		{"4 volumes parallel with removals", 4, true, true},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			vm := newBenchmarkVolumeManager(b, bm.volumes)
+
+			sectors := make([][rhp2.SectorSize]byte, b.N)
+			roots := make([]types.Hash256, b.N)
+			for i := range sectors {
+				frand.Read(sectors[i][:256])
+				roots[i] = rhp2.SectorRoot(&sectors[i])
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			b.SetBytes(rhp2.SectorSize)
+
+			if !bm.parallel {
+				// fill the volume
+				for i := 0; i < b.N; i++ {
+					root, sector := roots[i], sectors[i]
+					err := vm.Write(root, &sector)
+					if err != nil {
+						b.Fatal(i, err)
+					}
+				}
+				return
+			}
+
+			// fill the volumes concurrently
+			var next atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				prev := -1
+				for pb.Next() {
+					if bm.remove && prev >= 0 {
+						if err := vm.RemoveSector(roots[prev]); err != nil {
+							b.Error(prev, err)
+							return
+						}
+						prev = -1
+						continue
+					}
+
+					i := next.Add(1) - 1
+					if err := vm.Write(roots[i], &sectors[i]); err != nil {
+						b.Error(i, err)
+						return
+					}
+					prev = int(i)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkNewVolume(b *testing.B) {
+	const sectors = 100
 	dir := b.TempDir()
 
 	// create the database
@@ -1324,36 +2454,80 @@ func BenchmarkVolumeManagerRead(b *testing.B) {
 	}
 	defer vm.Close()
 
-	result := make(chan error, 1)
-	volumeFilePath := filepath.Join(b.TempDir(), "hostdata.dat")
-	_, err = vm.AddVolume(context.Background(), volumeFilePath, uint64(b.N), result)
-	if err != nil {
-		b.Fatal(err)
-	} else if err := <-result; err != nil {
-		b.Fatal(err)
-	}
+	b.ResetTimer()
+	b.ReportMetric(float64(sectors), "sectors")
+	b.SetBytes(sectors * rhp2.SectorSize)
 
-	// fill the volume
-	written := make([]types.Hash256, 0, b.N)
+	result := make(chan error, 1)
 	for i := 0; i < b.N; i++ {
-		var sector [rhp2.SectorSize]byte
-		frand.Read(sector[:256])
-		root := rhp2.SectorRoot(&sector)
-		err := vm.Write(root, &sector)
+		volumeFilePath := filepath.Join(b.TempDir(), "hostdata.dat")
+		_, err = vm.AddVolume(context.Background(), volumeFilePath, sectors, result)
 		if err != nil {
-			b.Fatal(i, err)
+			b.Fatal(err)
+		} else if err := <-result; err != nil {
+			b.Fatal(err)
 		}
-		written = append(written, root)
 	}
+}
 
-	b.ResetTimer()
-	b.ReportAllocs()
-	b.SetBytes(rhp2.SectorSize)
-	// read the sectors back
-	for _, root := range written {
-		if _, err := vm.ReadSector(root); err != nil {
-			b.Fatal(err)
-		}
+func BenchmarkVolumeManagerRead(b *testing.B) {
+	benchmarks := []struct {
+		name     string
+		volumes  int
+		parallel bool
+		// remove alternates between writing a sector and removing the
+		// previously written sector
+		remove bool
+	}{
+		{"1 volume", 1, false, false},
+		{"1 volume parallel", 1, true, false},
+		{"4 volumes parallel", 4, true, false},
+		{"8 volumes parallel", 8, true, false},
+		{"4 volumes parallel with removals", 4, true, true},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			vm := newBenchmarkVolumeManager(b, bm.volumes)
+
+			// fill the volumes
+			written := make([]types.Hash256, 0, b.N)
+			for i := 0; i < b.N; i++ {
+				var sector [rhp2.SectorSize]byte
+				frand.Read(sector[:256])
+				root := rhp2.SectorRoot(&sector)
+				err := vm.Write(root, &sector)
+				if err != nil {
+					b.Fatal(i, err)
+				}
+				written = append(written, root)
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			b.SetBytes(rhp2.SectorSize)
+
+			if !bm.parallel {
+				// read the sectors back
+				for _, root := range written {
+					if _, err := vm.ReadSector(root); err != nil {
+						b.Fatal(err)
+					}
+				}
+				return
+			}
+
+			// read the sectors back concurrently
+			var next atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := next.Add(1) - 1
+					if _, err := vm.ReadSector(written[i]); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
 	}
 }
 
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Sync modes control when volume data is flushed to disk.
+const (
+	// SyncModeImmediate syncs every changed volume as soon as Sync is
+	// called. This is the default mode.
+	SyncModeImmediate = "immediate"
+	// SyncModeGroup delays each call to Sync by up to the policy's window
+	// so that concurrent calls share a single sync of every changed volume.
+	SyncModeGroup = "group"
+	// SyncModeBackground periodically syncs changed volumes in the
+	// background so that less data needs to be flushed when Sync is
+	// called.
+	SyncModeBackground = "background"
+)
+
+// SyncBatchBuckets are the upper bounds of the buckets of a batch size
+// histogram. The histogram has an additional bucket for batches larger than
+// the last bound.
+var SyncBatchBuckets = [...]uint64{1, 2, 4, 8, 16, 32, 64, 128}
+
+// ErrInvalidSyncMode is returned when a sync mode is not recognized.
+var ErrInvalidSyncMode = errors.New("invalid sync mode")
+
+type (
+	// A SyncPolicy controls how sector data is flushed to disk. Regardless
+	// of the policy, a call to Sync does not return until every sector
+	// written before the call is durable.
+	SyncPolicy struct {
+		Mode string `json:"mode"`
+		// Window is the maximum time a call to Sync waits for other calls
+		// to join its batch in the group mode.
+		Window time.Duration `json:"window"`
+		// MaxBatch is the number of calls to Sync that immediately
+		// commit a batch in the group mode. A value of 0 disables the
+		// limit.
+		MaxBatch int `json:"maxBatch"`
+		// Interval is the time between syncs of changed volumes in the
+		// background mode.
+		Interval time.Duration `json:"interval"`
+	}
+
+	// SyncMetrics contains metrics about syncing sector data to disk.
+	SyncMetrics struct {
+		Policy SyncPolicy `json:"policy"`
+		// Requests is the number of calls to Sync.
+		Requests uint64 `json:"requests"`
+		// Batches is the number of times changed volumes were synced to
+		// satisfy calls to Sync.
+		Batches uint64 `json:"batches"`
+		// BackgroundSyncs is the number of times changed volumes were
+		// synced in the background.
+		BackgroundSyncs uint64 `json:"backgroundSyncs"`
+		// VolumeSyncs is the number of individual volume syncs.
+		VolumeSyncs uint64 `json:"volumeSyncs"`
+		// Latency is the time calls to Sync took to return, including
+		// the time spent waiting for a batch.
+		Latency LatencyHistogram `json:"latency"`
+		// BatchSizes counts the number of calls to Sync satisfied by
+		// each batch. BatchSizes[i] is the number of batches with a size
+		// less than or equal to SyncBatchBuckets[i] and greater than the
+		// previous bound.
+		BatchSizes [len(SyncBatchBuckets) + 1]uint64 `json:"batchSizes"`
+	}
+
+	// A syncBatch groups concurrent calls to Sync in the group mode.
+	syncBatch struct {
+		size int
+		err  error
+		// full is closed when the batch reaches the policy's max size
+		full chan struct{}
+		// done is closed after the batch has been synced
+		done chan struct{}
+	}
+)
+
+// ValidateSyncPolicy returns an error if the sync policy is not valid.
+func ValidateSyncPolicy(policy SyncPolicy) error {
+	switch policy.Mode {
+	case SyncModeImmediate:
+	case SyncModeGroup:
+		if policy.Window <= 0 {
+			return errors.New("group sync window must be greater than 0")
+		} else if policy.MaxBatch < 0 {
+			return errors.New("group sync max batch must not be negative")
+		}
+	case SyncModeBackground:
+		if policy.Interval <= 0 {
+			return errors.New("background sync interval must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidSyncMode, policy.Mode)
+	}
+	return nil
+}
+
+// observeBatchSize adds a batch to the batch size histogram.
+func (sm *SyncMetrics) observeBatchSize(n int) {
+	for i, bound := range SyncBatchBuckets {
+		if uint64(n) <= bound {
+			sm.BatchSizes[i]++
+			return
+		}
+	}
+	sm.BatchSizes[len(SyncBatchBuckets)]++
+}
+
+// syncVolumes syncs every changed volume in parallel and returns the number
+// of volumes that were synced.
+func (vm *VolumeManager) syncVolumes() (int, error) {
+	type syncResult struct {
+		synced bool
+		err    error
+	}
+
+	volumes := vm.loadedVolumes()
+	results := make(chan syncResult, len(volumes))
+	for id, vol := range volumes {
+		go func(id int64, vol *volume) {
+			synced, err := vol.SyncIfDirty()
+			if err != nil {
+				err = fmt.Errorf("failed to sync volume %v: %w", id, err)
+			}
+			results <- syncResult{synced, err}
+		}(id, vol)
+	}
+
+	var synced int
+	var syncErr error
+	for range volumes {
+		res := <-results
+		if res.err != nil && syncErr == nil {
+			syncErr = res.err
+		} else if res.synced {
+			synced++
+		}
+	}
+	return synced, syncErr
+}
+
+// syncGroup adds the caller to the pending batch and waits for the batch to
+// be synced. The first caller of a batch waits for the policy's window, or
+// until the batch is full, then syncs the changed volumes on behalf of every
+// caller in the batch.
+func (vm *VolumeManager) syncGroup() error {
+	vm.syncMu.Lock()
+	b := vm.syncBatch
+	leader := b == nil
+	if leader {
+		b = &syncBatch{
+			full: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		vm.syncBatch = b
+	}
+	b.size++
+	if vm.syncPolicy.MaxBatch > 0 && b.size >= vm.syncPolicy.MaxBatch {
+		// detach the full batch so new callers start the next batch
+		vm.syncBatch = nil
+		close(b.full)
+	}
+	vm.syncMu.Unlock()
+
+	if !leader {
+		<-b.done
+		return b.err
+	}
+
+	t := time.NewTimer(vm.syncPolicy.Window)
+	select {
+	case <-b.full:
+	case <-t.C:
+	case <-vm.tg.Done():
+	}
+	t.Stop()
+
+	// detach the batch before syncing so that any caller that joins after
+	// this point waits for a sync that starts after its writes completed
+	vm.syncMu.Lock()
+	if vm.syncBatch == b {
+		vm.syncBatch = nil
+	}
+	size := b.size
+	vm.syncMu.Unlock()
+
+	synced, err := vm.syncVolumes()
+	b.err = err
+	close(b.done)
+
+	vm.syncMu.Lock()
+	vm.syncMetrics.Batches++
+	vm.syncMetrics.VolumeSyncs += uint64(synced)
+	vm.syncMetrics.observeBatchSize(size)
+	vm.syncMu.Unlock()
+	return err
+}
+
+// Sync syncs the data files of changed volumes according to the sync
+// policy. Sync does not return until every sector written before it was
+// called is durable.
+func (vm *VolumeManager) Sync() error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	start := time.Now()
+	if vm.syncPolicy.Mode == SyncModeGroup {
+		err = vm.syncGroup()
+	} else {
+		var synced int
+		synced, err = vm.syncVolumes()
+		vm.syncMu.Lock()
+		vm.syncMetrics.Batches++
+		vm.syncMetrics.VolumeSyncs += uint64(synced)
+		vm.syncMetrics.observeBatchSize(1)
+		vm.syncMu.Unlock()
+	}
+
+	vm.syncMu.Lock()
+	vm.syncMetrics.Requests++
+	vm.syncMetrics.Latency.Observe(time.Since(start))
+	vm.syncMu.Unlock()
+	return err
+}
+
+// SyncMetrics returns the sync policy and metrics about syncing sector data
+// to disk.
+func (vm *VolumeManager) SyncMetrics() SyncMetrics {
+	vm.syncMu.Lock()
+	defer vm.syncMu.Unlock()
+	metrics := vm.syncMetrics
+	metrics.Policy = vm.syncPolicy
+	return metrics
+}
+
+// watchBackgroundSync periodically syncs changed volumes in the background.
+func (vm *VolumeManager) watchBackgroundSync() {
+	ctx, cancel, err := vm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vm.syncPolicy.Interval):
+		}
+
+		synced, err := vm.syncVolumes()
+		if err != nil {
+			vm.log.Error("failed to sync volumes", zap.Error(err))
+		}
+		vm.syncMu.Lock()
+		vm.syncMetrics.BackgroundSyncs++
+		vm.syncMetrics.VolumeSyncs += uint64(synced)
+		vm.syncMu.Unlock()
+	}
+}
@@ -0,0 +1,89 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/persist/sqlite"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSyncPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithSyncPolicy(storage.SyncPolicy{Mode: "never"}))
+	if !errors.Is(err, storage.ErrInvalidSyncMode) {
+		t.Fatalf("expected ErrInvalidSyncMode, got %v", err)
+	}
+
+	// use a long window so the batch is only committed when it is full
+	const callers = 8
+	policy := storage.SyncPolicy{
+		Mode:     storage.SyncModeGroup,
+		Window:   time.Minute,
+		MaxBatch: callers,
+	}
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithSyncPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	for _, name := range []string{"vol1.dat", "vol2.dat"} {
+		if _, err := vm.AddVolume(context.Background(), filepath.Join(dir, name), 32, result); err != nil {
+			t.Fatal(err)
+		} else if err := <-result; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := storeRandomSector(vm, 100); err != nil {
+				errCh <- err
+				return
+			}
+			errCh <- vm.Sync()
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metrics := vm.SyncMetrics()
+	switch {
+	case metrics.Policy != policy:
+		t.Fatalf("expected policy %v, got %v", policy, metrics.Policy)
+	case metrics.Requests != callers:
+		t.Fatalf("expected %d requests, got %d", callers, metrics.Requests)
+	case metrics.Batches != 1:
+		t.Fatalf("expected 1 batch, got %d", metrics.Batches)
+	case metrics.BatchSizes[3] != 1: // 8 callers
+		t.Fatalf("expected a batch of %d, got %v", callers, metrics.BatchSizes)
+	case metrics.VolumeSyncs == 0:
+		t.Fatal("expected volumes to be synced")
+	case metrics.Latency.Count != callers:
+		t.Fatalf("expected %d latency observations, got %d", callers, metrics.Latency.Count)
+	}
+}
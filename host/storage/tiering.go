@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.uber.org/zap"
+)
+
+// Volume tiers control which volumes sectors are moved between by sector
+// tiering.
+const (
+	// VolumeTierNone excludes a volume from sector tiering. This is the
+	// default tier.
+	VolumeTierNone = ""
+	// VolumeTierFast marks a volume on fast storage. Frequently read
+	// sectors are moved to fast volumes.
+	VolumeTierFast = "fast"
+	// VolumeTierBulk marks a volume on large, slower storage. Sectors that
+	// have not been accessed recently are moved to bulk volumes.
+	VolumeTierBulk = "bulk"
+)
+
+// tieringBatchSize is the number of sector locations retrieved from the store
+// at a time.
+const tieringBatchSize = 64
+
+var (
+	// ErrInvalidVolumeTier is returned when a volume tier is not recognized.
+	ErrInvalidVolumeTier = errors.New("invalid volume tier")
+	// ErrTieringRunning is returned when trying to start sector tiering
+	// while it is already running.
+	ErrTieringRunning = errors.New("tiering already running")
+)
+
+type (
+	// A TieringPolicy controls when sectors are moved between fast and bulk
+	// volumes.
+	TieringPolicy struct {
+		// Interval is the time between tiering runs. An interval of 0
+		// disables automatic tiering and sector read tracking.
+		Interval time.Duration `json:"interval"`
+		// HotReads is the minimum number of recent reads for a sector in a
+		// bulk volume to be moved to a fast volume. Read counts are halved
+		// after every run so older reads count for less.
+		HotReads uint64 `json:"hotReads"`
+		// HotWindow is the maximum time since a sector's last read for it
+		// to be moved to a fast volume.
+		HotWindow time.Duration `json:"hotWindow"`
+		// ColdAge is the minimum time since a sector in a fast volume was
+		// last read or written for it to be moved to a bulk volume.
+		ColdAge time.Duration `json:"coldAge"`
+		// MaxSectors is the maximum number of sectors moved per run. A value
+		// of 0 disables the limit.
+		MaxSectors uint64 `json:"maxSectors"`
+		// MaxRate is the maximum number of bytes per second moved between
+		// volumes. A value of 0 disables the limit.
+		MaxRate uint64 `json:"maxRate"`
+	}
+
+	// VolumeTierStats contains sector tiering stats for a volume.
+	VolumeTierStats struct {
+		VolumeID int64  `json:"volumeID"`
+		Tier     string `json:"tier"`
+		// HotSectors is the number of sectors in the volume that meet the
+		// policy's hot criteria.
+		HotSectors uint64 `json:"hotSectors"`
+		// ColdSectors is the number of sectors in the volume that meet the
+		// policy's cold criteria.
+		ColdSectors uint64 `json:"coldSectors"`
+
+		// MovedIn and MovedOut are the number of sectors moved into and out
+		// of the volume since the volume manager was started. FailedMoves is
+		// the number of sectors that could not be moved out of the volume.
+		MovedIn     uint64 `json:"movedIn"`
+		MovedOut    uint64 `json:"movedOut"`
+		FailedMoves uint64 `json:"failedMoves"`
+	}
+
+	// TieringStatus contains the status of sector tiering.
+	TieringStatus struct {
+		Policy  TieringPolicy     `json:"policy"`
+		Running bool              `json:"running"`
+		LastRun time.Time         `json:"lastRun"`
+		Volumes []VolumeTierStats `json:"volumes"`
+	}
+
+	// tieringState tracks the progress of sector tiering. It is protected
+	// by the volume manager's mutex.
+	tieringState struct {
+		running bool
+		lastRun time.Time
+		// readAfter is the start of the read window of the previous run.
+		// Sectors last read before it have already had their reads reset.
+		readAfter time.Time
+		moves     map[int64]*VolumeTierStats
+	}
+)
+
+// ValidateVolumeTier returns an error if the tier is not recognized.
+func ValidateVolumeTier(tier string) error {
+	switch tier {
+	case VolumeTierNone, VolumeTierFast, VolumeTierBulk:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidVolumeTier, tier)
+	}
+}
+
+// recordTierMove updates the move stats of the source and destination
+// volumes.
+func (vm *VolumeManager) recordTierMove(from, to int64, err error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	stats := func(id int64) *VolumeTierStats {
+		s, ok := vm.tiering.moves[id]
+		if !ok {
+			s = &VolumeTierStats{VolumeID: id}
+			vm.tiering.moves[id] = s
+		}
+		return s
+	}
+	if err != nil {
+		stats(from).FailedMoves++
+		return
+	}
+	stats(from).MovedOut++
+	stats(to).MovedIn++
+}
+
+// moveSectorsToTier moves the sectors returned by next to volumes in the
+// destination tier until next returns no sectors, the budget is exhausted, or
+// the tier is full. The number of sectors moved is returned.
+func (vm *VolumeManager) moveSectorsToTier(ctx context.Context, tier string, budget uint64, delay time.Duration, next func(limit int) ([]SectorLocation, error)) (moved uint64, err error) {
+	log := vm.log.Named("tiering").With(zap.String("tier", tier))
+	for budget == 0 || moved < budget {
+		limit := tieringBatchSize
+		if budget > 0 && budget-moved < uint64(limit) {
+			limit = int(budget - moved)
+		}
+
+		locations, err := next(limit)
+		if err != nil {
+			return moved, fmt.Errorf("failed to get sectors: %w", err)
+		} else if len(locations) == 0 {
+			return moved, nil
+		}
+
+		var batchMoved int
+		for _, loc := range locations {
+			start := time.Now()
+			select {
+			case <-ctx.Done():
+				return moved, ctx.Err()
+			default:
+			}
+
+			var dest SectorLocation
+			err := vm.vs.TierSector(loc, tier, func(from, to SectorLocation) error {
+				dest = to
+				vol, ok := vm.loadedVolume(to.Volume)
+				if !ok || vol.Status() != VolumeStatusReady {
+					return fmt.Errorf("volume %v is not ready", to.Volume)
+				}
+				return vm.migrateSector(from, to)
+			})
+			switch {
+			case errors.Is(err, ErrNotEnoughStorage):
+				log.Debug("no space left in tier")
+				return moved, nil
+			case errors.Is(err, ErrSectorNotFound):
+				// the sector was moved or removed after it was selected
+				continue
+			case err != nil:
+				log.Warn("failed to move sector", zap.Stringer("root", loc.Root), zap.Int64("volumeID", loc.Volume), zap.Error(err))
+				vm.recordTierMove(loc.Volume, dest.Volume, err)
+			default:
+				vm.recordTierMove(loc.Volume, dest.Volume, nil)
+				moved++
+				batchMoved++
+			}
+
+			if wait := delay - time.Since(start); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return moved, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		// stop if every sector in the batch failed to avoid retrying the
+		// same sectors
+		if batchMoved == 0 {
+			return moved, nil
+		}
+	}
+	return moved, nil
+}
+
+// TierSectors moves sectors that have not been accessed recently from fast
+// volumes to bulk volumes, then moves frequently read sectors from bulk
+// volumes to fast volumes. The number of sectors moved and the rate they are
+// moved at are limited by the tiering policy.
+func (vm *VolumeManager) TierSectors(ctx context.Context) error {
+	ctx, cancel, err := vm.tg.AddContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	vm.mu.Lock()
+	if vm.tiering.running {
+		vm.mu.Unlock()
+		return ErrTieringRunning
+	}
+	vm.tiering.running = true
+	prevReadAfter := vm.tiering.readAfter
+	vm.mu.Unlock()
+
+	defer func() {
+		vm.mu.Lock()
+		vm.tiering.running = false
+		vm.tiering.lastRun = time.Now()
+		vm.mu.Unlock()
+	}()
+
+	// persist any pending reads so they are considered
+	vm.recorder.Flush()
+
+	log := vm.log.Named("tiering")
+	policy := vm.tieringPolicy
+	start := time.Now()
+
+	// delay is the minimum time spent on each sector to limit the I/O rate
+	var delay time.Duration
+	if policy.MaxRate > 0 {
+		delay = time.Duration(float64(time.Second) * proto2.SectorSize / float64(policy.MaxRate))
+	}
+
+	// demote cold sectors first to free space for hot sectors
+	accessBefore := start.Add(-policy.ColdAge)
+	demoted, err := vm.moveSectorsToTier(ctx, VolumeTierBulk, policy.MaxSectors, delay, func(limit int) ([]SectorLocation, error) {
+		return vm.vs.ColdSectors(accessBefore, limit)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to demote cold sectors: %w", err)
+	}
+
+	var promoted uint64
+	readAfter := start.Add(-policy.HotWindow)
+	if policy.MaxSectors == 0 || demoted < policy.MaxSectors {
+		var budget uint64
+		if policy.MaxSectors > 0 {
+			budget = policy.MaxSectors - demoted
+		}
+		promoted, err = vm.moveSectorsToTier(ctx, VolumeTierFast, budget, delay, func(limit int) ([]SectorLocation, error) {
+			return vm.vs.HotSectors(policy.HotReads, readAfter, limit)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to promote hot sectors: %w", err)
+		}
+	}
+
+	if err := vm.vs.DecaySectorReads(readAfter, prevReadAfter); err != nil {
+		return fmt.Errorf("failed to decay sector reads: %w", err)
+	}
+	vm.mu.Lock()
+	vm.tiering.readAfter = readAfter
+	vm.mu.Unlock()
+	log.Info("tiered sectors", zap.Uint64("promoted", promoted), zap.Uint64("demoted", demoted), zap.Duration("elapsed", time.Since(start)))
+	return nil
+}
+
+// TieringStatus returns the status of sector tiering and the tiering stats of
+// every volume in a tier.
+func (vm *VolumeManager) TieringStatus() (TieringStatus, error) {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return TieringStatus{}, err
+	}
+	defer done()
+
+	policy := vm.tieringPolicy
+	now := time.Now()
+	stats, err := vm.vs.VolumeTierStats(policy.HotReads, now.Add(-policy.HotWindow), now.Add(-policy.ColdAge))
+	if err != nil {
+		return TieringStatus{}, fmt.Errorf("failed to get volume tier stats: %w", err)
+	}
+
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	for i := range stats {
+		if moves, ok := vm.tiering.moves[stats[i].VolumeID]; ok {
+			stats[i].MovedIn = moves.MovedIn
+			stats[i].MovedOut = moves.MovedOut
+			stats[i].FailedMoves = moves.FailedMoves
+		}
+	}
+	return TieringStatus{
+		Policy:  policy,
+		Running: vm.tiering.running,
+		LastRun: vm.tiering.lastRun,
+		Volumes: stats,
+	}, nil
+}
+
+// SetVolumeTier sets the storage tier of a volume.
+func (vm *VolumeManager) SetVolumeTier(id int64, tier string) error {
+	done, err := vm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := ValidateVolumeTier(tier); err != nil {
+		return err
+	} else if err := vm.vs.SetVolumeTier(id, tier); err != nil {
+		return fmt.Errorf("failed to set volume tier: %w", err)
+	}
+	return nil
+}
+
+// watchTiering periodically moves sectors between fast and bulk volumes.
+func (vm *VolumeManager) watchTiering() {
+	ctx, cancel, err := vm.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vm.tieringPolicy.Interval):
+		}
+
+		if err := vm.TierSectors(ctx); err != nil && !errors.Is(err, ErrTieringRunning) && !errors.Is(err, context.Canceled) {
+			vm.log.Error("failed to tier sectors", zap.Error(err))
+		}
+	}
+}
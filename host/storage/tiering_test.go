@@ -0,0 +1,149 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/storage"
+	"go.sia.tech/hostd/persist/sqlite"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTierSectors(t *testing.T) {
+	dir := t.TempDir()
+
+	log := zaptest.NewLogger(t)
+	db, err := sqlite.OpenDatabase(filepath.Join(dir, "hostd.db"), log.Named("sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the interval is long enough that tiering is only run manually, but
+	// sector reads are still tracked
+	policy := storage.TieringPolicy{
+		Interval:  24 * time.Hour,
+		HotReads:  2,
+		HotWindow: time.Hour,
+		ColdAge:   24 * time.Hour,
+	}
+	vm, err := storage.NewVolumeManager(db, storage.WithLogger(log.Named("volumes")), storage.WithTieringPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.Close()
+
+	result := make(chan error, 1)
+	bulk, err := vm.AddVolume(context.Background(), filepath.Join(dir, "bulk.dat"), 10, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	// store the sectors before adding the fast volume so they are all
+	// placed in the bulk volume
+	roots := make([]types.Hash256, 4)
+	for i := range roots {
+		roots[i], err = storeRandomSector(vm, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fast, err := vm.AddVolume(context.Background(), filepath.Join(dir, "fast.dat"), 10, result)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.SetVolumeTier(bulk.ID, storage.VolumeTierBulk); err != nil {
+		t.Fatal(err)
+	} else if err := vm.SetVolumeTier(fast.ID, storage.VolumeTierFast); err != nil {
+		t.Fatal(err)
+	} else if err := vm.SetVolumeTier(fast.ID, "invalid"); err == nil {
+		t.Fatal("expected invalid tier to be rejected")
+	}
+
+	// read the first two sectors enough times to make them hot
+	for _, root := range roots[:2] {
+		for i := 0; i < 3; i++ {
+			if _, err := vm.ReadSector(root); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := vm.TierSectors(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertUsed := func(id int64, expected uint64) {
+		t.Helper()
+		vol, err := vm.Volume(id)
+		if err != nil {
+			t.Fatal(err)
+		} else if vol.UsedSectors != expected {
+			t.Fatalf("expected volume %d to have %d used sectors, got %d", id, expected, vol.UsedSectors)
+		}
+	}
+	assertUsed(bulk.ID, 2)
+	assertUsed(fast.ID, 2)
+
+	status, err := vm.TieringStatus()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(status.Volumes) != 2 {
+		t.Fatalf("expected 2 tiered volumes, got %d", len(status.Volumes))
+	}
+	for _, stats := range status.Volumes {
+		switch stats.VolumeID {
+		case bulk.ID:
+			if stats.Tier != storage.VolumeTierBulk || stats.MovedOut != 2 || stats.MovedIn != 0 {
+				t.Fatalf("unexpected bulk volume stats %+v", stats)
+			}
+		case fast.ID:
+			if stats.Tier != storage.VolumeTierFast || stats.MovedIn != 2 || stats.MovedOut != 0 {
+				t.Fatalf("unexpected fast volume stats %+v", stats)
+			}
+		}
+	}
+
+	// the promoted sectors should still be readable
+	for _, root := range roots[:2] {
+		if _, err := vm.ReadSector(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the read counts are halved after each run, so the sectors read once
+	// should not be promoted
+	for _, root := range roots[2:] {
+		if _, err := vm.ReadSector(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := vm.TierSectors(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertUsed(bulk.ID, 2)
+	assertUsed(fast.ID, 2)
+
+	// sectors in the fast volume are cold once they have not been accessed
+	// since the cutoff
+	cold, err := db.ColdSectors(time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(cold) != 2 {
+		t.Fatalf("expected 2 cold sectors, got %d", len(cold))
+	}
+	for _, loc := range cold {
+		if loc.Volume != fast.ID {
+			t.Fatalf("expected cold sector in volume %d, got %d", fast.ID, loc.Volume)
+		}
+	}
+}
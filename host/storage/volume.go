@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	rhp2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
@@ -27,14 +29,27 @@ type (
 	volume struct {
 		recorder *sectorAccessRecorder
 
-		// when reading or writing to the volume, a read lock should be held.
-		// When resizing or updating the volume's state, a write lock should be
-		// held.
+		// dirty is set when data has been written to the volume since it
+		// was last synced
+		dirty atomic.Bool
+		// syncMu serializes syncs so that a sync does not return before a
+		// concurrent sync of the same data has completed
+		syncMu sync.Mutex
+
+		// when reading, writing, or syncing the volume, a read lock should
+		// be held. When opening, resizing, or closing the volume, a write
+		// lock should be held.
 		mu sync.RWMutex
 
 		location string     // location is the path to the volume's file
 		data     volumeData // data is a flatfile that stores the volume's sector data
-		stats    VolumeStats
+
+		// statsMu protects the volume's stats separately from mu so that
+		// recording the result of an operation does not block I/O.
+		statsMu       sync.Mutex
+		stats         VolumeStats
+		readBaseline  latencyBaseline
+		writeBaseline latencyBaseline
 	}
 
 	// VolumeStats contains statistics about a volume
@@ -45,6 +60,14 @@ type (
 		SuccessfulWrites uint64  `json:"successfulWrites"`
 		Status           string  `json:"status"`
 		Errors           []error `json:"errors"`
+
+		ReadLatency  LatencyHistogram `json:"readLatency"`
+		WriteLatency LatencyHistogram `json:"writeLatency"`
+		SyncLatency  LatencyHistogram `json:"syncLatency"`
+		// Health is a score between 0 and 100 comparing the volume's recent
+		// read and write latency to its own baseline. A low score indicates
+		// the disk has slowed down and may be failing.
+		Health float64 `json:"health"`
 	}
 
 	// A Volume stores and retrieves sector data
@@ -55,6 +78,19 @@ type (
 		TotalSectors uint64 `json:"totalSectors"`
 		ReadOnly     bool   `json:"readOnly"`
 		Available    bool   `json:"available"`
+		// UUID is the persistent identity of the volume. It is stored in a
+		// sidecar file next to the volume and checked when the volume is
+		// loaded.
+		UUID string `json:"uuid"`
+		// Weight and Priority control how new sectors are placed in the
+		// volume. See the placement strategies for details.
+		Weight   uint64 `json:"weight"`
+		Priority int64  `json:"priority"`
+		// AutoGrow is the policy used to automatically resize the volume.
+		AutoGrow AutoGrowPolicy `json:"autoGrow"`
+		// Tier is the storage tier of the volume. Volumes without a tier are
+		// ignored by sector tiering.
+		Tier string `json:"tier"`
 	}
 
 	// VolumeMeta contains the metadata of a volume.
@@ -67,27 +103,35 @@ type (
 // ErrVolumeNotAvailable is returned when a volume is not available
 var ErrVolumeNotAvailable = errors.New("volume not available")
 
-func (v *volume) incrementReadStats(err error) {
-	v.mu.Lock()
-	defer v.mu.Unlock()
+// errPreallocateNotSupported is returned when the volume's filesystem does
+// not support preallocating space.
+var errPreallocateNotSupported = errors.New("preallocation not supported")
+
+func (v *volume) incrementReadStats(err error, latency time.Duration) {
+	v.statsMu.Lock()
+	defer v.statsMu.Unlock()
 	if err != nil {
 		v.stats.FailedReads++
 		v.appendError(err)
 	} else {
 		v.recorder.AddRead()
 		v.stats.SuccessfulReads++
+		v.stats.ReadLatency.Observe(latency)
+		v.readBaseline.observe(latency)
 	}
 }
 
-func (v *volume) incrementWriteStats(err error) {
-	v.mu.Lock()
-	defer v.mu.Unlock()
+func (v *volume) incrementWriteStats(err error, latency time.Duration) {
+	v.statsMu.Lock()
+	defer v.statsMu.Unlock()
 	if err != nil {
 		v.stats.FailedWrites++
 		v.appendError(err)
 	} else {
 		v.recorder.AddWrite()
 		v.stats.SuccessfulWrites++
+		v.stats.WriteLatency.Observe(latency)
+		v.writeBaseline.observe(latency)
 	}
 }
 
@@ -119,32 +163,31 @@ func (v *volume) OpenVolume(localPath string, reload bool) error {
 	if v.data != nil && !reload {
 		return nil
 	}
+	// set the location before opening so alerts for volumes that fail to
+	// open can be identified
+	v.location = localPath
 	f, err := os.OpenFile(localPath, os.O_RDWR, 0700)
 	if err != nil {
 		return err
 	}
-	v.location = localPath
 	v.data = f
 	return nil
 }
 
-// SetStatus sets the status of the volume. If the new status is resizing, the
-// volume must be ready. If the new status is removing, the volume must be ready
-// or unavailable.
+// SetStatus sets the status of the volume. If the new status is resizing,
+// evacuating, or receiving, the volume must be ready. If the new status is
+// removing, the volume must be ready or unavailable. A volume that is already
+// busy cannot be given the same status again.
 func (v *volume) SetStatus(status string) error {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-
-	if v.stats.Status == status {
-		return nil
-	}
+	v.statsMu.Lock()
+	defer v.statsMu.Unlock()
 
 	switch status {
 	case VolumeStatusRemoving:
 		if v.stats.Status != VolumeStatusReady && v.stats.Status != VolumeStatusUnavailable {
 			return fmt.Errorf("volume is %v", v.stats.Status)
 		}
-	case VolumeStatusResizing:
+	case VolumeStatusResizing, VolumeStatusEvacuating, VolumeStatusReceiving:
 		if v.stats.Status != VolumeStatusReady {
 			return fmt.Errorf("volume is %v", v.stats.Status)
 		}
@@ -157,8 +200,8 @@ func (v *volume) SetStatus(status string) error {
 }
 
 func (v *volume) Status() string {
-	v.mu.RLock()
-	defer v.mu.RUnlock()
+	v.statsMu.Lock()
+	defer v.statsMu.Unlock()
 	return v.stats.Status
 }
 
@@ -172,11 +215,13 @@ func (v *volume) ReadSector(index uint64) (*[rhp2.SectorSize]byte, error) {
 	}
 
 	var sector [rhp2.SectorSize]byte
+	start := time.Now()
 	_, err := v.data.ReadAt(sector[:], int64(index*rhp2.SectorSize))
+	latency := time.Since(start)
 	if err != nil {
 		err = fmt.Errorf("failed to read sector at index %v: %w", index, err)
 	}
-	go v.incrementReadStats(err)
+	v.incrementReadStats(err, latency)
 	return &sector, err
 }
 
@@ -188,7 +233,32 @@ func (v *volume) WriteSector(data *[rhp2.SectorSize]byte, index uint64) error {
 	if v.data == nil {
 		panic("volume not open") // developer error
 	}
+	return v.writeSector(data, index)
+}
+
+// WriteSectorSync writes a sector to the volume at index and syncs the
+// volume before returning. The volume cannot be closed or resized between
+// the write and the sync.
+func (v *volume) WriteSectorSync(data *[rhp2.SectorSize]byte, index uint64) error {
+	v.syncMu.Lock()
+	defer v.syncMu.Unlock()
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.data == nil {
+		return ErrVolumeNotAvailable
+	} else if err := v.writeSector(data, index); err != nil {
+		return err
+	}
+	return v.syncData()
+}
+
+// writeSector writes a sector to the volume at index. A read lock must be
+// held and the volume must be open.
+func (v *volume) writeSector(data *[rhp2.SectorSize]byte, index uint64) error {
+	start := time.Now()
 	_, err := v.data.WriteAt(data[:], int64(index*rhp2.SectorSize))
+	latency := time.Since(start)
 	if err != nil {
 		if isNotEnoughStorageErr(err) {
 			err = ErrNotEnoughStorage
@@ -196,21 +266,61 @@ func (v *volume) WriteSector(data *[rhp2.SectorSize]byte, index uint64) error {
 			err = fmt.Errorf("failed to write sector to index %v: %w", index, err)
 		}
 	}
-	go v.incrementWriteStats(err)
+	v.incrementWriteStats(err, latency)
+	if err == nil {
+		v.dirty.Store(true)
+	}
 	return err
 }
 
-// Sync syncs the volume
+// Sync syncs the volume. Reads and writes are not blocked while the volume
+// is syncing.
 func (v *volume) Sync() error {
-	v.mu.Lock()
-	defer v.mu.Unlock()
+	v.syncMu.Lock()
+	defer v.syncMu.Unlock()
+	return v.sync()
+}
+
+// SyncIfDirty syncs the volume if data has been written since it was last
+// synced and reports whether the volume was synced. If another sync is in
+// progress, SyncIfDirty waits for it to complete before checking whether the
+// volume is dirty.
+func (v *volume) SyncIfDirty() (bool, error) {
+	v.syncMu.Lock()
+	defer v.syncMu.Unlock()
+	if !v.dirty.Load() {
+		return false, nil
+	}
+	return true, v.sync()
+}
+
+// sync syncs the volume's data. The sync mutex must be held.
+func (v *volume) sync() error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.syncData()
+}
 
+// syncData syncs the volume's data. The sync mutex and a read lock must be
+// held.
+func (v *volume) syncData() error {
 	if v.data == nil {
 		return nil
 	}
+	// clear the dirty flag before syncing so writes that complete during
+	// the sync are synced by the next call
+	v.dirty.Store(false)
+	start := time.Now()
 	err := v.data.Sync()
+	latency := time.Since(start)
+
+	v.statsMu.Lock()
+	defer v.statsMu.Unlock()
 	if err != nil {
+		v.dirty.Store(true)
 		v.appendError(fmt.Errorf("failed to sync volume: %w", err))
+	} else {
+		v.stats.SyncLatency.Observe(latency)
 	}
 	return err
 }
@@ -226,10 +336,30 @@ func (v *volume) Resize(newSectors uint64) error {
 	return v.data.Truncate(int64(newSectors * rhp2.SectorSize))
 }
 
-func (v *volume) Stats() VolumeStats {
+// Preallocate reserves the disk space required to grow the volume from
+// oldSectors to newSectors without changing the size of the volume.
+func (v *volume) Preallocate(oldSectors, newSectors uint64) error {
+	// preallocating does not change the file size, so a read lock is
+	// sufficient
 	v.mu.RLock()
 	defer v.mu.RUnlock()
-	return v.stats
+
+	if v.data == nil {
+		return ErrVolumeNotAvailable
+	}
+	f, ok := v.data.(*os.File)
+	if !ok {
+		return errPreallocateNotSupported
+	}
+	return preallocate(f, int64(oldSectors*rhp2.SectorSize), int64((newSectors-oldSectors)*rhp2.SectorSize))
+}
+
+func (v *volume) Stats() VolumeStats {
+	v.statsMu.Lock()
+	defer v.statsMu.Unlock()
+	stats := v.stats
+	stats.Health = min(v.readBaseline.health(), v.writeBaseline.health())
+	return stats
 }
 
 // Close closes the volume
@@ -244,6 +374,10 @@ func (v *volume) Close() error {
 		return fmt.Errorf("failed to close volume: %w", err)
 	}
 	v.data = nil
+	v.dirty.Store(false)
+
+	v.statsMu.Lock()
 	v.stats.Status = VolumeStatusUnavailable
+	v.statsMu.Unlock()
 	return nil
 }
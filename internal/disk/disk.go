@@ -1,2 +1,11 @@
 // Package disk provides cross platform disk usage information
 package disk
+
+// Stats contains usage information about a filesystem.
+type Stats struct {
+	FreeBytes   uint64 `json:"freeBytes"`
+	TotalBytes  uint64 `json:"totalBytes"`
+	FreeInodes  uint64 `json:"freeInodes"`
+	TotalInodes uint64 `json:"totalInodes"`
+	ReadOnly    bool   `json:"readOnly"`
+}
@@ -0,0 +1,277 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/alerts"
+	"go.sia.tech/hostd/internal/threadgroup"
+	"go.uber.org/zap"
+)
+
+type (
+	// A MonitoredPath is a path whose filesystem is monitored.
+	MonitoredPath struct {
+		Name string
+		Path string
+		// Critical marks paths that hostd cannot operate without, such as
+		// the database directory. Alerts for critical paths have a higher
+		// severity.
+		Critical bool
+	}
+
+	// A FilesystemStatus is the result of the most recent check of a
+	// monitored path's filesystem.
+	FilesystemStatus struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+		Stats
+		Error     string    `json:"error,omitempty"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	// Thresholds determine when the monitor registers alerts. A threshold
+	// of zero disables the check.
+	Thresholds struct {
+		MinFreeBytes         uint64
+		MinFreePercent       float64
+		MinFreeInodesPercent float64
+	}
+
+	// Alerts registers and dismisses global alerts.
+	Alerts interface {
+		Register(alerts.Alert)
+		Dismiss(...types.Hash256)
+	}
+
+	// A Monitor periodically checks the free space, inode usage, and
+	// read-only status of the filesystems containing a set of paths.
+	Monitor struct {
+		interval   time.Duration
+		thresholds Thresholds
+		paths      func() ([]MonitoredPath, error)
+
+		alerts Alerts
+		tg     *threadgroup.ThreadGroup
+		log    *zap.Logger
+
+		mu       sync.Mutex // protects the following fields
+		statuses []FilesystemStatus
+		// active tracks registered alerts so they can be dismissed once the
+		// condition clears or the path is no longer monitored
+		active map[types.Hash256]bool
+	}
+
+	// A MonitorOption sets options on a Monitor.
+	MonitorOption func(*Monitor)
+)
+
+// WithMonitorLog sets the logger for the Monitor.
+func WithMonitorLog(l *zap.Logger) MonitorOption {
+	return func(m *Monitor) {
+		m.log = l
+	}
+}
+
+// WithMonitorAlerts sets the alerts manager for the Monitor.
+func WithMonitorAlerts(a Alerts) MonitorOption {
+	return func(m *Monitor) {
+		m.alerts = a
+	}
+}
+
+// WithMonitorInterval sets the interval between checks.
+func WithMonitorInterval(d time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.interval = d
+	}
+}
+
+// WithThresholds sets the thresholds at which alerts are registered.
+func WithThresholds(t Thresholds) MonitorOption {
+	return func(m *Monitor) {
+		m.thresholds = t
+	}
+}
+
+func monitorAlertID(path, kind string) types.Hash256 {
+	return types.HashBytes([]byte("diskMonitor" + path + kind))
+}
+
+// percent returns n as a percentage of total.
+func percent(n, total uint64) float64 {
+	return float64(n) / float64(total) * 100
+}
+
+// checkFilesystem returns the alerts that should be registered for a
+// path's filesystem.
+func checkFilesystem(p MonitoredPath, stats Stats, thresholds Thresholds, timestamp time.Time) (registered []alerts.Alert) {
+	severity := alerts.SeverityWarning
+	if p.Critical {
+		severity = alerts.SeverityCritical
+	}
+
+	data := map[string]any{
+		"name":        p.Name,
+		"path":        p.Path,
+		"freeBytes":   stats.FreeBytes,
+		"totalBytes":  stats.TotalBytes,
+		"freeInodes":  stats.FreeInodes,
+		"totalInodes": stats.TotalInodes,
+	}
+
+	if stats.ReadOnly {
+		registered = append(registered, alerts.Alert{
+			ID:        monitorAlertID(p.Path, "readOnly"),
+			Severity:  alerts.SeverityCritical,
+			Message:   "Filesystem is read-only",
+			Data:      data,
+			Timestamp: timestamp,
+		})
+	}
+
+	lowSpace := stats.FreeBytes < thresholds.MinFreeBytes
+	if thresholds.MinFreePercent > 0 && stats.TotalBytes > 0 {
+		lowSpace = lowSpace || percent(stats.FreeBytes, stats.TotalBytes) < thresholds.MinFreePercent
+	}
+	if lowSpace {
+		registered = append(registered, alerts.Alert{
+			ID:        monitorAlertID(p.Path, "space"),
+			Severity:  severity,
+			Message:   "Filesystem is low on free space",
+			Data:      data,
+			Timestamp: timestamp,
+		})
+	}
+
+	// some filesystems do not report inodes
+	if thresholds.MinFreeInodesPercent > 0 && stats.TotalInodes > 0 && percent(stats.FreeInodes, stats.TotalInodes) < thresholds.MinFreeInodesPercent {
+		registered = append(registered, alerts.Alert{
+			ID:        monitorAlertID(p.Path, "inodes"),
+			Severity:  severity,
+			Message:   "Filesystem is low on free inodes",
+			Data:      data,
+			Timestamp: timestamp,
+		})
+	}
+	return
+}
+
+// check updates the status of every monitored path and registers or
+// dismisses alerts.
+func (m *Monitor) check() {
+	paths, err := m.paths()
+	if err != nil {
+		// continue with the paths that were returned
+		m.log.Error("failed to get monitored paths", zap.Error(err))
+	}
+
+	now := time.Now()
+	statuses := make([]FilesystemStatus, 0, len(paths))
+	active := make(map[types.Hash256]bool)
+	for _, p := range paths {
+		status := FilesystemStatus{
+			Name:      p.Name,
+			Path:      p.Path,
+			Timestamp: now,
+		}
+
+		stats, err := Stat(p.Path)
+		if err != nil {
+			m.log.Warn("failed to check filesystem", zap.String("name", p.Name), zap.String("path", p.Path), zap.Error(err))
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+
+			alert := alerts.Alert{
+				ID:       monitorAlertID(p.Path, "error"),
+				Severity: alerts.SeverityError,
+				Message:  "Failed to check filesystem",
+				Data: map[string]any{
+					"name":  p.Name,
+					"path":  p.Path,
+					"error": err.Error(),
+				},
+				Timestamp: now,
+			}
+			m.alerts.Register(alert)
+			active[alert.ID] = true
+			continue
+		}
+		status.Stats = stats
+		statuses = append(statuses, status)
+
+		for _, alert := range checkFilesystem(p, stats, m.thresholds, now) {
+			m.log.Warn(alert.Message, zap.String("name", p.Name), zap.String("path", p.Path), zap.Uint64("freeBytes", stats.FreeBytes), zap.Uint64("freeInodes", stats.FreeInodes), zap.Bool("readOnly", stats.ReadOnly))
+			m.alerts.Register(alert)
+			active[alert.ID] = true
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.active {
+		if !active[id] {
+			m.alerts.Dismiss(id)
+		}
+	}
+	m.active = active
+	m.statuses = statuses
+}
+
+// Filesystems returns the most recent status of each monitored path.
+func (m *Monitor) Filesystems() []FilesystemStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]FilesystemStatus(nil), m.statuses...)
+}
+
+// Close stops the monitor.
+func (m *Monitor) Close() error {
+	m.tg.Stop()
+	return nil
+}
+
+func (m *Monitor) run() {
+	ctx, cancel, err := m.tg.AddContext(context.Background())
+	if err != nil {
+		return
+	}
+	defer cancel()
+
+	for {
+		m.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.interval):
+		}
+	}
+}
+
+// NewMonitor creates a new Monitor. paths is called before every check to
+// get the paths to monitor.
+func NewMonitor(paths func() ([]MonitoredPath, error), opts ...MonitorOption) (*Monitor, error) {
+	m := &Monitor{
+		interval: time.Minute,
+		paths:    paths,
+
+		alerts: alerts.NewNop(),
+		tg:     threadgroup.New(),
+		log:    zap.NewNop(),
+
+		active: make(map[types.Hash256]bool),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.interval <= 0 {
+		return nil, fmt.Errorf("monitor interval must be greater than 0")
+	}
+
+	go m.run()
+	return m, nil
+}
@@ -0,0 +1,160 @@
+package disk
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/alerts"
+	"go.uber.org/zap/zaptest"
+)
+
+type alertRecorder struct {
+	mu     sync.Mutex
+	alerts map[types.Hash256]alerts.Alert
+}
+
+func (ar *alertRecorder) Register(a alerts.Alert) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.alerts[a.ID] = a
+}
+
+func (ar *alertRecorder) Dismiss(ids ...types.Hash256) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	for _, id := range ids {
+		delete(ar.alerts, id)
+	}
+}
+
+func (ar *alertRecorder) Messages() map[string]bool {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	messages := make(map[string]bool)
+	for _, a := range ar.alerts {
+		messages[a.Message] = true
+	}
+	return messages
+}
+
+func TestCheckFilesystem(t *testing.T) {
+	thresholds := Thresholds{
+		MinFreeBytes:         1 << 30,
+		MinFreePercent:       10,
+		MinFreeInodesPercent: 5,
+	}
+
+	tests := []struct {
+		name     string
+		stats    Stats
+		messages []string
+	}{
+		{
+			name:  "healthy",
+			stats: Stats{FreeBytes: 50 << 30, TotalBytes: 100 << 30, FreeInodes: 50, TotalInodes: 100},
+		},
+		{
+			name:     "low bytes",
+			stats:    Stats{FreeBytes: 1 << 20, TotalBytes: 1 << 21, FreeInodes: 50, TotalInodes: 100},
+			messages: []string{"Filesystem is low on free space"},
+		},
+		{
+			name:     "low percent",
+			stats:    Stats{FreeBytes: 5 << 30, TotalBytes: 100 << 30, FreeInodes: 50, TotalInodes: 100},
+			messages: []string{"Filesystem is low on free space"},
+		},
+		{
+			name:     "low inodes",
+			stats:    Stats{FreeBytes: 50 << 30, TotalBytes: 100 << 30, FreeInodes: 1, TotalInodes: 100},
+			messages: []string{"Filesystem is low on free inodes"},
+		},
+		{
+			name:  "no inodes reported",
+			stats: Stats{FreeBytes: 50 << 30, TotalBytes: 100 << 30},
+		},
+		{
+			name:     "read-only",
+			stats:    Stats{FreeBytes: 0, TotalBytes: 100 << 30, FreeInodes: 0, TotalInodes: 100, ReadOnly: true},
+			messages: []string{"Filesystem is read-only", "Filesystem is low on free space", "Filesystem is low on free inodes"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registered := checkFilesystem(MonitoredPath{Name: "test", Path: "/test"}, test.stats, thresholds, time.Now())
+			if len(registered) != len(test.messages) {
+				t.Fatalf("expected %d alerts, got %d", len(test.messages), len(registered))
+			}
+			for i := range registered {
+				if registered[i].Message != test.messages[i] {
+					t.Fatalf("expected alert %q, got %q", test.messages[i], registered[i].Message)
+				}
+			}
+		})
+	}
+
+	// critical paths should raise the severity of space alerts
+	registered := checkFilesystem(MonitoredPath{Name: "database", Path: "/db", Critical: true}, Stats{FreeBytes: 1, TotalBytes: 100}, thresholds, time.Now())
+	if len(registered) != 1 || registered[0].Severity != alerts.SeverityCritical {
+		t.Fatalf("expected a critical alert, got %v", registered)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	paths := []MonitoredPath{
+		{Name: "database", Path: dir, Critical: true},
+		{Name: "missing", Path: filepath.Join(dir, "missing")},
+	}
+	getPaths := func() ([]MonitoredPath, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]MonitoredPath(nil), paths...), nil
+	}
+
+	ar := &alertRecorder{alerts: make(map[types.Hash256]alerts.Alert)}
+	// a threshold above 100% always registers an alert
+	m, err := NewMonitor(getPaths, WithMonitorLog(zaptest.NewLogger(t)), WithMonitorAlerts(ar), WithMonitorInterval(10*time.Millisecond), WithThresholds(Thresholds{MinFreePercent: 101}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	waitFor := func(fn func() bool) {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			if fn() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("timed out")
+	}
+
+	waitFor(func() bool {
+		messages := ar.Messages()
+		return messages["Filesystem is low on free space"] && messages["Failed to check filesystem"]
+	})
+
+	statuses := m.Filesystems()
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 filesystems, got %d", len(statuses))
+	} else if statuses[0].Error != "" || statuses[0].TotalBytes == 0 {
+		t.Fatalf("expected database filesystem stats, got %+v", statuses[0])
+	} else if statuses[1].Error == "" {
+		t.Fatal("expected missing path to have an error")
+	}
+
+	// alerts for paths that are no longer monitored should be dismissed
+	mu.Lock()
+	paths = paths[:1]
+	mu.Unlock()
+	waitFor(func() bool {
+		return !ar.Messages()["Failed to check filesystem"]
+	})
+}
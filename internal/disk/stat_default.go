@@ -0,0 +1,17 @@
+//go:build !linux
+
+package disk
+
+// Stat returns usage information about the filesystem containing the
+// specified path. Inode usage and the read-only flag are only available on
+// Linux.
+func Stat(p string) (Stats, error) {
+	free, total, err := Usage(p)
+	if err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		FreeBytes:  free,
+		TotalBytes: total,
+	}, nil
+}
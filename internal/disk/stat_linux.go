@@ -0,0 +1,23 @@
+//go:build linux
+
+package disk
+
+import "golang.org/x/sys/unix"
+
+// Stat returns usage information about the filesystem containing the
+// specified path.
+func Stat(p string) (Stats, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(p, &stat); err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		// use the blocks available to unprivileged users since hostd is
+		// not expected to run as root
+		FreeBytes:   stat.Bavail * uint64(stat.Bsize),
+		TotalBytes:  stat.Blocks * uint64(stat.Bsize),
+		FreeInodes:  stat.Ffree,
+		TotalInodes: stat.Files,
+		ReadOnly:    stat.Flags&unix.ST_RDONLY != 0,
+	}, nil
+}
@@ -16,6 +16,7 @@ import (
 	"go.sia.tech/core/types"
 	rhp4 "go.sia.tech/coreutils/rhp/v4"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/host/contracts"
 	"go.sia.tech/hostd/internal/testutil"
 	"go.sia.tech/hostd/rhp"
 	"go.uber.org/zap"
@@ -49,7 +50,7 @@ func (fs *fundAndSign) Address() types.Address {
 }
 
 func testRenterHostPair(tb testing.TB, hostKey types.PrivateKey, hn *testutil.HostNode, log *zap.Logger) rhp4.TransportClient {
-	rs := rhp4.NewServer(hostKey, hn.Chain, hn.Syncer, hn.Contracts, hn.Wallet, hn.Settings, hn.Volumes, rhp4.WithPriceTableValidity(2*time.Minute))
+	rs := rhp4.NewServer(hostKey, hn.Contracts.RHP4Chain(hn.Chain), hn.Syncer, hn.Contracts, hn.Contracts.RHP4Wallet(hn.Wallet), hn.Settings, hn.Volumes, rhp4.WithPriceTableValidity(2*time.Minute))
 
 	l, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -1405,3 +1406,159 @@ func BenchmarkContractUpload(b *testing.B) {
 		b.Fatalf("expected %v sectors, got %v", b.N, appendResult.Revision.Filesize/proto4.SectorSize)
 	}
 }
+
+func TestRefusedRenter(t *testing.T) {
+	n, genesis := testutil.V2Network()
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	hn := testutil.NewHostNode(t, hostKey, n, genesis, zap.NewNop())
+	cm := hn.Chain
+	w := hn.Wallet
+
+	results := make(chan error, 1)
+	if _, err := hn.Volumes.AddVolume(context.Background(), filepath.Join(t.TempDir(), "test.dat"), 10, results); err != nil {
+		t.Fatal(err)
+	} else if err := <-results; err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.MineAndSync(t, hn, w.Address(), int(n.MaturityDelay+20))
+
+	transport := testRenterHostPair(t, hostKey, hn, zap.NewNop())
+
+	settings, err := rhp4.RPCSettings(context.Background(), transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fundAndSign := &fundAndSign{w, renterKey}
+
+	deny := func(t *testing.T) {
+		t.Helper()
+		if err := hn.Settings.UpdateRenterACLEntry(renterKey.PublicKey(), "", time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { hn.Settings.RemoveRenterACLEntry(renterKey.PublicKey()) })
+	}
+
+	limit := func(t *testing.T, quota contracts.RenterQuota) {
+		t.Helper()
+		if err := hn.Contracts.SetRenterQuota(renterKey.PublicKey(), quota); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { hn.Contracts.RemoveRenterQuota(renterKey.PublicKey()) })
+	}
+
+	// assertRefused checks that fn fails with the reason and that the host's
+	// inputs are released without adding anything to the pool
+	assertRefused := func(t *testing.T, reason string, fn func() error) {
+		t.Helper()
+
+		spendable, err := w.SpendableOutputs()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fn(); err == nil || !strings.Contains(err.Error(), reason) {
+			t.Fatalf("expected %q error, got %v", reason, err)
+		}
+
+		if after, err := w.SpendableOutputs(); err != nil {
+			t.Fatal(err)
+		} else if len(after) != len(spendable) {
+			t.Fatalf("expected %d spendable outputs, got %d", len(spendable), len(after))
+		} else if txns := cm.V2PoolTransactions(); len(txns) != 0 {
+			t.Fatalf("expected no pool transactions, got %d", len(txns))
+		}
+	}
+
+	formContract := func() (rhp4.RPCFormContractResult, error) {
+		return rhp4.RPCFormContract(context.Background(), transport, cm, fundAndSign, cm.TipState(), settings.Prices, hostKey.PublicKey(), settings.WalletAddress, proto4.RPCFormContractParams{
+			RenterPublicKey: renterKey.PublicKey(),
+			RenterAddress:   w.Address(),
+			Allowance:       types.Siacoins(100),
+			Collateral:      types.Siacoins(200),
+			ProofHeight:     cm.Tip().Height + 50,
+		})
+	}
+
+	t.Run("form denied", func(t *testing.T) {
+		deny(t)
+		assertRefused(t, "not allowed", func() error {
+			_, err := formContract()
+			return err
+		})
+	})
+
+	t.Run("form over quota", func(t *testing.T) {
+		limit(t, contracts.RenterQuota{MaxCollateral: types.Siacoins(100)})
+		assertRefused(t, "renter quota exceeded", func() error {
+			_, err := formContract()
+			return err
+		})
+	})
+
+	formResult, err := formContract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revision := formResult.Contract
+	// mine a few blocks to confirm the contract
+	testutil.MineAndSync(t, hn, types.VoidAddress, 10)
+
+	renewContract := func() error {
+		_, err := rhp4.RPCRenewContract(context.Background(), transport, cm, fundAndSign, cm.TipState(), settings.Prices, revision.Revision, proto4.RPCRenewContractParams{
+			ContractID:  revision.ID,
+			Allowance:   types.Siacoins(150),
+			Collateral:  types.Siacoins(300),
+			ProofHeight: revision.Revision.ProofHeight + 10,
+		})
+		return err
+	}
+
+	t.Run("renew denied", func(t *testing.T) {
+		deny(t)
+		assertRefused(t, "not allowed", renewContract)
+	})
+
+	t.Run("renew over quota", func(t *testing.T) {
+		limit(t, contracts.RenterQuota{MaxCollateral: types.Siacoins(250)})
+		assertRefused(t, "renter quota exceeded", renewContract)
+	})
+
+	t.Run("append over quota", func(t *testing.T) {
+		cs := cm.TipState()
+		account := proto4.Account(renterKey.PublicKey())
+		fundResult, err := rhp4.RPCFundAccounts(context.Background(), transport, cs, renterKey, revision, []proto4.AccountDeposit{
+			{Account: account, Amount: types.Siacoins(25)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		revision.Revision = fundResult.Revision
+
+		token := account.Token(renterKey, hostKey.PublicKey())
+		var roots []types.Hash256
+		for i := 0; i < 2; i++ {
+			var sector [proto4.SectorSize]byte
+			frand.Read(sector[:])
+			writeResult, err := rhp4.RPCWriteSector(context.Background(), transport, settings.Prices, token, bytes.NewReader(sector[:]), proto4.SectorSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roots = append(roots, writeResult.Root)
+		}
+
+		limit(t, contracts.RenterQuota{MaxSectors: 1})
+		assertRefused(t, "renter quota exceeded", func() error {
+			_, err := rhp4.RPCAppendSectors(context.Background(), transport, cs, settings.Prices, renterKey, revision, roots)
+			return err
+		})
+
+		// the revision should not have changed
+		rs, err := rhp4.RPCLatestRevision(context.Background(), transport, revision.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if rs.Contract.RevisionNumber != revision.Revision.RevisionNumber {
+			t.Fatalf("expected revision number %d, got %d", revision.Revision.RevisionNumber, rs.Contract.RevisionNumber)
+		}
+	})
+}
@@ -167,18 +167,12 @@ func NewHostNode(t testing.TB, pk types.PrivateKey, network *consensus.Network,
 	}
 	t.Cleanup(func() { wm.Close() })
 
-	vm, err := storage.NewVolumeManager(cn.Store, storage.WithLogger(log.Named("storage")), storage.WithPruneInterval(30*time.Second))
+	vm, err := storage.NewVolumeManager(cn.Store, storage.WithLogger(log.Named("storage")), storage.WithHostKey(pk.PublicKey()), storage.WithPruneInterval(30*time.Second))
 	if err != nil {
 		t.Fatal("failed to create volume manager:", err)
 	}
 	t.Cleanup(func() { vm.Close() })
 
-	contracts, err := contracts.NewManager(cn.Store, vm, cn.Chain, cn.Syncer, wm, contracts.WithRejectAfter(10), contracts.WithRevisionSubmissionBuffer(5), contracts.WithLog(log))
-	if err != nil {
-		t.Fatal("failed to create contracts manager:", err)
-	}
-	t.Cleanup(func() { contracts.Close() })
-
 	initialSettings := settings.DefaultSettings
 	initialSettings.AcceptingContracts = true
 	initialSettings.NetAddress = "127.0.0.1"
@@ -188,6 +182,12 @@ func NewHostNode(t testing.TB, pk types.PrivateKey, network *consensus.Network,
 		t.Fatal(err)
 	}
 
+	contracts, err := contracts.NewManager(cn.Store, vm, cn.Chain, cn.Syncer, wm, contracts.WithRejectAfter(10), contracts.WithRevisionSubmissionBuffer(5), contracts.WithLog(log), contracts.WithRenterAccess(sm))
+	if err != nil {
+		t.Fatal("failed to create contracts manager:", err)
+	}
+	t.Cleanup(func() { contracts.Close() })
+
 	idx, err := index.NewManager(cn.Store, cn.Chain, contracts, wm, sm, vm, index.WithLog(log.Named("index")), index.WithBatchSize(0)) // off-by-one
 	if err != nil {
 		t.Fatal("failed to create index manager:", err)
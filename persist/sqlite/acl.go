@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/settings"
+)
+
+// RenterACL returns the renter access control list.
+func (s *Store) RenterACL() (acl settings.RenterACL, err error) {
+	err = s.transaction(func(tx *txn) error {
+		var mode sql.NullString
+		if err := tx.QueryRow(`SELECT renter_acl_mode FROM global_settings`).Scan(&mode); err != nil {
+			return fmt.Errorf("failed to get renter ACL mode: %w", err)
+		} else if !mode.Valid || mode.String == "" {
+			acl.Mode = settings.RenterACLModeDeny
+		} else {
+			acl.Mode = mode.String
+		}
+
+		rows, err := tx.Query(`SELECT public_key, note, expiration_timestamp, created_timestamp FROM renter_acl ORDER BY created_timestamp ASC`)
+		if err != nil {
+			return fmt.Errorf("failed to query renter ACL: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var entry settings.RenterACLEntry
+			if err := rows.Scan(decode(&entry.PublicKey), &entry.Note, decode(&entry.Expiration), decode(&entry.CreatedAt)); err != nil {
+				return fmt.Errorf("failed to scan renter ACL entry: %w", err)
+			}
+			acl.Entries = append(acl.Entries, entry)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// SetRenterACLMode sets the mode of the renter access control list.
+func (s *Store) SetRenterACLMode(mode string) error {
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(`UPDATE global_settings SET renter_acl_mode=$1`, mode)
+		return err
+	})
+}
+
+// UpdateRenterACLEntry adds or updates an entry in the renter access control
+// list.
+func (s *Store) UpdateRenterACLEntry(entry settings.RenterACLEntry) error {
+	const query = `INSERT INTO renter_acl (public_key, note, expiration_timestamp, created_timestamp) VALUES ($1, $2, $3, $4)
+ON CONFLICT (public_key) DO UPDATE SET note=EXCLUDED.note, expiration_timestamp=EXCLUDED.expiration_timestamp`
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(query, encode(entry.PublicKey), entry.Note, encode(entry.Expiration), encode(entry.CreatedAt))
+		return err
+	})
+}
+
+// RemoveRenterACLEntry removes an entry from the renter access control list.
+func (s *Store) RemoveRenterACLEntry(pk types.PublicKey) error {
+	return s.transaction(func(tx *txn) error {
+		res, err := tx.Exec(`DELETE FROM renter_acl WHERE public_key=$1`, encode(pk))
+		if err != nil {
+			return err
+		} else if n, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		} else if n == 0 {
+			return settings.ErrRenterACLEntryNotFound
+		}
+		return nil
+	})
+}
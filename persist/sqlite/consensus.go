@@ -485,6 +485,21 @@ func (ux *updateTx) ContractRelevant(id types.FileContractID) (relevant bool, er
 	return
 }
 
+// ContractRenewedFrom returns the ID of the contract renewed by the contract
+// with the provided id. If the contract is not a renewal, false is returned.
+func (ux *updateTx) ContractRenewedFrom(id types.FileContractID) (parentID types.FileContractID, ok bool, err error) {
+	const query = `SELECT p.contract_id FROM contracts c
+INNER JOIN contracts p ON c.renewed_from=p.id
+WHERE c.contract_id=$1`
+	err = ux.tx.QueryRow(query, encode(id)).Scan(decode(&parentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.FileContractID{}, false, nil
+	} else if err != nil {
+		return types.FileContractID{}, false, err
+	}
+	return parentID, true, nil
+}
+
 // V2ContractRelevant returns true if the v2 contract is relevant to the host.
 // Otherwise, it returns false.
 func (ux *updateTx) V2ContractRelevant(id types.FileContractID) (relevant bool, err error) {
@@ -33,6 +33,10 @@ func (s *Store) batchExpireContractSectors(height uint64) (expired int, err erro
 		}
 		expired = len(sectorIDs)
 
+		if err := pruneLostSectors(tx, sectorIDs); err != nil {
+			return fmt.Errorf("failed to prune lost sectors: %w", err)
+		}
+
 		// decrement the contract metrics
 		if err := incrementNumericStat(tx, metricContractSectors, -len(sectorIDs), time.Now()); err != nil {
 			return fmt.Errorf("failed to decrement contract sectors: %w", err)
@@ -50,6 +54,10 @@ func (s *Store) batchExpireV2ContractSectors(height uint64) (expired int, err er
 		}
 		expired = len(sectorIDs)
 
+		if err := pruneLostSectors(tx, sectorIDs); err != nil {
+			return fmt.Errorf("failed to prune lost sectors: %w", err)
+		}
+
 		// decrement the contract metrics
 		if err := incrementNumericStat(tx, metricContractSectors, -len(sectorIDs), time.Now()); err != nil {
 			return fmt.Errorf("failed to decrement contract sectors: %w", err)
@@ -456,6 +464,48 @@ ORDER BY cr.contract_id, cr.root_index ASC;`
 	return
 }
 
+// ContractSectorRoots returns up to limit sector roots of a v1 or v2
+// contract starting at offset.
+func (s *Store) ContractSectorRoots(id types.FileContractID, offset, limit uint64) (roots []types.Hash256, err error) {
+	err = s.transaction(func(tx *txn) error {
+		query := `SELECT s.sector_root FROM contract_sector_roots csr
+INNER JOIN stored_sectors s ON (csr.sector_id = s.id)
+WHERE csr.contract_id=$1 AND csr.root_index >= $2
+ORDER BY csr.root_index ASC LIMIT $3;`
+
+		var contractDBID int64
+		err := tx.QueryRow(`SELECT id FROM contracts WHERE contract_id=$1`, encode(id)).Scan(&contractDBID)
+		if errors.Is(err, sql.ErrNoRows) {
+			query = `SELECT s.sector_root FROM contract_v2_sector_roots csr
+INNER JOIN stored_sectors s ON (csr.sector_id = s.id)
+WHERE csr.contract_id=$1 AND csr.root_index >= $2
+ORDER BY csr.root_index ASC LIMIT $3;`
+			err = tx.QueryRow(`SELECT id FROM contracts_v2 WHERE contract_id=$1`, encode(id)).Scan(&contractDBID)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return contracts.ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("failed to get contract: %w", err)
+		}
+
+		rows, err := tx.Query(query, contractDBID, offset, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var root types.Hash256
+			if err := rows.Scan(decode(&root)); err != nil {
+				return fmt.Errorf("failed to scan sector root: %w", err)
+			}
+			roots = append(roots, root)
+		}
+		return rows.Err()
+	})
+	return
+}
+
 // ContractActions returns the contract lifecycle actions for the given index.
 func (s *Store) ContractActions(index types.ChainIndex, revisionBroadcastHeight uint64) (actions contracts.LifecycleActions, err error) {
 	err = s.transaction(func(tx *txn) error {
@@ -652,6 +652,117 @@ WHERE c.contract_id=$1 AND csr.root_index= $2`)
 	trimSectors(t, frand.Intn(len(roots)/4)+1)
 }
 
+func TestContractSectorRoots(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const sectors = 25
+	volumeID, err := db.AddVolume("test.dat", false)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.SetAvailable(volumeID, true); err != nil {
+		t.Fatal(err)
+	} else if err = db.GrowVolume(volumeID, 2*sectors); err != nil {
+		t.Fatal(err)
+	}
+
+	storeRoots := func() []types.Hash256 {
+		t.Helper()
+		roots := make([]types.Hash256, 0, sectors)
+		for i := 0; i < sectors; i++ {
+			root := types.Hash256(frand.Entropy256())
+			if err := db.StoreSector(root, func(loc storage.SectorLocation) error { return nil }); err != nil {
+				t.Fatal(err)
+			} else if err := db.AddTemporarySectors([]storage.TempSector{{Root: root, Expiration: 100}}); err != nil {
+				t.Fatal(err)
+			}
+			roots = append(roots, root)
+		}
+		return roots
+	}
+
+	pageRoots := func(id types.FileContractID, limit uint64) []types.Hash256 {
+		t.Helper()
+		var roots []types.Hash256
+		for {
+			page, err := db.ContractSectorRoots(id, uint64(len(roots)), limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roots = append(roots, page...)
+			if uint64(len(page)) < limit {
+				return roots
+			}
+		}
+	}
+
+	renterKey := types.NewPrivateKeyFromSeed(frand.Bytes(32))
+	hostKey := types.NewPrivateKeyFromSeed(frand.Bytes(32))
+	contractUnlockConditions := types.UnlockConditions{
+		PublicKeys: []types.UnlockKey{
+			renterKey.PublicKey().UnlockKey(),
+			hostKey.PublicKey().UnlockKey(),
+		},
+		SignaturesRequired: 2,
+	}
+
+	// add a v1 contract
+	v1 := contracts.SignedRevision{
+		Revision: types.FileContractRevision{
+			ParentID:         frand.Entropy256(),
+			UnlockConditions: contractUnlockConditions,
+			FileContract: types.FileContract{
+				UnlockHash:     contractUnlockConditions.UnlockHash(),
+				RevisionNumber: 1,
+				WindowStart:    100,
+				WindowEnd:      200,
+			},
+		},
+	}
+	if err := db.AddContract(v1, []types.Transaction{}, types.ZeroCurrency, contracts.Usage{}, 0); err != nil {
+		t.Fatal(err)
+	}
+	v1Roots := storeRoots()
+	var changes []contracts.SectorChange
+	for _, root := range v1Roots {
+		changes = append(changes, contracts.SectorChange{Action: contracts.SectorActionAppend, Root: root})
+	}
+	if err := db.ReviseContract(v1, nil, contracts.Usage{}, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	// add a v2 contract
+	v2 := contracts.V2Contract{
+		ID: frand.Entropy256(),
+		V2FileContract: types.V2FileContract{
+			RevisionNumber: 1,
+		},
+	}
+	if err := db.AddV2Contract(v2, rhp4.TransactionSet{}); err != nil {
+		t.Fatal(err)
+	}
+	v2Roots := storeRoots()
+	if err := db.ReviseV2Contract(v2.ID, v2.V2FileContract, nil, v2Roots, proto4.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []uint64{1, 7, sectors, 100} {
+		if err := rootsEqual(v1Roots, pageRoots(v1.Revision.ParentID, limit)); err != nil {
+			t.Fatalf("v1 limit %d: %v", limit, err)
+		} else if err := rootsEqual(v2Roots, pageRoots(v2.ID, limit)); err != nil {
+			t.Fatalf("v2 limit %d: %v", limit, err)
+		}
+	}
+
+	if _, err := db.ContractSectorRoots(frand.Entropy256(), 0, 10); !errors.Is(err, contracts.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
 func BenchmarkTrimSectors(b *testing.B) {
 	log := zaptest.NewLogger(b)
 	db, err := OpenDatabase(filepath.Join(b.TempDir(), "test.db"), log)
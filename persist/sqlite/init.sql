@@ -25,10 +25,13 @@ CREATE INDEX wallet_events_maturity_height ON wallet_events(maturity_height DESC
 CREATE TABLE stored_sectors (
 	id INTEGER PRIMARY KEY,
 	sector_root BLOB UNIQUE NOT NULL,
-	last_access_timestamp INTEGER NOT NULL
+	last_access_timestamp INTEGER NOT NULL,
+	read_count INTEGER NOT NULL DEFAULT 0,
+	last_read_timestamp INTEGER NOT NULL DEFAULT 0
 );
 CREATE INDEX stored_sectors_sector_root ON stored_sectors(sector_root);
 CREATE INDEX stored_sectors_last_access ON stored_sectors(last_access_timestamp);
+CREATE INDEX stored_sectors_last_read ON stored_sectors(last_read_timestamp);
 
 CREATE TABLE storage_volumes (
 	id INTEGER PRIMARY KEY,
@@ -36,7 +39,13 @@ CREATE TABLE storage_volumes (
 	used_sectors INTEGER NOT NULL,
 	total_sectors INTEGER NOT NULL,
 	read_only BOOLEAN NOT NULL,
-	available BOOLEAN NOT NULL DEFAULT false
+	available BOOLEAN NOT NULL DEFAULT false,
+	volume_uuid TEXT,
+	placement_weight INTEGER NOT NULL DEFAULT 1,
+	placement_priority INTEGER NOT NULL DEFAULT 0,
+	auto_grow_max_sectors INTEGER NOT NULL DEFAULT 0,
+	auto_grow_reserved_bytes INTEGER NOT NULL DEFAULT 0,
+	volume_tier TEXT NOT NULL DEFAULT ''
 );
 CREATE INDEX storage_volumes_id_available_read_only ON storage_volumes(id, available, read_only);
 CREATE INDEX storage_volumes_read_only_available_used_sectors ON storage_volumes(available, read_only, used_sectors);
@@ -55,11 +64,124 @@ CREATE INDEX volume_sectors_volume_id ON volume_sectors(volume_id);
 CREATE INDEX volume_sectors_volume_index ON volume_sectors(volume_index ASC);
 CREATE INDEX volume_sectors_sector_id ON volume_sectors(sector_id);
 
+CREATE TABLE volume_scrubs (
+	volume_id INTEGER PRIMARY KEY REFERENCES storage_volumes(id),
+	scrub_status TEXT NOT NULL,
+	next_index INTEGER NOT NULL,
+	checked_sectors INTEGER NOT NULL,
+	corrupt_sectors INTEGER NOT NULL,
+	failed_reads INTEGER NOT NULL,
+	start_time INTEGER NOT NULL,
+	update_time INTEGER NOT NULL,
+	end_time INTEGER
+);
+
+CREATE TABLE volume_scrub_errors (
+	id INTEGER PRIMARY KEY,
+	volume_id INTEGER NOT NULL REFERENCES storage_volumes(id),
+	volume_index INTEGER NOT NULL,
+	sector_root BLOB NOT NULL,
+	actual_root BLOB NOT NULL,
+	error_message TEXT NOT NULL,
+	date_created INTEGER NOT NULL
+);
+CREATE INDEX volume_scrub_errors_volume_id ON volume_scrub_errors(volume_id);
+
+CREATE TABLE lost_sectors (
+	id INTEGER PRIMARY KEY,
+	sector_id INTEGER UNIQUE NOT NULL REFERENCES stored_sectors(id),
+	volume_id INTEGER NOT NULL, -- not a foreign key since the volume may have been removed
+	cause TEXT NOT NULL,
+	lost_timestamp INTEGER NOT NULL,
+	volume_sector_id INTEGER -- the location of a sector that was found missing or corrupt in place
+);
+CREATE INDEX lost_sectors_lost_timestamp ON lost_sectors(lost_timestamp);
+
 CREATE TABLE contract_renters (
 	id INTEGER PRIMARY KEY,
 	public_key BLOB UNIQUE NOT NULL
 );
 
+CREATE TABLE renter_quotas (
+	renter_id INTEGER PRIMARY KEY REFERENCES contract_renters(id),
+	max_sectors BLOB NOT NULL,
+	max_collateral BLOB NOT NULL,
+	max_contracts BLOB NOT NULL,
+	max_account_balance BLOB NOT NULL
+);
+
+CREATE TABLE renter_stats (
+	id INTEGER PRIMARY KEY,
+	renter_id INTEGER NOT NULL REFERENCES contract_renters(id),
+	date_created INTEGER NOT NULL,
+	active_contracts INTEGER NOT NULL,
+	successful_contracts INTEGER NOT NULL,
+	failed_contracts INTEGER NOT NULL,
+	rejected_contracts INTEGER NOT NULL,
+	renewed_contracts INTEGER NOT NULL,
+	stored_sectors INTEGER NOT NULL,
+	locked_collateral BLOB NOT NULL,
+	risked_collateral BLOB NOT NULL,
+	potential_rpc_revenue BLOB NOT NULL,
+	potential_storage_revenue BLOB NOT NULL,
+	potential_ingress_revenue BLOB NOT NULL,
+	potential_egress_revenue BLOB NOT NULL,
+	potential_account_funding BLOB NOT NULL,
+	potential_registry_read BLOB NOT NULL,
+	potential_registry_write BLOB NOT NULL,
+	potential_risked_collateral BLOB NOT NULL,
+	earned_rpc_revenue BLOB NOT NULL,
+	earned_storage_revenue BLOB NOT NULL,
+	earned_ingress_revenue BLOB NOT NULL,
+	earned_egress_revenue BLOB NOT NULL,
+	earned_account_funding BLOB NOT NULL,
+	earned_registry_read BLOB NOT NULL,
+	earned_registry_write BLOB NOT NULL,
+	earned_risked_collateral BLOB NOT NULL,
+	account_balance BLOB NOT NULL,
+	UNIQUE (renter_id, date_created)
+);
+CREATE INDEX renter_stats_date_created ON renter_stats(date_created);
+
+CREATE TABLE renter_metrics (
+	renter_id INTEGER PRIMARY KEY REFERENCES contract_renters(id),
+	active_contracts INTEGER NOT NULL,
+	successful_contracts INTEGER NOT NULL,
+	failed_contracts INTEGER NOT NULL,
+	rejected_contracts INTEGER NOT NULL,
+	renewed_contracts INTEGER NOT NULL,
+	stored_sectors INTEGER NOT NULL,
+	locked_collateral BLOB NOT NULL,
+	risked_collateral BLOB NOT NULL,
+	potential_rpc_revenue BLOB NOT NULL,
+	potential_storage_revenue BLOB NOT NULL,
+	potential_ingress_revenue BLOB NOT NULL,
+	potential_egress_revenue BLOB NOT NULL,
+	potential_account_funding BLOB NOT NULL,
+	potential_registry_read BLOB NOT NULL,
+	potential_registry_write BLOB NOT NULL,
+	potential_risked_collateral BLOB NOT NULL,
+	earned_rpc_revenue BLOB NOT NULL,
+	earned_storage_revenue BLOB NOT NULL,
+	earned_ingress_revenue BLOB NOT NULL,
+	earned_egress_revenue BLOB NOT NULL,
+	earned_account_funding BLOB NOT NULL,
+	earned_registry_read BLOB NOT NULL,
+	earned_registry_write BLOB NOT NULL,
+	earned_risked_collateral BLOB NOT NULL,
+	account_balance BLOB NOT NULL,
+	failure_rate REAL NOT NULL,
+	sort_potential_revenue BLOB NOT NULL, -- big-endian for sorting
+	sort_earned_revenue BLOB NOT NULL, -- big-endian for sorting
+	sort_locked_collateral BLOB NOT NULL, -- big-endian for sorting
+	sort_account_balance BLOB NOT NULL -- big-endian for sorting
+);
+
+-- renters whose metrics need to be recalculated, maintained by triggers
+CREATE TABLE renter_metrics_stale (
+	renter_id INTEGER PRIMARY KEY REFERENCES contract_renters(id)
+);
+
 CREATE TABLE contracts (
 	id INTEGER PRIMARY KEY,
 	renter_id INTEGER NOT NULL REFERENCES contract_renters(id),
@@ -208,6 +330,8 @@ CREATE TABLE contract_account_funding (
 	UNIQUE (contract_id, account_id)
 );
 
+CREATE INDEX contract_account_funding_account_id ON contract_account_funding(account_id);
+
 CREATE TABLE contract_v2_account_funding (
 	id INTEGER PRIMARY KEY,
 	contract_id INTEGER NOT NULL REFERENCES contracts_v2(id),
@@ -215,6 +339,73 @@ CREATE TABLE contract_v2_account_funding (
 	amount BLOB NOT NULL,
 	UNIQUE (contract_id, account_id)
 );
+CREATE INDEX contract_v2_account_funding_account_id ON contract_v2_account_funding(account_id);
+
+CREATE TRIGGER contracts_insert_renter_metrics AFTER INSERT ON contracts BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contracts_update_renter_metrics AFTER UPDATE ON contracts BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contracts_v2_insert_renter_metrics AFTER INSERT ON contracts_v2 BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contracts_v2_update_renter_metrics AFTER UPDATE ON contracts_v2 BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_account_funding_insert_renter_metrics AFTER INSERT ON contract_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts WHERE id=NEW.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_account_funding_delete_renter_metrics AFTER DELETE ON contract_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts WHERE id=OLD.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_v2_account_funding_insert_renter_metrics AFTER INSERT ON contract_v2_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts_v2 WHERE id=NEW.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_v2_account_funding_delete_renter_metrics AFTER DELETE ON contract_v2_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts_v2 WHERE id=OLD.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER accounts_update_renter_metrics AFTER UPDATE OF balance ON accounts BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT c.renter_id FROM contract_account_funding caf
+	INNER JOIN contracts c ON caf.contract_id=c.id
+	WHERE caf.account_id=NEW.id
+	UNION
+	SELECT c.renter_id FROM contract_v2_account_funding caf
+	INNER JOIN contracts_v2 c ON caf.contract_id=c.id
+	WHERE caf.account_id=NEW.id
+	ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TABLE contract_integrity_checks (
+	id INTEGER PRIMARY KEY,
+	contract_id BLOB NOT NULL,
+	v2 BOOLEAN NOT NULL,
+	start_time INTEGER NOT NULL,
+	end_time INTEGER NOT NULL,
+	total_sectors INTEGER NOT NULL,
+	checked_sectors INTEGER NOT NULL,
+	missing_sectors INTEGER NOT NULL,
+	corrupt_sectors INTEGER NOT NULL
+);
+CREATE INDEX contract_integrity_checks_contract_id_end_time ON contract_integrity_checks(contract_id, end_time DESC);
+CREATE INDEX contract_integrity_checks_end_time ON contract_integrity_checks(end_time DESC);
+
+CREATE TABLE contract_integrity_check_bad_sectors (
+	id INTEGER PRIMARY KEY,
+	check_id INTEGER NOT NULL REFERENCES contract_integrity_checks(id),
+	expected_root BLOB NOT NULL,
+	actual_root BLOB NOT NULL,
+	error_message TEXT NOT NULL
+);
+CREATE INDEX contract_integrity_check_bad_sectors_check_id ON contract_integrity_check_bad_sectors(check_id);
 
 CREATE TABLE host_stats (
 	date_created INTEGER NOT NULL,
@@ -249,7 +440,9 @@ CREATE TABLE host_settings (
 	ddns_update_v6 BOOLEAN NOT NULL,
 	ddns_opts BLOB,
 	registry_limit INTEGER NOT NULL,
-	sector_cache_size INTEGER NOT NULL DEFAULT 0
+	sector_cache_size INTEGER NOT NULL DEFAULT 0,
+	sector_cache_path TEXT NOT NULL DEFAULT '',
+	sector_cache_disk_size INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE host_pinned_settings (
@@ -273,6 +466,24 @@ CREATE TABLE webhooks (
 	secret_key TEXT UNIQUE NOT NULL
 );
 
+CREATE TABLE renter_acl (
+	public_key BLOB PRIMARY KEY NOT NULL,
+	note TEXT NOT NULL,
+	expiration_timestamp INTEGER NOT NULL,
+	created_timestamp INTEGER NOT NULL
+);
+
+CREATE TABLE pricing_profiles (
+	public_key BLOB PRIMARY KEY NOT NULL,
+	note TEXT NOT NULL,
+	contract_price BLOB, -- null uses the host's price
+	storage_price BLOB,
+	ingress_price BLOB,
+	egress_price BLOB,
+	collateral BLOB,
+	created_timestamp INTEGER NOT NULL
+);
+
 CREATE TABLE syncer_peers (
 	peer_address TEXT PRIMARY KEY NOT NULL,
 	first_seen INTEGER NOT NULL
@@ -293,7 +504,9 @@ CREATE TABLE global_settings (
 	last_scanned_index BLOB, -- chain index of the last scanned block
 	last_announce_index BLOB, -- chain index of the last host announcement
 	last_announce_address TEXT, -- address of the last host announcement
- 	last_v2_announce_hash BLOB -- hash of the last v2 host announcement
+ 	last_v2_announce_hash BLOB, -- hash of the last v2 host announcement
+	sector_placement TEXT, -- strategy used to place new sectors
+	renter_acl_mode TEXT -- mode of the renter access control list
 );
 
 -- initialize the global settings table
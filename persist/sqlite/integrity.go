@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+)
+
+// AddIntegrityCheck stores the result of a completed integrity check and
+// returns its ID.
+func (s *Store) AddIntegrityCheck(check contracts.IntegrityCheck) (id int64, err error) {
+	err = s.transaction(func(tx *txn) error {
+		const query = `INSERT INTO contract_integrity_checks (contract_id, v2, start_time, end_time, total_sectors, checked_sectors, missing_sectors, corrupt_sectors)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+		err := tx.QueryRow(query, encode(check.ContractID), check.V2, encode(check.StartTime), encode(check.EndTime), check.TotalSectors, check.CheckedSectors, check.MissingSectors, check.CorruptSectors).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to insert integrity check: %w", err)
+		} else if len(check.BadSectors) == 0 {
+			return nil
+		}
+
+		stmt, err := tx.Prepare(`INSERT INTO contract_integrity_check_bad_sectors (check_id, expected_root, actual_root, error_message) VALUES ($1, $2, $3, $4)`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, result := range check.BadSectors {
+			var errMsg string
+			if result.Error != nil {
+				errMsg = result.Error.Error()
+			}
+			if _, err := stmt.Exec(id, encode(result.ExpectedRoot), encode(result.ActualRoot), errMsg); err != nil {
+				return fmt.Errorf("failed to insert bad sector: %w", err)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// IntegrityChecks returns a paginated list of completed integrity checks
+// sorted by end time descending.
+func (s *Store) IntegrityChecks(limit, offset int) (checks []contracts.IntegrityCheck, err error) {
+	err = s.transaction(func(tx *txn) error {
+		const query = `SELECT id, contract_id, v2, start_time, end_time, total_sectors, checked_sectors, missing_sectors, corrupt_sectors
+FROM contract_integrity_checks ORDER BY end_time DESC, id DESC LIMIT $1 OFFSET $2`
+		checks, err = queryIntegrityChecks(tx, query, limit, offset)
+		return err
+	})
+	return
+}
+
+// ContractIntegrityChecks returns a paginated list of completed integrity
+// checks for a contract sorted by end time descending.
+func (s *Store) ContractIntegrityChecks(id types.FileContractID, limit, offset int) (checks []contracts.IntegrityCheck, err error) {
+	err = s.transaction(func(tx *txn) error {
+		const query = `SELECT id, contract_id, v2, start_time, end_time, total_sectors, checked_sectors, missing_sectors, corrupt_sectors
+FROM contract_integrity_checks WHERE contract_id=$1 ORDER BY end_time DESC, id DESC LIMIT $2 OFFSET $3`
+		checks, err = queryIntegrityChecks(tx, query, encode(id), limit, offset)
+		return err
+	})
+	return
+}
+
+// LastIntegrityChecks returns the end time of the most recent integrity check
+// for each contract.
+func (s *Store) LastIntegrityChecks() (last map[types.FileContractID]time.Time, err error) {
+	err = s.transaction(func(tx *txn) error {
+		rows, err := tx.Query(`SELECT contract_id, MAX(end_time) FROM contract_integrity_checks GROUP BY contract_id`)
+		if err != nil {
+			return fmt.Errorf("failed to query integrity checks: %w", err)
+		}
+		defer rows.Close()
+
+		last = make(map[types.FileContractID]time.Time)
+		for rows.Next() {
+			var id types.FileContractID
+			var endTime time.Time
+			if err := rows.Scan(decode(&id), decode(&endTime)); err != nil {
+				return fmt.Errorf("failed to scan integrity check: %w", err)
+			}
+			last[id] = endTime
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// PruneIntegrityChecks removes integrity checks that completed before the
+// given time.
+func (s *Store) PruneIntegrityChecks(before time.Time) error {
+	return s.transaction(func(tx *txn) error {
+		if _, err := tx.Exec(`DELETE FROM contract_integrity_check_bad_sectors WHERE check_id IN (SELECT id FROM contract_integrity_checks WHERE end_time < $1)`, encode(before)); err != nil {
+			return fmt.Errorf("failed to delete bad sectors: %w", err)
+		} else if _, err := tx.Exec(`DELETE FROM contract_integrity_checks WHERE end_time < $1`, encode(before)); err != nil {
+			return fmt.Errorf("failed to delete integrity checks: %w", err)
+		}
+		return nil
+	})
+}
+
+func queryIntegrityChecks(tx *txn, query string, args ...any) ([]contracts.IntegrityCheck, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query integrity checks: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []contracts.IntegrityCheck
+	for rows.Next() {
+		var check contracts.IntegrityCheck
+		if err := rows.Scan(&check.ID, decode(&check.ContractID), &check.V2, decode(&check.StartTime), decode(&check.EndTime), &check.TotalSectors, &check.CheckedSectors, &check.MissingSectors, &check.CorruptSectors); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		checks = append(checks, check)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	stmt, err := tx.Prepare(`SELECT expected_root, actual_root, error_message FROM contract_integrity_check_bad_sectors WHERE check_id=$1 ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range checks {
+		badSectors, err := queryIntegrityCheckBadSectors(stmt, checks[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get bad sectors for check %d: %w", checks[i].ID, err)
+		}
+		checks[i].BadSectors = badSectors
+	}
+	return checks, nil
+}
+
+func queryIntegrityCheckBadSectors(stmt *stmt, checkID int64) (results []contracts.IntegrityResult, err error) {
+	rows, err := stmt.Query(checkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result contracts.IntegrityResult
+		var errMsg string
+		if err := rows.Scan(decode(&result.ExpectedRoot), decode(&result.ActualRoot), &errMsg); err != nil {
+			return nil, err
+		} else if errMsg != "" {
+			result.Error = errors.New(errMsg)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+	"go.uber.org/zap/zaptest"
+	"lukechampine.com/frand"
+)
+
+func TestIntegrityChecks(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"), log.Named("sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	contractID := types.FileContractID(frand.Entropy256())
+	start := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	for i := 0; i < 5; i++ {
+		check := contracts.IntegrityCheck{
+			ContractID:     contractID,
+			V2:             true,
+			StartTime:      start.Add(time.Duration(i) * time.Minute),
+			EndTime:        start.Add(time.Duration(i)*time.Minute + 30*time.Second),
+			TotalSectors:   10,
+			CheckedSectors: 10,
+		}
+		if i%2 == 0 {
+			check.MissingSectors = 1
+			check.BadSectors = []contracts.IntegrityResult{
+				{ExpectedRoot: frand.Entropy256(), Error: errors.New("sector not found")},
+			}
+		}
+		if _, err := db.AddIntegrityCheck(check); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// add a check for another contract
+	if _, err := db.AddIntegrityCheck(contracts.IntegrityCheck{
+		ContractID: types.FileContractID(frand.Entropy256()),
+		StartTime:  start,
+		EndTime:    start,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	checks, err := db.ContractIntegrityChecks(contractID, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(checks) != 5 {
+		t.Fatalf("expected 5 checks, got %d", len(checks))
+	}
+	for i, check := range checks {
+		if i > 0 && check.EndTime.After(checks[i-1].EndTime) {
+			t.Fatal("expected checks to be sorted by end time descending")
+		} else if !check.V2 {
+			t.Fatal("expected v2 check")
+		} else if check.MissingSectors != uint64(len(check.BadSectors)) {
+			t.Fatalf("expected %d bad sectors, got %d", check.MissingSectors, len(check.BadSectors))
+		}
+		for _, result := range check.BadSectors {
+			if result.Error == nil || result.Error.Error() != "sector not found" {
+				t.Fatalf("unexpected error %v", result.Error)
+			}
+		}
+	}
+
+	all, err := db.IntegrityChecks(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(all) != 6 {
+		t.Fatalf("expected 6 checks, got %d", len(all))
+	}
+
+	last, err := db.LastIntegrityChecks()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(last) != 2 {
+		t.Fatalf("expected 2 contracts, got %d", len(last))
+	} else if !last[contractID].Equal(checks[0].EndTime) {
+		t.Fatalf("expected last check %v, got %v", checks[0].EndTime, last[contractID])
+	}
+
+	// prune all but the two most recent checks of the first contract
+	if err := db.PruneIntegrityChecks(checks[1].EndTime); err != nil {
+		t.Fatal(err)
+	}
+	all, err = db.IntegrityChecks(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(all) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(all))
+	}
+}
@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/storage"
+)
+
+// lostSectorCondition matches the lost_sectors rows of sectors that are
+// still lost. A sector removed from its volume is lost until it is stored
+// again. A sector found missing or corrupt in place is lost until it is
+// restored or stored in a different location.
+const lostSectorCondition = `NOT EXISTS (SELECT 1 FROM volume_sectors vs WHERE vs.sector_id=ls.sector_id AND (ls.volume_sector_id IS NULL OR vs.id <> ls.volume_sector_id))`
+
+// recordLostSector records that a sector is no longer stored by the host. If
+// the sector was previously lost and restored, the record is replaced.
+func recordLostSector(tx *txn, sectorID, volumeID int64, cause storage.LostSectorCause, timestamp time.Time) error {
+	const query = `INSERT INTO lost_sectors (sector_id, volume_id, cause, lost_timestamp) VALUES ($1, $2, $3, $4)
+ON CONFLICT (sector_id) DO UPDATE SET volume_id=EXCLUDED.volume_id, volume_sector_id=NULL, cause=EXCLUDED.cause, lost_timestamp=EXCLUDED.lost_timestamp`
+	if _, err := tx.Exec(query, sectorID, volumeID, cause, encode(timestamp)); err != nil {
+		return fmt.Errorf("failed to record lost sector: %w", err)
+	}
+	return nil
+}
+
+// MarkSectorsLost records that the sectors are missing or corrupt. Sectors
+// that are still stored in a volume remain lost until they are restored or
+// stored in a different location. Sectors that are not stored are ignored.
+func (s *Store) MarkSectorsLost(roots []types.Hash256, cause storage.LostSectorCause) error {
+	return s.transaction(func(tx *txn) error {
+		// sectors that are no longer indexed keep their existing record
+		insertStmt, err := tx.Prepare(`INSERT INTO lost_sectors (sector_id, volume_id, volume_sector_id, cause, lost_timestamp) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (sector_id) DO UPDATE SET volume_id=EXCLUDED.volume_id, volume_sector_id=EXCLUDED.volume_sector_id, cause=EXCLUDED.cause, lost_timestamp=EXCLUDED.lost_timestamp
+WHERE EXCLUDED.volume_sector_id IS NOT NULL`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer insertStmt.Close()
+
+		now := time.Now()
+		for _, root := range roots {
+			var sectorID int64
+			err := tx.QueryRow(`SELECT id FROM stored_sectors WHERE sector_root=$1`, encode(root)).Scan(&sectorID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to get sector %v: %w", root, err)
+			}
+
+			var volumeID int64
+			var volumeSectorID sql.NullInt64
+			err = tx.QueryRow(`SELECT id, volume_id FROM volume_sectors WHERE sector_id=$1`, sectorID).Scan(&volumeSectorID, &volumeID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("failed to get sector %v location: %w", root, err)
+			}
+
+			if _, err := insertStmt.Exec(sectorID, volumeID, volumeSectorID, cause, encode(now)); err != nil {
+				return fmt.Errorf("failed to record lost sector %v: %w", root, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClearLostSector removes the lost record of a restored sector.
+func (s *Store) ClearLostSector(root types.Hash256) error {
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(`DELETE FROM lost_sectors WHERE sector_id=(SELECT id FROM stored_sectors WHERE sector_root=$1)`, encode(root))
+		return err
+	})
+}
+
+// ClearVerifiedSectors removes the lost records of sectors that were found
+// missing or corrupt in place and have since been read correctly at the same
+// location.
+func (s *Store) ClearVerifiedSectors(locations []storage.SectorLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	return s.transaction(func(tx *txn) error {
+		stmt, err := tx.Prepare(`DELETE FROM lost_sectors WHERE volume_sector_id=$1 AND sector_id=(SELECT id FROM stored_sectors WHERE sector_root=$2)`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, loc := range locations {
+			if _, err := stmt.Exec(loc.ID, encode(loc.Root)); err != nil {
+				return fmt.Errorf("failed to clear lost sector %v: %w", loc.Root, err)
+			}
+		}
+		return nil
+	})
+}
+
+// pruneLostSectors removes the lost records of sectors that are no longer
+// referenced by any contract. It is called when expired contracts' sector
+// roots are deleted so that the records do not accumulate.
+func pruneLostSectors(tx *txn, sectorIDs []int64) error {
+	stmt, err := tx.Prepare(`DELETE FROM lost_sectors WHERE sector_id=$1
+AND NOT EXISTS (SELECT 1 FROM contract_sector_roots WHERE sector_id=$1)
+AND NOT EXISTS (SELECT 1 FROM contract_v2_sector_roots WHERE sector_id=$1)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, id := range sectorIDs {
+		if _, err := stmt.Exec(id); err != nil {
+			return fmt.Errorf("failed to prune lost sector %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// LostSectors returns a paginated list of sectors that have been lost and not
+// restored, sorted by the time they were lost, and the total number of lost
+// sectors.
+func (s *Store) LostSectors(limit, offset int) (lost []storage.LostSector, count int, err error) {
+	// sectors that have been stored again are no longer lost
+	const query = `SELECT ss.sector_root, ls.volume_id, ls.cause, ls.lost_timestamp,
+	(SELECT COUNT(DISTINCT c.id) FROM contract_sector_roots csr INNER JOIN contracts c ON (csr.contract_id = c.id) WHERE csr.sector_id=ls.sector_id AND c.contract_status IN ($1, $2)) +
+	(SELECT COUNT(DISTINCT c.id) FROM contract_v2_sector_roots csr INNER JOIN contracts_v2 c ON (csr.contract_id = c.id) WHERE csr.sector_id=ls.sector_id AND c.contract_status IN ($3, $4)) AS contracts
+FROM lost_sectors ls
+INNER JOIN stored_sectors ss ON (ls.sector_id = ss.id)
+WHERE ` + lostSectorCondition + `
+ORDER BY ls.lost_timestamp DESC, ls.id DESC
+LIMIT $5 OFFSET $6`
+
+	err = s.transaction(func(tx *txn) error {
+		err := tx.QueryRow(`SELECT COUNT(*) FROM lost_sectors ls WHERE ` + lostSectorCondition).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to count lost sectors: %w", err)
+		}
+
+		rows, err := tx.Query(query, contracts.ContractStatusPending, contracts.ContractStatusActive, contracts.V2ContractStatusPending, contracts.V2ContractStatusActive, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query lost sectors: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var sector storage.LostSector
+			if err := rows.Scan(decode(&sector.Root), &sector.VolumeID, &sector.Cause, decode(&sector.Timestamp), &sector.Contracts); err != nil {
+				return fmt.Errorf("failed to scan lost sector: %w", err)
+			}
+			lost = append(lost, sector)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// LostSectorContracts returns the unresolved v1 and v2 contracts that
+// reference lost sectors.
+func (s *Store) LostSectorContracts() (lost []contracts.LostSectorContract, err error) {
+	const v1Query = `SELECT c.contract_id, r.public_key, c.raw_revision, COUNT(*) AS lost_sectors
+FROM lost_sectors ls
+INNER JOIN contract_sector_roots csr ON (csr.sector_id = ls.sector_id)
+INNER JOIN contracts c ON (csr.contract_id = c.id)
+INNER JOIN contract_renters r ON (c.renter_id = r.id)
+WHERE c.contract_status IN ($1, $2) AND ` + lostSectorCondition + `
+GROUP BY c.id`
+	const v2Query = `SELECT c.contract_id, r.public_key, c.raw_revision, COUNT(*) AS lost_sectors
+FROM lost_sectors ls
+INNER JOIN contract_v2_sector_roots csr ON (csr.sector_id = ls.sector_id)
+INNER JOIN contracts_v2 c ON (csr.contract_id = c.id)
+INNER JOIN contract_renters r ON (c.renter_id = r.id)
+WHERE c.contract_status IN ($1, $2) AND ` + lostSectorCondition + `
+GROUP BY c.id`
+
+	err = s.transaction(func(tx *txn) error {
+		rows, err := tx.Query(v1Query, contracts.ContractStatusPending, contracts.ContractStatusActive)
+		if err != nil {
+			return fmt.Errorf("failed to query contracts: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c contracts.LostSectorContract
+			var rev types.FileContractRevision
+			if err := rows.Scan(decode(&c.ContractID), decode(&c.RenterKey), decode(&rev), &c.LostSectors); err != nil {
+				return fmt.Errorf("failed to scan contract: %w", err)
+			}
+			c.TotalSectors = (rev.Filesize + proto2.SectorSize - 1) / proto2.SectorSize
+			c.ProofWindowStart, c.ProofWindowEnd = rev.WindowStart, rev.WindowEnd
+			if burn, underflow := rev.ValidHostPayout().SubWithUnderflow(rev.MissedHostPayout()); !underflow {
+				c.CollateralAtRisk = burn
+			}
+			lost = append(lost, c)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(v2Query, contracts.V2ContractStatusPending, contracts.V2ContractStatusActive)
+		if err != nil {
+			return fmt.Errorf("failed to query v2 contracts: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			c := contracts.LostSectorContract{V2: true}
+			var fc types.V2FileContract
+			if err := rows.Scan(decode(&c.ContractID), decode(&c.RenterKey), decode(&fc), &c.LostSectors); err != nil {
+				return fmt.Errorf("failed to scan v2 contract: %w", err)
+			}
+			c.TotalSectors = (fc.Filesize + proto2.SectorSize - 1) / proto2.SectorSize
+			c.ProofWindowStart, c.ProofWindowEnd = fc.ProofHeight, fc.ExpirationHeight
+			if burn, underflow := fc.HostOutput.Value.SubWithUnderflow(fc.MissedHostValue); !underflow {
+				c.CollateralAtRisk = burn
+			}
+			lost = append(lost, c)
+		}
+		return rows.Err()
+	})
+	return
+}
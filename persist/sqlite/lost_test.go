@@ -0,0 +1,283 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	proto2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/storage"
+	"go.uber.org/zap/zaptest"
+	"lukechampine.com/frand"
+)
+
+func TestLostSectors(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	renterKey := types.NewPrivateKeyFromSeed(frand.Bytes(32))
+	hostKey := types.NewPrivateKeyFromSeed(frand.Bytes(32))
+	uc := types.UnlockConditions{
+		PublicKeys: []types.UnlockKey{
+			renterKey.PublicKey().UnlockKey(),
+			hostKey.PublicKey().UnlockKey(),
+		},
+		SignaturesRequired: 2,
+	}
+
+	contract := contracts.SignedRevision{
+		Revision: types.FileContractRevision{
+			ParentID:         frand.Entropy256(),
+			UnlockConditions: uc,
+			FileContract: types.FileContract{
+				UnlockHash:     uc.UnlockHash(),
+				RevisionNumber: 1,
+				WindowStart:    100,
+				WindowEnd:      200,
+				ValidProofOutputs: []types.SiacoinOutput{
+					{Value: types.Siacoins(1)},
+					{Value: types.Siacoins(10)},
+				},
+				MissedProofOutputs: []types.SiacoinOutput{
+					{Value: types.Siacoins(1)},
+					{Value: types.Siacoins(4)},
+					{Value: types.Siacoins(6)},
+				},
+			},
+		},
+	}
+	if err := db.AddContract(contract, []types.Transaction{}, types.ZeroCurrency, contracts.Usage{}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	volume, err := addTestVolume(db, "test", 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add sectors to the contract
+	var changes []contracts.SectorChange
+	var roots []types.Hash256
+	for i := 0; i < 4; i++ {
+		root := frand.Entropy256()
+		if err := db.StoreSector(root, func(storage.SectorLocation) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		changes = append(changes, contracts.SectorChange{Action: contracts.SectorActionAppend, Root: root})
+	}
+	contract.Revision.RevisionNumber++
+	contract.Revision.Filesize = uint64(len(roots)) * proto2.SectorSize
+	if err := db.ReviseContract(contract, nil, contracts.Usage{}, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	if lost, count, err := db.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != 0 || len(lost) != 0 {
+		t.Fatalf("expected no lost sectors, got %d", count)
+	}
+
+	// removing a sector should mark it as lost
+	if err := db.RemoveSector(roots[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	lost, count, err := db.LostSectors(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if count != 1 || len(lost) != 1 {
+		t.Fatalf("expected 1 lost sector, got %d", count)
+	} else if lost[0].Root != roots[0] || lost[0].Cause != storage.LostSectorCauseRemoved || lost[0].VolumeID != volume.ID || lost[0].Contracts != 1 {
+		t.Fatalf("unexpected lost sector %+v", lost[0])
+	}
+
+	// force removing the volume should mark the remaining sectors as lost
+	if err := db.RemoveVolume(volume.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lost, count, err = db.LostSectors(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if count != len(roots) || len(lost) != len(roots) {
+		t.Fatalf("expected %d lost sectors, got %d", len(roots), count)
+	}
+	causes := make(map[types.Hash256]storage.LostSectorCause)
+	for _, sector := range lost {
+		causes[sector.Root] = sector.Cause
+	}
+	for i, root := range roots {
+		expected := storage.LostSectorCauseVolumeRemoved
+		if i == 0 {
+			expected = storage.LostSectorCauseRemoved
+		}
+		if causes[root] != expected {
+			t.Fatalf("expected sector %d to be lost with cause %q, got %q", i, expected, causes[root])
+		}
+	}
+
+	// check the contract's risk
+	risks, err := db.LostSectorContracts()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(risks) != 1 {
+		t.Fatalf("expected 1 contract, got %d", len(risks))
+	}
+	risk := risks[0]
+	switch {
+	case risk.ContractID != contract.Revision.ParentID:
+		t.Fatalf("expected contract %v, got %v", contract.Revision.ParentID, risk.ContractID)
+	case risk.V2:
+		t.Fatal("expected v1 contract")
+	case risk.RenterKey != renterKey.PublicKey():
+		t.Fatalf("expected renter key %v, got %v", renterKey.PublicKey(), risk.RenterKey)
+	case risk.LostSectors != uint64(len(roots)) || risk.TotalSectors != uint64(len(roots)):
+		t.Fatalf("expected %d of %d sectors lost, got %d of %d", len(roots), len(roots), risk.LostSectors, risk.TotalSectors)
+	case risk.ProofWindowStart != 100 || risk.ProofWindowEnd != 200:
+		t.Fatalf("unexpected proof window %d-%d", risk.ProofWindowStart, risk.ProofWindowEnd)
+	case !risk.CollateralAtRisk.Equals(types.Siacoins(6)):
+		t.Fatalf("expected 6 SC at risk, got %v", risk.CollateralAtRisk)
+	}
+
+	// restoring a sector should remove it from the lost sectors
+	if _, err := addTestVolume(db, "test2", 64); err != nil {
+		t.Fatal(err)
+	} else if err := db.StoreSector(roots[1], func(storage.SectorLocation) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, count, err := db.LostSectors(100, 0); err != nil {
+		t.Fatal(err)
+	} else if count != len(roots)-1 {
+		t.Fatalf("expected %d lost sectors, got %d", len(roots)-1, count)
+	}
+	if risks, err := db.LostSectorContracts(); err != nil {
+		t.Fatal(err)
+	} else if len(risks) != 1 || risks[0].LostSectors != uint64(len(roots)-1) {
+		t.Fatalf("expected %d lost contract sectors, got %+v", len(roots)-1, risks)
+	}
+
+	// expiring the contract should remove the records of the sectors it
+	// referenced
+	if _, err := db.batchExpireContractSectors(contract.Revision.WindowEnd + 1); err != nil {
+		t.Fatal(err)
+	}
+	var records int
+	if err := db.transaction(func(tx *txn) error {
+		return tx.QueryRow(`SELECT COUNT(*) FROM lost_sectors`).Scan(&records)
+	}); err != nil {
+		t.Fatal(err)
+	} else if records != 0 {
+		t.Fatalf("expected no lost sector records, got %d", records)
+	}
+}
+
+func TestMarkSectorsLost(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	volume, err := addTestVolume(db, "test", 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var roots []types.Hash256
+	for i := 0; i < 2; i++ {
+		root := frand.Entropy256()
+		if err := db.StoreSector(root, func(storage.SectorLocation) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+
+	assertLost := func(t *testing.T, expected map[types.Hash256]storage.LostSectorCause) {
+		t.Helper()
+
+		lost, count, err := db.LostSectors(100, 0)
+		if err != nil {
+			t.Fatal(err)
+		} else if count != len(expected) || len(lost) != len(expected) {
+			t.Fatalf("expected %d lost sectors, got %d", len(expected), count)
+		}
+		for _, sector := range lost {
+			if cause, ok := expected[sector.Root]; !ok {
+				t.Fatalf("unexpected lost sector %v", sector.Root)
+			} else if sector.Cause != cause {
+				t.Fatalf("expected sector %v to be lost with cause %q, got %q", sector.Root, cause, sector.Cause)
+			} else if sector.VolumeID != volume.ID {
+				t.Fatalf("expected volume %d, got %d", volume.ID, sector.VolumeID)
+			}
+		}
+	}
+
+	// sectors found corrupt in place should be lost while they are still
+	// indexed. Unknown sectors are ignored.
+	if err := db.MarkSectorsLost([]types.Hash256{roots[0], frand.Entropy256()}, storage.LostSectorCauseScrubCorrupt); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{roots[0]: storage.LostSectorCauseScrubCorrupt})
+
+	// sectors that are no longer indexed should keep their existing cause
+	if err := db.RemoveSector(roots[1]); err != nil {
+		t.Fatal(err)
+	} else if err := db.MarkSectorsLost([]types.Hash256{roots[1]}, storage.LostSectorCauseIntegrityCheck); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{
+		roots[0]: storage.LostSectorCauseScrubCorrupt,
+		roots[1]: storage.LostSectorCauseRemoved,
+	})
+
+	// restoring a sector should clear its record
+	if err := db.ClearLostSector(roots[0]); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{roots[1]: storage.LostSectorCauseRemoved})
+
+	// reading a sector correctly at the location it was found missing
+	// should clear its record
+	if err := db.MarkSectorsLost([]types.Hash256{roots[0]}, storage.LostSectorCauseScrubReadFailed); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{
+		roots[0]: storage.LostSectorCauseScrubReadFailed,
+		roots[1]: storage.LostSectorCauseRemoved,
+	})
+	loc, err := db.SectorLocation(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.ClearVerifiedSectors([]storage.SectorLocation{loc, {ID: loc.ID + 1, Root: roots[1]}}); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{roots[1]: storage.LostSectorCauseRemoved})
+
+	// a corrupt sector that is stored in a different location is no longer
+	// lost
+	if err := db.MarkSectorsLost([]types.Hash256{roots[0]}, storage.LostSectorCauseIntegrityCheck); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{
+		roots[0]: storage.LostSectorCauseIntegrityCheck,
+		roots[1]: storage.LostSectorCauseRemoved,
+	})
+	err = db.transaction(func(tx *txn) error {
+		_, err := tx.Exec(`UPDATE volume_sectors SET sector_id=NULL WHERE sector_id=(SELECT id FROM stored_sectors WHERE sector_root=$1)`, encode(roots[0]))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if err := db.StoreSector(roots[0], func(storage.SectorLocation) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	assertLost(t, map[types.Hash256]storage.LostSectorCause{roots[1]: storage.LostSectorCauseRemoved})
+}
@@ -39,6 +39,8 @@ const (
 	metricSectorWrites    = "sectorWrites"
 	metricSectorCacheHit  = "sectorCacheHit"
 	metricSectorCacheMiss = "sectorCacheMiss"
+	metricDiskCacheHit    = "sectorDiskCacheHit"
+	metricDiskCacheMiss   = "sectorDiskCacheMiss"
 
 	// registry
 	metricMaxRegistryEntries = "maxRegistryEntries"
@@ -101,24 +103,9 @@ func (s *Store) PeriodMetrics(start time.Time, n int, interval metrics.Interval)
 		return nil, errors.New("n periods must be greater than 0")
 	}
 
-	var end time.Time
-	switch interval {
-	case metrics.Interval5Minutes:
-		end = start.Add(5 * time.Minute * time.Duration(n))
-	case metrics.Interval15Minutes:
-		end = start.Add(15 * time.Minute * time.Duration(n))
-	case metrics.IntervalHourly:
-		end = start.Add(time.Hour * time.Duration(n))
-	case metrics.IntervalDaily:
-		end = start.AddDate(0, 0, n)
-	case metrics.IntervalWeekly:
-		end = start.AddDate(0, 0, 7*n) // add n weeks
-	case metrics.IntervalMonthly:
-		end = start.AddDate(0, n, 0) // add n months
-	case metrics.IntervalYearly:
-		end = start.AddDate(n, 0, 0) // add n years
-	default:
-		return nil, fmt.Errorf("invalid interval: %v", interval)
+	end, err := periodEnd(start, n, interval)
+	if err != nil {
+		return nil, err
 	}
 
 	// get metrics as of the start time to backfill any missing periods
@@ -148,27 +135,7 @@ func (s *Store) PeriodMetrics(start time.Time, n int, interval metrics.Interval)
 		}
 
 		// normalize the stored timestamp to the locale and interval
-		timestamp = timestamp.In(start.Location())
-		switch interval {
-		case metrics.Interval5Minutes:
-			timestamp = timestamp.Truncate(5 * time.Minute)
-		case metrics.Interval15Minutes:
-			timestamp = timestamp.Truncate(15 * time.Minute)
-		case metrics.IntervalHourly:
-			timestamp = timestamp.Truncate(time.Hour)
-		case metrics.IntervalDaily:
-			y, m, d := timestamp.Date()
-			timestamp = time.Date(y, m, d, 0, 0, 0, 0, timestamp.Location())
-		case metrics.IntervalWeekly:
-			y, m, d := timestamp.Date()
-			d -= int(timestamp.Weekday())
-			timestamp = time.Date(y, m, d, 0, 0, 0, 0, timestamp.Location())
-		case metrics.IntervalMonthly:
-			y, m, _ := timestamp.Date()
-			timestamp = time.Date(y, m, 1, 0, 0, 0, 0, timestamp.Location())
-		case metrics.IntervalYearly:
-			timestamp = time.Date(timestamp.Year(), 1, 1, 0, 0, 0, 0, timestamp.Location())
-		}
+		timestamp = periodStart(timestamp.In(start.Location()), interval)
 
 		// if the timestamp is not the same as the last period, add a new period
 		if stats[len(stats)-1].Timestamp != timestamp {
@@ -195,22 +162,7 @@ func (s *Store) PeriodMetrics(start time.Time, n int, interval metrics.Interval)
 		}
 
 		// increment the current time by the interval
-		switch interval {
-		case metrics.Interval5Minutes:
-			current = current.Add(5 * time.Minute)
-		case metrics.Interval15Minutes:
-			current = current.Add(15 * time.Minute)
-		case metrics.IntervalHourly:
-			current = current.Add(time.Hour)
-		case metrics.IntervalDaily:
-			current = current.AddDate(0, 0, 1)
-		case metrics.IntervalWeekly:
-			current = current.AddDate(0, 0, 7)
-		case metrics.IntervalMonthly:
-			current = current.AddDate(0, 1, 0)
-		case metrics.IntervalYearly:
-			current = current.AddDate(1, 0, 0)
-		}
+		current = nextPeriod(current, interval)
 	}
 	return periods, nil
 }
@@ -266,7 +218,7 @@ func (s *Store) IncrementRHPDataUsage(ingress, egress uint64) error {
 }
 
 // IncrementSectorStats increments the sector read, write and cache metrics.
-func (s *Store) IncrementSectorStats(reads, writes, cacheHit, cacheMiss uint64) error {
+func (s *Store) IncrementSectorStats(reads, writes, cacheHit, cacheMiss, diskCacheHit, diskCacheMiss uint64) error {
 	return s.transaction(func(tx *txn) error {
 		if reads > 0 {
 			if err := incrementNumericStat(tx, metricSectorReads, int(reads), time.Now()); err != nil {
@@ -290,6 +242,18 @@ func (s *Store) IncrementSectorStats(reads, writes, cacheHit, cacheMiss uint64)
 				return fmt.Errorf("failed to track cache misses: %w", err)
 			}
 		}
+
+		if diskCacheHit > 0 {
+			if err := incrementNumericStat(tx, metricDiskCacheHit, int(diskCacheHit), time.Now()); err != nil {
+				return fmt.Errorf("failed to track disk cache hits: %w", err)
+			}
+		}
+
+		if diskCacheMiss > 0 {
+			if err := incrementNumericStat(tx, metricDiskCacheMiss, int(diskCacheMiss), time.Now()); err != nil {
+				return fmt.Errorf("failed to track disk cache misses: %w", err)
+			}
+		}
 		return nil
 	})
 }
@@ -383,6 +347,10 @@ func mustParseMetricValue(stat string, buf []byte, m *metrics.Metrics) {
 		m.Storage.SectorCacheHits = mustScanUint64(buf)
 	case metricSectorCacheMiss:
 		m.Storage.SectorCacheMisses = mustScanUint64(buf)
+	case metricDiskCacheHit:
+		m.Storage.SectorDiskCacheHits = mustScanUint64(buf)
+	case metricDiskCacheMiss:
+		m.Storage.SectorDiskCacheMisses = mustScanUint64(buf)
 	// registry
 	case metricRegistryEntries:
 		m.Registry.Entries = mustScanUint64(buf)
@@ -616,3 +584,71 @@ func setFloat64Stat(tx *txn, stat string, f float64, timestamp time.Time) error
 	}
 	return nil
 }
+
+// periodEnd returns the end of n periods starting at start.
+func periodEnd(start time.Time, n int, interval metrics.Interval) (time.Time, error) {
+	switch interval {
+	case metrics.Interval5Minutes:
+		return start.Add(5 * time.Minute * time.Duration(n)), nil
+	case metrics.Interval15Minutes:
+		return start.Add(15 * time.Minute * time.Duration(n)), nil
+	case metrics.IntervalHourly:
+		return start.Add(time.Hour * time.Duration(n)), nil
+	case metrics.IntervalDaily:
+		return start.AddDate(0, 0, n), nil
+	case metrics.IntervalWeekly:
+		return start.AddDate(0, 0, 7*n), nil // add n weeks
+	case metrics.IntervalMonthly:
+		return start.AddDate(0, n, 0), nil // add n months
+	case metrics.IntervalYearly:
+		return start.AddDate(n, 0, 0), nil // add n years
+	default:
+		return time.Time{}, fmt.Errorf("invalid interval: %v", interval)
+	}
+}
+
+// periodStart returns the start of the period containing the timestamp.
+func periodStart(timestamp time.Time, interval metrics.Interval) time.Time {
+	switch interval {
+	case metrics.Interval5Minutes:
+		return timestamp.Truncate(5 * time.Minute)
+	case metrics.Interval15Minutes:
+		return timestamp.Truncate(15 * time.Minute)
+	case metrics.IntervalHourly:
+		return timestamp.Truncate(time.Hour)
+	case metrics.IntervalDaily:
+		y, m, d := timestamp.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, timestamp.Location())
+	case metrics.IntervalWeekly:
+		y, m, d := timestamp.Date()
+		d -= int(timestamp.Weekday())
+		return time.Date(y, m, d, 0, 0, 0, 0, timestamp.Location())
+	case metrics.IntervalMonthly:
+		y, m, _ := timestamp.Date()
+		return time.Date(y, m, 1, 0, 0, 0, 0, timestamp.Location())
+	case metrics.IntervalYearly:
+		return time.Date(timestamp.Year(), 1, 1, 0, 0, 0, 0, timestamp.Location())
+	}
+	return timestamp
+}
+
+// nextPeriod returns the start of the period after current.
+func nextPeriod(current time.Time, interval metrics.Interval) time.Time {
+	switch interval {
+	case metrics.Interval5Minutes:
+		return current.Add(5 * time.Minute)
+	case metrics.Interval15Minutes:
+		return current.Add(15 * time.Minute)
+	case metrics.IntervalHourly:
+		return current.Add(time.Hour)
+	case metrics.IntervalDaily:
+		return current.AddDate(0, 0, 1)
+	case metrics.IntervalWeekly:
+		return current.AddDate(0, 0, 7)
+	case metrics.IntervalMonthly:
+		return current.AddDate(0, 1, 0)
+	case metrics.IntervalYearly:
+		return current.AddDate(1, 0, 0)
+	}
+	return current
+}
@@ -12,6 +12,299 @@ import (
 	"go.uber.org/zap"
 )
 
+// migrateVersion53 adds the renter_metrics table to store the current metrics of
+// each renter. Triggers mark a renter's metrics stale whenever one of its
+// contracts or funded accounts changes.
+func migrateVersion53(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE renter_metrics (
+	renter_id INTEGER PRIMARY KEY REFERENCES contract_renters(id),
+	active_contracts INTEGER NOT NULL,
+	successful_contracts INTEGER NOT NULL,
+	failed_contracts INTEGER NOT NULL,
+	rejected_contracts INTEGER NOT NULL,
+	renewed_contracts INTEGER NOT NULL,
+	stored_sectors INTEGER NOT NULL,
+	locked_collateral BLOB NOT NULL,
+	risked_collateral BLOB NOT NULL,
+	potential_rpc_revenue BLOB NOT NULL,
+	potential_storage_revenue BLOB NOT NULL,
+	potential_ingress_revenue BLOB NOT NULL,
+	potential_egress_revenue BLOB NOT NULL,
+	potential_account_funding BLOB NOT NULL,
+	potential_registry_read BLOB NOT NULL,
+	potential_registry_write BLOB NOT NULL,
+	potential_risked_collateral BLOB NOT NULL,
+	earned_rpc_revenue BLOB NOT NULL,
+	earned_storage_revenue BLOB NOT NULL,
+	earned_ingress_revenue BLOB NOT NULL,
+	earned_egress_revenue BLOB NOT NULL,
+	earned_account_funding BLOB NOT NULL,
+	earned_registry_read BLOB NOT NULL,
+	earned_registry_write BLOB NOT NULL,
+	earned_risked_collateral BLOB NOT NULL,
+	account_balance BLOB NOT NULL,
+	failure_rate REAL NOT NULL,
+	sort_potential_revenue BLOB NOT NULL, -- big-endian for sorting
+	sort_earned_revenue BLOB NOT NULL, -- big-endian for sorting
+	sort_locked_collateral BLOB NOT NULL, -- big-endian for sorting
+	sort_account_balance BLOB NOT NULL -- big-endian for sorting
+);
+
+-- renters whose metrics need to be recalculated, maintained by triggers
+CREATE TABLE renter_metrics_stale (
+	renter_id INTEGER PRIMARY KEY REFERENCES contract_renters(id)
+);
+
+CREATE INDEX contract_account_funding_account_id ON contract_account_funding(account_id);
+CREATE INDEX contract_v2_account_funding_account_id ON contract_v2_account_funding(account_id);
+
+CREATE TRIGGER contracts_insert_renter_metrics AFTER INSERT ON contracts BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contracts_update_renter_metrics AFTER UPDATE ON contracts BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contracts_v2_insert_renter_metrics AFTER INSERT ON contracts_v2 BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contracts_v2_update_renter_metrics AFTER UPDATE ON contracts_v2 BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) VALUES (NEW.renter_id) ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_account_funding_insert_renter_metrics AFTER INSERT ON contract_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts WHERE id=NEW.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_account_funding_delete_renter_metrics AFTER DELETE ON contract_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts WHERE id=OLD.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_v2_account_funding_insert_renter_metrics AFTER INSERT ON contract_v2_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts_v2 WHERE id=NEW.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER contract_v2_account_funding_delete_renter_metrics AFTER DELETE ON contract_v2_account_funding BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts_v2 WHERE id=OLD.contract_id ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+CREATE TRIGGER accounts_update_renter_metrics AFTER UPDATE OF balance ON accounts BEGIN
+	INSERT INTO renter_metrics_stale (renter_id) SELECT c.renter_id FROM contract_account_funding caf
+	INNER JOIN contracts c ON caf.contract_id=c.id
+	WHERE caf.account_id=NEW.id
+	UNION
+	SELECT c.renter_id FROM contract_v2_account_funding caf
+	INNER JOIN contracts_v2 c ON caf.contract_id=c.id
+	WHERE caf.account_id=NEW.id
+	ON CONFLICT (renter_id) DO NOTHING;
+END;
+
+-- calculate the metrics of every existing renter on the next refresh
+INSERT INTO renter_metrics_stale (renter_id) SELECT renter_id FROM contracts UNION SELECT renter_id FROM contracts_v2;`)
+	return err
+}
+
+// migrateVersion52 adds the volume_sector_id column to the lost_sectors table
+// to track sectors that were found missing or corrupt in place.
+func migrateVersion52(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`ALTER TABLE lost_sectors ADD COLUMN volume_sector_id INTEGER;`)
+	return err
+}
+
+// migrateVersion51 adds the renter_stats table to store snapshots of each
+// renter's metrics.
+func migrateVersion51(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE renter_stats (
+	id INTEGER PRIMARY KEY,
+	renter_id INTEGER NOT NULL REFERENCES contract_renters(id),
+	date_created INTEGER NOT NULL,
+	active_contracts INTEGER NOT NULL,
+	successful_contracts INTEGER NOT NULL,
+	failed_contracts INTEGER NOT NULL,
+	rejected_contracts INTEGER NOT NULL,
+	renewed_contracts INTEGER NOT NULL,
+	stored_sectors INTEGER NOT NULL,
+	locked_collateral BLOB NOT NULL,
+	risked_collateral BLOB NOT NULL,
+	potential_rpc_revenue BLOB NOT NULL,
+	potential_storage_revenue BLOB NOT NULL,
+	potential_ingress_revenue BLOB NOT NULL,
+	potential_egress_revenue BLOB NOT NULL,
+	potential_account_funding BLOB NOT NULL,
+	potential_registry_read BLOB NOT NULL,
+	potential_registry_write BLOB NOT NULL,
+	potential_risked_collateral BLOB NOT NULL,
+	earned_rpc_revenue BLOB NOT NULL,
+	earned_storage_revenue BLOB NOT NULL,
+	earned_ingress_revenue BLOB NOT NULL,
+	earned_egress_revenue BLOB NOT NULL,
+	earned_account_funding BLOB NOT NULL,
+	earned_registry_read BLOB NOT NULL,
+	earned_registry_write BLOB NOT NULL,
+	earned_risked_collateral BLOB NOT NULL,
+	account_balance BLOB NOT NULL,
+	UNIQUE (renter_id, date_created)
+);
+CREATE INDEX renter_stats_date_created ON renter_stats(date_created);`)
+	return err
+}
+
+// migrateVersion50 adds the pricing_profiles table to store per-renter price
+// overrides.
+func migrateVersion50(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE pricing_profiles (
+	public_key BLOB PRIMARY KEY NOT NULL,
+	note TEXT NOT NULL,
+	contract_price BLOB, -- null uses the host's price
+	storage_price BLOB,
+	ingress_price BLOB,
+	egress_price BLOB,
+	collateral BLOB,
+	created_timestamp INTEGER NOT NULL
+);`)
+	return err
+}
+
+// migrateVersion49 adds the renter_quotas table to store per-renter quota
+// overrides.
+func migrateVersion49(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE renter_quotas (
+	renter_id INTEGER PRIMARY KEY REFERENCES contract_renters(id),
+	max_sectors BLOB NOT NULL,
+	max_collateral BLOB NOT NULL,
+	max_contracts BLOB NOT NULL,
+	max_account_balance BLOB NOT NULL
+);`)
+	return err
+}
+
+// migrateVersion48 adds the renter_acl table and the renter_acl_mode column
+// to the global_settings table.
+func migrateVersion48(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE renter_acl (
+	public_key BLOB PRIMARY KEY NOT NULL,
+	note TEXT NOT NULL,
+	expiration_timestamp INTEGER NOT NULL,
+	created_timestamp INTEGER NOT NULL
+);
+ALTER TABLE global_settings ADD COLUMN renter_acl_mode TEXT;`)
+	return err
+}
+
+// migrateVersion47 adds the read_count and last_read_timestamp columns to the
+// stored_sectors table and the volume_tier column to the storage_volumes table
+// for sector tiering.
+func migrateVersion47(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`ALTER TABLE stored_sectors ADD COLUMN read_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE stored_sectors ADD COLUMN last_read_timestamp INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX stored_sectors_last_read ON stored_sectors(last_read_timestamp);
+ALTER TABLE storage_volumes ADD COLUMN volume_tier TEXT NOT NULL DEFAULT '';`)
+	return err
+}
+
+// migrateVersion46 adds the lost_sectors table to track sectors that are no
+// longer stored by the host.
+func migrateVersion46(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE lost_sectors (
+	id INTEGER PRIMARY KEY,
+	sector_id INTEGER UNIQUE NOT NULL REFERENCES stored_sectors(id),
+	volume_id INTEGER NOT NULL, -- not a foreign key since the volume may have been removed
+	cause TEXT NOT NULL,
+	lost_timestamp INTEGER NOT NULL
+);
+CREATE INDEX lost_sectors_lost_timestamp ON lost_sectors(lost_timestamp);`)
+	return err
+}
+
+// migrateVersion45 adds the auto_grow_max_sectors and
+// auto_grow_reserved_bytes columns to the storage_volumes table.
+func migrateVersion45(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`ALTER TABLE storage_volumes ADD COLUMN auto_grow_max_sectors INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE storage_volumes ADD COLUMN auto_grow_reserved_bytes INTEGER NOT NULL DEFAULT 0;`)
+	return err
+}
+
+// migrateVersion44 adds the placement_weight and placement_priority columns
+// to the storage_volumes table and the sector_placement column to the
+// global_settings table.
+func migrateVersion44(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`ALTER TABLE storage_volumes ADD COLUMN placement_weight INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE storage_volumes ADD COLUMN placement_priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE global_settings ADD COLUMN sector_placement TEXT;`)
+	return err
+}
+
+// migrateVersion43 adds the sector_cache_path and sector_cache_disk_size
+// columns to the host_settings table.
+func migrateVersion43(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`ALTER TABLE host_settings ADD COLUMN sector_cache_path TEXT NOT NULL DEFAULT '';
+ALTER TABLE host_settings ADD COLUMN sector_cache_disk_size INTEGER NOT NULL DEFAULT 0;`)
+	return err
+}
+
+// migrateVersion42 adds the volume_uuid column to the storage_volumes table.
+func migrateVersion42(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`ALTER TABLE storage_volumes ADD COLUMN volume_uuid TEXT;`)
+	return err
+}
+
+// migrateVersion41 adds the volume_scrubs and volume_scrub_errors tables.
+func migrateVersion41(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE volume_scrubs (
+	volume_id INTEGER PRIMARY KEY REFERENCES storage_volumes(id),
+	scrub_status TEXT NOT NULL,
+	next_index INTEGER NOT NULL,
+	checked_sectors INTEGER NOT NULL,
+	corrupt_sectors INTEGER NOT NULL,
+	failed_reads INTEGER NOT NULL,
+	start_time INTEGER NOT NULL,
+	update_time INTEGER NOT NULL,
+	end_time INTEGER
+);
+
+CREATE TABLE volume_scrub_errors (
+	id INTEGER PRIMARY KEY,
+	volume_id INTEGER NOT NULL REFERENCES storage_volumes(id),
+	volume_index INTEGER NOT NULL,
+	sector_root BLOB NOT NULL,
+	actual_root BLOB NOT NULL,
+	error_message TEXT NOT NULL,
+	date_created INTEGER NOT NULL
+);
+CREATE INDEX volume_scrub_errors_volume_id ON volume_scrub_errors(volume_id);`)
+	return err
+}
+
+// migrateVersion40 adds the contract_integrity_checks and
+// contract_integrity_check_bad_sectors tables.
+func migrateVersion40(tx *txn, _ *zap.Logger) error {
+	_, err := tx.Exec(`CREATE TABLE contract_integrity_checks (
+	id INTEGER PRIMARY KEY,
+	contract_id BLOB NOT NULL,
+	v2 BOOLEAN NOT NULL,
+	start_time INTEGER NOT NULL,
+	end_time INTEGER NOT NULL,
+	total_sectors INTEGER NOT NULL,
+	checked_sectors INTEGER NOT NULL,
+	missing_sectors INTEGER NOT NULL,
+	corrupt_sectors INTEGER NOT NULL
+);
+CREATE INDEX contract_integrity_checks_contract_id_end_time ON contract_integrity_checks(contract_id, end_time DESC);
+CREATE INDEX contract_integrity_checks_end_time ON contract_integrity_checks(end_time DESC);
+
+CREATE TABLE contract_integrity_check_bad_sectors (
+	id INTEGER PRIMARY KEY,
+	check_id INTEGER NOT NULL REFERENCES contract_integrity_checks(id),
+	expected_root BLOB NOT NULL,
+	actual_root BLOB NOT NULL,
+	error_message TEXT NOT NULL
+);
+CREATE INDEX contract_integrity_check_bad_sectors_check_id ON contract_integrity_check_bad_sectors(check_id);`)
+	return err
+}
+
 func migrateVersion39(tx *txn, _ *zap.Logger) error {
 	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS contracts_v2_chain_index_elements_height ON contracts_v2_chain_index_elements(height);`)
 	return err
@@ -1036,4 +1329,18 @@ var migrations = []func(tx *txn, log *zap.Logger) error{
 	migrateVersion37,
 	migrateVersion38,
 	migrateVersion39,
+	migrateVersion40,
+	migrateVersion41,
+	migrateVersion42,
+	migrateVersion43,
+	migrateVersion44,
+	migrateVersion45,
+	migrateVersion46,
+	migrateVersion47,
+	migrateVersion48,
+	migrateVersion49,
+	migrateVersion50,
+	migrateVersion51,
+	migrateVersion52,
+	migrateVersion53,
 }
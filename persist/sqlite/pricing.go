@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/settings"
+)
+
+// encodeNullableCurrency encodes a currency that may be nil as a nullable
+// BLOB.
+func encodeNullableCurrency(c *types.Currency) any {
+	if c == nil {
+		return nil
+	}
+	return encode(*c)
+}
+
+// nullCurrency scans a nullable currency column into a pointer.
+type nullCurrency struct {
+	v **types.Currency
+}
+
+func (nc nullCurrency) Scan(src any) error {
+	var c types.Currency
+	nd := decodeNullable(&c)
+	if err := nd.Scan(src); err != nil {
+		return err
+	} else if nd.valid {
+		*nc.v = &c
+	} else {
+		*nc.v = nil
+	}
+	return nil
+}
+
+// PricingProfiles returns every renter's pricing profile.
+func (s *Store) PricingProfiles() (profiles []settings.PricingProfile, err error) {
+	const query = `SELECT public_key, note, contract_price, storage_price, ingress_price, egress_price, collateral, created_timestamp FROM pricing_profiles ORDER BY created_timestamp ASC`
+	err = s.transaction(func(tx *txn) error {
+		rows, err := tx.Query(query)
+		if err != nil {
+			return fmt.Errorf("failed to query pricing profiles: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var p settings.PricingProfile
+			if err := rows.Scan(decode(&p.PublicKey), &p.Note, nullCurrency{&p.ContractPrice}, nullCurrency{&p.StoragePrice}, nullCurrency{&p.IngressPrice}, nullCurrency{&p.EgressPrice}, nullCurrency{&p.Collateral}, decode(&p.CreatedAt)); err != nil {
+				return fmt.Errorf("failed to scan pricing profile: %w", err)
+			}
+			profiles = append(profiles, p)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// UpdatePricingProfile adds or replaces a renter's pricing profile.
+func (s *Store) UpdatePricingProfile(p settings.PricingProfile) error {
+	const query = `INSERT INTO pricing_profiles (public_key, note, contract_price, storage_price, ingress_price, egress_price, collateral, created_timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (public_key) DO UPDATE SET note=EXCLUDED.note, contract_price=EXCLUDED.contract_price, storage_price=EXCLUDED.storage_price, ingress_price=EXCLUDED.ingress_price,
+egress_price=EXCLUDED.egress_price, collateral=EXCLUDED.collateral`
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(query, encode(p.PublicKey), p.Note, encodeNullableCurrency(p.ContractPrice), encodeNullableCurrency(p.StoragePrice),
+			encodeNullableCurrency(p.IngressPrice), encodeNullableCurrency(p.EgressPrice), encodeNullableCurrency(p.Collateral), encode(p.CreatedAt))
+		return err
+	})
+}
+
+// RemovePricingProfile removes a renter's pricing profile.
+func (s *Store) RemovePricingProfile(pk types.PublicKey) error {
+	return s.transaction(func(tx *txn) error {
+		res, err := tx.Exec(`DELETE FROM pricing_profiles WHERE public_key=$1`, encode(pk))
+		if err != nil {
+			return err
+		} else if n, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		} else if n == 0 {
+			return settings.ErrPricingProfileNotFound
+		}
+		return nil
+	})
+}
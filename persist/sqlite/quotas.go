@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+)
+
+// RenterUsage returns the resources used by the renter's active contracts.
+func (s *Store) RenterUsage(pk types.PublicKey) (usage contracts.RenterUsage, err error) {
+	err = s.transaction(func(tx *txn) error {
+		var renterID int64
+		err := tx.QueryRow(`SELECT id FROM contract_renters WHERE public_key=$1`, encode(pk)).Scan(&renterID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get renter: %w", err)
+		}
+
+		const contractsQuery = `SELECT c.locked_collateral, (SELECT COUNT(*) FROM contract_sector_roots csr WHERE csr.contract_id=c.id) FROM contracts c
+WHERE c.renter_id=$1 AND c.renewed_to IS NULL AND c.contract_status IN ($2, $3)
+UNION ALL
+SELECT c.locked_collateral, (SELECT COUNT(*) FROM contract_v2_sector_roots csr WHERE csr.contract_id=c.id) FROM contracts_v2 c
+WHERE c.renter_id=$1 AND c.renewed_to IS NULL AND c.contract_status IN ($4, $5)`
+		rows, err := tx.Query(contractsQuery, renterID, contracts.ContractStatusPending, contracts.ContractStatusActive, contracts.V2ContractStatusPending, contracts.V2ContractStatusActive)
+		if err != nil {
+			return fmt.Errorf("failed to query renter contracts: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var collateral types.Currency
+			var sectors uint64
+			if err := rows.Scan(decode(&collateral), &sectors); err != nil {
+				return fmt.Errorf("failed to scan renter contract: %w", err)
+			}
+			usage.Contracts++
+			usage.Sectors += sectors
+			usage.LockedCollateral = usage.LockedCollateral.Add(collateral)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// accounts outlive the contracts that funded them, so every contract
+		// formed by the renter is considered
+		const accountsQuery = `SELECT a.balance FROM accounts a WHERE a.id IN (
+	SELECT caf.account_id FROM contract_account_funding caf INNER JOIN contracts c ON caf.contract_id=c.id WHERE c.renter_id=$1
+	UNION
+	SELECT caf.account_id FROM contract_v2_account_funding caf INNER JOIN contracts_v2 c ON caf.contract_id=c.id WHERE c.renter_id=$1
+)`
+		accountRows, err := tx.Query(accountsQuery, renterID)
+		if err != nil {
+			return fmt.Errorf("failed to query renter accounts: %w", err)
+		}
+		defer accountRows.Close()
+
+		for accountRows.Next() {
+			var balance types.Currency
+			if err := accountRows.Scan(decode(&balance)); err != nil {
+				return fmt.Errorf("failed to scan account balance: %w", err)
+			}
+			usage.AccountBalance = usage.AccountBalance.Add(balance)
+		}
+		return accountRows.Err()
+	})
+	return
+}
+
+// RenterQuota returns the renter's quota override. The bool is false if the
+// renter does not have an override.
+func (s *Store) RenterQuota(pk types.PublicKey) (quota contracts.RenterQuota, exists bool, err error) {
+	const query = `SELECT rq.max_sectors, rq.max_collateral, rq.max_contracts, rq.max_account_balance FROM renter_quotas rq
+INNER JOIN contract_renters cr ON rq.renter_id=cr.id
+WHERE cr.public_key=$1`
+	err = s.transaction(func(tx *txn) error {
+		err := tx.QueryRow(query, encode(pk)).Scan(decode(&quota.MaxSectors), decode(&quota.MaxCollateral), decode(&quota.MaxContracts), decode(&quota.MaxAccountBalance))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		exists = true
+		return nil
+	})
+	return
+}
+
+// SetRenterQuota sets the renter's quota override.
+func (s *Store) SetRenterQuota(pk types.PublicKey, quota contracts.RenterQuota) error {
+	const query = `INSERT INTO renter_quotas (renter_id, max_sectors, max_collateral, max_contracts, max_account_balance) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (renter_id) DO UPDATE SET max_sectors=EXCLUDED.max_sectors, max_collateral=EXCLUDED.max_collateral, max_contracts=EXCLUDED.max_contracts, max_account_balance=EXCLUDED.max_account_balance`
+	return s.transaction(func(tx *txn) error {
+		renterID, err := renterDBID(tx, pk)
+		if err != nil {
+			return fmt.Errorf("failed to get renter id: %w", err)
+		}
+		_, err = tx.Exec(query, renterID, encode(quota.MaxSectors), encode(quota.MaxCollateral), encode(quota.MaxContracts), encode(quota.MaxAccountBalance))
+		return err
+	})
+}
+
+// RemoveRenterQuota removes the renter's quota override.
+func (s *Store) RemoveRenterQuota(pk types.PublicKey) error {
+	return s.transaction(func(tx *txn) error {
+		res, err := tx.Exec(`DELETE FROM renter_quotas WHERE renter_id=(SELECT id FROM contract_renters WHERE public_key=$1)`, encode(pk))
+		if err != nil {
+			return err
+		} else if n, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		} else if n == 0 {
+			return contracts.ErrRenterQuotaNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,498 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/metrics"
+)
+
+const renterStatsColumns = `active_contracts, successful_contracts, failed_contracts, rejected_contracts, renewed_contracts, stored_sectors, locked_collateral, risked_collateral,
+potential_rpc_revenue, potential_storage_revenue, potential_ingress_revenue, potential_egress_revenue, potential_account_funding, potential_registry_read, potential_registry_write, potential_risked_collateral,
+earned_rpc_revenue, earned_storage_revenue, earned_ingress_revenue, earned_egress_revenue, earned_account_funding, earned_registry_read, earned_registry_write, earned_risked_collateral,
+account_balance`
+
+// Renters returns a paginated list of metrics for every renter and the total
+// number of renters.
+func (s *Store) Renters(filter contracts.RenterFilter) (renters []contracts.RenterMetrics, total int, err error) {
+	var orderBy string
+	switch filter.SortField {
+	case "", contracts.RenterSortActiveContracts:
+		orderBy = "rm.active_contracts"
+	case contracts.RenterSortStoredSectors:
+		orderBy = "rm.stored_sectors"
+	case contracts.RenterSortPotentialRevenue:
+		orderBy = "rm.sort_potential_revenue"
+	case contracts.RenterSortEarnedRevenue:
+		orderBy = "rm.sort_earned_revenue"
+	case contracts.RenterSortLockedCollateral:
+		orderBy = "rm.sort_locked_collateral"
+	case contracts.RenterSortFailureRate:
+		orderBy = "rm.failure_rate"
+	case contracts.RenterSortAccountBalance:
+		orderBy = "rm.sort_account_balance"
+	default:
+		return nil, 0, fmt.Errorf("%w: %q", contracts.ErrInvalidRenterSort, filter.SortField)
+	}
+	if filter.SortDesc {
+		orderBy += " DESC"
+	} else {
+		orderBy += " ASC"
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	err = s.transaction(func(tx *txn) error {
+		if err := refreshRenterMetrics(tx); err != nil {
+			return fmt.Errorf("failed to refresh renter metrics: %w", err)
+		} else if err := tx.QueryRow(`SELECT COUNT(*) FROM renter_metrics`).Scan(&total); err != nil {
+			return fmt.Errorf("failed to count renters: %w", err)
+		}
+
+		// ties are broken by public key so the order of equal renters is
+		// stable between pages
+		rows, err := tx.Query(`SELECT cr.public_key, `+renterStatsColumns+` FROM renter_metrics rm
+INNER JOIN contract_renters cr ON rm.renter_id=cr.id
+ORDER BY `+orderBy+`, cr.public_key ASC
+LIMIT $1 OFFSET $2`, limit, filter.Offset)
+		if err != nil {
+			return fmt.Errorf("failed to query renter metrics: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var rm contracts.RenterMetrics
+			if err := scanRenterStats(rows, &rm, decode(&rm.PublicKey)); err != nil {
+				return fmt.Errorf("failed to scan renter metrics: %w", err)
+			}
+			renters = append(renters, rm)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// RenterMetrics returns the current metrics for a renter.
+func (s *Store) RenterMetrics(pk types.PublicKey) (rm contracts.RenterMetrics, err error) {
+	err = s.transaction(func(tx *txn) error {
+		if err := refreshRenterMetrics(tx); err != nil {
+			return fmt.Errorf("failed to refresh renter metrics: %w", err)
+		}
+
+		err := scanRenterStats(tx.QueryRow(`SELECT `+renterStatsColumns+` FROM renter_metrics rm
+INNER JOIN contract_renters cr ON rm.renter_id=cr.id
+WHERE cr.public_key=$1`, encode(pk)), &rm)
+		if errors.Is(err, sql.ErrNoRows) {
+			// renters without contracts have empty metrics
+			rm = contracts.RenterMetrics{}
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get renter metrics: %w", err)
+		}
+		return nil
+	})
+	rm.PublicKey = pk
+	return
+}
+
+// RenterPeriodMetrics returns a renter's recorded metrics for n periods
+// starting at start.
+func (s *Store) RenterPeriodMetrics(pk types.PublicKey, start time.Time, n int, interval metrics.Interval) ([]contracts.RenterMetrics, error) {
+	if n <= 0 {
+		return nil, errors.New("n periods must be greater than 0")
+	}
+
+	end, err := periodEnd(start, n, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []contracts.RenterMetrics
+	err = s.transaction(func(tx *txn) error {
+		// get the metrics as of the start time to backfill any missing
+		// periods
+		initial := contracts.RenterMetrics{PublicKey: pk}
+		err := scanRenterStats(tx.QueryRow(`SELECT `+renterStatsColumns+` FROM renter_stats rs
+INNER JOIN contract_renters cr ON rs.renter_id=cr.id
+WHERE cr.public_key=$1 AND rs.date_created <= $2
+ORDER BY rs.date_created DESC LIMIT 1`, encode(pk), encode(start)), &initial)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get initial metrics: %w", err)
+		}
+		initial.Timestamp = start
+		stats = append(stats, initial)
+
+		rows, err := tx.Query(`SELECT rs.date_created, `+renterStatsColumns+` FROM renter_stats rs
+INNER JOIN contract_renters cr ON rs.renter_id=cr.id
+WHERE cr.public_key=$1 AND rs.date_created BETWEEN $2 AND $3
+ORDER BY rs.date_created ASC`, encode(pk), encode(start), encode(end))
+		if err != nil {
+			return fmt.Errorf("failed to query metrics: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			rm := contracts.RenterMetrics{PublicKey: pk}
+			if err := scanRenterStats(rows, &rm, decode(&rm.Timestamp)); err != nil {
+				return fmt.Errorf("failed to scan metrics: %w", err)
+			}
+
+			// normalize the stored timestamp to the locale and interval
+			rm.Timestamp = periodStart(rm.Timestamp.In(start.Location()), interval)
+
+			// each row is a snapshot, the most recent snapshot in a period
+			// replaces the previous value
+			if stats[len(stats)-1].Timestamp.Equal(rm.Timestamp) {
+				stats[len(stats)-1] = rm
+			} else {
+				stats = append(stats, rm)
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// fill in any missing periods
+	periods := make([]contracts.RenterMetrics, 0, n)
+	current := start
+	for i := 0; i < n; i++ {
+		if len(stats) != 0 && stats[0].Timestamp.Equal(current) {
+			periods = append(periods, stats[0])
+			stats = stats[1:]
+		} else {
+			// if there is not a metric for the current period, copy previous
+			// period and overwrite the timestamp
+			periods = append(periods, periods[len(periods)-1])
+			periods[len(periods)-1].Timestamp = current
+		}
+		current = nextPeriod(current, interval)
+	}
+	return periods, nil
+}
+
+// RecordRenterMetrics records the current metrics of every renter whose
+// metrics changed since they were last recorded.
+func (s *Store) RecordRenterMetrics(timestamp time.Time) error {
+	return s.transaction(func(tx *txn) error {
+		if err := refreshRenterMetrics(tx); err != nil {
+			return fmt.Errorf("failed to refresh renter metrics: %w", err)
+		}
+
+		current, err := queryRenterStats(tx, `SELECT renter_id, `+renterStatsColumns+` FROM renter_metrics`)
+		if err != nil {
+			return fmt.Errorf("failed to query current metrics: %w", err)
+		}
+
+		recorded, err := queryRenterStats(tx, `SELECT rs.renter_id, `+renterStatsColumns+` FROM renter_stats rs
+WHERE rs.date_created=(SELECT MAX(date_created) FROM renter_stats WHERE renter_id=rs.renter_id)`)
+		if err != nil {
+			return fmt.Errorf("failed to query recorded metrics: %w", err)
+		}
+
+		stmt, err := tx.Prepare(`INSERT INTO renter_stats (renter_id, date_created, ` + renterStatsColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
+ON CONFLICT (renter_id, date_created) DO NOTHING`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for renterID, rm := range current {
+			if prev, ok := recorded[renterID]; ok && prev == rm {
+				continue
+			}
+
+			args := append([]any{renterID, encode(timestamp)}, renterStatsArgs(rm)...)
+			if _, err := stmt.Exec(args...); err != nil {
+				return fmt.Errorf("failed to record metrics for renter %d: %w", renterID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// queryRenterStats returns the renter_stats columns of each row keyed by the
+// renter's database ID. The query must select the renter ID followed by
+// renterStatsColumns.
+func queryRenterStats(tx *txn, query string) (map[int64]contracts.RenterMetrics, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	m := make(map[int64]contracts.RenterMetrics)
+	for rows.Next() {
+		var renterID int64
+		var rm contracts.RenterMetrics
+		if err := scanRenterStats(rows, &rm, &renterID); err != nil {
+			return nil, fmt.Errorf("failed to scan metrics: %w", err)
+		}
+		m[renterID] = rm
+	}
+	return m, rows.Err()
+}
+
+// renterStatsArgs returns the values of rm in the order of
+// renterStatsColumns.
+func renterStatsArgs(rm contracts.RenterMetrics) []any {
+	p, e := rm.Potential, rm.Earned
+	return []any{rm.ActiveContracts, rm.SuccessfulContracts, rm.FailedContracts, rm.RejectedContracts, rm.RenewedContracts, rm.StoredSectors,
+		encode(rm.LockedCollateral), encode(rm.RiskedCollateral),
+		encode(p.RPCRevenue), encode(p.StorageRevenue), encode(p.IngressRevenue), encode(p.EgressRevenue), encode(p.AccountFunding), encode(p.RegistryRead), encode(p.RegistryWrite), encode(p.RiskedCollateral),
+		encode(e.RPCRevenue), encode(e.StorageRevenue), encode(e.IngressRevenue), encode(e.EgressRevenue), encode(e.AccountFunding), encode(e.RegistryRead), encode(e.RegistryWrite), encode(e.RiskedCollateral),
+		encode(rm.AccountBalance)}
+}
+
+// scanRenterStats scans a row of renter_stats columns into rm. Additional
+// destinations are scanned before the stats columns.
+func scanRenterStats(row scanner, rm *contracts.RenterMetrics, prefix ...any) error {
+	p, e := &rm.Potential, &rm.Earned
+	dst := append(prefix, &rm.ActiveContracts, &rm.SuccessfulContracts, &rm.FailedContracts, &rm.RejectedContracts, &rm.RenewedContracts, &rm.StoredSectors,
+		decode(&rm.LockedCollateral), decode(&rm.RiskedCollateral),
+		decode(&p.RPCRevenue), decode(&p.StorageRevenue), decode(&p.IngressRevenue), decode(&p.EgressRevenue), decode(&p.AccountFunding), decode(&p.RegistryRead), decode(&p.RegistryWrite), decode(&p.RiskedCollateral),
+		decode(&e.RPCRevenue), decode(&e.StorageRevenue), decode(&e.IngressRevenue), decode(&e.EgressRevenue), decode(&e.AccountFunding), decode(&e.RegistryRead), decode(&e.RegistryWrite), decode(&e.RiskedCollateral),
+		decode(&rm.AccountBalance))
+	if err := row.Scan(dst...); err != nil {
+		return err
+	}
+	rm.FailureRate = failureRate(*rm)
+	return nil
+}
+
+// failureRate returns the fraction of the renter's resolved contracts that
+// failed.
+func failureRate(rm contracts.RenterMetrics) float64 {
+	resolved := rm.SuccessfulContracts + rm.FailedContracts
+	if resolved == 0 {
+		return 0
+	}
+	return float64(rm.FailedContracts) / float64(resolved)
+}
+
+// refreshRenterMetrics recalculates the metrics of every renter marked stale
+// by the renter metrics triggers.
+func refreshRenterMetrics(tx *txn) error {
+	m, err := aggregateStaleRenterMetrics(tx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM renter_metrics WHERE renter_id IN (SELECT renter_id FROM renter_metrics_stale)`); err != nil {
+		return fmt.Errorf("failed to clear stale metrics: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO renter_metrics (renter_id, ` + renterStatsColumns + `, failure_rate,
+sort_potential_revenue, sort_earned_revenue, sort_locked_collateral, sort_account_balance) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for renterID, rm := range m {
+		args := append([]any{renterID}, renterStatsArgs(rm)...)
+		args = append(args, rm.FailureRate, sortableCurrency(rm.Potential.Revenue()), sortableCurrency(rm.Earned.Revenue()), sortableCurrency(rm.LockedCollateral), sortableCurrency(rm.AccountBalance))
+		if _, err := stmt.Exec(args...); err != nil {
+			return fmt.Errorf("failed to update metrics for renter %d: %w", renterID, err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM renter_metrics_stale`); err != nil {
+		return fmt.Errorf("failed to clear stale renters: %w", err)
+	}
+	return nil
+}
+
+// sortableCurrency encodes c as a big-endian integer so it can be sorted by
+// SQLite.
+func sortableCurrency(c types.Currency) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], c.Hi)
+	binary.BigEndian.PutUint64(buf[8:], c.Lo)
+	return buf
+}
+
+// aggregateStaleRenterMetrics returns the current metrics of every renter
+// marked stale keyed by the renter's database ID. Renters without any
+// contracts are omitted.
+func aggregateStaleRenterMetrics(tx *txn) (map[int64]contracts.RenterMetrics, error) {
+	m := make(map[int64]contracts.RenterMetrics)
+
+	const v1Query = `SELECT c.renter_id, c.contract_status, c.renewed_to IS NOT NULL, c.locked_collateral,
+c.rpc_revenue, c.storage_revenue, c.ingress_revenue, c.egress_revenue, c.account_funding, c.registry_read, c.registry_write, c.risked_collateral
+FROM contracts c
+WHERE c.renter_id IN (SELECT renter_id FROM renter_metrics_stale)`
+	rows, err := tx.Query(v1Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contracts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var status contracts.ContractStatus
+		var renewed bool
+		var collateral types.Currency
+		var usage contracts.Usage
+		if err := rows.Scan(&id, &status, &renewed, decode(&collateral),
+			decode(&usage.RPCRevenue), decode(&usage.StorageRevenue), decode(&usage.IngressRevenue), decode(&usage.EgressRevenue), decode(&usage.AccountFunding),
+			decode(&usage.RegistryRead), decode(&usage.RegistryWrite), decode(&usage.RiskedCollateral)); err != nil {
+			return nil, fmt.Errorf("failed to scan contract: %w", err)
+		}
+
+		rm := m[id]
+		if renewed {
+			rm.RenewedContracts++
+		}
+		switch status {
+		case contracts.ContractStatusPending, contracts.ContractStatusActive:
+			addActiveUsage(&rm, renewed, collateral, usage)
+		case contracts.ContractStatusSuccessful:
+			rm.SuccessfulContracts++
+			rm.Earned = rm.Earned.Add(usage)
+		case contracts.ContractStatusFailed:
+			rm.FailedContracts++
+		case contracts.ContractStatusRejected:
+			rm.RejectedContracts++
+		}
+		m[id] = rm
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const v2Query = `SELECT c.renter_id, c.contract_status, c.renewed_to IS NOT NULL, c.locked_collateral,
+c.rpc_revenue, c.storage_revenue, c.ingress_revenue, c.egress_revenue, c.account_funding, c.risked_collateral
+FROM contracts_v2 c
+WHERE c.renter_id IN (SELECT renter_id FROM renter_metrics_stale)`
+	v2Rows, err := tx.Query(v2Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query v2 contracts: %w", err)
+	}
+	defer v2Rows.Close()
+
+	for v2Rows.Next() {
+		var id int64
+		var status contracts.V2ContractStatus
+		var renewed bool
+		var collateral types.Currency
+		var usage contracts.Usage
+		if err := v2Rows.Scan(&id, &status, &renewed, decode(&collateral),
+			decode(&usage.RPCRevenue), decode(&usage.StorageRevenue), decode(&usage.IngressRevenue), decode(&usage.EgressRevenue), decode(&usage.AccountFunding),
+			decode(&usage.RiskedCollateral)); err != nil {
+			return nil, fmt.Errorf("failed to scan v2 contract: %w", err)
+		}
+
+		rm := m[id]
+		if renewed {
+			rm.RenewedContracts++
+		}
+		switch status {
+		case contracts.V2ContractStatusPending, contracts.V2ContractStatusActive:
+			addActiveUsage(&rm, renewed, collateral, usage)
+		case contracts.V2ContractStatusSuccessful, contracts.V2ContractStatusRenewed:
+			rm.SuccessfulContracts++
+			rm.Earned = rm.Earned.Add(usage)
+		case contracts.V2ContractStatusFailed:
+			rm.FailedContracts++
+		case contracts.V2ContractStatusRejected:
+			rm.RejectedContracts++
+		}
+		m[id] = rm
+	}
+	if err := v2Rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// only the sectors of active contracts that have not been renewed are
+	// counted since renewals take over the sectors of the renewed contract
+	const sectorsQuery = `SELECT c.renter_id, COUNT(*) FROM contract_sector_roots csr
+INNER JOIN contracts c ON csr.contract_id=c.id
+WHERE c.renter_id IN (SELECT renter_id FROM renter_metrics_stale) AND c.contract_status IN ($1, $2) AND c.renewed_to IS NULL
+GROUP BY c.renter_id
+UNION ALL
+SELECT c.renter_id, COUNT(*) FROM contract_v2_sector_roots csr
+INNER JOIN contracts_v2 c ON csr.contract_id=c.id
+WHERE c.renter_id IN (SELECT renter_id FROM renter_metrics_stale) AND c.contract_status IN ($3, $4) AND c.renewed_to IS NULL
+GROUP BY c.renter_id`
+	sectorRows, err := tx.Query(sectorsQuery, contracts.ContractStatusPending, contracts.ContractStatusActive, contracts.V2ContractStatusPending, contracts.V2ContractStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored sectors: %w", err)
+	}
+	defer sectorRows.Close()
+
+	for sectorRows.Next() {
+		var id int64
+		var sectors uint64
+		if err := sectorRows.Scan(&id, &sectors); err != nil {
+			return nil, fmt.Errorf("failed to scan stored sectors: %w", err)
+		}
+		rm := m[id]
+		rm.StoredSectors += sectors
+		m[id] = rm
+	}
+	if err := sectorRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// accounts outlive the contracts that funded them, so every contract
+	// formed by the renter is considered
+	const accountsQuery = `SELECT c.renter_id, a.id, a.balance FROM accounts a
+INNER JOIN contract_account_funding caf ON caf.account_id=a.id
+INNER JOIN contracts c ON caf.contract_id=c.id
+WHERE c.renter_id IN (SELECT renter_id FROM renter_metrics_stale)
+UNION
+SELECT c.renter_id, a.id, a.balance FROM accounts a
+INNER JOIN contract_v2_account_funding caf ON caf.account_id=a.id
+INNER JOIN contracts_v2 c ON caf.contract_id=c.id
+WHERE c.renter_id IN (SELECT renter_id FROM renter_metrics_stale)`
+	accountRows, err := tx.Query(accountsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer accountRows.Close()
+
+	for accountRows.Next() {
+		var id, accountID int64
+		var balance types.Currency
+		if err := accountRows.Scan(&id, &accountID, decode(&balance)); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		rm := m[id]
+		rm.AccountBalance = rm.AccountBalance.Add(balance)
+		m[id] = rm
+	}
+	if err := accountRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for id, rm := range m {
+		rm.FailureRate = failureRate(rm)
+		m[id] = rm
+	}
+	return m, nil
+}
+
+// addActiveUsage adds a pending or active contract to the renter's metrics.
+// Contracts that have been renewed only contribute to potential revenue
+// since their collateral was moved to the renewal.
+func addActiveUsage(rm *contracts.RenterMetrics, renewed bool, collateral types.Currency, usage contracts.Usage) {
+	rm.Potential = rm.Potential.Add(usage)
+	if renewed {
+		return
+	}
+	rm.ActiveContracts++
+	rm.LockedCollateral = rm.LockedCollateral.Add(collateral)
+	rm.RiskedCollateral = rm.RiskedCollateral.Add(usage.RiskedCollateral)
+}
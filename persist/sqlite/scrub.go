@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.sia.tech/hostd/host/storage"
+)
+
+// ScrubSectors returns up to limit occupied sector locations in a volume with
+// an index greater than or equal to min, sorted by index.
+func (s *Store) ScrubSectors(volumeID int64, min uint64, limit int) (locations []storage.SectorLocation, err error) {
+	err = s.transaction(func(tx *txn) error {
+		const query = `SELECT vs.id, vs.volume_id, vs.volume_index, ss.sector_root FROM volume_sectors vs
+INNER JOIN stored_sectors ss ON vs.sector_id=ss.id
+WHERE vs.volume_id=$1 AND vs.volume_index >= $2
+ORDER BY vs.volume_index ASC
+LIMIT $3`
+		rows, err := tx.Query(query, volumeID, min, limit)
+		if err != nil {
+			return fmt.Errorf("failed to query sectors: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var loc storage.SectorLocation
+			if err := rows.Scan(&loc.ID, &loc.Volume, &loc.Index, decode(&loc.Root)); err != nil {
+				return fmt.Errorf("failed to scan sector location: %w", err)
+			}
+			locations = append(locations, loc)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// VolumeScrubs returns the most recent scrub of every volume.
+func (s *Store) VolumeScrubs() (scrubs []storage.VolumeScrub, err error) {
+	err = s.transaction(func(tx *txn) error {
+		rows, err := tx.Query(`SELECT volume_id, scrub_status, next_index, checked_sectors, corrupt_sectors, failed_reads, start_time, update_time, end_time FROM volume_scrubs`)
+		if err != nil {
+			return fmt.Errorf("failed to query volume scrubs: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			scrub, err := scanVolumeScrub(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan volume scrub: %w", err)
+			}
+			scrubs = append(scrubs, scrub)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// VolumeScrub returns the most recent scrub of a volume. If the volume has
+// never been scrubbed, storage.ErrScrubNotFound is returned.
+func (s *Store) VolumeScrub(volumeID int64) (scrub storage.VolumeScrub, err error) {
+	err = s.transaction(func(tx *txn) error {
+		const query = `SELECT volume_id, scrub_status, next_index, checked_sectors, corrupt_sectors, failed_reads, start_time, update_time, end_time FROM volume_scrubs WHERE volume_id=$1`
+		scrub, err = scanVolumeScrub(tx.QueryRow(query, volumeID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrScrubNotFound
+		}
+		return err
+	})
+	return
+}
+
+// UpdateVolumeScrub stores the progress of a volume scrub.
+func (s *Store) UpdateVolumeScrub(scrub storage.VolumeScrub) error {
+	var endTime any
+	if !scrub.EndTime.IsZero() {
+		endTime = encode(scrub.EndTime)
+	}
+	return s.transaction(func(tx *txn) error {
+		const query = `INSERT INTO volume_scrubs (volume_id, scrub_status, next_index, checked_sectors, corrupt_sectors, failed_reads, start_time, update_time, end_time)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (volume_id) DO UPDATE SET scrub_status=EXCLUDED.scrub_status, next_index=EXCLUDED.next_index, checked_sectors=EXCLUDED.checked_sectors,
+corrupt_sectors=EXCLUDED.corrupt_sectors, failed_reads=EXCLUDED.failed_reads, start_time=EXCLUDED.start_time, update_time=EXCLUDED.update_time, end_time=EXCLUDED.end_time`
+		_, err := tx.Exec(query, scrub.VolumeID, scrub.Status, scrub.NextIndex, scrub.CheckedSectors, scrub.CorruptSectors, scrub.FailedReads, encode(scrub.StartTime), encode(scrub.UpdateTime), endTime)
+		return err
+	})
+}
+
+// AddScrubError records a sector that failed verification.
+func (s *Store) AddScrubError(se storage.ScrubError) error {
+	return s.transaction(func(tx *txn) error {
+		const query = `INSERT INTO volume_scrub_errors (volume_id, volume_index, sector_root, actual_root, error_message, date_created) VALUES ($1, $2, $3, $4, $5, $6)`
+		_, err := tx.Exec(query, se.VolumeID, se.Index, encode(se.Root), encode(se.ActualRoot), se.Error, encode(se.Timestamp))
+		return err
+	})
+}
+
+// ScrubErrors returns the sectors that failed verification during the most
+// recent scrub of a volume.
+func (s *Store) ScrubErrors(volumeID int64, limit, offset int) (scrubErrs []storage.ScrubError, err error) {
+	err = s.transaction(func(tx *txn) error {
+		const query = `SELECT volume_id, volume_index, sector_root, actual_root, error_message, date_created FROM volume_scrub_errors
+WHERE volume_id=$1 ORDER BY id ASC LIMIT $2 OFFSET $3`
+		rows, err := tx.Query(query, volumeID, limit, offset)
+		if err != nil {
+			return fmt.Errorf("failed to query scrub errors: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var se storage.ScrubError
+			if err := rows.Scan(&se.VolumeID, &se.Index, decode(&se.Root), decode(&se.ActualRoot), &se.Error, decode(&se.Timestamp)); err != nil {
+				return fmt.Errorf("failed to scan scrub error: %w", err)
+			}
+			scrubErrs = append(scrubErrs, se)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// ClearScrubErrors removes all scrub errors for a volume.
+func (s *Store) ClearScrubErrors(volumeID int64) error {
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(`DELETE FROM volume_scrub_errors WHERE volume_id=$1`, volumeID)
+		return err
+	})
+}
+
+func scanVolumeScrub(s scanner) (scrub storage.VolumeScrub, err error) {
+	err = s.Scan(&scrub.VolumeID, &scrub.Status, &scrub.NextIndex, &scrub.CheckedSectors, &scrub.CorruptSectors, &scrub.FailedReads, decode(&scrub.StartTime), decode(&scrub.UpdateTime), decodeNullable(&scrub.EndTime))
+	return
+}
@@ -74,6 +74,8 @@ func (s *Store) RemoveSector(root types.Hash256) (err error) {
 			return fmt.Errorf("failed to update volume usage: %w", err)
 		} else if err := incrementNumericStat(tx, metricLostSectors, 1, time.Now()); err != nil {
 			return fmt.Errorf("failed to update metric: %w", err)
+		} else if err := recordLostSector(tx, sectorID, volumeID, storage.LostSectorCauseRemoved, time.Now()); err != nil {
+			return err
 		}
 		return nil
 	})
@@ -36,6 +36,8 @@ func randomSettings() settings.Settings {
 		AccountExpiry:        time.Duration(frand.Intn(math.MaxInt)),
 		PriceTableValidity:   time.Duration(frand.Intn(math.MaxInt)),
 		MaxAccountBalance:    types.NewCurrency(frand.Uint64n(math.MaxUint64), frand.Uint64n(math.MaxUint64)),
+		SectorCachePath:      hex.EncodeToString(frand.Bytes(16)),
+		SectorCacheDiskSize:  uint64(frand.Intn(math.MaxInt)),
 	}
 }
 
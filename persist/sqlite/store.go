@@ -25,6 +25,9 @@ type (
 	Store struct {
 		db  *sql.DB
 		log *zap.Logger
+
+		// placer tracks the state of the sector placement strategy
+		placer storage.Placer
 	}
 )
 
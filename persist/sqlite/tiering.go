@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/hostd/host/storage"
+)
+
+// RecordSectorReads adds to the read count of each sector and sets its last
+// read time.
+func (s *Store) RecordSectorReads(reads map[types.Hash256]uint64, timestamp time.Time) error {
+	return s.transaction(func(tx *txn) error {
+		stmt, err := tx.Prepare(`UPDATE stored_sectors SET read_count=read_count+$1, last_read_timestamp=$2 WHERE sector_root=$3`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare update statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for root, n := range reads {
+			if _, err := stmt.Exec(n, encode(timestamp), encode(root)); err != nil {
+				return fmt.Errorf("failed to record reads of sector %v: %w", root, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DecaySectorReads halves the read count of sectors last read after readAfter
+// so that older reads have less influence than recent reads. The read count
+// of sectors last read between resetAfter and readAfter is reset since they
+// are no longer considered hot. Only sectors last read after resetAfter are
+// updated.
+func (s *Store) DecaySectorReads(readAfter, resetAfter time.Time) error {
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(`UPDATE stored_sectors SET read_count=CASE WHEN last_read_timestamp > $1 THEN read_count/2 ELSE 0 END
+WHERE last_read_timestamp > $2 AND read_count > 0`, encode(readAfter), encode(resetAfter))
+		return err
+	})
+}
+
+// HotSectors returns up to limit sectors stored in bulk volumes that have
+// been read at least minReads times and were last read after readAfter,
+// sorted by read count.
+func (s *Store) HotSectors(minReads uint64, readAfter time.Time, limit int) (locations []storage.SectorLocation, err error) {
+	const query = `SELECT vs.id, vs.volume_id, vs.volume_index, ss.sector_root FROM stored_sectors ss
+INNER JOIN volume_sectors vs ON vs.sector_id=ss.id
+INNER JOIN storage_volumes sv ON vs.volume_id=sv.id
+WHERE sv.volume_tier=$1 AND ss.read_count >= $2 AND ss.last_read_timestamp > $3
+ORDER BY ss.read_count DESC
+LIMIT $4`
+
+	err = s.transaction(func(tx *txn) error {
+		locations, err = queryTierSectors(tx, query, storage.VolumeTierBulk, minReads, encode(readAfter), limit)
+		return err
+	})
+	return
+}
+
+// ColdSectors returns up to limit sectors stored in fast volumes that have not
+// been read or written since accessBefore, sorted by last access.
+func (s *Store) ColdSectors(accessBefore time.Time, limit int) (locations []storage.SectorLocation, err error) {
+	const query = `SELECT vs.id, vs.volume_id, vs.volume_index, ss.sector_root FROM stored_sectors ss
+INNER JOIN volume_sectors vs ON vs.sector_id=ss.id
+INNER JOIN storage_volumes sv ON vs.volume_id=sv.id
+WHERE sv.volume_tier=$1 AND ss.last_read_timestamp < $2 AND ss.last_access_timestamp < $2
+ORDER BY MAX(ss.last_read_timestamp, ss.last_access_timestamp) ASC
+LIMIT $3`
+
+	err = s.transaction(func(tx *txn) error {
+		locations, err = queryTierSectors(tx, query, storage.VolumeTierFast, encode(accessBefore), limit)
+		return err
+	})
+	return
+}
+
+// TierSector moves a sector to an empty location in a volume of the given
+// tier. The sector data should be copied to the new location and synced to
+// disk during fn. If the sector is no longer stored at its location,
+// ErrSectorNotFound is returned. If no volume in the tier has space,
+// ErrNotEnoughStorage is returned.
+func (s *Store) TierSector(from storage.SectorLocation, tier string, fn storage.MigrateFunc) error {
+	return s.transaction(func(tx *txn) error {
+		var sectorID int64
+		err := tx.QueryRow(`SELECT ss.id FROM volume_sectors vs INNER JOIN stored_sectors ss ON vs.sector_id=ss.id WHERE vs.id=$1 AND ss.sector_root=$2`, from.ID, encode(from.Root)).Scan(&sectorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrSectorNotFound
+		} else if err != nil {
+			return fmt.Errorf("failed to get sector: %w", err)
+		}
+
+		to, err := emptyLocationInTier(tx, tier)
+		if err != nil {
+			return fmt.Errorf("failed to get empty location: %w", err)
+		}
+		to.Root = from.Root
+
+		if err := fn(from, to); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE volume_sectors SET sector_id=NULL WHERE id=$1`, from.ID); err != nil {
+			return fmt.Errorf("failed to clear old sector location: %w", err)
+		} else if _, err := tx.Exec(`UPDATE volume_sectors SET sector_id=$1 WHERE id=$2`, sectorID, to.ID); err != nil {
+			return fmt.Errorf("failed to update sector location: %w", err)
+		} else if err := incrementVolumeUsage(tx, from.Volume, -1); err != nil {
+			return fmt.Errorf("failed to update old volume metadata: %w", err)
+		} else if err := incrementVolumeUsage(tx, to.Volume, 1); err != nil {
+			return fmt.Errorf("failed to update new volume metadata: %w", err)
+		}
+		return nil
+	})
+}
+
+// VolumeTierStats returns the number of hot and cold sectors stored in each
+// volume with a tier.
+func (s *Store) VolumeTierStats(minReads uint64, readAfter, accessBefore time.Time) (stats []storage.VolumeTierStats, err error) {
+	const query = `SELECT sv.id, sv.volume_tier,
+	(SELECT COUNT(*) FROM volume_sectors vs INNER JOIN stored_sectors ss ON vs.sector_id=ss.id WHERE vs.volume_id=sv.id AND ss.read_count >= $1 AND ss.last_read_timestamp > $2),
+	(SELECT COUNT(*) FROM volume_sectors vs INNER JOIN stored_sectors ss ON vs.sector_id=ss.id WHERE vs.volume_id=sv.id AND ss.last_read_timestamp < $3 AND ss.last_access_timestamp < $3)
+FROM storage_volumes sv
+WHERE sv.volume_tier <> ''
+ORDER BY sv.id ASC`
+
+	err = s.transaction(func(tx *txn) error {
+		rows, err := tx.Query(query, minReads, encode(readAfter), encode(accessBefore))
+		if err != nil {
+			return fmt.Errorf("failed to query volume tier stats: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var vs storage.VolumeTierStats
+			if err := rows.Scan(&vs.VolumeID, &vs.Tier, &vs.HotSectors, &vs.ColdSectors); err != nil {
+				return fmt.Errorf("failed to scan volume tier stats: %w", err)
+			}
+			stats = append(stats, vs)
+		}
+		return rows.Err()
+	})
+	return
+}
+
+// queryTierSectors returns the sector locations selected by query.
+func queryTierSectors(tx *txn, query string, args ...any) (locations []storage.SectorLocation, err error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sectors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var loc storage.SectorLocation
+		if err := rows.Scan(&loc.ID, &loc.Volume, &loc.Index, decode(&loc.Root)); err != nil {
+			return nil, fmt.Errorf("failed to scan sector location: %w", err)
+		}
+		locations = append(locations, loc)
+	}
+	return locations, rows.Err()
+}
+
+// emptyLocationInTier returns an empty location in a writable volume of the
+// given tier. If there is no space available, ErrNotEnoughStorage is returned.
+func emptyLocationInTier(tx *txn, tier string) (loc storage.SectorLocation, err error) {
+	const query = `SELECT vs.id, vs.volume_id, vs.volume_index
+	FROM volume_sectors vs INDEXED BY volume_sectors_sector_writes_volume_id_sector_id_volume_index_compound
+	INNER JOIN storage_volumes sv ON (sv.id=vs.volume_id)
+	WHERE vs.sector_id IS NULL AND sv.volume_tier=$1 AND sv.available=true AND sv.read_only=false
+	ORDER BY vs.sector_writes ASC
+	LIMIT 1;`
+	err = tx.QueryRow(query, tier).Scan(&loc.ID, &loc.Volume, &loc.Index)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.ErrNotEnoughStorage
+		return
+	} else if err != nil {
+		return
+	}
+	_, err = tx.Exec(`UPDATE volume_sectors SET sector_writes=sector_writes+1 WHERE id=$1`, loc.ID)
+	return
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -13,7 +14,7 @@ import (
 )
 
 func forceDeleteVolumeSectors(tx *txn, volumeID int64) (removed, lost int64, err error) {
-	const query = `DELETE FROM volume_sectors WHERE id IN (SELECT id FROM volume_sectors WHERE volume_id=$1 LIMIT $2) RETURNING sector_id IS NULL AS empty`
+	const query = `DELETE FROM volume_sectors WHERE id IN (SELECT id FROM volume_sectors WHERE volume_id=$1 LIMIT $2) RETURNING sector_id`
 
 	rows, err := tx.Query(query, volumeID, sqlSectorBatchSize)
 	if err != nil {
@@ -21,19 +22,30 @@ func forceDeleteVolumeSectors(tx *txn, volumeID int64) (removed, lost int64, err
 	}
 	defer rows.Close()
 
+	var lostIDs []int64
 	for rows.Next() {
-		var empty bool
-		if err := rows.Scan(&empty); err != nil {
+		var sectorID sql.NullInt64
+		if err := rows.Scan(&sectorID); err != nil {
 			return 0, 0, fmt.Errorf("failed to scan volume sector: %w", err)
 		}
 
 		removed++
-		if !empty {
-			lost++
+		if sectorID.Valid {
+			lostIDs = append(lostIDs, sectorID.Int64)
 		}
 	}
-	err = rows.Err()
-	return
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, id := range lostIDs {
+		if err := recordLostSector(tx, id, volumeID, storage.LostSectorCauseVolumeRemoved, now); err != nil {
+			return 0, 0, err
+		}
+	}
+	return removed, int64(len(lostIDs)), nil
 }
 
 func deleteVolumeSectors(tx *txn, volumeID int64) (removed int64, err error) {
@@ -107,7 +119,7 @@ func (s *Store) StorageUsage() (usedSectors, totalSectors uint64, err error) {
 
 // Volumes returns a list of all volumes.
 func (s *Store) Volumes() (volumes []storage.Volume, err error) {
-	const query = `SELECT v.id, v.disk_path, v.read_only, v.available, v.total_sectors, v.used_sectors
+	const query = `SELECT v.id, v.disk_path, v.read_only, v.available, v.total_sectors, v.used_sectors, v.volume_uuid, v.placement_weight, v.placement_priority, v.auto_grow_max_sectors, v.auto_grow_reserved_bytes, v.volume_tier
 FROM storage_volumes v
 ORDER BY v.id ASC`
 
@@ -132,7 +144,7 @@ ORDER BY v.id ASC`
 
 // Volume returns a volume by its ID.
 func (s *Store) Volume(id int64) (vol storage.Volume, err error) {
-	const query = `SELECT v.id, v.disk_path, v.read_only, v.available, v.total_sectors, v.used_sectors
+	const query = `SELECT v.id, v.disk_path, v.read_only, v.available, v.total_sectors, v.used_sectors, v.volume_uuid, v.placement_weight, v.placement_priority, v.auto_grow_max_sectors, v.auto_grow_reserved_bytes, v.volume_tier
 FROM storage_volumes v
 WHERE v.id=$1`
 
@@ -170,7 +182,7 @@ func (s *Store) StoreSector(root types.Hash256, fn storage.StoreFunc) error {
 			return nil
 		}
 
-		location, err = emptyLocation(tx)
+		location, err = s.placeSector(tx)
 		if err != nil {
 			return fmt.Errorf("failed to get empty location: %w", err)
 		}
@@ -226,11 +238,55 @@ func (s *Store) StoreSector(root types.Hash256, fn storage.StoreFunc) error {
 // be returned. The number of sectors migrated and failed will always be returned, even if an
 // error occurs.
 func (s *Store) MigrateSectors(ctx context.Context, volumeID int64, startIndex uint64, migrateFn storage.MigrateFunc) (migrated, failed int, err error) {
-	log := s.log.Named("migrate").With(zap.Uint64("startIndex", startIndex))
+	return s.migrateSectors(ctx, volumeID, startIndex, 0, func(tx *txn) (storage.SectorLocation, error) {
+		return emptyLocationForMigration(tx, volumeID, startIndex)
+	}, migrateFn)
+}
+
+// EvacuateSectors migrates each occupied sector of a volume to the
+// destination volume. migrateFn will be called for each sector that needs to
+// be migrated. The sector data should be copied to the new location and synced
+// to disk immediately. If migrateFn returns an error, that sector will be
+// considered failed and the migration will continue. If the destination volume
+// runs out of space, migration will stop and ErrNotEnoughStorage will be
+// returned. The number of sectors migrated and failed will always be returned,
+// even if an error occurs.
+func (s *Store) EvacuateSectors(ctx context.Context, volumeID, destID int64, migrateFn storage.MigrateFunc) (migrated, failed int, err error) {
+	if volumeID == destID {
+		return 0, 0, errors.New("source and destination volumes must be different")
+	}
+	return s.migrateSectors(ctx, volumeID, 0, 0, func(tx *txn) (storage.SectorLocation, error) {
+		return emptyLocationInVolume(tx, destID)
+	}, migrateFn)
+}
+
+// MoveSectors migrates up to count occupied sectors of a volume to the
+// destination volume. migrateFn will be called for each sector that needs to
+// be migrated. If migrateFn returns an error, that sector will be considered
+// failed and the migration will continue. If the destination volume runs out
+// of space, migration will stop and ErrNotEnoughStorage will be returned.
+func (s *Store) MoveSectors(ctx context.Context, volumeID, destID int64, count int, migrateFn storage.MigrateFunc) (migrated, failed int, err error) {
+	if volumeID == destID {
+		return 0, 0, errors.New("source and destination volumes must be different")
+	} else if count <= 0 {
+		return 0, 0, nil
+	}
+	return s.migrateSectors(ctx, volumeID, 0, count, func(tx *txn) (storage.SectorLocation, error) {
+		return emptyLocationInVolume(tx, destID)
+	}, migrateFn)
+}
+
+// migrateSectors migrates each occupied sector of a volume starting at
+// startIndex to the location returned by emptyFn. If limit is greater than
+// zero, migration stops after limit sectors have been migrated.
+func (s *Store) migrateSectors(ctx context.Context, volumeID int64, startIndex uint64, limit int, emptyFn func(*txn) (storage.SectorLocation, error), migrateFn storage.MigrateFunc) (migrated, failed int, err error) {
+	log := s.log.Named("migrate").With(zap.Int64("volumeID", volumeID), zap.Uint64("startIndex", startIndex))
 	for index := startIndex; ; index++ {
 		if ctx.Err() != nil {
 			err = ctx.Err()
 			return
+		} else if limit > 0 && migrated >= limit {
+			return
 		}
 
 		var done bool
@@ -253,7 +309,7 @@ LIMIT 1;`
 			}
 			sectorID := nullSectorID.Int64
 
-			to, err := emptyLocationForMigration(tx, volumeID, startIndex)
+			to, err := emptyFn(tx)
 			if err != nil {
 				return fmt.Errorf("failed to get empty location: %w", err)
 			}
@@ -310,6 +366,60 @@ LIMIT 1;`
 	}
 }
 
+// RecoverSector sets the location of a sector to the given volume index. The
+// data at the location must have already been verified to match the sector
+// root. If the sector was previously stored in a different location, its
+// location is updated. If the location was previously occupied by a different
+// sector, that sector's location is cleared. false is returned if the sector is
+// already stored at the location.
+func (s *Store) RecoverSector(volumeID int64, index uint64, root types.Hash256) (recovered bool, err error) {
+	err = s.transaction(func(tx *txn) error {
+		sectorID, err := insertSectorDBID(tx, root)
+		if err != nil {
+			return fmt.Errorf("failed to get sector id: %w", err)
+		}
+
+		var locationID int64
+		var currentID sql.NullInt64
+		err = tx.QueryRow(`SELECT id, sector_id FROM volume_sectors WHERE volume_id=$1 AND volume_index=$2`, volumeID, index).Scan(&locationID, &currentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("volume %v has no index %v", volumeID, index)
+		} else if err != nil {
+			return fmt.Errorf("failed to get volume sector: %w", err)
+		} else if currentID.Valid && currentID.Int64 == sectorID {
+			return nil // already indexed
+		}
+
+		// clear the sector's previous location, if any
+		var oldVolumeID int64
+		err = tx.QueryRow(`UPDATE volume_sectors SET sector_id=NULL WHERE sector_id=$1 RETURNING volume_id`, sectorID).Scan(&oldVolumeID)
+		if err == nil {
+			if err := incrementVolumeUsage(tx, oldVolumeID, -1); err != nil {
+				return fmt.Errorf("failed to update old volume usage: %w", err)
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to clear old sector location: %w", err)
+		}
+
+		// the location is no longer used by any other sector since the
+		// data was overwritten.
+		if currentID.Valid {
+			if err := incrementVolumeUsage(tx, volumeID, -1); err != nil {
+				return fmt.Errorf("failed to update volume usage: %w", err)
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE volume_sectors SET sector_id=$1 WHERE id=$2`, sectorID, locationID); err != nil {
+			return fmt.Errorf("failed to set sector location: %w", err)
+		} else if err := incrementVolumeUsage(tx, volumeID, 1); err != nil {
+			return fmt.Errorf("failed to update volume usage: %w", err)
+		}
+		recovered = true
+		return nil
+	})
+	return
+}
+
 // AddVolume initializes a new storage volume and adds it to the volume
 // store. GrowVolume must be called afterwards to initialize the volume
 // to its desired size.
@@ -358,6 +468,13 @@ func (s *Store) RemoveVolume(id int64, force bool) error {
 			return storage.ErrVolumeNotEmpty
 		}
 
+		// delete the volume's scrub progress
+		if _, err := tx.Exec(`DELETE FROM volume_scrub_errors WHERE volume_id=$1`, id); err != nil {
+			return fmt.Errorf("failed to delete scrub errors: %w", err)
+		} else if _, err := tx.Exec(`DELETE FROM volume_scrubs WHERE volume_id=$1`, id); err != nil {
+			return fmt.Errorf("failed to delete volume scrub: %w", err)
+		}
+
 		// delete the volume
 		_, err = tx.Exec(`DELETE FROM storage_volumes WHERE id=$1`, id)
 		return err
@@ -434,6 +551,59 @@ func (s *Store) SetAvailable(volumeID int64, available bool) error {
 	})
 }
 
+// SetVolumePlacement sets the placement weight and priority of a volume.
+func (s *Store) SetVolumePlacement(volumeID int64, weight uint64, priority int64) error {
+	const query = `UPDATE storage_volumes SET placement_weight=$1, placement_priority=$2 WHERE id=$3;`
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(query, weight, priority, volumeID)
+		return err
+	})
+}
+
+// SetVolumeAutoGrow sets the auto-grow policy of a volume.
+func (s *Store) SetVolumeAutoGrow(volumeID int64, policy storage.AutoGrowPolicy) error {
+	const query = `UPDATE storage_volumes SET auto_grow_max_sectors=$1, auto_grow_reserved_bytes=$2 WHERE id=$3;`
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(query, policy.MaxSectors, policy.ReservedBytes, volumeID)
+		return err
+	})
+}
+
+// SetVolumeTier sets the storage tier of a volume.
+func (s *Store) SetVolumeTier(volumeID int64, tier string) error {
+	const query = `UPDATE storage_volumes SET volume_tier=$1 WHERE id=$2;`
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(query, tier, volumeID)
+		return err
+	})
+}
+
+// PlacementStrategy returns the strategy used to place new sectors.
+func (s *Store) PlacementStrategy() (strategy string, err error) {
+	err = s.transaction(func(tx *txn) error {
+		strategy, err = placementStrategy(tx)
+		return err
+	})
+	return
+}
+
+// SetPlacementStrategy sets the strategy used to place new sectors.
+func (s *Store) SetPlacementStrategy(strategy string) error {
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(`UPDATE global_settings SET sector_placement=$1`, strategy)
+		return err
+	})
+}
+
+// SetVolumeUUID sets the persistent identity of a volume.
+func (s *Store) SetVolumeUUID(volumeID int64, uuid string) error {
+	const query = `UPDATE storage_volumes SET volume_uuid=$1 WHERE id=$2;`
+	return s.transaction(func(tx *txn) error {
+		_, err := tx.Exec(query, uuid, volumeID)
+		return err
+	})
+}
+
 // sectorDBID returns the ID of a sector root in the stored_sectors table.
 func sectorDBID(tx *txn, root types.Hash256) (id int64, err error) {
 	err = tx.QueryRow(`UPDATE stored_sectors SET last_access_timestamp=$1 WHERE sector_root=$2 RETURNING id`, encode(time.Now()), encode(root)).Scan(&id)
@@ -503,6 +673,87 @@ WHERE v.sector_id=$1`
 	return
 }
 
+// placementStrategy returns the current sector placement strategy.
+func placementStrategy(tx *txn) (string, error) {
+	var strategy sql.NullString
+	if err := tx.QueryRow(`SELECT sector_placement FROM global_settings`).Scan(&strategy); err != nil {
+		return "", fmt.Errorf("failed to get placement strategy: %w", err)
+	} else if !strategy.Valid || strategy.String == "" {
+		return storage.PlacementLeastWritten, nil
+	}
+	return strategy.String, nil
+}
+
+// placeSector returns an empty location for a new sector chosen by the
+// placement strategy. If there is no space available, ErrNotEnoughStorage is
+// returned.
+func (s *Store) placeSector(tx *txn) (storage.SectorLocation, error) {
+	strategy, err := placementStrategy(tx)
+	if err != nil {
+		return storage.SectorLocation{}, err
+	}
+
+	volumes, err := writableVolumes(tx)
+	if err != nil {
+		return storage.SectorLocation{}, err
+	}
+
+	if strategy == storage.PlacementLeastWritten {
+		ids := storage.PlacementVolumes(volumes)
+		switch {
+		case len(ids) == 0:
+			// volumes with a weight of zero are only used when no other
+			// volume has space
+			return emptyLocation(tx)
+		case len(ids) == len(volumes):
+			// every writable volume is eligible, skip the filter
+			return emptyLocation(tx)
+		}
+		loc, err := emptyLocationInVolumes(tx, ids)
+		if errors.Is(err, storage.ErrNotEnoughStorage) {
+			// the volumes' usage may be out of sync with their free slots
+			return emptyLocation(tx)
+		}
+		return loc, err
+	}
+
+	volumeID, ok := s.placer.Choose(strategy, volumes)
+	if !ok {
+		// volumes with a weight of zero are only used when no other volume
+		// has space
+		return emptyLocation(tx)
+	}
+	loc, err := emptyLocationInVolume(tx, volumeID)
+	if errors.Is(err, storage.ErrNotEnoughStorage) {
+		// the volume's usage may be out of sync with its free slots
+		return emptyLocation(tx)
+	}
+	return loc, err
+}
+
+// writableVolumes returns the available, writable volumes that have free
+// space.
+func writableVolumes(tx *txn) (volumes []storage.Volume, err error) {
+	const query = `SELECT v.id, v.disk_path, v.read_only, v.available, v.total_sectors, v.used_sectors, v.volume_uuid, v.placement_weight, v.placement_priority, v.auto_grow_max_sectors, v.auto_grow_reserved_bytes, v.volume_tier
+FROM storage_volumes v
+WHERE v.available=true AND v.read_only=false AND v.used_sectors < v.total_sectors
+ORDER BY v.id ASC`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query volumes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		volume, err := scanVolume(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan volume: %w", err)
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, rows.Err()
+}
+
 // emptyLocation returns an empty location in a writable volume. If there is no
 // space available, ErrNotEnoughStorage is returned.
 func emptyLocation(tx *txn) (loc storage.SectorLocation, err error) {
@@ -543,7 +794,55 @@ func emptyLocationForMigration(tx *txn, volumeID int64, maxIndex uint64) (loc st
 	return
 }
 
+// emptyLocationInVolume returns an empty location in a specific writable
+// volume. If there is no space available in the volume, ErrNotEnoughStorage is
+// returned.
+func emptyLocationInVolume(tx *txn, volumeID int64) (loc storage.SectorLocation, err error) {
+	const query = `SELECT vs.id, vs.volume_id, vs.volume_index
+	FROM volume_sectors vs INDEXED BY volume_sectors_sector_writes_volume_id_sector_id_volume_index_compound
+	INNER JOIN storage_volumes sv ON (sv.id=vs.volume_id)
+	WHERE vs.sector_id IS NULL AND vs.volume_id=$1 AND sv.available=true AND sv.read_only=false
+	ORDER BY vs.sector_writes ASC
+	LIMIT 1;`
+	err = tx.QueryRow(query, volumeID).Scan(&loc.ID, &loc.Volume, &loc.Index)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.ErrNotEnoughStorage
+		return
+	} else if err != nil {
+		return
+	}
+	_, err = tx.Exec(`UPDATE volume_sectors SET sector_writes=sector_writes+1 WHERE id=$1`, loc.ID)
+	return
+}
+
+// emptyLocationInVolumes returns the least written empty location in any of
+// the given writable volumes. If there is no space available in the volumes,
+// ErrNotEnoughStorage is returned.
+func emptyLocationInVolumes(tx *txn, volumeIDs []int64) (loc storage.SectorLocation, err error) {
+	args := make([]any, 0, len(volumeIDs))
+	for _, id := range volumeIDs {
+		args = append(args, id)
+	}
+	query := `SELECT vs.id, vs.volume_id, vs.volume_index
+	FROM volume_sectors vs INDEXED BY volume_sectors_sector_writes_volume_id_sector_id_volume_index_compound
+	INNER JOIN storage_volumes sv ON (sv.id=vs.volume_id)
+	WHERE vs.sector_id IS NULL AND vs.volume_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(volumeIDs)), ",") + `) AND sv.available=true AND sv.read_only=false
+	ORDER BY vs.sector_writes ASC
+	LIMIT 1;`
+	err = tx.QueryRow(query, args...).Scan(&loc.ID, &loc.Volume, &loc.Index)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.ErrNotEnoughStorage
+		return
+	} else if err != nil {
+		return
+	}
+	_, err = tx.Exec(`UPDATE volume_sectors SET sector_writes=sector_writes+1 WHERE id=$1`, loc.ID)
+	return
+}
+
 func scanVolume(s scanner) (volume storage.Volume, err error) {
-	err = s.Scan(&volume.ID, &volume.LocalPath, &volume.ReadOnly, &volume.Available, &volume.TotalSectors, &volume.UsedSectors)
+	var uuid sql.NullString
+	err = s.Scan(&volume.ID, &volume.LocalPath, &volume.ReadOnly, &volume.Available, &volume.TotalSectors, &volume.UsedSectors, &uuid, &volume.Weight, &volume.Priority, &volume.AutoGrow.MaxSectors, &volume.AutoGrow.ReservedBytes, &volume.Tier)
+	volume.UUID = uuid.String
 	return
 }
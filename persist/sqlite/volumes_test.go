@@ -1036,3 +1036,54 @@ func BenchmarkReadSector(b *testing.B) {
 		}
 	}
 }
+
+func TestDecaySectorReads(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := addTestVolume(db, "test", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := make([]types.Hash256, 3)
+	for i := range roots {
+		roots[i] = frand.Entropy256()
+		if err := db.StoreSector(roots[i], func(storage.SectorLocation) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	if err := db.RecordSectorReads(map[types.Hash256]uint64{roots[0]: 4}, now); err != nil {
+		t.Fatal(err)
+	} else if err := db.RecordSectorReads(map[types.Hash256]uint64{roots[1]: 4}, now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if err := db.RecordSectorReads(map[types.Hash256]uint64{roots[2]: 4}, now.Add(-4*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	readCount := func(root types.Hash256) (n uint64) {
+		t.Helper()
+		if err := db.transaction(func(tx *txn) error {
+			return tx.QueryRow(`SELECT read_count FROM stored_sectors WHERE sector_root=$1`, encode(root)).Scan(&n)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// sectors read within the window are halved, sectors read since the
+	// reset cutoff are reset, and older sectors are not updated
+	if err := db.DecaySectorReads(now.Add(-time.Hour), now.Add(-3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []uint64{2, 0, 4} {
+		if n := readCount(roots[i]); n != expected {
+			t.Fatalf("expected sector %d to have %d reads, got %d", i, expected, n)
+		}
+	}
+}
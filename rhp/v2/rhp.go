@@ -46,6 +46,14 @@ type (
 
 		// SectorRoots returns the sector roots of the contract with the given ID.
 		SectorRoots(id types.FileContractID) []types.Hash256
+
+		// CheckRenterQuota returns an error if adding delta to the renter's
+		// usage would exceed the renter's quota.
+		CheckRenterQuota(pk types.PublicKey, delta contracts.RenterUsage) error
+		// CheckRenewalQuota returns an error if renewing the existing
+		// contract with the locked collateral would exceed the renter's
+		// quota.
+		CheckRenewalQuota(pk types.PublicKey, existingID types.FileContractID, lockedCollateral types.Currency) error
 	}
 
 	// Sectors reads and writes sectors to persistent storage
@@ -84,6 +92,12 @@ type (
 	// A SettingsReporter reports the host's current configuration.
 	SettingsReporter interface {
 		RHP2Settings() (rhp2.HostSettings, error)
+		// RHP2RenterSettings returns the host's settings with the renter's
+		// pricing profile applied.
+		RHP2RenterSettings(types.PublicKey) (rhp2.HostSettings, error)
+		// CheckRenterAccess returns an error if the renter is not allowed
+		// to form or renew contracts with the host.
+		CheckRenterAccess(types.PublicKey) error
 	}
 
 	// A SessionHandler handles the host side of the renter-host protocol and
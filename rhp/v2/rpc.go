@@ -41,7 +41,16 @@ var (
 )
 
 func (sh *SessionHandler) rpcSettings(s *session, log *zap.Logger) (contracts.Usage, error) {
-	settings, err := sh.settings.RHP2Settings()
+	var settings rhp2.HostSettings
+	var err error
+	if s.contract.Revision.ParentID != (types.FileContractID{}) {
+		// the renter is known if a contract is locked
+		s.pricedFor = s.contract.RenterKey()
+		settings, err = sh.settings.RHP2RenterSettings(s.pricedFor)
+	} else {
+		s.pricedFor = types.PublicKey{}
+		settings, err = sh.settings.RHP2Settings()
+	}
 	if err != nil {
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, fmt.Errorf("failed to get host settings: %w", err)
@@ -147,6 +156,10 @@ func (sh *SessionHandler) rpcFormContract(s *session, log *zap.Logger) (contract
 		return contracts.Usage{}, err
 	}
 	renterPub := *(*types.PublicKey)(req.RenterKey.Key)
+	if err := sh.settings.CheckRenterAccess(renterPub); err != nil {
+		s.t.WriteResponseErr(err)
+		return contracts.Usage{}, err
+	}
 	// get the host's public key, current block height, and settings
 	hostPub := sh.privateKey.PublicKey()
 
@@ -169,6 +182,11 @@ func (sh *SessionHandler) rpcFormContract(s *session, log *zap.Logger) (contract
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, err
 	}
+	// check the renter's quota before the host's inputs are added
+	if err := sh.contracts.CheckRenterQuota(renterPub, contracts.RenterUsage{Contracts: 1, LockedCollateral: hostCollateral}); err != nil {
+		s.t.WriteResponseErr(err)
+		return contracts.Usage{}, err
+	}
 
 	// calculate the host's collateral and add the inputs to the transaction
 	renterInputs, renterOutputs := len(formationTxn.SiacoinInputs), len(formationTxn.SiacoinOutputs)
@@ -278,6 +296,18 @@ func (sh *SessionHandler) rpcRenewAndClearContract(s *session, log *zap.Logger)
 		err = fmt.Errorf("failed to convert renter key: %w", err)
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, err
+	} else if err := sh.settings.CheckRenterAccess(renterKey); err != nil {
+		s.t.WriteResponseErr(err)
+		return contracts.Usage{}, err
+	}
+	// the renewal is priced with the settings the renter received. The
+	// renter's prices are only used if they were sent in this session.
+	if s.pricedFor == renterKey {
+		settings, err = sh.settings.RHP2RenterSettings(renterKey)
+		if err != nil {
+			s.t.WriteResponseErr(err)
+			return contracts.Usage{}, fmt.Errorf("failed to get renter settings: %w", err)
+		}
 	}
 
 	renewalTxnSet := req.Transactions
@@ -343,6 +373,12 @@ func (sh *SessionHandler) rpcRenewAndClearContract(s *session, log *zap.Logger)
 		StorageRevenue:   baseRevenue.Sub(settings.ContractPrice),
 	}
 
+	// check the renter's quota before the host's inputs are added
+	if err := sh.contracts.CheckRenewalQuota(renterKey, existingRevision.ParentID, lockedCollateral); err != nil {
+		s.t.WriteResponseErr(err)
+		return contracts.Usage{}, err
+	}
+
 	renterInputs, renterOutputs := len(renewalTxn.SiacoinInputs), len(renewalTxn.SiacoinOutputs)
 	toSign, err := sh.wallet.FundTransaction(&renewalTxn, lockedCollateral, false)
 	if err != nil {
@@ -457,7 +493,7 @@ func (sh *SessionHandler) rpcSectorRoots(s *session, log *zap.Logger) (contracts
 		return contracts.Usage{}, err
 	}
 
-	settings, err := sh.settings.RHP2Settings()
+	settings, err := sh.settings.RHP2RenterSettings(s.contract.RenterKey())
 	if err != nil {
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, fmt.Errorf("failed to get host settings: %w", err)
@@ -547,7 +583,7 @@ func (sh *SessionHandler) rpcWrite(s *session, log *zap.Logger) (contracts.Usage
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, err
 	}
-	settings, err := sh.settings.RHP2Settings()
+	settings, err := sh.settings.RHP2RenterSettings(s.contract.RenterKey())
 	if err != nil {
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, fmt.Errorf("failed to get settings: %w", err)
@@ -736,8 +772,8 @@ func (sh *SessionHandler) rpcRead(s *session, log *zap.Logger) (contracts.Usage,
 		return contracts.Usage{}, err
 	}
 
-	// get the host's current settings
-	settings, err := sh.settings.RHP2Settings()
+	// get the host's current settings for the renter
+	settings, err := sh.settings.RHP2RenterSettings(s.contract.RenterKey())
 	if err != nil {
 		s.t.WriteResponseErr(err)
 		return contracts.Usage{}, fmt.Errorf("failed to get host settings: %w", err)
@@ -8,11 +8,15 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	crhp2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/hostd/host/contracts"
+	hostsettings "go.sia.tech/hostd/host/settings"
 	"go.sia.tech/hostd/internal/testutil"
 	rpc2 "go.sia.tech/hostd/internal/testutil/rhp/v2"
 	"go.sia.tech/hostd/rhp"
@@ -393,6 +397,48 @@ func TestRenew(t *testing.T) {
 		}
 		assertContract(t, renewal, 2, crhp2.MetaRoot(roots), uint64(len(roots))*crhp2.SectorSize)
 	})
+
+	t.Run("pricing profile", func(t *testing.T) {
+		fc := formContract(t, 145)
+		// mine to confirm the contract
+		testutil.MineAndSync(t, node, node.Wallet.Address(), 5)
+		if _, err := rpc2.RPCLock(transport, renterKey, fc.ID()); err != nil {
+			t.Fatal(err)
+		}
+		defer rpc2.RPCUnlock(transport)
+
+		contractPrice := settings.ContractPrice.Div64(2)
+		err := node.Settings.UpdatePricingProfile(hostsettings.PricingProfile{
+			PublicKey:     renterKey.PublicKey(),
+			ContractPrice: &contractPrice,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer node.Settings.RemovePricingProfile(renterKey.PublicKey())
+
+		// the renter receives its own prices after locking the contract
+		renterSettings, err := rpc2.RPCSettings(transport)
+		if err != nil {
+			t.Fatal(err)
+		} else if !renterSettings.ContractPrice.Equals(contractPrice) {
+			t.Fatalf("expected contract price %v, got %v", contractPrice, renterSettings.ContractPrice)
+		}
+
+		public := settings
+		settings = renterSettings
+		defer func() { settings = public }()
+
+		renewal := renewContract(t, fc, fc.Revision.WindowEnd+10)
+		assertContract(t, renewal, 1, types.Hash256{}, 0)
+
+		contract, err := node.Contracts.Contract(renewal.ID())
+		if err != nil {
+			t.Fatal(err)
+		} else if !contract.Usage.RPCRevenue.Equals(contractPrice) {
+			t.Fatalf("expected contract price %v, got %v", contractPrice, contract.Usage.RPCRevenue)
+		}
+	})
 }
 
 func TestRPCV2(t *testing.T) {
@@ -466,3 +512,85 @@ func TestRPCV2(t *testing.T) {
 		}
 	})
 }
+
+func TestFormContractRefused(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	renterKey, hostKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	network, genesis := testutil.V1Network()
+	node := testutil.NewHostNode(t, hostKey, network, genesis, log)
+
+	// fund the wallet
+	testutil.MineAndSync(t, node, node.Wallet.Address(), int(network.MaturityDelay+5))
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	sh := rhp2.NewSessionHandler(l, hostKey, node.Chain, node.Syncer, node.Wallet, node.Contracts, node.Settings, node.Volumes, log)
+	defer sh.Close()
+	go sh.Serve()
+
+	assertRefused := func(t *testing.T, reason string) {
+		t.Helper()
+
+		transport := dialHost(t, hostKey.PublicKey(), l.Addr().String())
+		defer transport.Close()
+
+		settings, err := rpc2.RPCSettings(transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fc := crhp2.PrepareContractFormation(renterKey.PublicKey(), hostKey.PublicKey(), types.Siacoins(10), types.Siacoins(20), node.Chain.Tip().Height+50, settings, node.Wallet.Address())
+		formationCost := crhp2.ContractFormationCost(node.Chain.TipState(), fc, settings.ContractPrice)
+		txn := types.Transaction{
+			FileContracts: []types.FileContract{fc},
+		}
+		toSign, err := node.Wallet.FundTransaction(&txn, formationCost, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer node.Wallet.ReleaseInputs([]types.Transaction{txn}, nil)
+		node.Wallet.SignTransaction(&txn, toSign, wallet.ExplicitCoveredFields(txn))
+		formationSet := append(node.Chain.UnconfirmedParents(txn), txn)
+
+		spendable, err := node.Wallet.SpendableOutputs()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := rpc2.RPCFormContract(transport, renterKey, formationSet); err == nil || !strings.Contains(err.Error(), reason) {
+			t.Fatalf("expected %q error, got %v", reason, err)
+		}
+
+		// the host's inputs should not be locked and nothing should be
+		// added to the pool
+		if after, err := node.Wallet.SpendableOutputs(); err != nil {
+			t.Fatal(err)
+		} else if len(after) != len(spendable) {
+			t.Fatalf("expected %d spendable outputs, got %d", len(spendable), len(after))
+		} else if txns := node.Chain.PoolTransactions(); len(txns) != 0 {
+			t.Fatalf("expected no pool transactions, got %d", len(txns))
+		}
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		if err := node.Settings.UpdateRenterACLEntry(renterKey.PublicKey(), "", time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		defer node.Settings.RemoveRenterACLEntry(renterKey.PublicKey())
+
+		assertRefused(t, "not allowed")
+	})
+
+	t.Run("over quota", func(t *testing.T) {
+		if err := node.Contracts.SetRenterQuota(renterKey.PublicKey(), contracts.RenterQuota{MaxCollateral: types.Siacoins(10)}); err != nil {
+			t.Fatal(err)
+		}
+		defer node.Contracts.RemoveRenterQuota(renterKey.PublicKey())
+
+		assertRefused(t, "renter quota exceeded")
+	})
+}
@@ -17,6 +17,9 @@ const minMessageSize = 4096
 type session struct {
 	t        *rhp2.Transport
 	contract contracts.SignedRevision
+	// pricedFor is the renter whose prices were last sent to the renter by
+	// RPCSettings. It is empty if the host's public prices were sent.
+	pricedFor types.PublicKey
 }
 
 func (s *session) readRequest(req rhp2.ProtocolObject, maxSize uint64, timeout time.Duration) error {
@@ -208,6 +208,13 @@ func (sh *SessionHandler) processFundAccountPayment(pt rhp3.HostPriceTable, s *r
 		s.WriteResponseErr(err)
 		return types.ZeroCurrency, types.ZeroCurrency, fmt.Errorf("failed to get host settings: %w", err)
 	}
+	// check the deposit against the renter's account balance quota
+	depositAmount := totalAmount.Sub(pt.FundAccountCost)
+	if err := sh.contracts.CheckRenterQuota(contract.RenterKey(), contracts.RenterUsage{AccountBalance: depositAmount}); err != nil {
+		s.WriteResponseErr(err)
+		return types.ZeroCurrency, types.ZeroCurrency, fmt.Errorf("failed to check renter quota: %w", err)
+	}
+
 	// credit the account with the deposit
 	hostSig := sh.privateKey.SignHash(sigHash)
 	fundReq := accounts.FundAccountWithContract{
@@ -218,7 +225,7 @@ func (sh *SessionHandler) processFundAccountPayment(pt rhp3.HostPriceTable, s *r
 			RenterSignature: req.Signature,
 		},
 		Cost:       pt.FundAccountCost,
-		Amount:     totalAmount.Sub(pt.FundAccountCost),
+		Amount:     depositAmount,
 		Expiration: time.Now().Add(settings.EphemeralAccountExpiry),
 	}
 	// credit the account with the deposit
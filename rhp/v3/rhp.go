@@ -47,6 +47,13 @@ type (
 		RenewContract(renewal contracts.SignedRevision, existing contracts.SignedRevision, formationSet []types.Transaction, lockedCollateral types.Currency, clearingUsage, renewalUsage contracts.Usage) error
 		// ReviseContract atomically revises a contract and its sector roots
 		ReviseContract(contractID types.FileContractID) (*contracts.ContractUpdater, error)
+		// CheckRenterQuota returns an error if adding delta to the renter's
+		// usage would exceed the renter's quota.
+		CheckRenterQuota(pk types.PublicKey, delta contracts.RenterUsage) error
+		// CheckRenewalQuota returns an error if renewing the existing
+		// contract with the locked collateral would exceed the renter's
+		// quota.
+		CheckRenewalQuota(pk types.PublicKey, existingID types.FileContractID, lockedCollateral types.Currency) error
 	}
 
 	// Sectors reads and writes sectors to persistent storage.
@@ -102,6 +109,12 @@ type (
 		AcceptingContracts() bool
 		RHP2Settings() (rhp2.HostSettings, error)
 		RHP3PriceTable() (rhp3.HostPriceTable, error)
+		// RHP3RenterPriceTable returns the price table with the renter's
+		// pricing profile applied.
+		RHP3RenterPriceTable(types.PublicKey, rhp3.HostPriceTable) rhp3.HostPriceTable
+		// CheckRenterAccess returns an error if the renter is not allowed
+		// to form or renew contracts with the host.
+		CheckRenterAccess(types.PublicKey) error
 	}
 
 	// A SessionHandler handles the host side of the renter-host protocol and
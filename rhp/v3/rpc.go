@@ -286,6 +286,10 @@ func (sh *SessionHandler) handleRPCRenew(s *rhp3.Stream, log *zap.Logger) (contr
 	}
 
 	renterKey := *(*types.PublicKey)(req.RenterKey.Key)
+	if err := sh.settings.CheckRenterAccess(renterKey); err != nil {
+		s.WriteResponseErr(err)
+		return contracts.Usage{}, err
+	}
 	hostUnlockKey := sh.privateKey.PublicKey().UnlockKey()
 	parents := req.TransactionSet[:len(req.TransactionSet)-1]
 	renewalTxn := req.TransactionSet[len(req.TransactionSet)-1]
@@ -347,6 +351,12 @@ func (sh *SessionHandler) handleRPCRenew(s *rhp3.Stream, log *zap.Logger) (contr
 		s.WriteResponseErr(err)
 		return contracts.Usage{}, err
 	}
+	// check the renter's quota before the host's inputs are added
+	if err := sh.contracts.CheckRenewalQuota(renterKey, existing.Revision.ParentID, lockedCollateral); err != nil {
+		s.WriteResponseErr(err)
+		return contracts.Usage{}, err
+	}
+
 	renterInputs, renterOutputs := len(renewalTxn.SiacoinInputs), len(renewalTxn.SiacoinOutputs)
 	toSign, err := sh.wallet.FundTransaction(&renewalTxn, lockedCollateral, false)
 	if err != nil {
@@ -513,6 +523,8 @@ func (sh *SessionHandler) handleRPCExecute(s *rhp3.Stream, log *zap.Logger) (con
 		}
 		defer sh.contracts.Unlock(contract.Revision.ParentID)
 		revision = &contract
+		// the renter is known from the contract, so its prices are used
+		pt = sh.settings.RHP3RenterPriceTable(contract.RenterKey(), pt)
 		log = log.With(zap.String("contractID", contract.Revision.ParentID.String())) // attach the contract ID to the logger
 		log.Debug("locked contract", zap.Duration("elapsed", time.Since(contractLockStart)))
 	}
@@ -17,6 +17,7 @@ import (
 	"go.sia.tech/coreutils/chain"
 	"go.sia.tech/coreutils/wallet"
 	"go.sia.tech/hostd/host/contracts"
+	"go.sia.tech/hostd/host/settings"
 	"go.sia.tech/hostd/host/storage"
 	"go.sia.tech/hostd/internal/testutil"
 	proto2 "go.sia.tech/hostd/internal/testutil/rhp/v2"
@@ -264,6 +265,86 @@ func TestAppendSector(t *testing.T) {
 	}
 }
 
+func TestPricingProfile(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	network, genesis := testutil.V1Network()
+	node := testutil.NewHostNode(t, hostKey, network, genesis, log)
+
+	// fund the wallet
+	testutil.MineAndSync(t, node, node.Wallet.Address(), int(network.MaturityDelay+5))
+
+	// start the node
+	sh2, sh3 := setupRHP3Host(t, node, hostKey, 10, log)
+
+	// create a RHP3 session
+	session, err := proto3.NewSession(context.Background(), hostKey.PublicKey(), sh3.LocalAddr(), node.Chain, node.Wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	revision := formContract(t, node.Chain, node.Wallet, sh2.LocalAddr(), renterKey, hostKey.PublicKey(), 200)
+
+	// the renter only receives the public price table
+	account := crhp3.Account(renterKey.PublicKey())
+	payment := proto3.ContractPayment(&revision, renterKey, account)
+	pt, err := session.RegisterPriceTable(payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storagePrice, collateral := types.ZeroCurrency, pt.CollateralCost.Mul64(2)
+	err = node.Settings.UpdatePricingProfile(settings.PricingProfile{
+		PublicKey:    renterKey.PublicKey(),
+		StoragePrice: &storagePrice,
+		Collateral:   &collateral,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing, err := node.Contracts.Contract(revision.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duration := revision.Revision.WindowEnd - node.Chain.Tip().Height
+	cost, _ := pt.BaseCost().Add(pt.AppendSectorCost(duration)).Total()
+	var sector [crhp2.SectorSize]byte
+	frand.Read(sector[:256])
+	if _, err = session.AppendSector(&sector, &revision, renterKey, payment, cost); err != nil {
+		t.Fatal(err)
+	}
+
+	// the host should charge the renter's storage price and risk the
+	// renter's collateral
+	contract, err := node.Contracts.Contract(revision.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	storageRevenue := contract.Usage.StorageRevenue.Sub(existing.Usage.StorageRevenue)
+	riskedCollateral := contract.Usage.RiskedCollateral.Sub(existing.Usage.RiskedCollateral)
+	if expected := collateral.Mul64(crhp2.SectorSize).Mul64(duration); !riskedCollateral.Equals(expected) {
+		t.Fatalf("expected risked collateral %v, got %v", expected, riskedCollateral)
+	} else if !storageRevenue.IsZero() {
+		t.Fatalf("expected no storage revenue, got %v", storageRevenue)
+	}
+
+	// renewals are priced with the public price table the renter received
+	err = node.Settings.UpdatePricingProfile(settings.PricingProfile{
+		PublicKey:    renterKey.PublicKey(),
+		StoragePrice: &storagePrice,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.MineAndSync(t, node, node.Wallet.Address(), 1)
+	if _, _, err := session.RenewContract(&revision, node.Wallet.Address(), renterKey, types.Siacoins(10), types.Siacoins(20), revision.Revision.WindowEnd+10); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestStoreSector(t *testing.T) {
 	log := zaptest.NewLogger(t)
 	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
@@ -629,3 +710,82 @@ func TestRPCV2(t *testing.T) {
 		t.Fatalf("expected after v2 hardfork error, got %v", err)
 	}
 }
+
+func TestRenewRefused(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	network, genesis := testutil.V1Network()
+	node := testutil.NewHostNode(t, hostKey, network, genesis, log)
+
+	// fund the wallet
+	testutil.MineAndSync(t, node, node.Wallet.Address(), int(network.MaturityDelay+5))
+
+	// start the node
+	sh2, sh3 := setupRHP3Host(t, node, hostKey, 10, log)
+
+	// create a RHP3 session
+	session, err := proto3.NewSession(context.Background(), hostKey.PublicKey(), sh3.LocalAddr(), node.Chain, node.Wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	account := crhp3.Account(renterKey.PublicKey())
+	origin := formContract(t, node.Chain, node.Wallet, sh2.LocalAddr(), renterKey, hostKey.PublicKey(), 200)
+	testutil.MineAndSync(t, node, node.Wallet.Address(), 5)
+
+	assertRefused := func(t *testing.T, reason string) {
+		t.Helper()
+
+		payment := proto3.ContractPayment(&origin, renterKey, account)
+		if _, err := session.RegisterPriceTable(payment); err != nil {
+			t.Fatal(err)
+		}
+
+		spendable, err := node.Wallet.SpendableOutputs()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the renewal locks more collateral than the existing contract
+		_, _, err = session.RenewContract(&origin, node.Wallet.Address(), renterKey, types.Siacoins(10), types.Siacoins(2000), origin.Revision.WindowEnd+10)
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Fatalf("expected %q error, got %v", reason, err)
+		}
+
+		// the host's inputs should not be locked and nothing should be
+		// added to the pool
+		if after, err := node.Wallet.SpendableOutputs(); err != nil {
+			t.Fatal(err)
+		} else if len(after) != len(spendable) {
+			t.Fatalf("expected %d spendable outputs, got %d", len(spendable), len(after))
+		} else if txns := node.Chain.PoolTransactions(); len(txns) != 0 {
+			t.Fatalf("expected no pool transactions, got %d", len(txns))
+		}
+
+		contract, err := node.Contracts.Contract(origin.ID())
+		if err != nil {
+			t.Fatal(err)
+		} else if contract.RenewedTo != (types.FileContractID{}) {
+			t.Fatal("expected contract to not be renewed")
+		}
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		if err := node.Settings.UpdateRenterACLEntry(renterKey.PublicKey(), "", time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		defer node.Settings.RemoveRenterACLEntry(renterKey.PublicKey())
+
+		assertRefused(t, "not allowed")
+	})
+
+	t.Run("over quota", func(t *testing.T) {
+		if err := node.Contracts.SetRenterQuota(renterKey.PublicKey(), contracts.RenterQuota{MaxCollateral: types.Siacoins(1500)}); err != nil {
+			t.Fatal(err)
+		}
+		defer node.Contracts.RemoveRenterQuota(renterKey.PublicKey())
+
+		assertRefused(t, "renter quota exceeded")
+	})
+}
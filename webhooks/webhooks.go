@@ -26,6 +26,29 @@ const (
 	ScopeAlertsError    = "alerts/error"
 	ScopeAlertsCritical = "alerts/critical"
 
+	ScopeVolumes          = "volumes"
+	ScopeVolumesAvailable = "volumes/available"
+
+	ScopeContracts           = "contracts"
+	ScopeContractsFormed     = "contracts/formed"
+	ScopeContractsRevised    = "contracts/revised"
+	ScopeContractsRevision   = "contracts/revision"
+	ScopeContractsProof      = "contracts/proof"
+	ScopeContractsRenewed    = "contracts/renewed"
+	ScopeContractsSuccessful = "contracts/successful"
+	ScopeContractsFailed     = "contracts/failed"
+	ScopeContractsRejected   = "contracts/rejected"
+
+	ScopeContractsV2           = "contracts/v2"
+	ScopeContractsV2Formed     = "contracts/v2/formed"
+	ScopeContractsV2Revised    = "contracts/v2/revised"
+	ScopeContractsV2Revision   = "contracts/v2/revision"
+	ScopeContractsV2Proof      = "contracts/v2/proof"
+	ScopeContractsV2Renewed    = "contracts/v2/renewed"
+	ScopeContractsV2Successful = "contracts/v2/successful"
+	ScopeContractsV2Failed     = "contracts/v2/failed"
+	ScopeContractsV2Rejected   = "contracts/v2/rejected"
+
 	ScopeWallet = "wallet"
 	ScopeTest   = "test"
 )